@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var importOverwrite bool
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Build the state from the groups and users that already exist in AWS Single Sign-On",
+	Long: `
+Build the state from the groups, users and groups members that already exist in AWS Single Sign-On,
+e.g. created by ssosync, matching them with the Google Workspace ones, the groups by name or email
+and the users by email. Nothing is changed in AWS SSO, the next sync reconciles from the state
+and only updates the differences with Google Workspace.
+The state of a previous sync is only replaced with --overwrite.`,
+	Example: `  idpscim import --aws-s3-bucket-name my-bucket
+  idpscim import --aws-s3-bucket-name my-bucket --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := newSyncServices(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(
+			log.Fields{"codeVersion": version.Version},
+		).Info("starting import of the state")
+		timeStart := time.Now()
+
+		if err := svc.sync.ImportState(cmd.Context(), importOverwrite); err != nil {
+			return errors.Wrap(err, "cannot import the state")
+		}
+
+		log.WithFields(log.Fields{
+			"duration": time.Since(timeStart).String(),
+		}).Info("import of the state completed")
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "replace the state of a previous sync")
+}
@@ -3,33 +3,57 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/pkg/errors"
+	"github.com/slashdevops/idp-scim-sync/internal/audit"
 	"github.com/slashdevops/idp-scim-sync/internal/config"
 	"github.com/slashdevops/idp-scim-sync/internal/core"
 	"github.com/slashdevops/idp-scim-sync/internal/idp"
+	"github.com/slashdevops/idp-scim-sync/internal/metrics"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/notify"
 	"github.com/slashdevops/idp-scim-sync/internal/repository"
 	"github.com/slashdevops/idp-scim-sync/internal/scim"
+	"github.com/slashdevops/idp-scim-sync/internal/secrets"
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
 	"github.com/slashdevops/idp-scim-sync/internal/utils"
 	"github.com/slashdevops/idp-scim-sync/internal/version"
 	"github.com/slashdevops/idp-scim-sync/pkg/aws"
 	"github.com/slashdevops/idp-scim-sync/pkg/google"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	admin "google.golang.org/api/admin/directory/v1"
+	reports "google.golang.org/api/admin/reports/v1"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// exitCodeSyncFailures is the exit code of a sync finished with failures when it continues on error
+const exitCodeSyncFailures = 2
+
 var cfg config.Config
 
+// secretsResolver resolves the secret references of the configuration
+var secretsResolver *secrets.Resolver
+
+// scimAccessTokenRef is the secret reference of the AWS SSO SCIM access token, used to reload it when it is rotated
+var scimAccessTokenRef string
+
+// metricsRecorder records the metrics of the syncs and of the APIs requests, metrics are discarded until a recorder is set
+var metricsRecorder metrics.Recorder = metrics.NewNoopRecorder()
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:     "idpscim",
@@ -39,7 +63,7 @@ var rootCmd = &cobra.Command{
 Sync your Google Workspace Groups and Users to AWS Single Sign-On using
 AWS SSO SCIM API (https://docs.aws.amazon.com/singlesignon/latest/developerguide/what-is-scim.html).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return sync()
+		return sync(cmd.Context())
 	},
 }
 
@@ -47,9 +71,19 @@ AWS SSO SCIM API (https://docs.aws.amazon.com/singlesignon/latest/developerguide
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if cfg.IsLambda {
-		lambda.Start(rootCmd.Execute)
+		// the context of the invocation has the deadline of the AWS Lambda timeout
+		lambda.Start(rootCmd.ExecuteContext)
+	}
+
+	if err := rootCmd.Execute(); err != nil {
+		// the syncs finished with failures exit with a different code than the ones aborted,
+		// the error was already printed by cobra
+		var sfe *core.SyncFailuresError
+		if errors.As(err, &sfe) {
+			os.Exit(exitCodeSyncFailures)
+		}
+		cobra.CheckErr(err)
 	}
-	cobra.CheckErr(rootCmd.Execute())
 }
 
 func init() {
@@ -70,6 +104,19 @@ func init() {
 		"AWS Secrets Manager secret name for AWS SSO SCIM API Access Token",
 	)
 
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.AWSSCIMAccessTokenCreatedAt, "aws-scim-access-token-created-at", "",
+		"creation date of the AWS SSO SCIM API Access Token (RFC3339 or YYYY-MM-DD), used to warn before its expiry",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.AWSSCIMAccessTokenExpiresAt, "aws-scim-access-token-expires-at", "",
+		"expiry date of the AWS SSO SCIM API Access Token (RFC3339 or YYYY-MM-DD), by default a year after its creation",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&cfg.AWSSCIMAccessTokenExpiryWarningDays, "aws-scim-access-token-expiry-warning-days", config.DefaultAWSSCIMAccessTokenExpiryWarningDays,
+		"number of days before the expiry of the AWS SSO SCIM API Access Token when the expiry warnings start",
+	)
+
 	rootCmd.PersistentFlags().StringVarP(&cfg.AWSSCIMEndpoint, "aws-scim-endpoint", "e", "", "AWS SSO SCIM API Endpoint")
 	rootCmd.PersistentFlags().StringVarP(&cfg.AWSSCIMEndpointSecretName,
 		"aws-scim-endpoint-secret-name", "n", config.DefaultAWSSCIMEndpointSecretName,
@@ -101,9 +148,120 @@ func init() {
 		&cfg.GWSGroupsFilter, "gws-groups-filter", "q", []string{""},
 		"GWS Groups query parameter, example: --gws-groups-filter 'name:Admin* email:admin*' --gws-groups-filter 'name:Power* email:power*'",
 	)
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.GWSSignerServiceAccount, "gws-signer-service-account", "",
+		"GWS service account whose domain-wide delegation JWT is signed through the IAM Credentials API instead of a private key",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.GWSCustomerID, "gws-customer-id", "",
+		"GWS customer ID to read, by default the customer of the gws-user-email",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&cfg.GWSDomains, "gws-domains", []string{},
+		"GWS domains to read instead of the whole customer directory, example: --gws-domains 'mydomain.com' --gws-domains 'myotherdomain.com'",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&cfg.GWSOrgUnitGroups, "gws-org-unit-groups", []string{},
+		"GWS organizational units synced as groups (sub organizational units included), example: --gws-org-unit-groups 'Backend=/Engineering/Backend' --gws-org-unit-groups '/Sales'",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.GWSInactiveUsersPolicy, "gws-inactive-users-policy", config.DefaultGWSInactiveUsersPolicy,
+		"what to do with the suspended and archived GWS users and the non ACTIVE group members [drop|keep-inactive|keep-active]",
+	)
 
 	rootCmd.PersistentFlags().StringVarP(&cfg.SyncMethod, "sync-method", "m", config.DefaultSyncMethod, "Sync method to use [groups]")
 	rootCmd.PersistentFlags().BoolVarP(&cfg.UseSecretsManager, "use-secrets-manager", "g", config.DefaultUseSecretsManager, "use AWS Secrets Manager content or not (default false)")
+
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.UserDeprovisioningPolicy, "user-deprovisioning-policy", config.DefaultUserDeprovisioningPolicy,
+		"what to do with the users removed from the identity provider [delete|deactivate|deactivate-then-delete]",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&cfg.UserDeprovisioningGracePeriod, "user-deprovisioning-grace-period", config.DefaultUserDeprovisioningGracePeriod,
+		"time a deactivated user is kept before being deleted when using the deactivate-then-delete policy",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&cfg.RemovalGraceRuns, "removal-grace-runs", config.DefaultRemovalGraceRuns,
+		"number of consecutive syncs a group or user must be missing in the identity provider before being removed (0 disabled)",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&cfg.RemovalGracePeriod, "removal-grace-period", config.DefaultRemovalGracePeriod,
+		"time a group or user must be missing in the identity provider before being removed (0 disabled)",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&cfg.DryRun, "dry-run", config.DefaultDryRun,
+		"only report the changes of the sync, without applying them to AWS SSO nor storing the state",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&cfg.ContinueOnError, "continue-on-error", config.DefaultContinueOnError,
+		"continue the sync when a group, a user or the members of a group fail, the failed ones are retried by the next sync",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&cfg.Checkpoints, "checkpoints", config.DefaultCheckpoints,
+		"store the state of the first sync after reconciling the groups and the users, so the next sync resumes it when it doesn't finish",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&cfg.DeadlineMargin, "deadline-margin", config.DefaultDeadlineMargin,
+		"stop the sync without starting a new phase when the time left before the AWS Lambda timeout is less than this (0 disabled)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&cfg.UserDriftDetection, "user-drift-detection", config.DefaultUserDriftDetection,
+		"compare the users of the state with the AWS SSO ones in the syncs from the state and put back the ones changed in AWS SSO",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&cfg.UserDriftSample, "user-drift-sample", config.DefaultUserDriftSample,
+		"number of random users compared by the user drift detection (0 all of them)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&cfg.Incremental, "incremental", config.DefaultIncremental,
+		"get only the groups and users changed in Google Workspace since the last sync, from the activities of the Reports API",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&cfg.FullRefreshInterval, "full-refresh-interval", config.DefaultFullRefreshInterval,
+		"interval of the syncs getting all the Google Workspace data in incremental mode (0 disabled)",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.MetricsPushgatewayURL, "metrics-pushgateway-url", "",
+		"Prometheus Pushgateway url where the metrics are pushed after the sync, e.g. http://pushgateway:9091",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.MetricsPushgatewayJob, "metrics-pushgateway-job", config.DefaultMetricsPushgatewayJob,
+		"job name of the metrics pushed to the Prometheus Pushgateway",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.MetricsCloudWatchNamespace, "metrics-cloudwatch-namespace", config.DefaultMetricsCloudWatchNamespace,
+		"CloudWatch namespace of the metrics emitted in the Embedded Metric Format when running as AWS Lambda",
+	)
+
+	rootCmd.PersistentFlags().StringVar(&cfg.NotifySlackWebhookURL, "notify-slack-webhook-url", "", "Slack incoming webhook url where the syncs are notified")
+	rootCmd.PersistentFlags().StringVar(&cfg.NotifyWebhookURL, "notify-webhook-url", "", "url of a webhook where the syncs are notified as JSON")
+	rootCmd.PersistentFlags().StringVar(&cfg.NotifySNSTopicARN, "notify-sns-topic-arn", "", "AWS SNS topic arn where the syncs are notified")
+	rootCmd.PersistentFlags().IntVar(
+		&cfg.NotifyThreshold, "notify-threshold", config.DefaultNotifyThreshold,
+		"minimum number of changes of a successful sync to be notified, the failed syncs are always notified",
+	)
+	rootCmd.PersistentFlags().StringVar(&cfg.NotifySubjectTemplate, "notify-subject-template", "", "Go template of the subject of the notifications")
+	rootCmd.PersistentFlags().StringVar(&cfg.NotifyTextTemplate, "notify-text-template", "", "Go template of the text of the notifications")
+
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.TracingOTLPEndpoint, "tracing-otlp-endpoint", "",
+		"OTLP/HTTP collector url where the OpenTelemetry spans are exported, e.g. http://otel-collector:4318, empty disables the tracing",
+	)
+	rootCmd.PersistentFlags().Float64Var(
+		&cfg.TracingSampleRatio, "tracing-sample-ratio", config.DefaultTracingSampleRatio,
+		"ratio, between 0 and 1, of the sampled traces",
+	)
+
+	rootCmd.PersistentFlags().StringVar(&cfg.AuditFile, "audit-file", "", "local file where the audit records of the SCIM changes are appended as JSON Lines")
+	rootCmd.PersistentFlags().StringVar(&cfg.AuditS3Bucket, "audit-s3-bucket", "", "AWS S3 bucket where the audit records of every sync are uploaded as JSON Lines")
+	rootCmd.PersistentFlags().StringVar(&cfg.AuditS3Prefix, "audit-s3-prefix", config.DefaultAuditS3Prefix, "prefix of the keys of the audit objects in the AWS S3 bucket")
+	rootCmd.PersistentFlags().StringVar(&cfg.AuditCloudWatchLogGroup, "audit-cloudwatch-log-group", "", "AWS CloudWatch Logs log group where the audit records are sent")
+	rootCmd.PersistentFlags().StringVar(
+		&cfg.AuditCloudWatchLogStream, "audit-cloudwatch-log-stream", config.DefaultAuditCloudWatchLogStream,
+		"AWS CloudWatch Logs log stream where the audit records are sent, it is created when it doesn't exist",
+	)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -121,11 +279,59 @@ func initConfig() {
 		"gws_service_account_file",
 		"gws_service_account_file_secret_name",
 		"gws_groups_filter",
+		"gws_inactive_users_policy",
+		"gws_org_unit_groups",
+		"gws_customer_id",
+		"gws_signer_service_account",
+		"gws_domains",
 		"aws_scim_access_token",
 		"aws_scim_access_token_secret_name",
+		"aws_scim_access_token_created_at",
+		"aws_scim_access_token_expires_at",
+		"aws_scim_access_token_expiry_warning_days",
 		"aws_scim_endpoint",
 		"aws_scim_endpoint_secret_name",
 		"use_secrets_manager",
+		"vault_address",
+		"vault_token",
+		"user_deprovisioning_policy",
+		"user_deprovisioning_grace_period",
+		"removal_grace_runs",
+		"removal_grace_period",
+		"serve_schedule",
+		"serve_interval",
+		"serve_jitter",
+		"serve_run_on_start",
+		"serve_shutdown_timeout",
+		"serve_status_file",
+		"dry_run",
+		"continue_on_error",
+		"checkpoints",
+		"deadline_margin",
+		"user_drift_detection",
+		"user_drift_sample",
+		"incremental",
+		"full_refresh_interval",
+		"admin_address",
+		"admin_token",
+		"webhook_token",
+		"webhook_debounce",
+		"metrics_pushgateway_url",
+		"metrics_pushgateway_job",
+		"metrics_cloudwatch_namespace",
+		"notify_slack_webhook_url",
+		"notify_webhook_url",
+		"notify_sns_topic_arn",
+		"notify_threshold",
+		"notify_subject_template",
+		"notify_text_template",
+		"tracing_otlp_endpoint",
+		"tracing_sample_ratio",
+		"audit_file",
+		"audit_s3_bucket",
+		"audit_s3_prefix",
+		"audit_cloudwatch_log_group",
+		"audit_cloudwatch_log_stream",
 	}
 	for _, e := range envVars {
 		if err := viper.BindEnv(e); err != nil {
@@ -186,9 +392,7 @@ func initConfig() {
 		log.SetLevel(level)
 	}
 
-	if cfg.IsLambda || cfg.UseSecretsManager {
-		getSecrets()
-	}
+	getSecrets()
 
 	// not implemented yet block
 	if cfg.SyncMethod != "groups" {
@@ -196,106 +400,400 @@ func initConfig() {
 	}
 }
 
+// getSecrets reads the secrets of the configuration.
+// When AWS Secrets Manager is used, the well known secret names are read, these names could be secret references too.
+// Then, the rest of the configuration values that are secret references (secret://<provider>/<key>[#field]) are resolved,
+// the secret names are not resolved again.
 func getSecrets() {
-	log.Info("reading values from AWS Secrets Manager")
+	ctx := context.Background()
 
-	awsConf, err := aws.NewDefaultConf(context.Background())
+	resolver, err := newSecretsResolver(ctx)
 	if err != nil {
-		log.Fatalf(errors.Wrap(err, "cannot load aws config").Error())
+		log.Fatalf(errors.Wrap(err, "cannot create secrets resolver").Error())
+	}
+	secretsResolver = resolver
+
+	if secrets.IsReference(cfg.AWSSCIMAccessToken) {
+		scimAccessTokenRef = cfg.AWSSCIMAccessToken
 	}
 
-	svc := secretsmanager.NewFromConfig(awsConf)
+	if cfg.IsLambda || cfg.UseSecretsManager {
+		log.Info("reading values from AWS Secrets Manager")
+
+		wellKnown := []struct {
+			name  string
+			value *string
+		}{
+			{cfg.GWSUserEmailSecretName, &cfg.GWSUserEmail},
+			{cfg.GWSServiceAccountFileSecretName, &cfg.GWSServiceAccountFile},
+			{cfg.AWSSCIMAccessTokenSecretName, &cfg.AWSSCIMAccessToken},
+			{cfg.AWSSCIMEndpointSecretName, &cfg.AWSSCIMEndpoint},
+		}
+
+		for _, secret := range wellKnown {
+			ref := secret.name
+			if !secrets.IsReference(ref) {
+				ref = secrets.ReferencePrefix + secretsManagerProviderName + "/" + secret.name
+			}
+
+			log.WithField("name", secret.name).Debug("reading secret")
+			unwrap, err := resolver.Resolve(ctx, ref)
+			if err != nil {
+				log.Fatalf(errors.Wrap(err, "cannot get secret value").Error())
+			}
+			*secret.value = unwrap
 
-	secrets, err := aws.NewSecretsManagerService(svc)
+			if secret.value == &cfg.AWSSCIMAccessToken {
+				scimAccessTokenRef = ref
+			}
+		}
+	}
+
+	if err := resolver.ResolveAll(ctx, &cfg); err != nil {
+		log.Fatalf(errors.Wrap(err, "cannot resolve secret references").Error())
+	}
+}
+
+const (
+	secretsManagerProviderName = "secretsmanager"
+	ssmProviderName            = "ssm"
+	envProviderName            = "env"
+	fileProviderName           = "file"
+	vaultProviderName          = "vault"
+)
+
+// newSecretsResolver returns the secrets resolver with the available secrets providers,
+// HashiCorp Vault is only available when its address is configured.
+func newSecretsResolver(ctx context.Context) (*secrets.Resolver, error) {
+	awsConf, err := aws.NewDefaultConf(ctx)
 	if err != nil {
-		log.Fatalf(errors.Wrap(err, "cannot create aws secrets manager service").Error())
+		return nil, errors.Wrap(err, "cannot load aws config")
 	}
 
-	log.WithField("name", cfg.GWSUserEmailSecretName).Debug("reading secret")
-	unwrap, err := secrets.GetSecretValue(context.Background(), cfg.GWSUserEmailSecretName)
+	smService, err := aws.NewSecretsManagerService(secretsmanager.NewFromConfig(awsConf))
 	if err != nil {
-		log.Fatalf(errors.Wrap(err, "cannot get secretmanager value").Error())
+		return nil, errors.Wrap(err, "cannot create aws secrets manager service")
 	}
-	cfg.GWSUserEmail = unwrap
 
-	log.WithField("name", cfg.GWSServiceAccountFileSecretName).Debug("reading secret")
-	unwrap, err = secrets.GetSecretValue(context.Background(), cfg.GWSServiceAccountFileSecretName)
+	smProvider, err := secrets.NewSecretsManagerProvider(smService)
 	if err != nil {
-		log.Fatalf(errors.Wrap(err, "cannot get secretmanager value").Error())
+		return nil, errors.Wrap(err, "cannot create aws secrets manager provider")
 	}
-	cfg.GWSServiceAccountFile = unwrap
 
-	log.WithField("name", cfg.AWSSCIMAccessTokenSecretName).Debug("reading secret")
-	unwrap, err = secrets.GetSecretValue(context.Background(), cfg.AWSSCIMAccessTokenSecretName)
+	ssmService, err := aws.NewSSMService(ssm.NewFromConfig(awsConf))
 	if err != nil {
-		log.Fatalf(errors.Wrap(err, "cannot get secretmanager value").Error())
+		return nil, errors.Wrap(err, "cannot create aws ssm service")
 	}
-	cfg.AWSSCIMAccessToken = unwrap
 
-	log.WithField("name", cfg.AWSSCIMEndpointSecretName).Debug("reading secret")
-	unwrap, err = secrets.GetSecretValue(context.Background(), cfg.AWSSCIMEndpointSecretName)
+	ssmProvider, err := secrets.NewSSMProvider(ssmService)
 	if err != nil {
-		log.Fatalf(errors.Wrap(err, "cannot get secretmanager value").Error())
+		return nil, errors.Wrap(err, "cannot create aws ssm provider")
+	}
+
+	opts := []secrets.ResolverOption{
+		secrets.WithProvider(secretsManagerProviderName, smProvider),
+		secrets.WithProvider(ssmProviderName, ssmProvider),
+		secrets.WithProvider(envProviderName, secrets.NewEnvProvider()),
+		secrets.WithProvider(fileProviderName, secrets.NewFileProvider()),
+	}
+
+	vaultAddress := cfg.VaultAddress
+	if vaultAddress == "" {
+		vaultAddress = os.Getenv("VAULT_ADDR")
+	}
+
+	if vaultAddress != "" {
+		vaultToken := cfg.VaultToken
+		if vaultToken == "" {
+			vaultToken = os.Getenv("VAULT_TOKEN")
+		}
+
+		// the vault token could be a reference to a secret of the other providers, e.g. secret://file//var/run/vault/token
+		base, err := secrets.NewResolver(opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create secrets resolver")
+		}
+
+		vaultToken, err = base.Resolve(ctx, vaultToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot resolve vault token")
+		}
+
+		vaultProvider, err := secrets.NewVaultProvider(vaultAddress, vaultToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create vault provider")
+		}
+		opts = append(opts, secrets.WithProvider(vaultProviderName, vaultProvider))
 	}
-	cfg.AWSSCIMEndpoint = unwrap
+
+	return secrets.NewResolver(opts...)
 }
 
-func sync() error {
+func sync(ctx context.Context) error {
 	log.Tracef("viper config: %s", utils.ToJSON(viper.AllSettings()))
 
 	if cfg.SyncMethod == "groups" {
-		return syncGroups()
+		return syncGroups(ctx, fullSync)
 	}
 	return fmt.Errorf("unknown sync method: %s", cfg.SyncMethod)
 }
 
-func syncGroups() error {
+// syncGroups runs the given sync of the groups and their members with the services of the configuration.
+func syncGroups(ctx context.Context, fn syncFunc) error {
+	shutdownTracing, err := setupTracing()
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+
+	var recorders metrics.MultiRecorder
+
+	var prom *metrics.PrometheusRecorder
+	if cfg.MetricsPushgatewayURL != "" {
+		prom = metrics.NewPrometheusRecorder()
+		recorders = append(recorders, prom)
+	}
+
+	// as AWS Lambda the metrics are written to the logs in the CloudWatch Embedded Metric Format
+	var emf *metrics.EMFRecorder
+	if cfg.IsLambda {
+		emf = metrics.NewEMFRecorder(cfg.MetricsCloudWatchNamespace, os.Stdout)
+		recorders = append(recorders, emf)
+	}
+
+	switch len(recorders) {
+	case 0:
+	case 1:
+		metricsRecorder = recorders[0]
+	default:
+		metricsRecorder = recorders
+	}
+
+	svc, err := newSyncServices(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = svc.run(ctx, svc.sync, fn)
+
+	if prom != nil {
+		// the metrics are pushed even when the sync fails, a failed push doesn't fail the sync
+		if pushErr := prom.Push(ctx, cfg.MetricsPushgatewayURL, cfg.MetricsPushgatewayJob); pushErr != nil {
+			log.WithError(pushErr).Warn("cannot push the metrics")
+		}
+	}
+
+	if emf != nil {
+		if flushErr := emf.Flush(); flushErr != nil {
+			log.WithError(flushErr).Warn("cannot write the CloudWatch metrics")
+		}
+	}
+
+	return err
+}
+
+// syncFunc runs one of the syncs of the sync service.
+type syncFunc func(ctx context.Context, ss *core.SyncService) error
+
+// fullSync syncs all the groups and their members.
+func fullSync(ctx context.Context, ss *core.SyncService) error {
+	return ss.SyncGroupsAndTheirMembers(ctx)
+}
+
+// changesSync syncs only the groups and users of the given changes.
+func changesSync(changes *model.IdentityProviderChanges) syncFunc {
+	return func(ctx context.Context, ss *core.SyncService) error {
+		return ss.SyncChanges(ctx, changes)
+	}
+}
+
+// groupSync syncs only the group of the given email or name.
+func groupSync(group string) syncFunc {
+	return func(ctx context.Context, ss *core.SyncService) error {
+		return ss.SyncGroup(ctx, group)
+	}
+}
+
+// userSync syncs only the user of the given email.
+func userSync(email string) syncFunc {
+	return func(ctx context.Context, ss *core.SyncService) error {
+		return ss.SyncUser(ctx, email)
+	}
+}
+
+// runSyncGroups runs the given sync of the groups and their members using the given sync service.
+func runSyncGroups(ctx context.Context, ss *core.SyncService, fn syncFunc) error {
 	log.WithFields(
 		log.Fields{"codeVersion": version.Version},
 	).Info("starting sync groups")
 	timeStart := time.Now()
 
-	// cfg.GWSServiceAccountFile could be a file path or a content of the file
-	gwsServiceAccountContent := []byte(cfg.GWSServiceAccountFile)
+	checkSCIMAccessTokenExpiry(ctx)
 
-	if !cfg.IsLambda {
-		gwsServiceAccount, err := os.ReadFile(cfg.GWSServiceAccountFile)
-		if err != nil {
-			log.Fatalf(errors.Wrap(err, "cannot read service account file").Error())
+	err := fn(ctx, ss)
+	metrics.RecordSyncReport(metricsRecorder, ss.LastReport(), time.Since(timeStart))
+
+	if err != nil {
+		if aws.IsAuthError(err) {
+			log.Error("the AWS SSO SCIM access token was rejected, check it is not expired or revoked and rotate it")
 		}
-		gwsServiceAccountContent = gwsServiceAccount
+		return errors.Wrap(err, "cannot sync groups and their members")
 	}
 
-	gwsAPIScopes := []string{
-		"https://www.googleapis.com/auth/admin.directory.group.readonly",
-		"https://www.googleapis.com/auth/admin.directory.group.member.readonly",
-		"https://www.googleapis.com/auth/admin.directory.user.readonly",
+	log.WithFields(log.Fields{
+		"duration": time.Since(timeStart).String(),
+	}).Info("sync groups completed")
+
+	return nil
+}
+
+// identityProviderService is the identity provider service of the sync that can be checked by the admin API.
+type identityProviderService interface {
+	core.IdentityProviderService
+	Check(ctx context.Context) error
+}
+
+// syncServices are the services created from the configuration, the sync services and the ones used by the admin API.
+type syncServices struct {
+	idp  identityProviderService
+	scim *aws.SCIMService
+	repo *repository.S3Repository
+
+	// sync is the sync service of the configuration and dryRunSync the one that never applies the changes
+	sync       *core.SyncService
+	dryRunSync *core.SyncService
+
+	// notifier notifies the sync reports, nil when there is no notifier configured
+	notifier *notify.Dispatcher
+
+	// auditSink receives the audit records of the SCIM changes, nil when there is no audit sink configured
+	auditSink audit.Sink
+}
+
+// run runs a sync with one of the sync services, then flushes its audit records and notifies its report.
+// Every run has its own run id, it correlates the report with the audit records.
+func (s *syncServices) run(ctx context.Context, ss *core.SyncService, fn syncFunc) error {
+	ctx = audit.ContextWithRunID(ctx, audit.NewRunID())
+
+	err := runSyncGroups(ctx, ss, fn)
+	s.flushAudit(ctx)
+	s.notify(ctx, ss.LastReport())
+
+	return err
+}
+
+// flushAudit flushes the audit records of the sync, a failed flush doesn't fail the sync.
+func (s *syncServices) flushAudit(ctx context.Context) {
+	if s.auditSink == nil {
+		return
 	}
 
-	ctx := context.Background()
+	if err := s.auditSink.Flush(ctx); err != nil {
+		log.WithError(err).Error("cannot flush the audit records")
+	}
+}
 
-	// Google Client Service
-	gwsService, err := google.NewService(ctx, cfg.GWSUserEmail, gwsServiceAccountContent, gwsAPIScopes...)
-	if err != nil {
-		return errors.Wrap(err, "cannot create google service")
+// notify sends the notification of the sync report, a failed notification doesn't fail the sync.
+func (s *syncServices) notify(ctx context.Context, report *core.SyncReport) {
+	if s.notifier == nil || report == nil {
+		return
 	}
 
-	// Google Directory Service
-	gwsDS, err := google.NewDirectoryService(gwsService)
+	if err := s.notifier.Notify(ctx, report); err != nil {
+		log.WithError(err).Warn("cannot notify the sync")
+	}
+}
+
+// newSyncServices creates the sync services with the identity provider, scim and state repository services of the configuration.
+func newSyncServices(ctx context.Context) (*syncServices, error) {
+	ctx = google.ContextWithRequestObserver(ctx, func(method, resource string, statusCode int, duration time.Duration) {
+		metricsRecorder.APIRequest(metrics.APIGoogle, method, resource, statusCode, duration)
+	})
+
+	// cfg.GWSServiceAccountFile could be a file path or a content of the file
+	gwsServiceAccountContent, err := serviceAccountContent(cfg.GWSServiceAccountFile)
 	if err != nil {
-		return errors.Wrap(err, "cannot create google directory service")
+		log.Fatalf(errors.Wrap(err, "cannot read service account file").Error())
 	}
 
 	// Identity Provider Service
-	idpService, err := idp.NewIdentityProvider(gwsDS)
+	orgUnitGroups := make([]idp.OrgUnitGroup, 0, len(cfg.GWSOrgUnitGroups))
+	for _, oug := range cfg.GWSOrgUnitGroups {
+		g, err := idp.ParseOrgUnitGroup(oug)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse organizational unit group")
+		}
+		orgUnitGroups = append(orgUnitGroups, g)
+	}
+
+	idpOpts := []idp.IdentityProviderOption{
+		idp.WithInactiveUsersPolicy(cfg.GWSInactiveUsersPolicy),
+		idp.WithUserIncludeRules(userRules(cfg.GWSUsersInclude)),
+		idp.WithUserExcludeRules(userRules(cfg.GWSUsersExclude)),
+	}
+
+	mainIdp, err := newIdentityProvider(
+		ctx, cfg.GWSUserEmail, cfg.GWSSignerServiceAccount, gwsServiceAccountContent, cfg.GWSCustomerID, cfg.GWSDomains,
+		append(idpOpts, idp.WithOrgUnitGroups(orgUnitGroups))...,
+	)
 	if err != nil {
-		return errors.Wrap(err, "cannot create identity provider service")
+		return nil, errors.Wrap(err, "cannot create identity provider service")
+	}
+
+	var idpService identityProviderService = mainIdp
+
+	// reportsServices get the changes of each tenant for the incremental syncs
+	reportsServices := make([]idp.GoogleReportsService, 0)
+	if cfg.Incremental {
+		rs, err := newReportsService(ctx, cfg.GWSUserEmail, cfg.GWSSignerServiceAccount, gwsServiceAccountContent)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create google reports service")
+		}
+		reportsServices = append(reportsServices, rs)
+	}
+
+	if len(cfg.GWSTenants) > 0 {
+		tenants := []*idp.IdentityProvider{mainIdp}
+
+		for _, tenant := range cfg.GWSTenants {
+			// tenant.ServiceAccountFile could be a file path or a content of the file
+			tenantServiceAccountContent, err := serviceAccountContent(tenant.ServiceAccountFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot read tenant service account file")
+			}
+
+			tenantIdp, err := newIdentityProvider(ctx, tenant.UserEmail, tenant.SignerServiceAccount, tenantServiceAccountContent, tenant.CustomerID, tenant.Domains, idpOpts...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot create identity provider service for tenant: %s", tenant.UserEmail)
+			}
+
+			tenants = append(tenants, tenantIdp)
+
+			if cfg.Incremental {
+				rs, err := newReportsService(ctx, tenant.UserEmail, tenant.SignerServiceAccount, tenantServiceAccountContent)
+				if err != nil {
+					return nil, errors.Wrapf(err, "cannot create google reports service for tenant: %s", tenant.UserEmail)
+				}
+				reportsServices = append(reportsServices, rs)
+			}
+		}
+
+		idpService, err = idp.NewMultiIdentityProvider(tenants...)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create multi tenant identity provider service")
+		}
 	}
 
 	// httpClient
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = 10
 	retryClient.RetryWaitMin = time.Millisecond * 100
+	retryClient.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+		if attempt > 0 {
+			metricsRecorder.APIRetry(metrics.APISCIM)
+			aws.RecordRetry(req.Context(), attempt)
+		}
+	}
 
 	if cfg.Debug {
 		retryClient.Logger = log.StandardLogger()
@@ -306,15 +804,36 @@ func syncGroups() error {
 	httpClient := retryClient.StandardClient()
 
 	// AWS SCIM Service
-	awsSCIM, err := aws.NewSCIMService(httpClient, cfg.AWSSCIMEndpoint, cfg.AWSSCIMAccessToken)
+	scimOpts := []aws.SCIMServiceOption{
+		aws.WithRequestObserver(func(method, resource string, statusCode int, duration time.Duration) {
+			metricsRecorder.APIRequest(metrics.APISCIM, method, resource, statusCode, duration)
+		}),
+	}
+	if scimAccessTokenRef != "" && secretsResolver != nil {
+		scimOpts = append(scimOpts, aws.WithTokenReloader(func(ctx context.Context) (string, error) {
+			return secretsResolver.Resolve(ctx, scimAccessTokenRef)
+		}))
+	}
+
+	awsSCIM, err := aws.NewSCIMService(httpClient, cfg.AWSSCIMEndpoint, cfg.AWSSCIMAccessToken, scimOpts...)
 	if err != nil {
-		return errors.Wrap(err, "cannot create aws scim service")
+		return nil, errors.Wrap(err, "cannot create aws scim service")
 	}
 	awsSCIM.UserAgent = "idp-scim-sync/" + version.Version
 
-	scimService, err := scim.NewProvider(awsSCIM)
+	auditSink, err := newAuditSink(ctx)
 	if err != nil {
-		return errors.Wrap(err, "cannot create scim provider")
+		return nil, errors.Wrap(err, "cannot create audit sink")
+	}
+
+	var scimProviderOpts []scim.ProviderOption
+	if auditSink != nil {
+		scimProviderOpts = append(scimProviderOpts, scim.WithAuditSink(auditSink))
+	}
+
+	scimService, err := scim.NewProvider(awsSCIM, scimProviderOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create scim provider")
 	}
 
 	awsConf, err := aws.NewDefaultConf(context.Background())
@@ -328,20 +847,312 @@ func syncGroups() error {
 		log.Fatalf(errors.Wrap(err, "cannot create s3 repository").Error())
 	}
 
-	ss, err := core.NewSyncService(idpService, scimService, repo, core.WithIdentityProviderGroupsFilter(cfg.GWSGroupsFilter))
+	ssOpts := []core.SyncServiceOption{
+		core.WithIdentityProviderGroupsFilter(cfg.GWSGroupsFilter),
+		core.WithUserDeprovisioningPolicy(cfg.UserDeprovisioningPolicy),
+		core.WithUserDeprovisioningGracePeriod(cfg.UserDeprovisioningGracePeriod),
+		core.WithRemovalGraceRuns(cfg.RemovalGraceRuns),
+		core.WithRemovalGracePeriod(cfg.RemovalGracePeriod),
+		core.WithContinueOnError(cfg.ContinueOnError),
+		core.WithCheckpoints(cfg.Checkpoints),
+		core.WithDeadlineMargin(cfg.DeadlineMargin),
+		core.WithUserDriftDetection(cfg.UserDriftDetection),
+		core.WithUserDriftSample(cfg.UserDriftSample),
+	}
+
+	if cfg.Incremental {
+		changesSource, err := idp.NewChangesSource(reportsServices...)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create identity provider changes source")
+		}
+
+		ssOpts = append(ssOpts,
+			core.WithIdentityProviderChangesSource(changesSource),
+			core.WithFullRefreshInterval(cfg.FullRefreshInterval),
+		)
+	}
+
+	ss, err := core.NewSyncService(idpService, scimService, repo, append(ssOpts, core.WithDryRun(cfg.DryRun))...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create sync service")
+	}
+
+	dryRunSS, err := core.NewSyncService(idpService, scimService, repo, append(ssOpts, core.WithDryRun(true))...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create dry run sync service")
+	}
+
+	notifier, err := newNotifier(ctx)
 	if err != nil {
-		return errors.Wrap(err, "cannot create sync service")
+		return nil, errors.Wrap(err, "cannot create notifier")
 	}
 
 	log.Tracef("app config: %s", utils.ToJSON(cfg))
 
-	if err := ss.SyncGroupsAndTheirMembers(ctx); err != nil {
-		return errors.Wrap(err, "cannot sync groups and their members")
+	return &syncServices{
+		idp:        idpService,
+		scim:       awsSCIM,
+		repo:       repo,
+		sync:       ss,
+		dryRunSync: dryRunSS,
+		notifier:   notifier,
+		auditSink:  auditSink,
+	}, nil
+}
+
+// newAuditSink returns the sink of the audit records of the configuration, nil when there is none.
+func newAuditSink(ctx context.Context) (audit.Sink, error) {
+	var sinks audit.MultiSink
+
+	if cfg.AuditFile != "" {
+		s, err := audit.NewFileSink(cfg.AuditFile)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
 	}
 
-	log.WithFields(log.Fields{
-		"duration": time.Since(timeStart).String(),
-	}).Info("sync groups completed")
+	if cfg.AuditS3Bucket != "" || cfg.AuditCloudWatchLogGroup != "" {
+		awsConf, err := aws.NewDefaultConf(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot load aws config")
+		}
 
-	return nil
+		if cfg.AuditS3Bucket != "" {
+			s, err := audit.NewS3Sink(s3.NewFromConfig(awsConf), cfg.AuditS3Bucket, audit.WithS3Prefix(cfg.AuditS3Prefix))
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, s)
+		}
+
+		if cfg.AuditCloudWatchLogGroup != "" {
+			s, err := audit.NewCloudWatchLogsSink(cloudwatchlogs.NewFromConfig(awsConf), cfg.AuditCloudWatchLogGroup, cfg.AuditCloudWatchLogStream)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, s)
+		}
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	default:
+		return sinks, nil
+	}
+}
+
+// newNotifier returns the dispatcher of the notifiers of the configuration, nil when there is none.
+func newNotifier(ctx context.Context) (*notify.Dispatcher, error) {
+	notifiers := make([]notify.Notifier, 0)
+
+	if cfg.NotifySlackWebhookURL != "" {
+		n, err := notify.NewSlackNotifier(cfg.NotifySlackWebhookURL)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+
+	if cfg.NotifyWebhookURL != "" {
+		n, err := notify.NewWebhookNotifier(cfg.NotifyWebhookURL)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+
+	if cfg.NotifySNSTopicARN != "" {
+		awsConf, err := aws.NewDefaultConf(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot load aws config")
+		}
+
+		snsService, err := aws.NewSNSService(sns.NewFromConfig(awsConf))
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := notify.NewSNSNotifier(snsService, cfg.NotifySNSTopicARN)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+
+	return notify.NewDispatcher(
+		notifiers,
+		notify.WithThreshold(cfg.NotifyThreshold),
+		notify.WithSubjectTemplate(cfg.NotifySubjectTemplate),
+		notify.WithTextTemplate(cfg.NotifyTextTemplate),
+	)
+}
+
+const (
+	// scimAccessTokenCreatedAtTag and scimAccessTokenExpiresAtTag are the tags of the AWS SSO SCIM access token secret
+	// used when the token dates are not configured
+	scimAccessTokenCreatedAtTag = "idpscim:token-created-at"
+	scimAccessTokenExpiresAtTag = "idpscim:token-expires-at"
+)
+
+// tracingShutdownTimeout is the maximum time waited to export the pending spans before exiting
+const tracingShutdownTimeout = 5 * time.Second
+
+// setupTracing enables the OpenTelemetry tracing when the OTLP endpoint is configured,
+// the returned function exports the pending spans and must be called before exiting.
+func setupTracing() (func(), error) {
+	shutdown, err := tracing.Setup(
+		cfg.TracingOTLPEndpoint,
+		tracing.WithServiceVersion(version.Version),
+		tracing.WithSampleRatio(cfg.TracingSampleRatio),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot setup tracing")
+	}
+
+	if cfg.TracingOTLPEndpoint != "" {
+		log.WithFields(log.Fields{
+			"endpoint":    cfg.TracingOTLPEndpoint,
+			"sampleRatio": cfg.TracingSampleRatio,
+		}).Info("exporting traces")
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if err := shutdown(ctx); err != nil {
+			log.WithError(err).Warn("cannot export the pending traces")
+		}
+	}, nil
+}
+
+// checkSCIMAccessTokenExpiry warns when the AWS SSO SCIM access token is expired or it is close to expire.
+// The token dates are read from the configuration or from the tags of its secret.
+func checkSCIMAccessTokenExpiry(ctx context.Context) {
+	createdAt, expiresAt := cfg.AWSSCIMAccessTokenCreatedAt, cfg.AWSSCIMAccessTokenExpiresAt
+
+	if createdAt == "" && expiresAt == "" && scimAccessTokenRef != "" && secretsResolver != nil {
+		tags, err := secretsResolver.Tags(ctx, scimAccessTokenRef)
+		if err != nil {
+			log.WithError(err).Debug("cannot read the scim access token secret tags")
+		}
+		createdAt, expiresAt = tags[scimAccessTokenCreatedAtTag], tags[scimAccessTokenExpiresAtTag]
+	}
+
+	expiry, err := aws.NewSCIMAccessTokenExpiry(createdAt, expiresAt)
+	if err != nil {
+		log.WithError(err).Warn("cannot check the scim access token expiry")
+		return
+	}
+
+	remaining, ok := expiry.Remaining(time.Now())
+	if !ok {
+		log.Debug("the scim access token expiry is unknown, set its creation or expiry date to be warned before it expires")
+		return
+	}
+
+	expiresOn, _ := expiry.Expiry()
+	metricsRecorder.SCIMAccessTokenExpiry(expiresOn)
+
+	daysToExpiry := int(remaining.Hours() / 24)
+
+	fields := log.Fields{
+		"expiresAt":    expiresOn.Format(time.RFC3339),
+		"daysToExpiry": daysToExpiry,
+	}
+
+	switch {
+	case remaining <= 0:
+		log.WithFields(fields).Error("the scim access token is expired, generate a new one in AWS SSO and rotate it")
+	case daysToExpiry < cfg.AWSSCIMAccessTokenExpiryWarningDays:
+		log.WithFields(fields).Warn("the scim access token expires soon, generate a new one in AWS SSO and rotate it")
+	default:
+		log.WithFields(fields).Debug("the scim access token is not close to expire")
+	}
+}
+
+// serviceAccountContent returns the content of the service account, the value is the content itself
+// when it was read from a secret or when running as a lambda, otherwise it is the path of the file.
+func serviceAccountContent(value string) ([]byte, error) {
+	if cfg.IsLambda || strings.HasPrefix(strings.TrimSpace(value), "{") {
+		return []byte(value), nil
+	}
+
+	return os.ReadFile(value)
+}
+
+// userRules converts the configuration user rules to the identity provider ones
+func userRules(rules []config.UserRule) []idp.UserRule {
+	idpRules := make([]idp.UserRule, 0, len(rules))
+
+	for _, r := range rules {
+		idpRules = append(idpRules, idp.UserRule{
+			Email:           r.Email,
+			Domain:          r.Domain,
+			OrgUnitPath:     r.OrgUnitPath,
+			CustomAttribute: r.CustomAttribute,
+		})
+	}
+
+	return idpRules
+}
+
+// newIdentityProvider creates the identity provider service of a Google Workspace tenant,
+// when signerServiceAccount is set, the domain-wide delegation JWT is signed through the IAM Credentials API
+// using the serviceAccount credentials (e.g. workload identity federation) instead of a private key
+func newIdentityProvider(ctx context.Context, userEmail, signerServiceAccount string, serviceAccount []byte, customer string, domains []string, opts ...idp.IdentityProviderOption) (*idp.IdentityProvider, error) {
+	gwsAPIScopes := []string{
+		"https://www.googleapis.com/auth/admin.directory.group.readonly",
+		"https://www.googleapis.com/auth/admin.directory.group.member.readonly",
+		"https://www.googleapis.com/auth/admin.directory.user.readonly",
+	}
+
+	// Google Client Service
+	var gwsService *admin.Service
+	var err error
+
+	if signerServiceAccount != "" {
+		gwsService, err = google.NewServiceWithSignJWT(ctx, userEmail, signerServiceAccount, serviceAccount, gwsAPIScopes...)
+	} else {
+		gwsService, err = google.NewService(ctx, userEmail, serviceAccount, gwsAPIScopes...)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create google service")
+	}
+
+	// Google Directory Service
+	gwsDS, err := google.NewDirectoryService(gwsService, google.WithCustomer(customer), google.WithDomains(domains))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create google directory service")
+	}
+
+	return idp.NewIdentityProvider(gwsDS, opts...)
+}
+
+// newReportsService creates the Google Reports service of a Google Workspace tenant to get its changes,
+// the domain-wide delegation needs the admin.reports.audit.readonly scope too
+func newReportsService(ctx context.Context, userEmail, signerServiceAccount string, serviceAccount []byte) (*google.ReportsService, error) {
+	reportsAPIScope := "https://www.googleapis.com/auth/admin.reports.audit.readonly"
+
+	var reportsService *reports.Service
+	var err error
+
+	if signerServiceAccount != "" {
+		reportsService, err = google.NewReportsAPIServiceWithSignJWT(ctx, userEmail, signerServiceAccount, serviceAccount, reportsAPIScope)
+	} else {
+		reportsService, err = google.NewReportsAPIService(ctx, userEmail, serviceAccount, reportsAPIScope)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create google reports api service")
+	}
+
+	return google.NewReportsService(reportsService)
 }
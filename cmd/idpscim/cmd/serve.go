@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/admin"
+	"github.com/slashdevops/idp-scim-sync/internal/config"
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+	"github.com/slashdevops/idp-scim-sync/internal/metrics"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/scheduler"
+	"github.com/slashdevops/idp-scim-sync/internal/version"
+	"github.com/slashdevops/idp-scim-sync/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the sync continuously on a schedule",
+	Long: `
+Run as a long-running process (e.g. in Kubernetes) that syncs your Google Workspace Groups and Users
+to AWS Single Sign-On on a cron schedule or at a fixed interval, without overlapping runs.
+On SIGTERM or SIGINT the sync in progress is finished before exiting.
+An admin HTTP API exposes the health and readiness of the process, the state and the report of the last sync,
+and allows to trigger a sync (or a dry run) on demand.
+With a webhook token the admin HTTP API also receives the Google Workspace change events on /webhook,
+syncing only the groups and users changed within seconds.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return serve()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&cfg.ServeSchedule, "schedule", "", "cron schedule of the syncs, e.g. '*/15 * * * *' or '@hourly', instead of the interval")
+	serveCmd.Flags().DurationVar(&cfg.ServeInterval, "interval", config.DefaultServeInterval, "time between the end of a sync and the start of the next one")
+	serveCmd.Flags().DurationVar(&cfg.ServeJitter, "jitter", config.DefaultServeJitter, "maximum random delay added to the start of each sync")
+	serveCmd.Flags().BoolVar(&cfg.ServeRunOnStart, "run-on-start", config.DefaultServeRunOnStart, "run a sync as soon as the program starts")
+	serveCmd.Flags().DurationVar(
+		&cfg.ServeShutdownTimeout, "shutdown-timeout", config.DefaultServeShutdownTimeout,
+		"maximum time to wait for the sync in progress on shutdown before canceling it (0 waits forever)",
+	)
+	serveCmd.Flags().StringVar(&cfg.ServeStatusFile, "status-file", "", "file where the status of the last sync is written as JSON after each sync")
+	serveCmd.Flags().StringVar(&cfg.AdminAddress, "admin-address", config.DefaultAdminAddress, "address of the admin HTTP API, empty disables it")
+	serveCmd.Flags().StringVar(&cfg.AdminToken, "admin-token", "", "bearer token required by the /sync, /state and /last-report endpoints of the admin HTTP API")
+	serveCmd.Flags().StringVar(&cfg.WebhookToken, "webhook-token", "", "token of the Google Workspace events received by the /webhook endpoint of the admin HTTP API, empty disables it")
+	serveCmd.Flags().DurationVar(
+		&cfg.WebhookDebounce, "webhook-debounce", config.DefaultWebhookDebounce,
+		"time the webhook events are collected before triggering the sync of the groups and users changed",
+	)
+}
+
+func serve() error {
+	if cfg.IsLambda {
+		return errors.New("serve mode is not available in AWS Lambda")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	shutdownTracing, err := setupTracing()
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+
+	prom := metrics.NewPrometheusRecorder()
+	metricsRecorder = prom
+
+	svc, err := newSyncServices(ctx)
+	if err != nil {
+		return err
+	}
+
+	// lastReport is the report of the last sync, scheduled or triggered by the admin API
+	var lastReport atomic.Pointer[core.SyncReport]
+
+	syncJob := func(ss *core.SyncService, fn syncFunc) scheduler.Job {
+		return func(ctx context.Context) error {
+			err := svc.run(ctx, ss, fn)
+			lastReport.Store(ss.LastReport())
+			return err
+		}
+	}
+
+	opts := []scheduler.SchedulerOption{
+		scheduler.WithJitter(cfg.ServeJitter),
+		scheduler.WithRunOnStart(cfg.ServeRunOnStart),
+		scheduler.WithShutdownTimeout(cfg.ServeShutdownTimeout),
+		scheduler.WithStatusHandler(writeStatusFile),
+	}
+
+	if cfg.ServeSchedule != "" {
+		opts = append(opts, scheduler.WithCron(cfg.ServeSchedule))
+	} else {
+		opts = append(opts, scheduler.WithInterval(cfg.ServeInterval))
+	}
+
+	sched, err := scheduler.NewScheduler(syncJob(svc.sync, fullSync), opts...)
+	if err != nil {
+		return errors.Wrap(err, "cannot create scheduler")
+	}
+
+	log.WithFields(log.Fields{
+		"codeVersion": version.Version,
+		"schedule":    cfg.ServeSchedule,
+		"interval":    cfg.ServeInterval.String(),
+		"jitter":      cfg.ServeJitter.String(),
+	}).Info("starting serve mode")
+
+	adminErr := make(chan error, 1)
+
+	if cfg.AdminAddress != "" {
+		if cfg.AdminToken == "" {
+			log.Warn("the admin HTTP API has no token, the /sync, /state and /last-report endpoints are only protected by the loopback address")
+		}
+
+		adminOpts := []admin.ServerOption{
+			admin.WithToken(cfg.AdminToken),
+			admin.WithCheck("google", svc.idp.Check),
+			admin.WithCheck("scim", func(ctx context.Context) error {
+				_, err := svc.scim.ServiceProviderConfig(ctx)
+				return err
+			}),
+			admin.WithSyncTrigger(func(dryRun bool) (<-chan struct{}, error) {
+				if dryRun {
+					return sched.TriggerJob(syncJob(svc.dryRunSync, fullSync))
+				}
+				return sched.Trigger()
+			}),
+			admin.WithStateRepository(svc.repo),
+			admin.WithLastReport(lastReport.Load),
+			admin.WithMetricsHandler(prom.Handler()),
+		}
+
+		if cfg.WebhookToken != "" {
+			receiver, err := webhook.NewReceiver(
+				cfg.WebhookToken,
+				func(changes *model.IdentityProviderChanges) error {
+					// the changes are triggered again by the receiver when another sync is in progress
+					_, err := sched.TriggerJob(syncJob(svc.sync, changesSync(changes)))
+					return err
+				},
+				webhook.WithDebounce(cfg.WebhookDebounce),
+			)
+			if err != nil {
+				return errors.Wrap(err, "cannot create webhook receiver")
+			}
+			defer receiver.Stop()
+
+			adminOpts = append(adminOpts, admin.WithWebhookHandler(receiver))
+		}
+
+		srv, err := admin.NewServer(cfg.AdminAddress, adminOpts...)
+		if err != nil {
+			return errors.Wrap(err, "cannot create admin server")
+		}
+
+		go func() {
+			err := srv.ListenAndServe(ctx)
+			if err != nil {
+				// the serve mode stops when the admin API cannot be served
+				stop()
+			}
+			adminErr <- err
+		}()
+	} else {
+		if cfg.WebhookToken != "" {
+			log.Warn("the webhook token is ignored without the admin HTTP API address")
+		}
+		adminErr <- nil
+	}
+
+	runErr := sched.Run(ctx)
+	stop()
+
+	if err := <-adminErr; err != nil {
+		return errors.Wrap(err, "admin server stopped with error")
+	}
+
+	if runErr != nil {
+		return errors.Wrap(runErr, "scheduler stopped with error")
+	}
+
+	log.Info("serve mode stopped")
+
+	return nil
+}
+
+// writeStatusFile writes the status of the last sync to the configured status file, if any.
+func writeStatusFile(status scheduler.Status) {
+	if cfg.ServeStatusFile == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		log.WithError(err).Warn("cannot encode the sync status")
+		return
+	}
+
+	if err := os.WriteFile(cfg.ServeStatusFile, data, 0o644); err != nil {
+		log.WithError(err).Warn("cannot write the sync status file")
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync all the groups and users, or only one group or user",
+	Long: `
+Sync your Google Workspace Groups and Users to AWS Single Sign-On, the same as running the program without command.
+The subcommands sync only one group or user immediately, the rest of the groups and users are taken from the state.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sync(cmd.Context())
+	},
+}
+
+// syncGroupCmd represents the sync group command
+var syncGroupCmd = &cobra.Command{
+	Use:   "group <email|name>",
+	Short: "Sync only one group and its members",
+	Long: `
+Sync only the Google Workspace Group of the given email or name and its members to AWS Single Sign-On.
+The group is got from Google Workspace with its members, only the users that are not in the state are got,
+and only the group is reconciled with AWS SSO, the rest of the state is not changed.
+It needs the state of a previous full sync.`,
+	Example: `  idpscim sync group admins@example.com
+  idpscim sync group "AWS Administrators"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return syncGroups(cmd.Context(), groupSync(args[0]))
+	},
+}
+
+// syncUserCmd represents the sync user command
+var syncUserCmd = &cobra.Command{
+	Use:   "user <email>",
+	Short: "Sync only one user",
+	Long: `
+Sync only the Google Workspace User of the given email to AWS Single Sign-On.
+The user is got from Google Workspace and only the user is reconciled with AWS SSO, its memberships are removed
+when it is not synced anymore (e.g. suspended or excluded by the rules), the rest of the state is not changed.
+The user must be a member of the synced groups and it needs the state of a previous full sync.`,
+	Example: `  idpscim sync user john.doe@example.com`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return syncGroups(cmd.Context(), userSync(args[0]))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.AddCommand(syncGroupCmd)
+	syncCmd.AddCommand(syncUserCmd)
+}
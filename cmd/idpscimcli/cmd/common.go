@@ -1,10 +1,22 @@
 package cmd
 
 import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/repository"
 	"github.com/slashdevops/idp-scim-sync/internal/utils"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
 )
 
+// stateFile is the path to a local state file used instead of the AWS S3 Bucket
+var stateFile string
+
 // show resource structure as outFormat
 func show(outFormat string, resource interface{}) {
 	switch outFormat {
@@ -16,3 +28,105 @@ func show(outFormat string, resource interface{}) {
 		log.Infof("%s", utils.ToJSON(resource))
 	}
 }
+
+// getState returns the state from the local state file when it is set, otherwise from the AWS S3 Bucket
+func getState(ctx context.Context) (*model.State, error) {
+	if stateFile != "" {
+		f, err := os.Open(stateFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot open the state file")
+		}
+		defer f.Close()
+
+		repo, err := repository.NewDiskRepository(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create disk repository")
+		}
+
+		return repo.GetState(ctx)
+	}
+
+	repo, err := newS3Repository(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.GetState(ctx)
+}
+
+// setState stores the state in the local state file when it is set, otherwise in the AWS S3 Bucket
+func setState(ctx context.Context, state *model.State) error {
+	if stateFile != "" {
+		f, err := os.Create(stateFile)
+		if err != nil {
+			return errors.Wrap(err, "cannot create the state file")
+		}
+		defer f.Close()
+
+		repo, err := repository.NewDiskRepository(f)
+		if err != nil {
+			return errors.Wrap(err, "cannot create disk repository")
+		}
+
+		return repo.SetState(ctx, state)
+	}
+
+	repo, err := newS3Repository(ctx)
+	if err != nil {
+		return err
+	}
+
+	return repo.SetState(ctx, state)
+}
+
+// getStateData returns the content of the local state file when it is set, otherwise of the AWS S3 Bucket object,
+// without decoding it
+func getStateData(ctx context.Context) ([]byte, error) {
+	if stateFile != "" {
+		data, err := os.ReadFile(stateFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read the state file")
+		}
+
+		return data, nil
+	}
+
+	s3Client, err := newS3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &cfg.AWSS3BucketName,
+		Key:    &cfg.AWSS3BucketKey,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get the state from the s3 bucket")
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func newS3Client(ctx context.Context) (*s3.Client, error) {
+	awsConf, err := aws.NewDefaultConf(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load aws config")
+	}
+
+	return s3.NewFromConfig(awsConf), nil
+}
+
+func newS3Repository(ctx context.Context) (*repository.S3Repository, error) {
+	s3Client, err := newS3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := repository.NewS3Repository(s3Client, repository.WithBucket(cfg.AWSS3BucketName), repository.WithKey(cfg.AWSS3BucketKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create s3 repository")
+	}
+
+	return repo, nil
+}
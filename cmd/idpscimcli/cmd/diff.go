@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/config"
+	"github.com/slashdevops/idp-scim-sync/internal/idp"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/scim"
+	"github.com/slashdevops/idp-scim-sync/internal/utils"
+	"github.com/slashdevops/idp-scim-sync/internal/version"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+	"github.com/spf13/cobra"
+)
+
+// sides of the diff command
+const (
+	sideGWS   = "gws"
+	sideSCIM  = "scim"
+	sideState = "state"
+)
+
+var diffFormat string
+
+// command diff
+var diffCmd = &cobra.Command{
+	Use:   "diff <from> <to>",
+	Short: "Compare Google Workspace, AWS SSO SCIM and the state",
+	Long: `
+Compare the groups, users and groups members of two sides, gws (Google Workspace), scim (AWS SSO SCIM) or state,
+using the same operations of the sync, the side "from" is taken as the source and "to" as the target, so
+  + exists in "from" but not in "to", the sync creates it
+  ~ exists in both sides but the attributes are different, the sync updates it
+  - exists in "to" but not in "from", the sync removes it
+This command only reads the data of both sides, nothing is changed.
+NOTE: the groups members of AWS SSO SCIM are got checking every user in every group, it takes time.`,
+	Example: `  idpscimcli diff gws scim -s credentials.json -u admin@example.com -t <token> -e <endpoint>
+  idpscimcli diff gws state -s credentials.json -u admin@example.com --state-file state.json
+  idpscimcli diff state scim -b my-bucket -t <token> -e <endpoint> --format json`,
+	Args:      diffArgs,
+	ValidArgs: []string{sideGWS, sideSCIM, sideState},
+	RunE:      runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "diff format (text|json|yaml)")
+
+	diffCmd.Flags().StringVarP(&cfg.GWSServiceAccountFile, "gws-service-account-file", "s", config.DefaultGWSServiceAccountFile, "path to Google Workspace service account file")
+	diffCmd.Flags().StringVarP(&cfg.GWSUserEmail, "gws-user-email", "u", "", "Google Workspace user email with allowed access to the Google Workspace service account")
+	diffCmd.Flags().StringSliceVarP(
+		&cfg.GWSGroupsFilter, "gws-groups-filter", "q", []string{""},
+		"GWS Groups query parameter, example: --gws-groups-filter 'name:Admin* email:admin*' --gws-groups-filter 'name:Power* email:power*'",
+	)
+
+	diffCmd.Flags().StringVarP(&cfg.AWSSCIMAccessToken, "aws-scim-access-token", "t", "", "AWS SSO SCIM API Access Token")
+	diffCmd.Flags().StringVarP(&cfg.AWSSCIMEndpoint, "aws-scim-endpoint", "e", "", "AWS SSO SCIM API Endpoint")
+
+	diffCmd.Flags().StringVarP(&cfg.AWSS3BucketName, "aws-s3-bucket-name", "b", "", "AWS S3 Bucket name of the state")
+	diffCmd.Flags().StringVarP(&cfg.AWSS3BucketKey, "aws-s3-bucket-key", "k", config.DefaultAWSS3BucketKey, "AWS S3 Bucket key of the state")
+	diffCmd.Flags().StringVar(&stateFile, "state-file", "", "path to a local state file, used instead of the AWS S3 Bucket")
+}
+
+// diffArgs validates the sides to compare
+func diffArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactValidArgs(2)(cmd, args); err != nil {
+		return err
+	}
+
+	if args[0] == args[1] {
+		return fmt.Errorf("the sides to compare must be different: %s", args[0])
+	}
+
+	return nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reqTimeout)
+	defer cancel()
+
+	from, err := getSideResources(ctx, args[0])
+	if err != nil {
+		log.Errorf("error getting %s resources: %s", args[0], err)
+		return err
+	}
+
+	to, err := getSideResources(ctx, args[1])
+	if err != nil {
+		log.Errorf("error getting %s resources: %s", args[1], err)
+		return err
+	}
+
+	rd, err := model.ResourcesDifferences(from, to)
+	if err != nil {
+		log.Errorf("error comparing %s with %s: %s", args[0], args[1], err)
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+
+	switch diffFormat {
+	case "json":
+		fmt.Fprintln(out, utils.ToJSON(rd))
+	case "yaml":
+		fmt.Fprintln(out, utils.ToYAML(rd))
+	default:
+		printDiff(out, args[0], args[1], rd, to)
+	}
+
+	return nil
+}
+
+// getSideResources returns the groups, users and groups members of the side
+func getSideResources(ctx context.Context, side string) (*model.StateResources, error) {
+	switch side {
+	case sideGWS:
+		return getGWSResources(ctx)
+	case sideSCIM:
+		return getSCIMResources(ctx)
+	case sideState:
+		state, err := getState(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if state.Resources == nil {
+			return nil, errors.New("the state has no resources")
+		}
+
+		return state.Resources, nil
+	default:
+		return nil, fmt.Errorf("unknown side: %s", side)
+	}
+}
+
+// getGWSResources returns the groups, their members and users from Google Workspace, as the sync gets them
+func getGWSResources(ctx context.Context) (*model.StateResources, error) {
+	idpService, err := idp.NewIdentityProvider(getGWSDirectoryService(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create identity provider service")
+	}
+
+	groups, err := idpService.GetGroups(ctx, cfg.GWSGroupsFilter)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting groups")
+	}
+
+	groupsMembers, err := idpService.GetGroupsMembers(ctx, groups)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting groups members")
+	}
+
+	users, err := idpService.GetUsersByGroupsMembers(ctx, groupsMembers)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting users")
+	}
+
+	return &model.StateResources{Groups: groups, Users: users, GroupsMembers: groupsMembers}, nil
+}
+
+// getSCIMResources returns the groups, users and groups members from AWS SSO SCIM
+func getSCIMResources(ctx context.Context) (*model.StateResources, error) {
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.MaxIdleConns = 100
+	httpTransport.MaxConnsPerHost = 100
+	httpTransport.MaxIdleConnsPerHost = 100
+
+	httpClient := &http.Client{
+		Transport: httpTransport,
+		Timeout:   maxTimeout,
+	}
+
+	awsSCIMService, err := aws.NewSCIMService(httpClient, cfg.AWSSCIMEndpoint, cfg.AWSSCIMAccessToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating SCIM service")
+	}
+	awsSCIMService.UserAgent = "idp-scim-sync/" + version.Version
+
+	scimService, err := scim.NewProvider(awsSCIMService)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating SCIM provider")
+	}
+
+	groups, err := scimService.GetGroups(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting groups")
+	}
+
+	users, err := scimService.GetUsers(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting users")
+	}
+
+	groupsMembers, err := scimService.GetGroupsMembersBruteForce(ctx, groups, users)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting groups members")
+	}
+
+	return &model.StateResources{Groups: groups, Users: users, GroupsMembers: groupsMembers}, nil
+}
+
+// printDiff writes the differences in a human-readable format
+func printDiff(out io.Writer, from, to string, rd *model.ResourcesDiff, toResources *model.StateResources) {
+	fmt.Fprintf(out, "comparing %s (+) with %s (-)\n", from, to)
+
+	fmt.Fprintf(out, "\ngroups: %d to create, %d to update, %d equal, %d to remove\n",
+		rd.Groups.Create.Items, rd.Groups.Update.Items, rd.Groups.Equal.Items, rd.Groups.Remove.Items)
+
+	toGroups := make(map[string]*model.Group)
+	for _, group := range toResources.Groups.Resources {
+		toGroups[group.Name] = group
+	}
+
+	for _, group := range rd.Groups.Create.Resources {
+		fmt.Fprintf(out, "  + %s <%s>\n", group.Name, group.Email)
+	}
+	for _, group := range rd.Groups.Update.Resources {
+		fmt.Fprintf(out, "  ~ %s <%s>: ipid %s != %s\n", group.Name, group.Email, group.IPID, toGroups[group.Name].IPID)
+	}
+	for _, group := range rd.Groups.Remove.Resources {
+		fmt.Fprintf(out, "  - %s <%s>\n", group.Name, group.Email)
+	}
+
+	fmt.Fprintf(out, "\nusers: %d to create, %d to update, %d equal, %d to remove\n",
+		rd.Users.Create.Items, rd.Users.Update.Items, rd.Users.Equal.Items, rd.Users.Remove.Items)
+
+	toUsers := make(map[string]*model.User)
+	for _, user := range toResources.Users.Resources {
+		toUsers[user.Email] = user
+	}
+
+	for _, user := range rd.Users.Create.Resources {
+		fmt.Fprintf(out, "  + %s\n", user.Email)
+	}
+	for _, user := range rd.Users.Update.Resources {
+		fmt.Fprintf(out, "  ~ %s: %s\n", user.Email, strings.Join(model.UserChangedFields(user, toUsers[user.Email]), ", "))
+	}
+	for _, user := range rd.Users.Remove.Resources {
+		fmt.Fprintf(out, "  - %s\n", user.Email)
+	}
+
+	fmt.Fprintf(out, "\ngroups members: %d to create, %d equal, %d to remove\n",
+		membersCount(rd.GroupsMembers.Create), membersCount(rd.GroupsMembers.Equal), membersCount(rd.GroupsMembers.Remove))
+
+	for _, groupMembers := range rd.GroupsMembers.Create.Resources {
+		for _, member := range groupMembers.Resources {
+			fmt.Fprintf(out, "  + %s: %s\n", groupMembers.Group.Name, member.Email)
+		}
+	}
+	for _, groupMembers := range rd.GroupsMembers.Remove.Resources {
+		for _, member := range groupMembers.Resources {
+			fmt.Fprintf(out, "  - %s: %s\n", groupMembers.Group.Name, member.Email)
+		}
+	}
+
+	if rd.Empty() {
+		fmt.Fprintf(out, "\nno differences between %s and %s\n", from, to)
+	}
+}
+
+// membersCount returns the number of members of all the groups
+func membersCount(gmr *model.GroupsMembersResult) int {
+	count := 0
+	for _, groupMembers := range gmr.Resources {
+		count += len(groupMembers.Resources)
+	}
+
+	return count
+}
@@ -66,6 +66,8 @@ func initConfig() {
 		"gws_users_filter",
 		"aws_scim_access_token",
 		"aws_scim_endpoint",
+		"aws_s3_bucket_name",
+		"aws_s3_bucket_key",
 	}
 	for _, e := range envVars {
 		if err := viper.BindEnv(e); err != nil {
@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/config"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneGroups []string
+	pruneUsers  []string
+	importForce bool
+)
+
+// command state
+var (
+	// base state command
+	stateCmd = &cobra.Command{
+		Use:   "state",
+		Short: "State commands",
+		Long: `available commands to inspect and edit the state of the sync,
+stored in the AWS S3 Bucket or in a local file with --state-file.`,
+	}
+
+	// state show command
+	stateShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "show the state",
+		Long:  `show the state as --output-format.`,
+		Args:  cobra.NoArgs,
+		RunE:  runStateShow,
+	}
+
+	// state validate command
+	stateValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "validate the state",
+		Long: `validate the JSON of the state, unknown fields are not allowed, and its content, e.g. the items counts,
+the groups and users duplicated or the members of the groups that are not in the users.`,
+		Args: cobra.NoArgs,
+		RunE: runStateValidate,
+	}
+
+	// state verify-hash command
+	stateVerifyHashCmd = &cobra.Command{
+		Use:   "verify-hash",
+		Short: "verify the hash codes of the state",
+		Long: `calculate again the hash codes of the state and its resources to detect the ones changed by hand or corrupted,
+the sync uses the hash codes to detect the changes of the Identity Provider.`,
+		Args: cobra.NoArgs,
+		RunE: runStateVerifyHash,
+	}
+
+	// state prune command
+	statePruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "remove groups or users from the state",
+		Long: `remove groups, by name or email, and users, by email, from the state so the next sync creates them again.
+The members of the groups and the memberships of the users are removed too and the hash codes are calculated again.`,
+		Example: `  idpscimcli state prune -b my-bucket --group "AWS Administrators" --user john.doe@example.com
+  idpscimcli state prune --state-file state.json --group admins@example.com`,
+		Args: cobra.NoArgs,
+		RunE: runStatePrune,
+	}
+
+	// state export command
+	stateExportCmd = &cobra.Command{
+		Use:   "export [file]",
+		Short: "export the state to a file",
+		Long:  `export the state to the given file, or to the standard output without file.`,
+		Example: `  idpscimcli state export -b my-bucket state.json
+  idpscimcli state export -b my-bucket > state.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runStateExport,
+	}
+
+	// state import command
+	stateImportCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "import the state from a file",
+		Long: `import the state from the given file, it replaces the current one.
+The state is only imported when it is valid and its hash codes are right, unless --force is used.`,
+		Example: `  idpscimcli state import -b my-bucket state.json`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runStateImport,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+
+	stateCmd.AddCommand(stateShowCmd)
+	stateCmd.AddCommand(stateValidateCmd)
+	stateCmd.AddCommand(stateVerifyHashCmd)
+	stateCmd.AddCommand(statePruneCmd)
+	stateCmd.AddCommand(stateExportCmd)
+	stateCmd.AddCommand(stateImportCmd)
+
+	stateCmd.PersistentFlags().StringVarP(&cfg.AWSS3BucketName, "aws-s3-bucket-name", "b", "", "AWS S3 Bucket name of the state")
+	stateCmd.PersistentFlags().StringVarP(&cfg.AWSS3BucketKey, "aws-s3-bucket-key", "k", config.DefaultAWSS3BucketKey, "AWS S3 Bucket key of the state")
+	stateCmd.PersistentFlags().StringVar(&stateFile, "state-file", "", "path to a local state file, used instead of the AWS S3 Bucket")
+
+	statePruneCmd.Flags().StringSliceVar(&pruneGroups, "group", []string{}, "name or email of the group to remove, it can be repeated")
+	statePruneCmd.Flags().StringSliceVar(&pruneUsers, "user", []string{}, "email of the user to remove, it can be repeated")
+
+	stateImportCmd.Flags().BoolVar(&importForce, "force", false, "import the state even when it is not valid or its hash codes are not right")
+}
+
+func runStateShow(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reqTimeout)
+	defer cancel()
+
+	state, err := getState(ctx)
+	if err != nil {
+		log.Errorf("error getting the state: %s", err)
+		return err
+	}
+
+	show(outFormat, state)
+
+	return nil
+}
+
+func runStateValidate(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reqTimeout)
+	defer cancel()
+
+	data, err := getStateData(ctx)
+	if err != nil {
+		log.Errorf("error getting the state: %s", err)
+		return err
+	}
+
+	state, err := model.ParseState(data)
+	if err != nil {
+		log.Errorf("error parsing the state: %s", err)
+		return err
+	}
+
+	if err := reportIssues("the state is not valid", state.Validate()); err != nil {
+		return err
+	}
+
+	log.Info("the state is valid")
+
+	return nil
+}
+
+func runStateVerifyHash(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reqTimeout)
+	defer cancel()
+
+	state, err := getState(ctx)
+	if err != nil {
+		log.Errorf("error getting the state: %s", err)
+		return err
+	}
+
+	if err := reportIssues("the hash codes of the state are not right", state.VerifyHashCodes()); err != nil {
+		return err
+	}
+
+	log.Info("the hash codes of the state are right")
+
+	return nil
+}
+
+func runStatePrune(cmd *cobra.Command, args []string) error {
+	if len(pruneGroups) == 0 && len(pruneUsers) == 0 {
+		return errors.New("at least one --group or --user is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reqTimeout)
+	defer cancel()
+
+	state, err := getState(ctx)
+	if err != nil {
+		log.Errorf("error getting the state: %s", err)
+		return err
+	}
+
+	removed := 0
+	for _, group := range pruneGroups {
+		if !state.RemoveGroup(group) {
+			log.WithField("group", group).Warn("the group is not in the state")
+			continue
+		}
+
+		log.WithField("group", group).Info("group removed from the state")
+		removed++
+	}
+
+	for _, user := range pruneUsers {
+		if !state.RemoveUser(user) {
+			log.WithField("user", user).Warn("the user is not in the state")
+			continue
+		}
+
+		log.WithField("user", user).Info("user removed from the state")
+		removed++
+	}
+
+	if removed == 0 {
+		log.Info("nothing to remove, the state is not changed")
+		return nil
+	}
+
+	if err := setState(ctx, state); err != nil {
+		log.Errorf("error storing the state: %s", err)
+		return err
+	}
+
+	log.Infof("%d entries removed from the state, the next sync creates them again", removed)
+
+	return nil
+}
+
+func runStateExport(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reqTimeout)
+	defer cancel()
+
+	state, err := getState(ctx)
+	if err != nil {
+		log.Errorf("error getting the state: %s", err)
+		return err
+	}
+
+	var out io.ReadWriter = os.Stdout
+	if len(args) == 1 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			log.Errorf("error creating the file: %s", err)
+			return err
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	repo, err := repository.NewDiskRepository(out)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.SetState(ctx, state); err != nil {
+		log.Errorf("error exporting the state: %s", err)
+		return err
+	}
+
+	if len(args) == 1 {
+		log.Infof("state exported to %s", args[0])
+	}
+
+	return nil
+}
+
+func runStateImport(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reqTimeout)
+	defer cancel()
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		log.Errorf("error reading the file: %s", err)
+		return err
+	}
+
+	state, err := model.ParseState(data)
+	if err != nil {
+		log.Errorf("error parsing the state: %s", err)
+		return err
+	}
+
+	issues := append(state.Validate(), state.VerifyHashCodes()...)
+	if importForce {
+		for _, issue := range issues {
+			log.Warn(issue)
+		}
+	} else if err := reportIssues("the state to import is not valid, use --force to import it anyway", issues); err != nil {
+		return err
+	}
+
+	if err := setState(ctx, state); err != nil {
+		log.Errorf("error storing the state: %s", err)
+		return err
+	}
+
+	log.Infof("state imported from %s", args[0])
+
+	return nil
+}
+
+// reportIssues logs the issues and returns an error when there is any
+func reportIssues(message string, issues []string) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	for _, issue := range issues {
+		log.Error(issue)
+	}
+
+	return fmt.Errorf("%s: %d issues found", message, len(issues))
+}
@@ -0,0 +1,367 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/repository"
+	"github.com/slashdevops/idp-scim-sync/internal/scheduler"
+)
+
+const (
+	// DefaultCheckTimeout is the default timeout of all the readiness checks.
+	DefaultCheckTimeout = 10 * time.Second
+
+	// DefaultShutdownTimeout is the default time waited for the in-flight requests on shutdown.
+	DefaultShutdownTimeout = 5 * time.Second
+
+	statusOK    = "ok"
+	statusError = "error"
+)
+
+var (
+	// ErrAddressEmpty is returned when the address of the server is empty.
+	ErrAddressEmpty = errors.New("admin: address cannot be empty")
+
+	// ErrCheckNil is returned when a readiness check is nil.
+	ErrCheckNil = errors.New("admin: readiness check cannot be nil")
+
+	// ErrTokenRequired is returned when the server listens on a non-loopback address without token.
+	ErrTokenRequired = errors.New("admin: token is required to listen on a non-loopback address")
+)
+
+// Check is a readiness check, it returns an error when the dependency is not ready.
+type Check func(ctx context.Context) error
+
+// SyncTrigger starts a sync out of the schedule, the returned channel is closed when the sync finishes.
+type SyncTrigger func(dryRun bool) (<-chan struct{}, error)
+
+// StateRepository is the interface that wraps the state repository methods used by the server.
+type StateRepository interface {
+	GetState(ctx context.Context) (*model.State, error)
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// CheckResult is the result of a readiness check.
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Readiness is the response of the readiness endpoint.
+type Readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Server is the HTTP admin API of the serve mode.
+//
+// The endpoints are:
+//   - GET /healthz: liveness, always ok while the process is up.
+//   - GET /readyz: readiness, runs the checks of the dependencies.
+//   - POST /sync: triggers a sync, ?dryRun=true runs it without applying the changes, ?wait=true waits the sync and returns its report.
+//   - GET /state: the current state stored in the state repository.
+//   - GET /last-report: the report of the last sync.
+//   - GET /metrics: the metrics in the Prometheus format.
+//   - POST /webhook: the Google Workspace change events triggering the sync of the groups and users changed.
+//
+// The endpoints /sync, /state and /last-report are protected by a bearer token when it is given,
+// the /webhook endpoint verifies its own token.
+type Server struct {
+	addr            string
+	token           string
+	checks          []namedCheck
+	checkTimeout    time.Duration
+	shutdownTimeout time.Duration
+	trigger         SyncTrigger
+	repo            StateRepository
+	lastReport      func() *core.SyncReport
+	metrics         http.Handler
+	webhook         http.Handler
+}
+
+// NewServer returns a new admin Server listening on the given address.
+func NewServer(addr string, opts ...ServerOption) (*Server, error) {
+	if addr == "" {
+		return nil, ErrAddressEmpty
+	}
+
+	s := &Server{
+		addr:            addr,
+		checkTimeout:    DefaultCheckTimeout,
+		shutdownTimeout: DefaultShutdownTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, c := range s.checks {
+		if c.check == nil {
+			return nil, fmt.Errorf("%w: %s", ErrCheckNil, c.name)
+		}
+	}
+
+	// without token the /sync and /state endpoints are only reachable from the host
+	if s.token == "" && !isLoopback(addr) {
+		return nil, fmt.Errorf("%w: %s", ErrTokenRequired, addr)
+	}
+
+	return s, nil
+}
+
+// isLoopback returns true when the host of the address is localhost or a loopback IP,
+// an address without host, e.g. ":8080", listens on all the interfaces.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Handler returns the handler of the admin API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", method(http.MethodGet, s.healthz))
+	mux.HandleFunc("/readyz", method(http.MethodGet, s.readyz))
+
+	if s.trigger != nil {
+		mux.HandleFunc("/sync", method(http.MethodPost, s.authorized(s.sync)))
+	}
+
+	if s.repo != nil {
+		mux.HandleFunc("/state", method(http.MethodGet, s.authorized(s.state)))
+	}
+
+	if s.lastReport != nil {
+		mux.HandleFunc("/last-report", method(http.MethodGet, s.authorized(s.report)))
+	}
+
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics)
+	}
+
+	if s.webhook != nil {
+		mux.Handle("/webhook", s.webhook)
+	}
+
+	return mux
+}
+
+// ListenAndServe serves the admin API until the context is done, then it shuts down the server
+// waiting the in-flight requests up to the shutdown timeout.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.WithField("address", s.addr).Info("admin: listening")
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("admin: error serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("admin: error shutting down: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.checkTimeout)
+	defer cancel()
+
+	code := http.StatusOK
+	readiness := Readiness{
+		Status: statusOK,
+		Checks: make(map[string]CheckResult, len(s.checks)),
+	}
+
+	for _, c := range s.checks {
+		if err := c.check(ctx); err != nil {
+			log.WithField("check", c.name).WithError(err).Warn("admin: readiness check failed")
+
+			code = http.StatusServiceUnavailable
+			readiness.Status = statusError
+			readiness.Checks[c.name] = CheckResult{Status: statusError, Error: err.Error()}
+			continue
+		}
+
+		readiness.Checks[c.name] = CheckResult{Status: statusOK}
+	}
+
+	writeJSON(w, code, readiness)
+}
+
+func (s *Server) sync(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := queryBool(r, "dryRun")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	wait, err := queryBool(r, "wait")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	done, err := s.trigger(dryRun)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrRunInProgress) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"dryRun": dryRun,
+		"remote": r.RemoteAddr,
+	}).Info("admin: sync triggered")
+
+	if !wait {
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{"status": "triggered", "dryRun": dryRun})
+		return
+	}
+
+	select {
+	case <-r.Context().Done():
+		return
+	case <-done:
+	}
+
+	if s.lastReport == nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "finished", "dryRun": dryRun})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.lastReport())
+}
+
+func (s *Server) state(w http.ResponseWriter, r *http.Request) {
+	state, err := s.repo.GetState(r.Context())
+	if err != nil {
+		var nsk *types.NoSuchKey
+		var stateFileEmpty *repository.ErrStateFileEmpty
+
+		if errors.As(err, &nsk) || errors.As(err, &stateFileEmpty) {
+			writeError(w, http.StatusNotFound, errors.New("admin: there is no state yet"))
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) report(w http.ResponseWriter, r *http.Request) {
+	report := s.lastReport()
+	if report == nil {
+		writeError(w, http.StatusNotFound, errors.New("admin: there is no sync report yet"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// authorized requires the bearer token on the request when the server has a token.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			want := "Bearer " + s.token
+			got := r.Header.Get("Authorization")
+
+			if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, errors.New("admin: unauthorized"))
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// method only allows the given HTTP method.
+func method(m string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != m {
+			w.Header().Set("Allow", m)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("admin: method %s not allowed", r.Method))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func queryBool(r *http.Request, key string) (bool, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("admin: invalid value %q for %s", value, key)
+	}
+
+	return b, nil
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("admin: error writing the response")
+	}
+}
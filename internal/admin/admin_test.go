@@ -0,0 +1,301 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/scheduler"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func doRequest(t *testing.T, s *Server, method, target, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestNewServer(t *testing.T) {
+	t.Run("Should return a server", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithCheck("test", func(ctx context.Context) error { return nil }))
+		assert.NoError(t, err)
+		assert.NotNil(t, s)
+		assert.Equal(t, DefaultCheckTimeout, s.checkTimeout)
+	})
+
+	t.Run("Should return an error when the address is empty", func(t *testing.T) {
+		s, err := NewServer("")
+		assert.ErrorIs(t, err, ErrAddressEmpty)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should return an error when a check is nil", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithCheck("test", nil))
+		assert.ErrorIs(t, err, ErrCheckNil)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should return an error without token on a non-loopback address", func(t *testing.T) {
+		for _, addr := range []string{":8080", "0.0.0.0:8080", "10.0.0.1:8080"} {
+			s, err := NewServer(addr)
+			assert.ErrorIs(t, err, ErrTokenRequired, addr)
+			assert.Nil(t, s)
+		}
+	})
+
+	t.Run("Should return a server without token on a loopback address", func(t *testing.T) {
+		for _, addr := range []string{"127.0.0.1:8080", "localhost:8080", "[::1]:8080"} {
+			s, err := NewServer(addr)
+			assert.NoError(t, err, addr)
+			assert.NotNil(t, s)
+		}
+	})
+
+	t.Run("Should return a server with token on a non-loopback address", func(t *testing.T) {
+		s, err := NewServer(":8080", WithToken("secret"))
+		assert.NoError(t, err)
+		assert.NotNil(t, s)
+	})
+}
+
+func TestServer_Healthz(t *testing.T) {
+	s, err := NewServer("127.0.0.1:8080")
+	assert.NoError(t, err)
+
+	t.Run("Should return ok", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/healthz", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+	})
+
+	t.Run("Should not allow other methods", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPost, "/healthz", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestServer_Readyz(t *testing.T) {
+	t.Run("Should return ok when all the checks pass", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080",
+			WithCheck("google", func(ctx context.Context) error { return nil }),
+			WithCheck("scim", func(ctx context.Context) error { return nil }),
+		)
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/readyz", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ok","checks":{"google":{"status":"ok"},"scim":{"status":"ok"}}}`, rec.Body.String())
+	})
+
+	t.Run("Should return service unavailable when a check fails", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080",
+			WithCheck("google", func(ctx context.Context) error { return nil }),
+			WithCheck("scim", func(ctx context.Context) error { return errors.New("test error") }),
+		)
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/readyz", "")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"error","checks":{"google":{"status":"ok"},"scim":{"status":"error","error":"test error"}}}`, rec.Body.String())
+	})
+}
+
+func TestServer_Sync(t *testing.T) {
+	t.Run("Should trigger a sync", func(t *testing.T) {
+		var gotDryRun bool
+		s, err := NewServer("127.0.0.1:8080", WithSyncTrigger(func(dryRun bool) (<-chan struct{}, error) {
+			gotDryRun = dryRun
+			return make(chan struct{}), nil
+		}))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/sync?dryRun=true", "")
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.JSONEq(t, `{"status":"triggered","dryRun":true}`, rec.Body.String())
+		assert.True(t, gotDryRun)
+	})
+
+	t.Run("Should wait the sync and return its report", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080",
+			WithSyncTrigger(func(dryRun bool) (<-chan struct{}, error) {
+				done := make(chan struct{})
+				close(done)
+				return done, nil
+			}),
+			WithLastReport(func() *core.SyncReport {
+				return &core.SyncReport{Success: true, Groups: core.OperationsReport{Create: 2}}
+			}),
+		)
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/sync?wait=true", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var report core.SyncReport
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.True(t, report.Success)
+		assert.Equal(t, 2, report.Groups.Create)
+	})
+
+	t.Run("Should return conflict when a sync is in progress", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithSyncTrigger(func(dryRun bool) (<-chan struct{}, error) {
+			return nil, scheduler.ErrRunInProgress
+		}))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/sync", "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Should return bad request with an invalid parameter", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithSyncTrigger(func(dryRun bool) (<-chan struct{}, error) {
+			t.Fatal("the sync should not be triggered")
+			return nil, nil
+		}))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/sync?dryRun=maybe", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Should require the token when it is given", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithToken("secret"), WithSyncTrigger(func(dryRun bool) (<-chan struct{}, error) {
+			return make(chan struct{}), nil
+		}))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/sync", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = doRequest(t, s, http.MethodPost, "/sync", "wrong")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = doRequest(t, s, http.MethodPost, "/sync", "secret")
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	})
+
+	t.Run("Should not expose the endpoint without trigger", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080")
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/sync", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestServer_State(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return the state", func(t *testing.T) {
+		repo := mocks.NewMockStateRepository(mockCtrl)
+		repo.EXPECT().GetState(gomock.Any()).Return(model.StateBuilder().WithLastSync("2022-01-10T10:00:00Z").Build(), nil).Times(1)
+
+		s, err := NewServer("127.0.0.1:8080", WithStateRepository(repo))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/state", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var state model.State
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+		assert.Equal(t, "2022-01-10T10:00:00Z", state.LastSync)
+	})
+
+	t.Run("Should return not found when there is no state", func(t *testing.T) {
+		repo := mocks.NewMockStateRepository(mockCtrl)
+		repo.EXPECT().GetState(gomock.Any()).Return(nil, &types.NoSuchKey{}).Times(1)
+
+		s, err := NewServer("127.0.0.1:8080", WithStateRepository(repo))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/state", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Should return internal server error when the state cannot be read", func(t *testing.T) {
+		repo := mocks.NewMockStateRepository(mockCtrl)
+		repo.EXPECT().GetState(gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		s, err := NewServer("127.0.0.1:8080", WithStateRepository(repo))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/state", "")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.JSONEq(t, `{"error":"test error"}`, rec.Body.String())
+	})
+}
+
+func TestServer_LastReport(t *testing.T) {
+	t.Run("Should return not found when there is no report yet", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithLastReport(func() *core.SyncReport { return nil }))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/last-report", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Should return the last report", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithLastReport(func() *core.SyncReport {
+			return &core.SyncReport{DryRun: true, Success: true}
+		}))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/last-report", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var report core.SyncReport
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		assert.True(t, report.Success)
+	})
+}
+
+func TestServer_Metrics(t *testing.T) {
+	t.Run("Should serve the metrics without token", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithToken("secret"), WithMetricsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("idpscim_sync_runs_total 1\n"))
+		})))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/metrics", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "idpscim_sync_runs_total 1\n", rec.Body.String())
+	})
+}
+
+func TestServer_Webhook(t *testing.T) {
+	t.Run("Should serve the webhook without token", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080", WithToken("secret"), WithWebhookHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		})))
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/webhook", "")
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	})
+
+	t.Run("Should not serve the webhook without handler", func(t *testing.T) {
+		s, err := NewServer("127.0.0.1:8080")
+		assert.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodPost, "/webhook", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+)
+
+// ServerOption is a function that can be used to configure the admin Server.
+type ServerOption func(*Server)
+
+// WithToken protects the /sync, /state and /last-report endpoints with the given bearer token.
+func WithToken(token string) ServerOption {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithCheck adds a readiness check run by the /readyz endpoint.
+func WithCheck(name string, check Check) ServerOption {
+	return func(s *Server) {
+		s.checks = append(s.checks, namedCheck{name: name, check: check})
+	}
+}
+
+// WithCheckTimeout sets the timeout of all the readiness checks.
+func WithCheckTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.checkTimeout = timeout
+		}
+	}
+}
+
+// WithShutdownTimeout sets the time waited for the in-flight requests on shutdown.
+func WithShutdownTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.shutdownTimeout = timeout
+		}
+	}
+}
+
+// WithSyncTrigger enables the /sync endpoint.
+func WithSyncTrigger(trigger SyncTrigger) ServerOption {
+	return func(s *Server) {
+		s.trigger = trigger
+	}
+}
+
+// WithStateRepository enables the /state endpoint.
+func WithStateRepository(repo StateRepository) ServerOption {
+	return func(s *Server) {
+		s.repo = repo
+	}
+}
+
+// WithLastReport enables the /last-report endpoint, it is also used to return the report of the /sync?wait=true requests.
+func WithLastReport(lastReport func() *core.SyncReport) ServerOption {
+	return func(s *Server) {
+		s.lastReport = lastReport
+	}
+}
+
+// WithMetricsHandler enables the /metrics endpoint served by the given handler, it is not protected by the token.
+func WithMetricsHandler(handler http.Handler) ServerOption {
+	return func(s *Server) {
+		s.metrics = handler
+	}
+}
+
+// WithWebhookHandler enables the /webhook endpoint served by the given handler, it is not protected by the token
+// because the handler verifies the token of the events.
+func WithWebhookHandler(handler http.Handler) ServerOption {
+	return func(s *Server) {
+		s.webhook = handler
+	}
+}
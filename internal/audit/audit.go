@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operations over the SCIM resources
+const (
+	OperationCreate        = "create"
+	OperationUpdate        = "update"
+	OperationDeactivate    = "deactivate"
+	OperationDelete        = "delete"
+	OperationAddMembers    = "add_members"
+	OperationRemoveMembers = "remove_members"
+)
+
+// Types of the SCIM resources
+const (
+	ResourceTypeGroup = "Group"
+	ResourceTypeUser  = "User"
+)
+
+// Outcomes of the SCIM requests
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Record is the audit record of a mutation of a SCIM resource.
+type Record struct {
+	Time         time.Time              `json:"time"`
+	RunID        string                 `json:"runId,omitempty"`
+	Operation    string                 `json:"operation"`
+	ResourceType string                 `json:"resourceType"`
+	SCIMID       string                 `json:"scimId,omitempty"`
+	IPID         string                 `json:"idpId,omitempty"`
+	Before       map[string]interface{} `json:"before,omitempty"`
+	After        map[string]interface{} `json:"after,omitempty"`
+	Outcome      string                 `json:"outcome"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Sink is the destination of the audit records.
+type Sink interface {
+	// Write writes the record, the sinks could buffer it until Flush is called
+	Write(ctx context.Context, r *Record) error
+
+	// Flush writes the buffered records, it is called at the end of every sync
+	Flush(ctx context.Context) error
+}
+
+// MultiSink is a Sink that writes the records in several sinks, e.g. a local file and S3.
+type MultiSink []Sink
+
+// Write writes the record in all the sinks, all of them are tried even when some of them fail.
+func (m MultiSink) Write(ctx context.Context, r *Record) error {
+	var errs []string
+	for _, s := range m {
+		if err := s.Write(ctx, r); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return multiError("writing", errs, len(m))
+}
+
+// Flush flushes all the sinks, all of them are tried even when some of them fail.
+func (m MultiSink) Flush(ctx context.Context) error {
+	var errs []string
+	for _, s := range m {
+		if err := s.Flush(ctx); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return multiError("flushing", errs, len(m))
+}
+
+func multiError(action string, errs []string, sinks int) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("audit: error %s %d of %d sinks: %s", action, len(errs), sinks, strings.Join(errs, "; "))
+}
+
+type runIDKey struct{}
+
+// NewRunID returns a new unique identifier of a sync run.
+func NewRunID() string {
+	return uuid.NewString()
+}
+
+// ContextWithRunID returns a copy of the context with the identifier of the sync run,
+// the audit records written with it are correlated by this identifier.
+func ContextWithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunID returns the identifier of the sync run of the context, empty when there is none.
+func RunID(ctx context.Context) string {
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	return runID
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunID(t *testing.T) {
+	t.Run("Should return the run id of the context", func(t *testing.T) {
+		ctx := ContextWithRunID(context.TODO(), "run-1")
+		assert.Equal(t, "run-1", RunID(ctx))
+	})
+
+	t.Run("Should return empty without run id", func(t *testing.T) {
+		assert.Equal(t, "", RunID(context.TODO()))
+	})
+
+	t.Run("Should return unique run ids", func(t *testing.T) {
+		assert.NotEqual(t, NewRunID(), NewRunID())
+		assert.Len(t, NewRunID(), 36)
+	})
+}
+
+// fakeSink records the records written and the flushes, failing with err.
+type fakeSink struct {
+	records []*Record
+	flushes int
+	err     error
+}
+
+func (s *fakeSink) Write(ctx context.Context, r *Record) error {
+	s.records = append(s.records, r)
+	return s.err
+}
+
+func (s *fakeSink) Flush(ctx context.Context) error {
+	s.flushes++
+	return s.err
+}
+
+func TestMultiSink(t *testing.T) {
+	t.Run("Should write and flush all the sinks", func(t *testing.T) {
+		s1, s2 := &fakeSink{}, &fakeSink{}
+		ctx := context.TODO()
+		r := &Record{Operation: OperationCreate}
+
+		m := MultiSink{s1, s2}
+		assert.NoError(t, m.Write(ctx, r))
+		assert.NoError(t, m.Flush(ctx))
+
+		assert.Equal(t, []*Record{r}, s1.records)
+		assert.Equal(t, []*Record{r}, s2.records)
+		assert.Equal(t, 1, s1.flushes)
+		assert.Equal(t, 1, s2.flushes)
+	})
+
+	t.Run("Should try all the sinks and return their errors", func(t *testing.T) {
+		s1, s2 := &fakeSink{err: errors.New("test error")}, &fakeSink{}
+		ctx := context.TODO()
+		r := &Record{Operation: OperationCreate}
+
+		m := MultiSink{s1, s2}
+		assert.EqualError(t, m.Write(ctx, r), "audit: error writing 1 of 2 sinks: test error")
+		assert.EqualError(t, m.Flush(ctx), "audit: error flushing 1 of 2 sinks: test error")
+
+		assert.Len(t, s2.records, 1)
+		assert.Equal(t, 1, s2.flushes)
+	})
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// CloudWatch Logs limits of the PutLogEvents requests
+// reference: https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+const (
+	maxLogEventsPerRequest = 10000
+	maxLogEventsBytes      = 1048576
+	logEventOverheadBytes  = 26
+)
+
+var (
+	// ErrCloudWatchLogsClientNil is returned when the CloudWatch Logs client is nil.
+	ErrCloudWatchLogsClientNil = errors.New("audit: CloudWatch Logs client cannot be nil")
+
+	// ErrLogGroupEmpty is returned when the name of the log group is empty.
+	ErrLogGroupEmpty = errors.New("audit: CloudWatch Logs log group cannot be empty")
+
+	// ErrLogStreamEmpty is returned when the name of the log stream is empty.
+	ErrLogStreamEmpty = errors.New("audit: CloudWatch Logs log stream cannot be empty")
+)
+
+//go:generate go run github.com/golang/mock/mockgen@v1.6.0 -package=mocks -destination=../../mocks/audit/cloudwatchlogs_mocks.go -source=cloudwatchlogs.go CloudWatchLogsClientAPI
+
+// CloudWatchLogsClientAPI is the interface to consume the cloudwatchlogs client methods.
+type CloudWatchLogsClientAPI interface {
+	CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+}
+
+// CloudWatchLogsSink buffers the audit records and sends them as JSON log events to a
+// CloudWatch Logs log stream on every flush. The log group must exist, the log stream
+// is created when it doesn't exist.
+type CloudWatchLogsSink struct {
+	client CloudWatchLogsClientAPI
+	group  string
+	stream string
+
+	mu            sync.Mutex
+	events        []types.InputLogEvent
+	streamCreated bool
+	sequenceToken *string
+}
+
+// NewCloudWatchLogsSink returns a new CloudWatchLogsSink sending the records to the log stream of the log group.
+func NewCloudWatchLogsSink(client CloudWatchLogsClientAPI, group, stream string) (*CloudWatchLogsSink, error) {
+	if client == nil {
+		return nil, ErrCloudWatchLogsClientNil
+	}
+	if group == "" {
+		return nil, ErrLogGroupEmpty
+	}
+	if stream == "" {
+		return nil, ErrLogStreamEmpty
+	}
+
+	return &CloudWatchLogsSink{
+		client: client,
+		group:  group,
+		stream: stream,
+	}, nil
+}
+
+// Write buffers the record until the next flush, the time of the event is the time of the record.
+func (s *CloudWatchLogsSink) Write(ctx context.Context, r *Record) error {
+	line, err := marshalLine(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, types.InputLogEvent{
+		Message:   aws.String(string(line[:len(line)-1])),
+		Timestamp: aws.Int64(r.Time.UnixMilli()),
+	})
+	return nil
+}
+
+// Flush sends the buffered records in as many requests as the CloudWatch Logs limits require,
+// the records not sent are kept in the buffer when a request fails, so the next flush retries them.
+func (s *CloudWatchLogsSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.events) == 0 {
+		return nil
+	}
+
+	if err := s.createLogStream(ctx); err != nil {
+		return err
+	}
+
+	for len(s.events) > 0 {
+		n := batchSize(s.events)
+
+		out, err := s.client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(s.group),
+			LogStreamName: aws.String(s.stream),
+			LogEvents:     s.events[:n],
+			SequenceToken: s.sequenceToken,
+		})
+		if err != nil {
+			return fmt.Errorf("audit: error putting log events: log group: %s, log stream: %s, error: %w", s.group, s.stream, err)
+		}
+
+		s.sequenceToken = out.NextSequenceToken
+		s.events = s.events[n:]
+	}
+
+	s.events = nil
+	return nil
+}
+
+// createLogStream creates the log stream once, it is not an error when it already exists.
+func (s *CloudWatchLogsSink) createLogStream(ctx context.Context) error {
+	if s.streamCreated {
+		return nil
+	}
+
+	_, err := s.client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(s.group),
+		LogStreamName: aws.String(s.stream),
+	})
+	if err != nil {
+		var ae *types.ResourceAlreadyExistsException
+		if !errors.As(err, &ae) {
+			return fmt.Errorf("audit: error creating log stream: log group: %s, log stream: %s, error: %w", s.group, s.stream, err)
+		}
+	}
+
+	s.streamCreated = true
+	return nil
+}
+
+// batchSize returns the number of events of the next request, within the limits of events and bytes per request.
+func batchSize(events []types.InputLogEvent) int {
+	size := 0
+	for i, e := range events {
+		size += len(aws.ToString(e.Message)) + logEventOverheadBytes
+		if i == maxLogEventsPerRequest || (size > maxLogEventsBytes && i > 0) {
+			return i
+		}
+	}
+
+	return len(events)
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/golang/mock/gomock"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCloudWatchLogsSink(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return an error without client", func(t *testing.T) {
+		s, err := NewCloudWatchLogsSink(nil, "group", "stream")
+		assert.ErrorIs(t, err, ErrCloudWatchLogsClientNil)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should return an error without log group", func(t *testing.T) {
+		s, err := NewCloudWatchLogsSink(mocks.NewMockCloudWatchLogsClientAPI(mockCtrl), "", "stream")
+		assert.ErrorIs(t, err, ErrLogGroupEmpty)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should return an error without log stream", func(t *testing.T) {
+		s, err := NewCloudWatchLogsSink(mocks.NewMockCloudWatchLogsClientAPI(mockCtrl), "group", "")
+		assert.ErrorIs(t, err, ErrLogStreamEmpty)
+		assert.Nil(t, s)
+	})
+}
+
+func TestCloudWatchLogsSink_Flush(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should not send anything without records", func(t *testing.T) {
+		mockCWL := mocks.NewMockCloudWatchLogsClientAPI(mockCtrl)
+		mockCWL.EXPECT().CreateLogStream(gomock.Any(), gomock.Any()).Times(0)
+		mockCWL.EXPECT().PutLogEvents(gomock.Any(), gomock.Any()).Times(0)
+
+		s, err := NewCloudWatchLogsSink(mockCWL, "group", "stream")
+		assert.NoError(t, err)
+		assert.NoError(t, s.Flush(context.TODO()))
+	})
+
+	t.Run("Should create the log stream once and send the records", func(t *testing.T) {
+		mockCWL := mocks.NewMockCloudWatchLogsClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		mockCWL.EXPECT().CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  aws.String("group"),
+			LogStreamName: aws.String("stream"),
+		}).Return(nil, &types.ResourceAlreadyExistsException{}).Times(1)
+
+		gomock.InOrder(
+			mockCWL.EXPECT().PutLogEvents(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, in *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+					assert.Equal(t, "group", aws.ToString(in.LogGroupName))
+					assert.Equal(t, "stream", aws.ToString(in.LogStreamName))
+					assert.Nil(t, in.SequenceToken)
+					assert.Len(t, in.LogEvents, 1)
+					assert.Equal(t, now.UnixMilli(), aws.ToInt64(in.LogEvents[0].Timestamp))
+					assert.JSONEq(t, `{"time":"2022-12-01T10:00:00Z","runId":"run-1","operation":"create","resourceType":"Group","outcome":"success"}`, aws.ToString(in.LogEvents[0].Message))
+
+					return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String("token-1")}, nil
+				}).Times(1),
+			mockCWL.EXPECT().PutLogEvents(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, in *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+					assert.Equal(t, "token-1", aws.ToString(in.SequenceToken))
+					assert.Len(t, in.LogEvents, 1)
+
+					return &cloudwatchlogs.PutLogEventsOutput{}, nil
+				}).Times(1),
+		)
+
+		s, err := NewCloudWatchLogsSink(mockCWL, "group", "stream")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, RunID: "run-1", Operation: OperationCreate, ResourceType: ResourceTypeGroup, Outcome: OutcomeSuccess}))
+		assert.NoError(t, s.Flush(ctx))
+
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, RunID: "run-2", Operation: OperationDelete, ResourceType: ResourceTypeGroup, Outcome: OutcomeSuccess}))
+		assert.NoError(t, s.Flush(ctx))
+	})
+
+	t.Run("Should return an error when the log stream cannot be created", func(t *testing.T) {
+		mockCWL := mocks.NewMockCloudWatchLogsClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		mockCWL.EXPECT().CreateLogStream(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+		mockCWL.EXPECT().PutLogEvents(gomock.Any(), gomock.Any()).Times(0)
+
+		s, err := NewCloudWatchLogsSink(mockCWL, "group", "stream")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, Operation: OperationCreate, ResourceType: ResourceTypeGroup, Outcome: OutcomeSuccess}))
+		assert.Error(t, s.Flush(ctx))
+	})
+
+	t.Run("Should keep the records not sent when a request fails", func(t *testing.T) {
+		mockCWL := mocks.NewMockCloudWatchLogsClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		mockCWL.EXPECT().CreateLogStream(ctx, gomock.Any()).Return(&cloudwatchlogs.CreateLogStreamOutput{}, nil).Times(1)
+		gomock.InOrder(
+			mockCWL.EXPECT().PutLogEvents(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1),
+			mockCWL.EXPECT().PutLogEvents(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, in *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+					assert.Len(t, in.LogEvents, 2)
+					return &cloudwatchlogs.PutLogEventsOutput{}, nil
+				}).Times(1),
+		)
+
+		s, err := NewCloudWatchLogsSink(mockCWL, "group", "stream")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, Operation: OperationCreate, ResourceType: ResourceTypeGroup, Outcome: OutcomeSuccess}))
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, Operation: OperationDelete, ResourceType: ResourceTypeGroup, Outcome: OutcomeSuccess}))
+		assert.Error(t, s.Flush(ctx))
+		assert.NoError(t, s.Flush(ctx))
+	})
+}
+
+func TestBatchSize(t *testing.T) {
+	event := func(size int) types.InputLogEvent {
+		return types.InputLogEvent{Message: aws.String(strings.Repeat("x", size))}
+	}
+
+	t.Run("Should return all the events under the limits", func(t *testing.T) {
+		events := []types.InputLogEvent{event(10), event(10)}
+		assert.Equal(t, 2, batchSize(events))
+	})
+
+	t.Run("Should limit the number of events", func(t *testing.T) {
+		events := make([]types.InputLogEvent, maxLogEventsPerRequest+5)
+		for i := range events {
+			events[i] = event(1)
+		}
+		assert.Equal(t, maxLogEventsPerRequest, batchSize(events))
+	})
+
+	t.Run("Should limit the bytes of the events", func(t *testing.T) {
+		events := []types.InputLogEvent{event(600000), event(600000), event(10)}
+		assert.Equal(t, 1, batchSize(events))
+		assert.Equal(t, 2, batchSize(events[1:]))
+	})
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrFileNameEmpty is returned when the name of the audit file is empty.
+var ErrFileNameEmpty = errors.New("audit: file name cannot be empty")
+
+// FileSink appends the audit records as JSON Lines to a local file.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a new FileSink appending to the file, it is created when it doesn't exist.
+func NewFileSink(name string) (*FileSink, error) {
+	if name == "" {
+		return nil, ErrFileNameEmpty
+	}
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: error opening file: %w", err)
+	}
+
+	return &FileSink{file: f}, nil
+}
+
+// Write appends the record to the file.
+func (s *FileSink) Write(ctx context.Context, r *Record) error {
+	line, err := marshalLine(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("audit: error writing file: %w", err)
+	}
+
+	return nil
+}
+
+// Flush commits the content of the file to the disk.
+func (s *FileSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("audit: error syncing file: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// marshalLine returns the record as a JSON line.
+func marshalLine(r *Record) ([]byte, error) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("audit: error marshalling record: %w", err)
+	}
+
+	return append(line, '\n'), nil
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSink(t *testing.T) {
+	t.Run("Should return an error without file name", func(t *testing.T) {
+		s, err := NewFileSink("")
+		assert.ErrorIs(t, err, ErrFileNameEmpty)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should append the records as JSON lines", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "audit.jsonl")
+		assert.NoError(t, os.WriteFile(name, []byte("{\"existing\":true}\n"), 0o600))
+
+		s, err := NewFileSink(name)
+		assert.NoError(t, err)
+
+		ctx := context.TODO()
+		now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, RunID: "run-1", Operation: OperationCreate, ResourceType: ResourceTypeUser, SCIMID: "1", Outcome: OutcomeSuccess}))
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, RunID: "run-1", Operation: OperationDelete, ResourceType: ResourceTypeGroup, SCIMID: "2", Outcome: OutcomeFailure, Error: "test error"}))
+		assert.NoError(t, s.Flush(ctx))
+		assert.NoError(t, s.Close())
+
+		content, err := os.ReadFile(name)
+		assert.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		assert.Len(t, lines, 3)
+		assert.Equal(t, `{"existing":true}`, lines[0])
+		assert.JSONEq(t, `{"time":"2022-12-01T10:00:00Z","runId":"run-1","operation":"create","resourceType":"User","scimId":"1","outcome":"success"}`, lines[1])
+
+		var r Record
+		assert.NoError(t, json.Unmarshal([]byte(lines[2]), &r))
+		assert.Equal(t, OperationDelete, r.Operation)
+		assert.Equal(t, "test error", r.Error)
+	})
+
+	t.Run("Should return an error when the file cannot be opened", func(t *testing.T) {
+		s, err := NewFileSink(filepath.Join(t.TempDir(), "missing", "audit.jsonl"))
+		assert.Error(t, err)
+		assert.Nil(t, s)
+	})
+}
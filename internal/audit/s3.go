@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	// ErrS3ClientNil is returned when the S3 client is nil.
+	ErrS3ClientNil = errors.New("audit: S3 client cannot be nil")
+
+	// ErrBucketEmpty is returned when the name of the S3 bucket is empty.
+	ErrBucketEmpty = errors.New("audit: S3 bucket cannot be empty")
+)
+
+//go:generate go run github.com/golang/mock/mockgen@v1.6.0 -package=mocks -destination=../../mocks/audit/s3_mocks.go -source=s3.go S3ClientAPI
+
+// S3ClientAPI is the interface to consume the s3 client methods.
+type S3ClientAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Sink buffers the audit records and uploads them as a JSON Lines object to S3 on every flush,
+// the key of the objects is <prefix>/<yyyy>/<mm>/<dd>/<timestamp>-<run id>.jsonl.
+type S3Sink struct {
+	client S3ClientAPI
+	bucket string
+	prefix string
+	now    func() time.Time
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// S3SinkOption is a function that can be used to configure the S3Sink.
+type S3SinkOption func(*S3Sink)
+
+// WithS3Prefix sets the prefix of the keys of the objects.
+func WithS3Prefix(prefix string) S3SinkOption {
+	return func(s *S3Sink) {
+		s.prefix = prefix
+	}
+}
+
+// NewS3Sink returns a new S3Sink uploading the records to the bucket.
+func NewS3Sink(client S3ClientAPI, bucket string, opts ...S3SinkOption) (*S3Sink, error) {
+	if client == nil {
+		return nil, ErrS3ClientNil
+	}
+	if bucket == "" {
+		return nil, ErrBucketEmpty
+	}
+
+	s := &S3Sink{
+		client: client,
+		bucket: bucket,
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Write buffers the record until the next flush.
+func (s *S3Sink) Write(ctx context.Context, r *Record) error {
+	line, err := marshalLine(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Write(line)
+	return nil
+}
+
+// Flush uploads the buffered records, nothing is uploaded when there are no records.
+// The records are kept in the buffer when the upload fails, so the next flush retries them.
+func (s *S3Sink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buf.Len() == 0 {
+		return nil
+	}
+
+	now := s.now().UTC()
+	name := now.Format("20060102T150405Z")
+	if runID := RunID(ctx); runID != "" {
+		name += "-" + runID
+	}
+	key := path.Join(s.prefix, now.Format("2006/01/02"), name+".jsonl")
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(s.buf.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return fmt.Errorf("audit: error uploading records: bucket: %s, key: %s, error: %w", s.bucket, key, err)
+	}
+
+	s.buf.Reset()
+	return nil
+}
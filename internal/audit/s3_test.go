@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/golang/mock/gomock"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewS3Sink(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return an error without client", func(t *testing.T) {
+		s, err := NewS3Sink(nil, "bucket")
+		assert.ErrorIs(t, err, ErrS3ClientNil)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should return an error without bucket", func(t *testing.T) {
+		s, err := NewS3Sink(mocks.NewMockS3ClientAPI(mockCtrl), "")
+		assert.ErrorIs(t, err, ErrBucketEmpty)
+		assert.Nil(t, s)
+	})
+}
+
+func TestS3Sink_Flush(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should not upload anything without records", func(t *testing.T) {
+		mockS3 := mocks.NewMockS3ClientAPI(mockCtrl)
+		mockS3.EXPECT().PutObject(gomock.Any(), gomock.Any()).Times(0)
+
+		s, err := NewS3Sink(mockS3, "bucket")
+		assert.NoError(t, err)
+		assert.NoError(t, s.Flush(context.TODO()))
+	})
+
+	t.Run("Should upload the records of the run as JSON lines", func(t *testing.T) {
+		mockS3 := mocks.NewMockS3ClientAPI(mockCtrl)
+		ctx := ContextWithRunID(context.TODO(), "run-1")
+
+		mockS3.EXPECT().PutObject(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+				assert.Equal(t, "bucket", aws.ToString(in.Bucket))
+				assert.Equal(t, "audit/2022/12/01/20221201T100000Z-run-1.jsonl", aws.ToString(in.Key))
+
+				body, err := io.ReadAll(in.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "{\"time\":\"2022-12-01T10:00:00Z\",\"operation\":\"create\",\"resourceType\":\"User\",\"outcome\":\"success\"}\n"+
+					"{\"time\":\"2022-12-01T10:00:00Z\",\"operation\":\"delete\",\"resourceType\":\"User\",\"outcome\":\"success\"}\n", string(body))
+
+				return &s3.PutObjectOutput{}, nil
+			}).Times(1)
+
+		s, err := NewS3Sink(mockS3, "bucket", WithS3Prefix("audit"))
+		assert.NoError(t, err)
+		s.now = func() time.Time { return now }
+
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, Operation: OperationCreate, ResourceType: ResourceTypeUser, Outcome: OutcomeSuccess}))
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, Operation: OperationDelete, ResourceType: ResourceTypeUser, Outcome: OutcomeSuccess}))
+		assert.NoError(t, s.Flush(ctx))
+
+		// the records are uploaded only once
+		assert.NoError(t, s.Flush(ctx))
+	})
+
+	t.Run("Should keep the records when the upload fails", func(t *testing.T) {
+		mockS3 := mocks.NewMockS3ClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		gomock.InOrder(
+			mockS3.EXPECT().PutObject(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1),
+			mockS3.EXPECT().PutObject(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+					assert.Equal(t, "2022/12/01/20221201T100000Z.jsonl", aws.ToString(in.Key))
+
+					body, err := io.ReadAll(in.Body)
+					assert.NoError(t, err)
+					assert.Contains(t, string(body), `"operation":"create"`)
+
+					return &s3.PutObjectOutput{}, nil
+				}).Times(1),
+		)
+
+		s, err := NewS3Sink(mockS3, "bucket")
+		assert.NoError(t, err)
+		s.now = func() time.Time { return now }
+
+		assert.NoError(t, s.Write(ctx, &Record{Time: now, Operation: OperationCreate, ResourceType: ResourceTypeUser, Outcome: OutcomeSuccess}))
+		assert.Error(t, s.Flush(ctx))
+		assert.NoError(t, s.Flush(ctx))
+	})
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 const (
 	// DefaultIsLambda is the progam execute as a lambda function?
 	DefaultIsLambda = false
@@ -39,8 +41,95 @@ const (
 	// DefaultAWSSCIMAccessTokenSecretName is the name of the secret containing the SCIM access token.
 	DefaultAWSSCIMAccessTokenSecretName = "IDPSCIM_SCIMAccessToken"
 
+	// DefaultAWSSCIMAccessTokenExpiryWarningDays is the default number of days before the expiry of the
+	// AWS SSO SCIM access token when the expiry warnings start.
+	DefaultAWSSCIMAccessTokenExpiryWarningDays = 30
+
 	// DefaultUseSecretsManager determines if we will use the AWS Secrets Manager secrets or program parameter values
 	DefaultUseSecretsManager = false
+
+	// DefaultGWSInactiveUsersPolicy is the default policy applied to the suspended and archived Google Workspace users
+	// and the non ACTIVE group members.
+	// possible values: "drop", "keep-inactive", "keep-active"
+	DefaultGWSInactiveUsersPolicy = "drop"
+
+	// DefaultUserDeprovisioningPolicy is the default policy applied to the users removed from the identity provider.
+	// possible values: "delete", "deactivate", "deactivate-then-delete"
+	DefaultUserDeprovisioningPolicy = "delete"
+
+	// DefaultUserDeprovisioningGracePeriod is the default time a deactivated user is kept before being deleted
+	// when the "deactivate-then-delete" user deprovisioning policy is used.
+	DefaultUserDeprovisioningGracePeriod = 30 * 24 * time.Hour
+
+	// DefaultRemovalGraceRuns is the default number of consecutive syncs a group or user must be missing
+	// in the identity provider before being removed, 0 means disabled.
+	DefaultRemovalGraceRuns = 0
+
+	// DefaultServeInterval is the default time between the end of a sync and the start of the next one in serve mode.
+	DefaultServeInterval = 15 * time.Minute
+
+	// DefaultServeJitter is the default maximum random delay added to the start of each sync in serve mode.
+	DefaultServeJitter = time.Duration(0)
+
+	// DefaultServeRunOnStart determines if a sync runs as soon as the serve mode starts.
+	DefaultServeRunOnStart = true
+
+	// DefaultServeShutdownTimeout is the default maximum time to wait for the sync in progress when the serve mode stops.
+	DefaultServeShutdownTimeout = 5 * time.Minute
+
+	// DefaultDryRun determines if the syncs only report the changes without applying them.
+	DefaultDryRun = false
+
+	// DefaultContinueOnError determines if the syncs continue when a group, a user or the members of a group fail.
+	DefaultContinueOnError = false
+
+	// DefaultCheckpoints determines if the first sync stores the state after reconciling the groups and the users.
+	DefaultCheckpoints = false
+
+	// DefaultDeadlineMargin is the default time left before the deadline of a sync, e.g. the AWS Lambda timeout,
+	// when the sync stops without starting a new phase.
+	DefaultDeadlineMargin = 30 * time.Second
+
+	// DefaultUserDriftDetection determines if the syncs from the state compare the users with the AWS SSO ones.
+	DefaultUserDriftDetection = false
+
+	// DefaultUserDriftSample is the default number of users compared by the user drift detection, 0 compares all of them.
+	DefaultUserDriftSample = 0
+
+	// DefaultIncremental determines if the syncs from the state only get the Google Workspace changes since the last sync.
+	DefaultIncremental = false
+
+	// DefaultFullRefreshInterval is the default interval of the syncs getting all the Google Workspace data in incremental mode.
+	DefaultFullRefreshInterval = 24 * time.Hour
+
+	// DefaultAdminAddress is the default address of the admin HTTP API in serve mode, empty disables it.
+	// Only the loopback addresses are allowed without admin token.
+	DefaultAdminAddress = "127.0.0.1:8080"
+
+	// DefaultWebhookDebounce is the default time the webhook events are collected before triggering their sync.
+	DefaultWebhookDebounce = 10 * time.Second
+
+	// DefaultMetricsPushgatewayJob is the default job name of the metrics pushed to the Prometheus Pushgateway.
+	DefaultMetricsPushgatewayJob = "idpscim"
+
+	// DefaultMetricsCloudWatchNamespace is the default CloudWatch namespace of the EMF metrics emitted as AWS Lambda.
+	DefaultMetricsCloudWatchNamespace = "idp-scim-sync"
+
+	// DefaultNotifyThreshold is the default minimum number of changes of a successful sync to be notified.
+	DefaultNotifyThreshold = 1
+
+	// DefaultTracingSampleRatio is the default ratio of the sampled traces when the tracing is enabled, all of them.
+	DefaultTracingSampleRatio = 1.0
+
+	// DefaultAuditS3Prefix is the default prefix of the keys of the audit objects in S3.
+	DefaultAuditS3Prefix = "audit"
+
+	// DefaultAuditCloudWatchLogStream is the default CloudWatch Logs log stream of the audit records.
+	DefaultAuditCloudWatchLogStream = "idp-scim-sync"
+
+	// DefaultRemovalGracePeriod is the default time a group or user must be missing
+	// in the identity provider before being removed, 0 means disabled.
+	DefaultRemovalGracePeriod = time.Duration(0)
 )
 
 // Config represents the configuration of the application.
@@ -54,15 +143,49 @@ type Config struct {
 
 	GWSServiceAccountFile           string   `mapstructure:"gws_service_account_file" json:"gws_service_account_file" yaml:"gws_service_account_file"`
 	GWSUserEmail                    string   `mapstructure:"gws_user_email" json:"gws_user_email" yaml:"gws_user_email"`
-	GWSServiceAccountFileSecretName string   `mapstructure:"gws_service_account_file_secret_name" json:"gws_service_account_file_secret_name" yaml:"gws_service_account_file_secret_name"`
-	GWSUserEmailSecretName          string   `mapstructure:"gws_user_email_secret_name" json:"gws_user_email_secret_name" yaml:"gws_user_email_secret_name"`
+	GWSServiceAccountFileSecretName string   `mapstructure:"gws_service_account_file_secret_name" json:"gws_service_account_file_secret_name" yaml:"gws_service_account_file_secret_name" secret:"-"`
+	GWSUserEmailSecretName          string   `mapstructure:"gws_user_email_secret_name" json:"gws_user_email_secret_name" yaml:"gws_user_email_secret_name" secret:"-"`
 	GWSGroupsFilter                 []string `mapstructure:"gws_groups_filter" json:"gws_groups_filter" yaml:"gws_groups_filter"`
 	GWSUsersFilter                  []string `mapstructure:"gws_users_filter" json:"gws_users_filter" yaml:"gws_users_filter"`
 
+	// GWSSignerServiceAccount is the service account used for the domain-wide delegation without private key,
+	// its JWT is signed through the IAM Credentials signJwt API using the GWSServiceAccountFile credentials (e.g. workload identity federation)
+	GWSSignerServiceAccount string `mapstructure:"gws_signer_service_account" json:"gws_signer_service_account" yaml:"gws_signer_service_account"`
+
+	// GWSCustomerID is the Google Workspace customer ID to read, by default the customer of the GWSUserEmail
+	GWSCustomerID string `mapstructure:"gws_customer_id" json:"gws_customer_id" yaml:"gws_customer_id"`
+
+	// GWSDomains are the Google Workspace domains to read instead of the whole customer directory
+	GWSDomains []string `mapstructure:"gws_domains" json:"gws_domains" yaml:"gws_domains"`
+
+	// GWSTenants are additional Google Workspace tenants merged with the main one
+	GWSTenants []GWSTenant `mapstructure:"gws_tenants" json:"gws_tenants" yaml:"gws_tenants"`
+
+	// GWSOrgUnitGroups are the Google Workspace organizational units synced as groups, in the form [name=]/org/unit/path
+	GWSOrgUnitGroups []string `mapstructure:"gws_org_unit_groups" json:"gws_org_unit_groups" yaml:"gws_org_unit_groups"`
+
+	// GWSUsersInclude are the rules a Google Workspace user must match (any of them) to be synced
+	GWSUsersInclude []UserRule `mapstructure:"gws_users_include" json:"gws_users_include" yaml:"gws_users_include"`
+
+	// GWSUsersExclude are the rules that exclude a Google Workspace user from the sync
+	GWSUsersExclude []UserRule `mapstructure:"gws_users_exclude" json:"gws_users_exclude" yaml:"gws_users_exclude"`
+
+	// GWSInactiveUsersPolicy defines what happens with the suspended and archived users and the non ACTIVE group members
+	GWSInactiveUsersPolicy string `mapstructure:"gws_inactive_users_policy" json:"gws_inactive_users_policy" yaml:"gws_inactive_users_policy"`
+
 	AWSSCIMEndpoint              string `mapstructure:"aws_scim_endpoint" json:"aws_scim_endpoint" yaml:"aws_scim_endpoint"`
 	AWSSCIMAccessToken           string `mapstructure:"aws_scim_access_token" json:"aws_scim_access_token" yaml:"aws_scim_access_token"`
-	AWSSCIMEndpointSecretName    string `mapstructure:"aws_scim_endpoint_secret_name" json:"aws_scim_endpoint_secret_name" yaml:"aws_scim_endpoint_secret_name"`
-	AWSSCIMAccessTokenSecretName string `mapstructure:"aws_scim_access_token_secret_name" json:"aws_scim_access_token_secret_name" yaml:"aws_scim_access_token_secret_name"`
+	AWSSCIMEndpointSecretName    string `mapstructure:"aws_scim_endpoint_secret_name" json:"aws_scim_endpoint_secret_name" yaml:"aws_scim_endpoint_secret_name" secret:"-"`
+	AWSSCIMAccessTokenSecretName string `mapstructure:"aws_scim_access_token_secret_name" json:"aws_scim_access_token_secret_name" yaml:"aws_scim_access_token_secret_name" secret:"-"`
+
+	// AWSSCIMAccessTokenCreatedAt is the creation date of the AWS SSO SCIM access token, the tokens expire after a year
+	AWSSCIMAccessTokenCreatedAt string `mapstructure:"aws_scim_access_token_created_at" json:"aws_scim_access_token_created_at" yaml:"aws_scim_access_token_created_at"`
+
+	// AWSSCIMAccessTokenExpiresAt is the expiry date of the AWS SSO SCIM access token
+	AWSSCIMAccessTokenExpiresAt string `mapstructure:"aws_scim_access_token_expires_at" json:"aws_scim_access_token_expires_at" yaml:"aws_scim_access_token_expires_at"`
+
+	// AWSSCIMAccessTokenExpiryWarningDays is the number of days before the expiry of the AWS SSO SCIM access token when the expiry warnings start
+	AWSSCIMAccessTokenExpiryWarningDays int `mapstructure:"aws_scim_access_token_expiry_warning_days" json:"aws_scim_access_token_expiry_warning_days" yaml:"aws_scim_access_token_expiry_warning_days"`
 
 	AWSS3BucketName string `mapstructure:"aws_s3_bucket_name" json:"aws_s3_bucket_name" yaml:"aws_s3_bucket_name"`
 	AWSS3BucketKey  string `mapstructure:"aws_s3_bucket_key" json:"aws_s3_bucket_key" yaml:"aws_s3_bucket_key"`
@@ -72,23 +195,192 @@ type Config struct {
 
 	// UseSecretsManager determines if we will use the AWS Secrets Manager secrets or program parameter values
 	UseSecretsManager bool `mapstructure:"use_secrets_manager" json:"use_secrets_manager" yaml:"use_secrets_manager"`
+
+	// VaultAddress is the HashiCorp Vault address used to resolve the secret://vault/ references, by default VAULT_ADDR
+	VaultAddress string `mapstructure:"vault_address" json:"vault_address" yaml:"vault_address"`
+
+	// VaultToken is the HashiCorp Vault token used to resolve the secret://vault/ references, by default VAULT_TOKEN
+	VaultToken string `mapstructure:"vault_token" json:"vault_token" yaml:"vault_token"`
+
+	// UserDeprovisioningPolicy defines what happens with the users removed from the identity provider
+	UserDeprovisioningPolicy string `mapstructure:"user_deprovisioning_policy" json:"user_deprovisioning_policy" yaml:"user_deprovisioning_policy"`
+
+	// UserDeprovisioningGracePeriod is the time a deactivated user is kept before being deleted
+	UserDeprovisioningGracePeriod time.Duration `mapstructure:"user_deprovisioning_grace_period" json:"user_deprovisioning_grace_period" yaml:"user_deprovisioning_grace_period"`
+
+	// RemovalGraceRuns is the number of consecutive syncs a group or user must be missing in the identity provider before being removed
+	RemovalGraceRuns int `mapstructure:"removal_grace_runs" json:"removal_grace_runs" yaml:"removal_grace_runs"`
+
+	// RemovalGracePeriod is the time a group or user must be missing in the identity provider before being removed
+	RemovalGracePeriod time.Duration `mapstructure:"removal_grace_period" json:"removal_grace_period" yaml:"removal_grace_period"`
+
+	// ServeSchedule is the cron schedule of the syncs in serve mode, it takes precedence over ServeInterval
+	ServeSchedule string `mapstructure:"serve_schedule" json:"serve_schedule" yaml:"serve_schedule"`
+
+	// ServeInterval is the time between the end of a sync and the start of the next one in serve mode
+	ServeInterval time.Duration `mapstructure:"serve_interval" json:"serve_interval" yaml:"serve_interval"`
+
+	// ServeJitter is the maximum random delay added to the start of each sync in serve mode
+	ServeJitter time.Duration `mapstructure:"serve_jitter" json:"serve_jitter" yaml:"serve_jitter"`
+
+	// ServeRunOnStart determines if a sync runs as soon as the serve mode starts
+	ServeRunOnStart bool `mapstructure:"serve_run_on_start" json:"serve_run_on_start" yaml:"serve_run_on_start"`
+
+	// ServeShutdownTimeout is the maximum time to wait for the sync in progress when the serve mode stops
+	ServeShutdownTimeout time.Duration `mapstructure:"serve_shutdown_timeout" json:"serve_shutdown_timeout" yaml:"serve_shutdown_timeout"`
+
+	// ServeStatusFile is the file where the status of the last sync is written in serve mode
+	ServeStatusFile string `mapstructure:"serve_status_file" json:"serve_status_file" yaml:"serve_status_file"`
+
+	// DryRun determines if the syncs only report the changes without applying them to AWS SSO nor storing the state
+	DryRun bool `mapstructure:"dry_run" json:"dry_run" yaml:"dry_run"`
+
+	// ContinueOnError determines if the syncs continue when a group, a user or the members of a group fail,
+	// the failed ones are retried by the next sync
+	ContinueOnError bool `mapstructure:"continue_on_error" json:"continue_on_error" yaml:"continue_on_error"`
+
+	// Checkpoints determines if the first sync stores the state after reconciling the groups and the users,
+	// so a first sync that doesn't finish is resumed by the next sync
+	Checkpoints bool `mapstructure:"checkpoints" json:"checkpoints" yaml:"checkpoints"`
+
+	// DeadlineMargin is the time left before the deadline of a sync, e.g. the AWS Lambda timeout,
+	// when the sync stops without starting a new phase, 0 disables it
+	DeadlineMargin time.Duration `mapstructure:"deadline_margin" json:"deadline_margin" yaml:"deadline_margin"`
+
+	// UserDriftDetection determines if the syncs from the state compare the users of the state with the AWS SSO ones,
+	// the users changed in AWS SSO are put back with the values of the state
+	UserDriftDetection bool `mapstructure:"user_drift_detection" json:"user_drift_detection" yaml:"user_drift_detection"`
+
+	// UserDriftSample is the number of random users compared by the user drift detection, 0 compares all of them
+	UserDriftSample int `mapstructure:"user_drift_sample" json:"user_drift_sample" yaml:"user_drift_sample"`
+
+	// Incremental determines if the syncs from the state only get the groups and users changed in Google Workspace
+	// since the last sync, from the activities of the Reports API
+	Incremental bool `mapstructure:"incremental" json:"incremental" yaml:"incremental"`
+
+	// FullRefreshInterval is the interval of the syncs getting all the Google Workspace data in incremental mode, 0 disables them
+	FullRefreshInterval time.Duration `mapstructure:"full_refresh_interval" json:"full_refresh_interval" yaml:"full_refresh_interval"`
+
+	// AdminAddress is the address of the admin HTTP API in serve mode, empty disables it
+	AdminAddress string `mapstructure:"admin_address" json:"admin_address" yaml:"admin_address"`
+
+	// AdminToken is the bearer token required by the sync, state and report endpoints of the admin HTTP API
+	AdminToken string `mapstructure:"admin_token" json:"admin_token" yaml:"admin_token"`
+
+	// WebhookToken is the token verifying the Google Workspace events of the /webhook endpoint of the admin HTTP API, empty disables it
+	WebhookToken string `mapstructure:"webhook_token" json:"webhook_token" yaml:"webhook_token"`
+
+	// WebhookDebounce is the time the webhook events are collected before triggering the sync of their changes
+	WebhookDebounce time.Duration `mapstructure:"webhook_debounce" json:"webhook_debounce" yaml:"webhook_debounce"`
+
+	// MetricsPushgatewayURL is the Prometheus Pushgateway url where the metrics are pushed after a sync, empty disables it
+	MetricsPushgatewayURL string `mapstructure:"metrics_pushgateway_url" json:"metrics_pushgateway_url" yaml:"metrics_pushgateway_url"`
+
+	// MetricsPushgatewayJob is the job name of the metrics pushed to the Prometheus Pushgateway
+	MetricsPushgatewayJob string `mapstructure:"metrics_pushgateway_job" json:"metrics_pushgateway_job" yaml:"metrics_pushgateway_job"`
+
+	// MetricsCloudWatchNamespace is the CloudWatch namespace of the metrics emitted in the Embedded Metric Format as AWS Lambda
+	MetricsCloudWatchNamespace string `mapstructure:"metrics_cloudwatch_namespace" json:"metrics_cloudwatch_namespace" yaml:"metrics_cloudwatch_namespace"`
+
+	// NotifySlackWebhookURL is the Slack incoming webhook url where the syncs are notified
+	NotifySlackWebhookURL string `mapstructure:"notify_slack_webhook_url" json:"notify_slack_webhook_url" yaml:"notify_slack_webhook_url"`
+
+	// NotifyWebhookURL is the url of a generic webhook where the syncs are notified as JSON
+	NotifyWebhookURL string `mapstructure:"notify_webhook_url" json:"notify_webhook_url" yaml:"notify_webhook_url"`
+
+	// NotifySNSTopicARN is the AWS SNS topic where the syncs are notified
+	NotifySNSTopicARN string `mapstructure:"notify_sns_topic_arn" json:"notify_sns_topic_arn" yaml:"notify_sns_topic_arn"`
+
+	// NotifyThreshold is the minimum number of changes of a successful sync to be notified, the failed syncs are always notified
+	NotifyThreshold int `mapstructure:"notify_threshold" json:"notify_threshold" yaml:"notify_threshold"`
+
+	// NotifySubjectTemplate is the Go template of the subject of the notifications, empty uses the default one
+	NotifySubjectTemplate string `mapstructure:"notify_subject_template" json:"notify_subject_template" yaml:"notify_subject_template"`
+
+	// NotifyTextTemplate is the Go template of the text of the notifications, empty uses the default one
+	NotifyTextTemplate string `mapstructure:"notify_text_template" json:"notify_text_template" yaml:"notify_text_template"`
+
+	// TracingOTLPEndpoint is the OTLP/HTTP collector url where the OpenTelemetry spans are exported, empty disables the tracing
+	TracingOTLPEndpoint string `mapstructure:"tracing_otlp_endpoint" json:"tracing_otlp_endpoint" yaml:"tracing_otlp_endpoint"`
+
+	// TracingSampleRatio is the ratio, between 0 and 1, of the sampled traces
+	TracingSampleRatio float64 `mapstructure:"tracing_sample_ratio" json:"tracing_sample_ratio" yaml:"tracing_sample_ratio"`
+
+	// AuditFile is the local file where the audit records of the SCIM mutations are appended as JSON Lines
+	AuditFile string `mapstructure:"audit_file" json:"audit_file" yaml:"audit_file"`
+
+	// AuditS3Bucket is the AWS S3 bucket where the audit records of every sync are uploaded as JSON Lines
+	AuditS3Bucket string `mapstructure:"audit_s3_bucket" json:"audit_s3_bucket" yaml:"audit_s3_bucket"`
+
+	// AuditS3Prefix is the prefix of the keys of the audit objects in S3
+	AuditS3Prefix string `mapstructure:"audit_s3_prefix" json:"audit_s3_prefix" yaml:"audit_s3_prefix"`
+
+	// AuditCloudWatchLogGroup is the CloudWatch Logs log group where the audit records are sent
+	AuditCloudWatchLogGroup string `mapstructure:"audit_cloudwatch_log_group" json:"audit_cloudwatch_log_group" yaml:"audit_cloudwatch_log_group"`
+
+	// AuditCloudWatchLogStream is the CloudWatch Logs log stream where the audit records are sent
+	AuditCloudWatchLogStream string `mapstructure:"audit_cloudwatch_log_stream" json:"audit_cloudwatch_log_stream" yaml:"audit_cloudwatch_log_stream"`
+}
+
+// GWSTenant represents an additional Google Workspace tenant (customer or domains) synced together with the main one.
+type GWSTenant struct {
+	ServiceAccountFile   string   `mapstructure:"service_account_file" json:"service_account_file" yaml:"service_account_file"`
+	UserEmail            string   `mapstructure:"user_email" json:"user_email" yaml:"user_email"`
+	SignerServiceAccount string   `mapstructure:"signer_service_account" json:"signer_service_account,omitempty" yaml:"signer_service_account,omitempty"`
+	CustomerID           string   `mapstructure:"customer_id" json:"customer_id,omitempty" yaml:"customer_id,omitempty"`
+	Domains              []string `mapstructure:"domains" json:"domains,omitempty" yaml:"domains,omitempty"`
+}
+
+// UserRule represents a rule used to include or exclude Google Workspace users from the sync.
+// All the non empty fields of the rule must match the user.
+type UserRule struct {
+	Email           string `mapstructure:"email" json:"email,omitempty" yaml:"email,omitempty"`
+	Domain          string `mapstructure:"domain" json:"domain,omitempty" yaml:"domain,omitempty"`
+	OrgUnitPath     string `mapstructure:"org_unit_path" json:"org_unit_path,omitempty" yaml:"org_unit_path,omitempty"`
+	CustomAttribute string `mapstructure:"custom_attribute" json:"custom_attribute,omitempty" yaml:"custom_attribute,omitempty"`
 }
 
 // New returns a new Config
 func New() Config {
 	return Config{
-		ConfigFile:                      DefaultConfigFile,
-		IsLambda:                        DefaultIsLambda,
-		Debug:                           DefaultDebug,
-		LogLevel:                        DefaultLogLevel,
-		LogFormat:                       DefaultLogFormat,
-		GWSServiceAccountFile:           DefaultGWSServiceAccountFile,
-		SyncMethod:                      DefaultSyncMethod,
-		AWSS3BucketKey:                  DefaultAWSS3BucketKey,
-		GWSServiceAccountFileSecretName: DefaultGWSServiceAccountFileSecretName,
-		GWSUserEmailSecretName:          DefaultGWSUserEmailSecretName,
-		AWSSCIMEndpointSecretName:       DefaultAWSSCIMEndpointSecretName,
-		AWSSCIMAccessTokenSecretName:    DefaultAWSSCIMAccessTokenSecretName,
-		UseSecretsManager:               DefaultUseSecretsManager,
+		ConfigFile:                          DefaultConfigFile,
+		IsLambda:                            DefaultIsLambda,
+		Debug:                               DefaultDebug,
+		LogLevel:                            DefaultLogLevel,
+		LogFormat:                           DefaultLogFormat,
+		GWSServiceAccountFile:               DefaultGWSServiceAccountFile,
+		SyncMethod:                          DefaultSyncMethod,
+		AWSS3BucketKey:                      DefaultAWSS3BucketKey,
+		GWSServiceAccountFileSecretName:     DefaultGWSServiceAccountFileSecretName,
+		GWSUserEmailSecretName:              DefaultGWSUserEmailSecretName,
+		AWSSCIMEndpointSecretName:           DefaultAWSSCIMEndpointSecretName,
+		AWSSCIMAccessTokenSecretName:        DefaultAWSSCIMAccessTokenSecretName,
+		AWSSCIMAccessTokenExpiryWarningDays: DefaultAWSSCIMAccessTokenExpiryWarningDays,
+		UseSecretsManager:                   DefaultUseSecretsManager,
+		GWSInactiveUsersPolicy:              DefaultGWSInactiveUsersPolicy,
+		UserDeprovisioningPolicy:            DefaultUserDeprovisioningPolicy,
+		UserDeprovisioningGracePeriod:       DefaultUserDeprovisioningGracePeriod,
+		RemovalGraceRuns:                    DefaultRemovalGraceRuns,
+		RemovalGracePeriod:                  DefaultRemovalGracePeriod,
+		ServeInterval:                       DefaultServeInterval,
+		ServeJitter:                         DefaultServeJitter,
+		ServeRunOnStart:                     DefaultServeRunOnStart,
+		ServeShutdownTimeout:                DefaultServeShutdownTimeout,
+		DryRun:                              DefaultDryRun,
+		ContinueOnError:                     DefaultContinueOnError,
+		Checkpoints:                         DefaultCheckpoints,
+		DeadlineMargin:                      DefaultDeadlineMargin,
+		UserDriftDetection:                  DefaultUserDriftDetection,
+		UserDriftSample:                     DefaultUserDriftSample,
+		Incremental:                         DefaultIncremental,
+		FullRefreshInterval:                 DefaultFullRefreshInterval,
+		AdminAddress:                        DefaultAdminAddress,
+		WebhookDebounce:                     DefaultWebhookDebounce,
+		MetricsPushgatewayJob:               DefaultMetricsPushgatewayJob,
+		MetricsCloudWatchNamespace:          DefaultMetricsCloudWatchNamespace,
+		TracingSampleRatio:                  DefaultTracingSampleRatio,
+		NotifyThreshold:                     DefaultNotifyThreshold,
+		AuditS3Prefix:                       DefaultAuditS3Prefix,
+		AuditCloudWatchLogStream:            DefaultAuditCloudWatchLogStream,
 	}
 }
@@ -23,5 +23,31 @@ func TestNew(t *testing.T) {
 	assert.Equal(cfg.GWSUserEmailSecretName, DefaultGWSUserEmailSecretName)
 	assert.Equal(cfg.AWSSCIMEndpointSecretName, DefaultAWSSCIMEndpointSecretName)
 	assert.Equal(cfg.AWSSCIMAccessTokenSecretName, DefaultAWSSCIMAccessTokenSecretName)
+	assert.Equal(cfg.AWSSCIMAccessTokenExpiryWarningDays, DefaultAWSSCIMAccessTokenExpiryWarningDays)
 	assert.Equal(cfg.UseSecretsManager, DefaultUseSecretsManager)
+	assert.Equal(cfg.GWSInactiveUsersPolicy, DefaultGWSInactiveUsersPolicy)
+	assert.Equal(cfg.UserDeprovisioningPolicy, DefaultUserDeprovisioningPolicy)
+	assert.Equal(cfg.UserDeprovisioningGracePeriod, DefaultUserDeprovisioningGracePeriod)
+	assert.Equal(cfg.RemovalGraceRuns, DefaultRemovalGraceRuns)
+	assert.Equal(cfg.RemovalGracePeriod, DefaultRemovalGracePeriod)
+	assert.Equal(cfg.ServeInterval, DefaultServeInterval)
+	assert.Equal(cfg.ServeJitter, DefaultServeJitter)
+	assert.Equal(cfg.ServeRunOnStart, DefaultServeRunOnStart)
+	assert.Equal(cfg.ServeShutdownTimeout, DefaultServeShutdownTimeout)
+	assert.Equal(cfg.DryRun, DefaultDryRun)
+	assert.Equal(cfg.ContinueOnError, DefaultContinueOnError)
+	assert.Equal(cfg.Checkpoints, DefaultCheckpoints)
+	assert.Equal(cfg.DeadlineMargin, DefaultDeadlineMargin)
+	assert.Equal(cfg.UserDriftDetection, DefaultUserDriftDetection)
+	assert.Equal(cfg.UserDriftSample, DefaultUserDriftSample)
+	assert.Equal(cfg.Incremental, DefaultIncremental)
+	assert.Equal(cfg.FullRefreshInterval, DefaultFullRefreshInterval)
+	assert.Equal(cfg.AdminAddress, DefaultAdminAddress)
+	assert.Equal(cfg.WebhookDebounce, DefaultWebhookDebounce)
+	assert.Equal(cfg.MetricsPushgatewayJob, DefaultMetricsPushgatewayJob)
+	assert.Equal(cfg.MetricsCloudWatchNamespace, DefaultMetricsCloudWatchNamespace)
+	assert.Equal(cfg.TracingSampleRatio, DefaultTracingSampleRatio)
+	assert.Equal(cfg.NotifyThreshold, DefaultNotifyThreshold)
+	assert.Equal(cfg.AuditS3Prefix, DefaultAuditS3Prefix)
+	assert.Equal(cfg.AuditCloudWatchLogStream, DefaultAuditCloudWatchLogStream)
 }
@@ -10,10 +10,11 @@ import (
 )
 
 // scimSync executes the sync of the data on the SCIM side and
-// returns the datasets synced
-func scimSync(
+// returns the datasets synced, a first sync stopped after a checkpoint
+// resumes from the groups and users of the state.
+func (ss *SyncService) scimSync(
 	ctx context.Context,
-	scim SCIMService,
+	state *model.State,
 	idpGroupsResult *model.GroupsResult,
 	idpUsersResult *model.UsersResult,
 	idpGroupsMembersResult *model.GroupsMembersResult,
@@ -21,63 +22,137 @@ func scimSync(
 	var totalGroupsResult *model.GroupsResult
 	var totalUsersResult *model.UsersResult
 	var totalGroupsMembersResult *model.GroupsMembersResult
+	var scimGroupsResult, groupsRetained *model.GroupsResult
+	var scimUsersResult, usersRetained *model.UsersResult
 	log.Warn("reconciling the SCIM data with the Identity Provider data")
 
-	log.Info("getting SCIM Groups")
-	scimGroupsResult, err := scim.GetGroups(ctx)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error getting groups from the SCIM service: %w", err)
+	if err := ss.beforeDeadline(ctx, phaseGroups); err != nil {
+		return nil, nil, nil, err
 	}
 
-	log.WithFields(log.Fields{
-		"idp":  idpGroupsResult.Items,
-		"scim": scimGroupsResult.Items,
-	}).Info("reconciling groups")
-	groupsCreate, groupsUpdate, groupsEqual, groupsDelete, err := model.GroupsOperations(idpGroupsResult, scimGroupsResult)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error reconciling groups: %w", err)
-	}
+	if checkpointDone(state, model.CheckpointGroups) {
+		log.WithField("checkpoint", state.Checkpoint).Warn("groups already reconciled, resuming from the checkpoint")
 
-	groupsCreated, groupsUpdated, err := reconcilingGroups(ctx, scim, groupsCreate, groupsUpdate, groupsDelete)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error reconciling groups: %w", err)
-	}
+		// the groups of the checkpoint are the ones in the SCIM side
+		scimGroupsResult = state.Resources.Groups
+		groupsRetained = missingGroups(scimGroupsResult)
+
+		var err error
+		totalGroupsResult, err = ss.resumingGroups(ctx, state, idpGroupsResult)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	} else {
+		log.Info("getting SCIM Groups")
+		var err error
+		scimGroupsResult, err = ss.scim.GetGroups(ctx)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error getting groups from the SCIM service: %w", err)
+		}
 
-	// groupsCreated + groupsUpdated + groupsEqual = groups total
-	totalGroupsResult = model.MergeGroupsResult(groupsCreated, groupsUpdated, groupsEqual)
+		log.WithFields(log.Fields{
+			"idp":  idpGroupsResult.Items,
+			"scim": scimGroupsResult.Items,
+		}).Info("reconciling groups")
+		groupsCreate, groupsUpdate, groupsEqual, groupsDelete, err := model.GroupsOperations(idpGroupsResult, scimGroupsResult)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error reconciling groups: %w", err)
+		}
 
-	log.Info("getting SCIM Users")
-	scimUsersResult, err := scim.GetUsers(ctx)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error getting users from the SCIM service: %w", err)
+		groupsDelete, groupsRetained, err = retainingGroups(groupsDelete, ss.removalGraceRuns, ss.removalGracePeriod, !ss.targeted)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error retaining groups: %w", err)
+		}
+
+		ss.report.groups(groupsCreate, groupsUpdate, groupsEqual, groupsDelete, groupsRetained)
+
+		groupsCreated, groupsUpdated, err := reconcilingGroups(ctx, ss.scim, groupsCreate, groupsUpdate, groupsDelete)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error reconciling groups: %w", err)
+		}
+
+		// groupsCreated + groupsUpdated + groupsEqual + groupsRetained = groups total
+		totalGroupsResult = model.MergeGroupsResult(groupsCreated, groupsUpdated, groupsEqual, groupsRetained)
+
+		if err := ss.checkpoint(ctx, model.CheckpointGroups, totalGroupsResult, model.UsersResultBuilder().Build(), scimGroupsResult, model.UsersResultBuilder().Build()); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
-	log.WithFields(log.Fields{
-		"idp":  idpUsersResult.Items,
-		"scim": scimUsersResult.Items,
-	}).Info("reconciling users")
-	usersCreate, usersUpdate, usersEqual, usersDelete, err := model.UsersOperations(idpUsersResult, scimUsersResult)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error operating with users: %w", err)
+	if err := ss.beforeDeadline(ctx, phaseUsers); err != nil {
+		return nil, nil, nil, err
 	}
 
-	usersCreated, usersUpdated, err := reconcilingUsers(ctx, scim, usersCreate, usersUpdate, usersDelete)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error reconciling users: %w", err)
+	if checkpointDone(state, model.CheckpointUsers) {
+		log.WithField("checkpoint", state.Checkpoint).Warn("users already reconciled, resuming from the checkpoint")
+
+		// the users of the checkpoint are the ones in the SCIM side
+		scimUsersResult = state.Resources.Users
+		usersRetained = missingUsers(scimUsersResult)
+
+		var err error
+		totalUsersResult, err = ss.resumingUsers(ctx, state, totalGroupsResult, idpUsersResult)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	} else {
+		log.Info("getting SCIM Users")
+		var err error
+		scimUsersResult, err = ss.scim.GetUsers(ctx)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error getting users from the SCIM service: %w", err)
+		}
+
+		log.WithFields(log.Fields{
+			"idp":  idpUsersResult.Items,
+			"scim": scimUsersResult.Items,
+		}).Info("reconciling users")
+		usersCreate, usersUpdate, usersEqual, usersDelete, err := model.UsersOperations(idpUsersResult, scimUsersResult)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error operating with users: %w", err)
+		}
+
+		usersDelete, usersRetained, err = retainingUsers(usersDelete, ss.removalGraceRuns, ss.removalGracePeriod, !ss.targeted)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error retaining users: %w", err)
+		}
+
+		usersDelete, usersDeactivated, err := deprovisioningUsers(ctx, ss.scim, ss.userDeprovisioningPolicy, ss.userDeprovisioningGracePeriod, usersDelete)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error deprovisioning users: %w", err)
+		}
+
+		ss.report.users(usersCreate, usersUpdate, usersEqual, usersDelete, usersDeactivated, usersRetained)
+
+		usersCreated, usersUpdated, err := reconcilingUsers(ctx, ss.scim, usersCreate, usersUpdate, usersDelete)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error reconciling users: %w", err)
+		}
+
+		// usersCreated + usersUpdated + usersEqual + usersDeactivated + usersRetained = users total
+		totalUsersResult = model.MergeUsersResult(usersCreated, usersUpdated, usersEqual, usersDeactivated, usersRetained)
+
+		if err := ss.checkpoint(ctx, model.CheckpointUsers, totalGroupsResult, totalUsersResult, scimGroupsResult, scimUsersResult); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
-	// usersCreated + usersUpdated + usersEqual = users total
-	totalUsersResult = model.MergeUsersResult(usersCreated, usersUpdated, usersEqual)
+	if err := ss.beforeDeadline(ctx, phaseGroupsMembers); err != nil {
+		return nil, nil, nil, err
+	}
 
 	log.Info("getting SCIM Groups Members")
 	// unfortunately, the SCIM service does not support the getGroupsMembers method in and efficient way
 	// see: "Nor Supported" section in: https://docs.aws.amazon.com/singlesignon/latest/developerguide/listgroups.html
 	// scimGroupsMembersResult, err := scim.GetGroupsMembers(ctx, &totalGroupsResult) // not supported yet
-	scimGroupsMembersResult, err := scim.GetGroupsMembersBruteForce(ctx, totalGroupsResult, totalUsersResult)
+	scimGroupsMembersResult, err := ss.scim.GetGroupsMembersBruteForce(ctx, totalGroupsResult, totalUsersResult)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("error getting groups members from the SCIM service: %w", err)
 	}
 
+	// the memberships of the groups and users retained during the removal grace period are kept
+	idpGroupsMembersResult = retainingGroupsMembers(idpGroupsMembersResult, scimGroupsMembersResult, groupsRetained, usersRetained)
+
 	log.WithFields(log.Fields{
 		"idp":  idpGroupsMembersResult.Items,
 		"scim": scimGroupsMembersResult.Items,
@@ -87,7 +162,9 @@ func scimSync(
 		return nil, nil, nil, fmt.Errorf("error reconciling groups members: %w", err)
 	}
 
-	membersCreated, err := reconcilingGroupsMembers(ctx, scim, membersCreate, membersDelete)
+	ss.report.groupsMembers(membersCreate, membersEqual, membersDelete)
+
+	membersCreated, err := reconcilingGroupsMembers(ctx, ss.scim, membersCreate, membersDelete)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("error reconciling groups members: %w", err)
 	}
@@ -95,15 +172,23 @@ func scimSync(
 	// membersCreate + membersEqual = members total
 	totalGroupsMembersResult = model.MergeGroupsMembersResult(membersCreated, membersEqual)
 
+	// the failed operations are retried by the next sync, that will be done from the state
+	totalGroupsResult, totalUsersResult, totalGroupsMembersResult = ss.failures.retry(
+		totalGroupsResult,
+		totalUsersResult,
+		totalGroupsMembersResult,
+		scimGroupsResult,
+		scimUsersResult,
+	)
+
 	return totalGroupsResult, totalUsersResult, totalGroupsMembersResult, nil
 }
 
 // stateSync executes the sync of the data on the state side and
 // returns the datasets synced
-func stateSync(
+func (ss *SyncService) stateSync(
 	ctx context.Context,
 	state *model.State,
-	scim SCIMService,
 	idpGroupsResult *model.GroupsResult,
 	idpUsersResult *model.UsersResult,
 	idpGroupsMembersResult *model.GroupsMembersResult,
@@ -111,6 +196,8 @@ func stateSync(
 	var totalGroupsResult *model.GroupsResult
 	var totalUsersResult *model.UsersResult
 	var totalGroupsMembersResult *model.GroupsMembersResult
+	groupsRetained := model.GroupsResultBuilder().Build()
+	usersRetained := model.UsersResultBuilder().Build()
 	log.Warn("reconciling the state data with the Identity Provider data")
 
 	lastSyncTime, err := time.Parse(time.RFC3339, state.LastSync)
@@ -123,10 +210,23 @@ func stateSync(
 		"since":    time.Since(lastSyncTime).String(),
 	}).Info("syncing from state")
 
+	// the state is stored only at the end of the sync, without checkpoints, so the deadline is checked only
+	// before applying any change, otherwise the changes done would be missing in the state
+	if err := ss.beforeDeadline(ctx, phaseGroups); err != nil {
+		return nil, nil, nil, err
+	}
+
+	groupsRenamed, groupsNotRenamed, err := renamingGroups(ctx, ss.scim, idpGroupsResult, state)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error renaming groups: %w", err)
+	}
+	idpGroupsResult, idpGroupsMembersResult = withStateNames(groupsNotRenamed, idpGroupsResult, idpGroupsMembersResult)
+
 	if idpGroupsResult.HashCode == state.Resources.Groups.HashCode {
 		log.Info("provider groups and state groups are the same, nothing to do with groups")
 
 		totalGroupsResult = state.Resources.Groups
+		ss.report.groups(model.GroupsResultBuilder().Build(), model.GroupsResultBuilder().Build(), totalGroupsResult, model.GroupsResultBuilder().Build(), model.GroupsResultBuilder().Build())
 	} else {
 		log.Info("provider groups and state groups are different")
 		// now here we have the google fresh data and the last sync data state
@@ -142,19 +242,29 @@ func stateSync(
 			return nil, nil, nil, fmt.Errorf("error reconciling groups: %w", err)
 		}
 
-		groupsCreated, groupsUpdated, err := reconcilingGroups(ctx, scim, groupsCreate, groupsUpdate, groupsDelete)
+		groupsDelete, groupsRetained, err = retainingGroups(groupsDelete, ss.removalGraceRuns, ss.removalGracePeriod, !ss.targeted)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error retaining groups: %w", err)
+		}
+
+		ss.report.groups(groupsCreate, groupsUpdate, groupsEqual, groupsDelete, groupsRetained)
+
+		groupsCreated, groupsUpdated, err := reconcilingGroups(ctx, ss.scim, groupsCreate, groupsUpdate, groupsDelete)
 		if err != nil {
 			return nil, nil, nil, fmt.Errorf("error reconciling groups: %w", err)
 		}
 
-		// merge in only one data structure the groups created, updated amd equals who has the SCIMID
-		totalGroupsResult = model.MergeGroupsResult(groupsCreated, groupsUpdated, groupsEqual)
+		// merge in only one data structure the groups created, updated, equals and retained who has the SCIMID
+		totalGroupsResult = model.MergeGroupsResult(groupsCreated, groupsUpdated, groupsEqual, groupsRetained)
 	}
 
+	ss.report.groupsRenamed(groupsRenamed)
+
 	if idpUsersResult.HashCode == state.Resources.Users.HashCode {
 		log.Info("provider users and state users are the same, nothing to do with users")
 
 		totalUsersResult = state.Resources.Users
+		ss.report.users(model.UsersResultBuilder().Build(), model.UsersResultBuilder().Build(), totalUsersResult, model.UsersResultBuilder().Build(), model.UsersResultBuilder().Build(), model.UsersResultBuilder().Build())
 	} else {
 		log.Info("provider users and state users are different")
 
@@ -167,43 +277,78 @@ func stateSync(
 			return nil, nil, nil, fmt.Errorf("error operating with users: %w", err)
 		}
 
-		usersCreated, usersUpdated, err := reconcilingUsers(ctx, scim, usersCreate, usersUpdate, usersDelete)
+		usersDelete, usersRetained, err = retainingUsers(usersDelete, ss.removalGraceRuns, ss.removalGracePeriod, !ss.targeted)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error retaining users: %w", err)
+		}
+
+		usersDelete, usersDeactivated, err := deprovisioningUsers(ctx, ss.scim, ss.userDeprovisioningPolicy, ss.userDeprovisioningGracePeriod, usersDelete)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error deprovisioning users: %w", err)
+		}
+
+		ss.report.users(usersCreate, usersUpdate, usersEqual, usersDelete, usersDeactivated, usersRetained)
+
+		usersCreated, usersUpdated, err := reconcilingUsers(ctx, ss.scim, usersCreate, usersUpdate, usersDelete)
 		if err != nil {
 			return nil, nil, nil, fmt.Errorf("error reconciling users: %w", err)
 		}
 
-		// usersCreated + usersUpdated + usersEqual = users total
-		totalUsersResult = model.MergeUsersResult(usersCreated, usersUpdated, usersEqual)
+		// usersCreated + usersUpdated + usersEqual + usersDeactivated + usersRetained = users total
+		totalUsersResult = model.MergeUsersResult(usersCreated, usersUpdated, usersEqual, usersDeactivated, usersRetained)
+	}
+
+	if ss.userDriftDetection {
+		drifts, err := ss.detectingUsersDrift(ctx, totalUsersResult)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error detecting users drift: %w", err)
+		}
+		ss.report.usersDrift(drifts)
 	}
 
 	if idpGroupsMembersResult.HashCode == state.Resources.GroupsMembers.HashCode {
 		log.Info("provider groups-members and state groups-members are the same, nothing to do with groups-members")
 
 		totalGroupsMembersResult = state.Resources.GroupsMembers
+		ss.report.groupsMembers(model.GroupsMembersResultBuilder().Build(), totalGroupsMembersResult, model.GroupsMembersResultBuilder().Build())
 	} else {
 		log.Info("provider groups-members and state groups-members are different")
 
 		// if we create a group or user during the sync, we need the scimid of these new groups/users
 		// because to add members to a group the scim api needs that.
 		// so this function will fill the scimid of the new groups/users
-		groupsMembers := model.UpdateGroupsMembersSCIMID(idpGroupsMembersResult, totalGroupsResult, totalUsersResult)
+		// the memberships of the groups and users retained during the removal grace period are kept
+		groupsMembers := retainingGroupsMembers(idpGroupsMembersResult, state.Resources.GroupsMembers, groupsRetained, usersRetained)
+		groupsMembers = model.UpdateGroupsMembersSCIMID(groupsMembers, totalGroupsResult, totalUsersResult)
 
 		log.WithFields(log.Fields{
 			"idp":   idpGroupsMembersResult.Items,
 			"state": state.Resources.GroupsMembers.Items,
 		}).Info("reconciling groups members")
 
-		membersCreate, _, membersDelete, err := model.MembersOperations(groupsMembers, state.Resources.GroupsMembers)
+		membersCreate, membersEqual, membersDelete, err := model.MembersOperations(groupsMembers, state.Resources.GroupsMembers)
 		if err != nil {
 			return nil, nil, nil, fmt.Errorf("error reconciling groups members: %w", err)
 		}
 
-		_, err = reconcilingGroupsMembers(ctx, scim, membersCreate, membersDelete)
+		ss.report.groupsMembers(membersCreate, membersEqual, membersDelete)
+
+		_, err = reconcilingGroupsMembers(ctx, ss.scim, membersCreate, membersDelete)
 		if err != nil {
 			return nil, nil, nil, fmt.Errorf("error reconciling groups members: %w", err)
 		}
 
 		totalGroupsMembersResult = model.MergeGroupsMembersResult(groupsMembers)
 	}
+
+	// the failed operations are retried by the next sync
+	totalGroupsResult, totalUsersResult, totalGroupsMembersResult = ss.failures.retry(
+		totalGroupsResult,
+		totalUsersResult,
+		totalGroupsMembersResult,
+		state.Resources.Groups,
+		state.Resources.Users,
+	)
+
 	return totalGroupsResult, totalUsersResult, totalGroupsMembersResult, nil
 }
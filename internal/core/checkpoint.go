@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
+	"github.com/slashdevops/idp-scim-sync/internal/version"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrSyncDeadline is returned when the sync stops before the deadline of its context,
+// a first sync resumes from its last checkpoint in the next sync.
+var ErrSyncDeadline = errors.New("sync stopped before the deadline")
+
+// Phases of the sync
+const (
+	phaseGroups        = "groups"
+	phaseUsers         = "users"
+	phaseGroupsMembers = "groupsMembers"
+)
+
+// beforeDeadline returns ErrSyncDeadline when the time left before the deadline of the context,
+// e.g. the AWS Lambda timeout, is less than the deadline margin, so the phase is not started.
+func (ss *SyncService) beforeDeadline(ctx context.Context, phase string) error {
+	if ss.deadlineMargin <= 0 {
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	left := time.Until(deadline)
+	if left >= ss.deadlineMargin {
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"phase":    phase,
+		"timeLeft": left.Round(time.Second).String(),
+		"margin":   ss.deadlineMargin.String(),
+	}).Warn("stopping the sync before the deadline")
+
+	return fmt.Errorf("%w: %s not started, %s left", ErrSyncDeadline, phase, left.Round(time.Second))
+}
+
+// checkpoint stores the state of a first sync after reconciling the groups or the users, so the next sync
+// resumes from it when this one doesn't finish. The state has no last sync until the first sync finishes.
+func (ss *SyncService) checkpoint(
+	ctx context.Context,
+	checkpoint string,
+	groups *model.GroupsResult,
+	users *model.UsersResult,
+	prevGroups *model.GroupsResult,
+	prevUsers *model.UsersResult,
+) error {
+	if !ss.checkpoints || ss.dryRun {
+		return nil
+	}
+
+	// the failed operations are not in the checkpoint either, the next sync retries them
+	groups, users, _ = ss.failures.retry(groups, users, model.GroupsMembersResultBuilder().Build(), prevGroups, prevUsers)
+
+	state := model.StateBuilder().
+		WithCodeVersion(version.Version).
+		WithCheckpoint(checkpoint).
+		WithGroups(groups).
+		WithUsers(users).
+		Build()
+
+	log.WithFields(log.Fields{
+		"checkpoint": checkpoint,
+		"groups":     groups.Items,
+		"users":      users.Items,
+	}).Info("storing the checkpoint state")
+
+	ctx, span := tracing.Start(ctx, tracer, "state.Checkpoint", trace.WithAttributes(attribute.String("checkpoint", checkpoint)))
+	err := ss.repo.SetState(ctx, state)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("error storing the checkpoint state: %s, %w", checkpoint, err)
+	}
+
+	return nil
+}
+
+// checkpointDone returns true when the phase of the checkpoint, or a later one, was done by the previous sync.
+func checkpointDone(state *model.State, checkpoint string) bool {
+	switch state.Checkpoint {
+	case model.CheckpointUsers:
+		return checkpoint == model.CheckpointGroups || checkpoint == model.CheckpointUsers
+	case model.CheckpointGroups:
+		return checkpoint == model.CheckpointGroups
+	default:
+		return false
+	}
+}
+
+// resumingGroups returns the groups of the checkpoint with the groups created in the identity provider after it,
+// the new ones are created in the SCIM side now so their members can be added, the rest of the changes of the
+// groups since the checkpoint are reconciled by the next sync, from the state.
+func (ss *SyncService) resumingGroups(ctx context.Context, state *model.State, idpGroupsResult *model.GroupsResult) (*model.GroupsResult, error) {
+	groupsCreate, _, _, _, err := model.GroupsOperations(idpGroupsResult, state.Resources.Groups)
+	if err != nil {
+		return nil, fmt.Errorf("error reconciling groups: %w", err)
+	}
+
+	ss.report.groups(groupsCreate, model.GroupsResultBuilder().Build(), state.Resources.Groups, model.GroupsResultBuilder().Build(), model.GroupsResultBuilder().Build())
+
+	groupsCreated, _, err := reconcilingGroups(ctx, ss.scim, groupsCreate, model.GroupsResultBuilder().Build(), model.GroupsResultBuilder().Build())
+	if err != nil {
+		return nil, fmt.Errorf("error reconciling groups: %w", err)
+	}
+
+	totalGroupsResult := model.MergeGroupsResult(state.Resources.Groups, groupsCreated)
+
+	if groupsCreated.Items > 0 {
+		if err := ss.checkpoint(ctx, state.Checkpoint, totalGroupsResult, state.Resources.Users, state.Resources.Groups, state.Resources.Users); err != nil {
+			return nil, err
+		}
+	}
+
+	return totalGroupsResult, nil
+}
+
+// resumingUsers returns the users of the checkpoint with the users created in the identity provider after it,
+// the same as resumingGroups.
+func (ss *SyncService) resumingUsers(ctx context.Context, state *model.State, groups *model.GroupsResult, idpUsersResult *model.UsersResult) (*model.UsersResult, error) {
+	usersCreate, _, _, _, err := model.UsersOperations(idpUsersResult, state.Resources.Users)
+	if err != nil {
+		return nil, fmt.Errorf("error operating with users: %w", err)
+	}
+
+	ss.report.users(usersCreate, model.UsersResultBuilder().Build(), state.Resources.Users, model.UsersResultBuilder().Build(), model.UsersResultBuilder().Build(), model.UsersResultBuilder().Build())
+
+	usersCreated, _, err := reconcilingUsers(ctx, ss.scim, usersCreate, model.UsersResultBuilder().Build(), model.UsersResultBuilder().Build())
+	if err != nil {
+		return nil, fmt.Errorf("error reconciling users: %w", err)
+	}
+
+	totalUsersResult := model.MergeUsersResult(state.Resources.Users, usersCreated)
+
+	if usersCreated.Items > 0 {
+		if err := ss.checkpoint(ctx, model.CheckpointUsers, groups, totalUsersResult, groups, state.Resources.Users); err != nil {
+			return nil, err
+		}
+	}
+
+	return totalUsersResult, nil
+}
+
+// missingGroups returns the groups of a checkpoint retained during the removal grace period.
+func missingGroups(gr *model.GroupsResult) *model.GroupsResult {
+	groups := make([]*model.Group, 0)
+	for _, group := range gr.Resources {
+		if group.MissingSince != "" {
+			groups = append(groups, group)
+		}
+	}
+
+	return model.GroupsResultBuilder().WithResources(groups).Build()
+}
+
+// missingUsers returns the users of a checkpoint retained during the removal grace period.
+func missingUsers(ur *model.UsersResult) *model.UsersResult {
+	users := make([]*model.User, 0)
+	for _, user := range ur.Resources {
+		if user.MissingSince != "" {
+			users = append(users, user)
+		}
+	}
+
+	return model.UsersResultBuilder().WithResources(users).Build()
+}
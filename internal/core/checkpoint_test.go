@@ -0,0 +1,318 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncService_BeforeDeadline(t *testing.T) {
+	t.Run("Should not stop without deadline margin or without deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+		defer cancel()
+
+		ss := &SyncService{}
+		assert.NoError(t, ss.beforeDeadline(ctx, phaseGroups))
+
+		ss = &SyncService{deadlineMargin: time.Minute}
+		assert.NoError(t, ss.beforeDeadline(context.TODO(), phaseGroups))
+	})
+
+	t.Run("Should stop when the time left is less than the margin", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+		defer cancel()
+
+		ss := &SyncService{deadlineMargin: time.Minute}
+		err := ss.beforeDeadline(ctx, phaseUsers)
+		assert.ErrorIs(t, err, ErrSyncDeadline)
+		assert.Contains(t, err.Error(), "users not started")
+	})
+
+	t.Run("Should not stop when the time left is more than the margin", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Hour)
+		defer cancel()
+
+		ss := &SyncService{deadlineMargin: time.Minute}
+		assert.NoError(t, ss.beforeDeadline(ctx, phaseUsers))
+	})
+}
+
+func TestCheckpointDone(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      string
+		checkpoint string
+		want       bool
+	}{
+		{name: "no checkpoint", state: "", checkpoint: model.CheckpointGroups, want: false},
+		{name: "groups done", state: model.CheckpointGroups, checkpoint: model.CheckpointGroups, want: true},
+		{name: "users not done", state: model.CheckpointGroups, checkpoint: model.CheckpointUsers, want: false},
+		{name: "groups done before users", state: model.CheckpointUsers, checkpoint: model.CheckpointGroups, want: true},
+		{name: "users done", state: model.CheckpointUsers, checkpoint: model.CheckpointUsers, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := model.StateBuilder().WithCheckpoint(tt.state).Build()
+			assert.Equal(t, tt.want, checkpointDone(state, tt.checkpoint))
+		})
+	}
+}
+
+func TestSyncService_SyncGroupsAndTheirMembers_Checkpoints(t *testing.T) {
+	ctx := context.TODO()
+
+	group := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	user := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	member := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+
+	scimGroup := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	scimUser := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+
+	idpGroups := model.GroupsResultBuilder().WithResource(group).Build()
+	idpUsers := model.UsersResultBuilder().WithResource(user).Build()
+	idpGroupsMembers := model.GroupsMembersResultBuilder().WithResource(
+		model.GroupMembersBuilder().WithGroup(group).WithResource(member).Build(),
+	).Build()
+
+	t.Run("Should store a checkpoint after the groups and after the users", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(model.GroupsResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateGroups(ctx, gomock.Any()).Return(model.GroupsResultBuilder().WithResource(scimGroup).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateUsers(ctx, gomock.Any()).Return(model.UsersResultBuilder().WithResource(scimUser).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, gomock.Any(), gomock.Any()).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+
+		var stored []*model.State
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			stored = append(stored, state)
+			return nil
+		}).Times(3)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithCheckpoints(true))
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncGroupsAndTheirMembers(ctx))
+
+		assert.Len(t, stored, 3)
+
+		assert.Equal(t, model.CheckpointGroups, stored[0].Checkpoint)
+		assert.Empty(t, stored[0].LastSync)
+		assert.Equal(t, model.GroupsResultBuilder().WithResource(scimGroup).Build(), stored[0].Resources.Groups)
+		assert.Equal(t, 0, stored[0].Resources.Users.Items)
+
+		assert.Equal(t, model.CheckpointUsers, stored[1].Checkpoint)
+		assert.Empty(t, stored[1].LastSync)
+		assert.Equal(t, model.UsersResultBuilder().WithResource(scimUser).Build(), stored[1].Resources.Users)
+
+		assert.Empty(t, stored[2].Checkpoint)
+		assert.NotEmpty(t, stored[2].LastSync)
+	})
+
+	t.Run("Should resume the first sync from the checkpoint", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		checkpoint := model.StateBuilder().
+			WithCheckpoint(model.CheckpointUsers).
+			WithGroups(model.GroupsResultBuilder().WithResource(scimGroup).Build()).
+			WithUsers(model.UsersResultBuilder().WithResource(scimUser).Build()).
+			Build()
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(ctx).Return(checkpoint, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(gomock.Any()).Times(0)
+		mockSCIMService.EXPECT().GetUsers(gomock.Any()).Times(0)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, checkpoint.Resources.Groups, checkpoint.Resources.Users).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).Return(nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithCheckpoints(true))
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncGroupsAndTheirMembers(ctx))
+
+		report := svc.LastReport()
+		assert.True(t, report.FirstSync)
+		assert.Equal(t, OperationsReport{Equal: 1}, report.Groups)
+		assert.Equal(t, OperationsReport{Equal: 1}, report.Users)
+	})
+
+	t.Run("Should create the groups created in the identity provider after the checkpoint", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		checkpoint := model.StateBuilder().
+			WithCheckpoint(model.CheckpointGroups).
+			WithGroups(model.GroupsResultBuilder().WithResource(scimGroup).Build()).
+			Build()
+
+		group2 := model.GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+		scimGroup2 := model.GroupBuilder().WithIPID("2").WithSCIMID("g2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+		newIdpGroups := model.GroupsResultBuilder().WithResources([]*model.Group{group, group2}).Build()
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(newIdpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, newIdpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(ctx).Return(checkpoint, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(gomock.Any()).Times(0)
+		mockSCIMService.EXPECT().CreateGroups(ctx, model.GroupsResultBuilder().WithResource(group2).Build()).Return(model.GroupsResultBuilder().WithResource(scimGroup2).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().WithResource(scimUser).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, gomock.Any(), gomock.Any()).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+
+		var stored []*model.State
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			stored = append(stored, state)
+			return nil
+		}).Times(3)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithCheckpoints(true))
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncGroupsAndTheirMembers(ctx))
+
+		assert.Len(t, stored, 3)
+		assert.Equal(t, model.CheckpointGroups, stored[0].Checkpoint)
+		assert.Equal(t, model.GroupsResultBuilder().WithResources([]*model.Group{scimGroup, scimGroup2}).Build(), stored[0].Resources.Groups)
+		assert.Equal(t, 2, stored[2].Resources.Groups.Items)
+
+		assert.Equal(t, OperationsReport{Create: 1, Equal: 1}, svc.LastReport().Groups)
+	})
+
+	t.Run("Should stop before the deadline after the checkpoint of the groups", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		dctx, cancel := context.WithTimeout(ctx, time.Hour)
+		defer cancel()
+
+		mockProviderService.EXPECT().GetGroups(dctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(dctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(dctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(dctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(dctx).Return(model.GroupsResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithCheckpoints(true), WithDeadlineMargin(30*time.Minute))
+		assert.NoError(t, err)
+
+		// the groups take the time left before the deadline margin
+		mockSCIMService.EXPECT().CreateGroups(dctx, gomock.Any()).DoAndReturn(func(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+			svc.deadlineMargin = 2 * time.Hour
+			return model.GroupsResultBuilder().WithResource(scimGroup).Build(), nil
+		}).Times(1)
+		mockStateRepository.EXPECT().SetState(dctx, gomock.Any()).Return(nil).Times(1)
+
+		err = svc.SyncGroupsAndTheirMembers(dctx)
+		assert.True(t, errors.Is(err, ErrSyncDeadline))
+		assert.False(t, svc.LastReport().Success)
+	})
+}
+
+func TestSyncService_StateSync_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Hour)
+	defer cancel()
+
+	group1 := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	member1 := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+	member3 := model.MemberBuilder().WithIPID("3").WithEmail("user.3@mail.com").WithStatus("ACTIVE").Build()
+	user3 := model.UserBuilder().WithIPID("3").WithEmail("user.3@mail.com").WithGivenName("user").WithFamilyName("3").WithDisplayName("user 3").WithActive(true).Build()
+
+	t.Run("Should store the changes applied when the deadline margin is reached during the sync", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroup(ctx, "group 1").Return(group1, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, gomock.Any()).Return(
+			model.GroupsMembersResultBuilder().WithResource(
+				model.GroupMembersBuilder().WithGroup(group1).WithResources([]*model.Member{member1, member3}).Build(),
+			).Build(), nil,
+		).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, gomock.Any()).Return(model.UsersResultBuilder().WithResource(user3).Build(), nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithDeadlineMargin(30*time.Minute))
+		assert.NoError(t, err)
+
+		// the users take the time left before the deadline margin
+		mockSCIMService.EXPECT().CreateUsers(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+			svc.deadlineMargin = 2 * time.Hour
+
+			created := model.UserBuilder().WithIPID("3").WithSCIMID("u3").WithEmail("user.3@mail.com").
+				WithGivenName("user").WithFamilyName("3").WithDisplayName("user 3").WithActive(true).Build()
+			return model.UsersResultBuilder().WithResource(created).Build(), nil
+		}).Times(1)
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, gmr *model.GroupsMembersResult) (*model.GroupsMembersResult, error) {
+			return gmr, nil
+		}).Times(1)
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			// the user created is in the state, otherwise the next sync would create it again
+			assert.Equal(t, 3, state.Resources.Users.Items)
+			assert.Empty(t, state.Checkpoint)
+			return nil
+		}).Times(1)
+
+		assert.NoError(t, svc.SyncGroup(ctx, "group 1"))
+		assert.True(t, svc.LastReport().Success)
+	})
+
+	t.Run("Should stop before applying any change when the deadline margin is reached", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroup(ctx, "group 1").Return(group1, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, gomock.Any()).Return(
+			model.GroupsMembersResultBuilder().WithResource(
+				model.GroupMembersBuilder().WithGroup(group1).WithResources([]*model.Member{member1, member3}).Build(),
+			).Build(), nil,
+		).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, gomock.Any()).Return(model.UsersResultBuilder().WithResource(user3).Build(), nil).Times(1)
+		mockStateRepository.EXPECT().SetState(gomock.Any(), gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithDeadlineMargin(2*time.Hour))
+		assert.NoError(t, err)
+
+		// the state is not changed, there is nothing applied to store
+		err = svc.SyncGroup(ctx, "group 1")
+		assert.ErrorIs(t, err, ErrSyncDeadline)
+		assert.Contains(t, err.Error(), "groups not started")
+	})
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+)
+
+// UserDrift is a user of the state with attributes changed in the SCIM side out of the sync.
+type UserDrift struct {
+	Email  string `json:"email"`
+	SCIMID string `json:"scimId"`
+
+	// Attributes are the names of the attributes changed, corrected with the values of the state
+	Attributes []string `json:"attributes,omitempty"`
+
+	// Missing is true when the user is not in the SCIM side, it is not corrected
+	Missing bool `json:"missing,omitempty"`
+}
+
+// detectingUsersDrift compares the users of the state with the users of the SCIM side and puts the values
+// of the state to the users with name, displayName, email or active changed in the SCIM side.
+// All the users are compared, or a random sample of them when the drift sample is set.
+func (ss *SyncService) detectingUsersDrift(ctx context.Context, users *model.UsersResult) ([]*UserDrift, error) {
+	stateUsers := make([]*model.User, 0, len(users.Resources))
+	for _, user := range users.Resources {
+		// the users created by a dry run have no SCIMID
+		if user.SCIMID != "" {
+			stateUsers = append(stateUsers, user)
+		}
+	}
+
+	scimUsers, err := ss.scimUsersForDrift(ctx, stateUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := make([]*UserDrift, 0)
+	correct := make([]*model.User, 0)
+
+	for _, user := range stateUsers {
+		scimUser, ok := scimUsers[user.SCIMID]
+		if !ok {
+			continue
+		}
+
+		if scimUser == nil {
+			log.WithFields(log.Fields{
+				"email":  user.Email,
+				"scimid": user.SCIMID,
+			}).Warn("user of the state missing in the SCIM side")
+
+			drifts = append(drifts, &UserDrift{Email: user.Email, SCIMID: user.SCIMID, Missing: true})
+			continue
+		}
+
+		attributes := driftedAttributes(user, scimUser)
+		if len(attributes) == 0 {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"email":      user.Email,
+			"scimid":     user.SCIMID,
+			"attributes": attributes,
+		}).Warn("user changed in the SCIM side, putting the values of the state")
+
+		drifts = append(drifts, &UserDrift{Email: user.Email, SCIMID: user.SCIMID, Attributes: attributes})
+		correct = append(correct, user)
+	}
+
+	if len(correct) == 0 {
+		log.WithField("verified", len(scimUsers)).Info("no users changed in the SCIM side")
+		return drifts, nil
+	}
+
+	log.WithField("quantity", len(correct)).Warn("correcting users changed in the SCIM side")
+	if _, err := ss.scim.UpdateUsers(ctx, model.UsersResultBuilder().WithResources(correct).Build()); err != nil {
+		return nil, fmt.Errorf("error correcting users drift in SCIM provider: %w", err)
+	}
+
+	return drifts, nil
+}
+
+// scimUsersForDrift returns the SCIM users to compare by SCIMID, nil for the users of the state missing in the SCIM side.
+// Without drift sample all the SCIM users are listed, otherwise the users of the sample are got one by one.
+func (ss *SyncService) scimUsersForDrift(ctx context.Context, stateUsers []*model.User) (map[string]*model.User, error) {
+	scimUsers := make(map[string]*model.User)
+
+	if ss.userDriftSample <= 0 || ss.userDriftSample >= len(stateUsers) {
+		log.Info("getting SCIM Users to detect the drift")
+		ur, err := ss.scim.GetUsers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting users from the SCIM service: %w", err)
+		}
+
+		found := make(map[string]*model.User)
+		for _, user := range ur.Resources {
+			found[user.SCIMID] = user
+		}
+
+		for _, user := range stateUsers {
+			scimUsers[user.SCIMID] = found[user.SCIMID]
+		}
+
+		return scimUsers, nil
+	}
+
+	log.WithField("sample", ss.userDriftSample).Info("getting a sample of SCIM Users to detect the drift")
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, i := range r.Perm(len(stateUsers))[:ss.userDriftSample] {
+		user := stateUsers[i]
+
+		// the user is nil when it is missing in the SCIM side
+		scimUser, err := ss.scim.GetUser(ctx, user.SCIMID)
+		if err != nil {
+			// the request failed, the user is not verified in this sync
+			log.WithError(err).WithField("email", user.Email).Warn("cannot get the user to detect the drift")
+			continue
+		}
+
+		scimUsers[user.SCIMID] = scimUser
+	}
+
+	return scimUsers, nil
+}
+
+// driftedAttributes returns the names of the attributes of the SCIM user different than in the state user.
+func driftedAttributes(state, scim *model.User) []string {
+	attributes := make([]string, 0)
+
+	if state.Name.GivenName != scim.Name.GivenName {
+		attributes = append(attributes, "givenName")
+	}
+	if state.Name.FamilyName != scim.Name.FamilyName {
+		attributes = append(attributes, "familyName")
+	}
+	if state.DisplayName != scim.DisplayName {
+		attributes = append(attributes, "displayName")
+	}
+	if state.Email != scim.Email {
+		attributes = append(attributes, "email")
+	}
+	if state.Active != scim.Active {
+		attributes = append(attributes, "active")
+	}
+
+	return attributes
+}
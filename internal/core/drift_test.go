@@ -0,0 +1,196 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriftedAttributes(t *testing.T) {
+	user := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+
+	t.Run("Should return no attributes when the users are equal", func(t *testing.T) {
+		scimUser := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		assert.Empty(t, driftedAttributes(user, scimUser))
+	})
+
+	t.Run("Should return the attributes changed", func(t *testing.T) {
+		scimUser := model.UserBuilder().WithIPID("other").WithSCIMID("s1").WithEmail("other@mail.com").WithGivenName("other").WithFamilyName("other").WithDisplayName("other").WithActive(false).Build()
+		assert.Equal(t, []string{"givenName", "familyName", "displayName", "email", "active"}, driftedAttributes(user, scimUser))
+	})
+}
+
+func TestSyncService_DetectingUsersDrift(t *testing.T) {
+	ctx := context.TODO()
+
+	user1 := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	user2 := model.UserBuilder().WithIPID("2").WithSCIMID("s2").WithEmail("user.2@mail.com").WithGivenName("user").WithFamilyName("2").WithDisplayName("user 2").WithActive(true).Build()
+	user3 := model.UserBuilder().WithIPID("3").WithSCIMID("s3").WithEmail("user.3@mail.com").WithGivenName("user").WithFamilyName("3").WithDisplayName("user 3").WithActive(true).Build()
+	notCreated := model.UserBuilder().WithIPID("4").WithEmail("user.4@mail.com").WithGivenName("user").WithFamilyName("4").WithDisplayName("user 4").WithActive(true).Build()
+
+	drifted2 := model.UserBuilder().WithIPID("2").WithSCIMID("s2").WithEmail("user.2@mail.com").WithGivenName("user").WithFamilyName("2").WithDisplayName("changed").WithActive(false).Build()
+
+	users := model.UsersResultBuilder().WithResources([]*model.User{user1, user2, user3, notCreated}).Build()
+
+	t.Run("Should correct the users changed and report the missing ones", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().WithResources([]*model.User{user1, drifted2}).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().UpdateUsers(ctx, model.UsersResultBuilder().WithResource(user2).Build()).Return(model.UsersResultBuilder().WithResource(user2).Build(), nil).Times(1)
+
+		ss := &SyncService{scim: mockSCIMService}
+		drifts, err := ss.detectingUsersDrift(ctx, users)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []*UserDrift{
+			{Email: "user.2@mail.com", SCIMID: "s2", Attributes: []string{"displayName", "active"}},
+			{Email: "user.3@mail.com", SCIMID: "s3", Missing: true},
+		}, drifts)
+	})
+
+	t.Run("Should not correct anything without changes", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().WithResources([]*model.User{user1, user2, user3}).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().UpdateUsers(gomock.Any(), gomock.Any()).Times(0)
+
+		ss := &SyncService{scim: mockSCIMService}
+		drifts, err := ss.detectingUsersDrift(ctx, users)
+		assert.NoError(t, err)
+		assert.Empty(t, drifts)
+	})
+
+	t.Run("Should get only a sample of the users", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		mockSCIMService.EXPECT().GetUsers(gomock.Any()).Times(0)
+		mockSCIMService.EXPECT().GetUser(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, scimID string) (*model.User, error) {
+			switch scimID {
+			case "s1":
+				return user1, nil
+			case "s2":
+				return drifted2, nil
+			default:
+				return nil, errors.New("test error")
+			}
+		}).Times(2)
+		mockSCIMService.EXPECT().UpdateUsers(ctx, gomock.Any()).Return(model.UsersResultBuilder().Build(), nil).AnyTimes()
+
+		ss := &SyncService{scim: mockSCIMService, userDriftSample: 2}
+		drifts, err := ss.detectingUsersDrift(ctx, users)
+		assert.NoError(t, err)
+
+		// the users not got are not verified
+		for _, drift := range drifts {
+			assert.Equal(t, "s2", drift.SCIMID)
+			assert.False(t, drift.Missing)
+		}
+	})
+
+	t.Run("Should report the users of the sample missing in the SCIM side", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		// the users not found in the SCIM side are nil
+		got := make([]string, 0)
+		mockSCIMService.EXPECT().GetUsers(gomock.Any()).Times(0)
+		mockSCIMService.EXPECT().GetUser(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, scimID string) (*model.User, error) {
+			got = append(got, scimID)
+			if scimID == "s1" {
+				return user1, nil
+			}
+			return nil, nil
+		}).Times(2)
+		mockSCIMService.EXPECT().UpdateUsers(gomock.Any(), gomock.Any()).Times(0)
+
+		ss := &SyncService{scim: mockSCIMService, userDriftSample: 2}
+		drifts, err := ss.detectingUsersDrift(ctx, users)
+		assert.NoError(t, err)
+
+		missing := make([]*UserDrift, 0)
+		for _, scimID := range got {
+			switch scimID {
+			case "s2":
+				missing = append(missing, &UserDrift{Email: "user.2@mail.com", SCIMID: "s2", Missing: true})
+			case "s3":
+				missing = append(missing, &UserDrift{Email: "user.3@mail.com", SCIMID: "s3", Missing: true})
+			}
+		}
+		assert.NotEmpty(t, missing)
+		assert.ElementsMatch(t, missing, drifts)
+	})
+
+	t.Run("Should return the error correcting the users", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().WithResources([]*model.User{user1, drifted2, user3}).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().UpdateUsers(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		ss := &SyncService{scim: mockSCIMService}
+		drifts, err := ss.detectingUsersDrift(ctx, users)
+		assert.Error(t, err)
+		assert.Nil(t, drifts)
+	})
+}
+
+func TestSyncService_SyncGroupsAndTheirMembers_UserDrift(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Should correct the users changed in the SCIM side when the state is up to date", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		user := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		stateUser := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		scimUser := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("changed").WithDisplayName("user 1").WithActive(true).Build()
+
+		idpGroups := model.GroupsResultBuilder().Build()
+		idpUsers := model.UsersResultBuilder().WithResource(user).Build()
+		idpGroupsMembers := model.GroupsMembersResultBuilder().Build()
+
+		state := model.StateBuilder().
+			WithLastSync(time.Now().Format(time.RFC3339)).
+			WithUsers(model.UsersResultBuilder().WithResource(stateUser).Build()).
+			Build()
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(ctx).Return(state, nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().WithResource(scimUser).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().UpdateUsers(ctx, model.UsersResultBuilder().WithResource(stateUser).Build()).Return(model.UsersResultBuilder().WithResource(stateUser).Build(), nil).Times(1)
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).Return(nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithUserDriftDetection(true))
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncGroupsAndTheirMembers(ctx))
+
+		report := svc.LastReport()
+		assert.Equal(t, []*UserDrift{{Email: "user.1@mail.com", SCIMID: "s1", Attributes: []string{"familyName"}}}, report.UsersDrift)
+		assert.Equal(t, 1, report.Changes())
+	})
+}
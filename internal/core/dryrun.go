@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+)
+
+// dryRunSCIMService is a SCIMService that reads from the SCIM service
+// but doesn't apply any change, the changes are only logged by the sync.
+type dryRunSCIMService struct {
+	SCIMService
+}
+
+// CreateGroups returns the groups as they would be created, without SCIMID.
+func (d *dryRunSCIMService) CreateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	return gr, nil
+}
+
+// UpdateGroups returns the groups as they would be updated.
+func (d *dryRunSCIMService) UpdateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	return gr, nil
+}
+
+// DeleteGroups does nothing.
+func (d *dryRunSCIMService) DeleteGroups(ctx context.Context, gr *model.GroupsResult) error {
+	return nil
+}
+
+// CreateUsers returns the users as they would be created, without SCIMID.
+func (d *dryRunSCIMService) CreateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return ur, nil
+}
+
+// UpdateUsers returns the users as they would be updated.
+func (d *dryRunSCIMService) UpdateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return ur, nil
+}
+
+// DeactivateUsers returns the users as they would be deactivated.
+func (d *dryRunSCIMService) DeactivateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return ur, nil
+}
+
+// DeleteUsers does nothing.
+func (d *dryRunSCIMService) DeleteUsers(ctx context.Context, ur *model.UsersResult) error {
+	return nil
+}
+
+// GetGroupsMembersBruteForce avoids the groups and users that would be created, they don't exist in the SCIM service yet.
+func (d *dryRunSCIMService) GetGroupsMembersBruteForce(ctx context.Context, gr *model.GroupsResult, ur *model.UsersResult) (*model.GroupsMembersResult, error) {
+	groups := make([]*model.Group, 0, len(gr.Resources))
+	for _, group := range gr.Resources {
+		if group.SCIMID != "" {
+			groups = append(groups, group)
+		}
+	}
+
+	users := make([]*model.User, 0, len(ur.Resources))
+	for _, user := range ur.Resources {
+		if user.SCIMID != "" {
+			users = append(users, user)
+		}
+	}
+
+	return d.SCIMService.GetGroupsMembersBruteForce(
+		ctx,
+		model.GroupsResultBuilder().WithResources(groups).Build(),
+		model.UsersResultBuilder().WithResources(users).Build(),
+	)
+}
+
+// CreateGroupsMembers returns the groups members as they would be created.
+func (d *dryRunSCIMService) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.GroupsMembersResult, error) {
+	return gmr, nil
+}
+
+// DeleteGroupsMembers does nothing.
+func (d *dryRunSCIMService) DeleteGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) error {
+	return nil
+}
@@ -0,0 +1,417 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+)
+
+// Resources of the sync failures
+const (
+	FailureResourceGroup        = "group"
+	FailureResourceUser         = "user"
+	FailureResourceGroupMembers = "groupMembers"
+)
+
+// Operations of the sync failures
+const (
+	FailureOperationCreate        = "create"
+	FailureOperationUpdate        = "update"
+	FailureOperationDelete        = "delete"
+	FailureOperationDeactivate    = "deactivate"
+	FailureOperationAddMembers    = "add_members"
+	FailureOperationRemoveMembers = "remove_members"
+)
+
+// SyncFailure is an operation over a group, a user or the members of a group that failed
+// during a sync with continue on error, the sync went on with the rest of them.
+type SyncFailure struct {
+	Resource  string `json:"resource"`
+	Operation string `json:"operation"`
+
+	// Name is the name of the group or the email of the user
+	Name  string `json:"name"`
+	Error string `json:"error"`
+
+	// the resource of the failed operation, used to keep the state consistent with the SCIM side
+	group   *model.Group
+	user    *model.User
+	members *model.GroupMembers
+}
+
+// SyncFailuresError is returned by a sync with continue on error that finished with failures,
+// the state was stored with the operations that succeeded.
+type SyncFailuresError struct {
+	Failures []*SyncFailure
+}
+
+// Error implements the error interface.
+func (e *SyncFailuresError) Error() string {
+	return fmt.Sprintf("sync finished with %d failures", len(e.Failures))
+}
+
+// syncFailures collects the failures of a sync, it is nil when the sync is not tolerant to failures.
+type syncFailures struct {
+	mu       sync.Mutex
+	failures []*SyncFailure
+}
+
+// add records the failure of an operation.
+func (f *syncFailures) add(failure *SyncFailure) {
+	log.WithFields(log.Fields{
+		"resource":  failure.Resource,
+		"operation": failure.Operation,
+		"name":      failure.Name,
+	}).Errorf("continuing the sync after failure: %s", failure.Error)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = append(f.failures, failure)
+}
+
+// reset discards the failures of the previous sync.
+func (f *syncFailures) reset() {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = nil
+}
+
+// list returns the failures of the sync.
+func (f *syncFailures) list() []*SyncFailure {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*SyncFailure{}, f.failures...)
+}
+
+// retry returns the groups, users and groups members to store in the state, so the failed operations
+// are retried by the next sync. The state must have what is in the SCIM side after the sync:
+//   - failed creations are not in the state, they are created again
+//   - failed updates keep their previous value, taken from prevGroups and prevUsers, they are updated again
+//   - failed deletions and deactivations stay in the state, they are deleted or deactivated again
+//   - failed members additions are not in the state and failed members removals stay in it
+//   - the memberships of the groups and users not created are not in the state, they are added once created
+func (f *syncFailures) retry(
+	groups *model.GroupsResult,
+	users *model.UsersResult,
+	groupsMembers *model.GroupsMembersResult,
+	prevGroups *model.GroupsResult,
+	prevUsers *model.UsersResult,
+) (*model.GroupsResult, *model.UsersResult, *model.GroupsMembersResult) {
+	failures := f.list()
+	if len(failures) == 0 {
+		return groups, users, groupsMembers
+	}
+
+	prevGroupsByName := make(map[string]*model.Group)
+	for _, group := range prevGroups.Resources {
+		prevGroupsByName[group.Name] = group
+	}
+
+	prevUsersByEmail := make(map[string]*model.User)
+	for _, user := range prevUsers.Resources {
+		prevUsersByEmail[user.Email] = user
+	}
+
+	groupsResources := append([]*model.Group{}, groups.Resources...)
+	usersResources := append([]*model.User{}, users.Resources...)
+
+	// the users corrected after changing in the SCIM side are already in the state with their previous value
+	usersEmails := make(map[string]struct{})
+	for _, user := range usersResources {
+		usersEmails[user.Email] = struct{}{}
+	}
+	groupsMembersResources := groupsMembers.Resources
+
+	notCreatedGroups := make(map[string]struct{})
+	notCreatedUsers := make(map[string]struct{})
+
+	for _, failure := range failures {
+		switch failure.Operation {
+		case FailureOperationCreate:
+			if failure.group != nil {
+				notCreatedGroups[failure.group.Name] = struct{}{}
+			}
+			if failure.user != nil {
+				notCreatedUsers[failure.user.Email] = struct{}{}
+			}
+		case FailureOperationUpdate:
+			if failure.group != nil {
+				if prev, ok := prevGroupsByName[failure.group.Name]; ok {
+					groupsResources = append(groupsResources, prev)
+				}
+			}
+			if failure.user != nil {
+				if _, ok := usersEmails[failure.user.Email]; ok {
+					continue
+				}
+				if prev, ok := prevUsersByEmail[failure.user.Email]; ok {
+					usersResources = append(usersResources, prev)
+				}
+			}
+		case FailureOperationDelete, FailureOperationDeactivate:
+			if failure.group != nil {
+				groupsResources = append(groupsResources, failure.group)
+			}
+			if failure.user != nil {
+				usersResources = append(usersResources, failure.user)
+			}
+		case FailureOperationAddMembers:
+			groupsMembersResources = withoutMembers(groupsMembersResources, failure.members)
+		case FailureOperationRemoveMembers:
+			groupsMembersResources = withMembers(groupsMembersResources, failure.members)
+		}
+	}
+
+	if len(notCreatedGroups) > 0 || len(notCreatedUsers) > 0 {
+		groupsMembersResources = withoutNotCreated(groupsMembersResources, notCreatedGroups, notCreatedUsers)
+	}
+
+	return model.GroupsResultBuilder().WithResources(groupsResources).Build(),
+		model.UsersResultBuilder().WithResources(usersResources).Build(),
+		model.GroupsMembersResultBuilder().WithResources(groupsMembersResources).Build()
+}
+
+// withoutMembers returns the groups members without the members of the group.
+func withoutMembers(groupsMembers []*model.GroupMembers, gm *model.GroupMembers) []*model.GroupMembers {
+	remove := make(map[string]struct{})
+	for _, member := range gm.Resources {
+		remove[member.Email] = struct{}{}
+	}
+
+	result := make([]*model.GroupMembers, 0, len(groupsMembers))
+	for _, groupMembers := range groupsMembers {
+		if groupMembers.Group.Name != gm.Group.Name {
+			result = append(result, groupMembers)
+			continue
+		}
+
+		members := make([]*model.Member, 0)
+		for _, member := range groupMembers.Resources {
+			if _, ok := remove[member.Email]; !ok {
+				members = append(members, member)
+			}
+		}
+
+		result = append(result, model.GroupMembersBuilder().WithGroup(groupMembers.Group).WithResources(members).Build())
+	}
+
+	return result
+}
+
+// withoutNotCreated returns the groups members without the groups and the users not created.
+func withoutNotCreated(groupsMembers []*model.GroupMembers, groups, users map[string]struct{}) []*model.GroupMembers {
+	result := make([]*model.GroupMembers, 0, len(groupsMembers))
+	for _, groupMembers := range groupsMembers {
+		if _, ok := groups[groupMembers.Group.Name]; ok {
+			continue
+		}
+
+		members := make([]*model.Member, 0)
+		for _, member := range groupMembers.Resources {
+			if _, ok := users[member.Email]; !ok {
+				members = append(members, member)
+			}
+		}
+
+		result = append(result, model.GroupMembersBuilder().WithGroup(groupMembers.Group).WithResources(members).Build())
+	}
+
+	return result
+}
+
+// withMembers returns the groups members with the members of the group added to it.
+func withMembers(groupsMembers []*model.GroupMembers, gm *model.GroupMembers) []*model.GroupMembers {
+	result := make([]*model.GroupMembers, 0, len(groupsMembers)+1)
+	added := false
+
+	for _, groupMembers := range groupsMembers {
+		if added || groupMembers.Group.Name != gm.Group.Name {
+			result = append(result, groupMembers)
+			continue
+		}
+
+		members := append([]*model.Member{}, groupMembers.Resources...)
+		members = append(members, gm.Resources...)
+
+		result = append(result, model.GroupMembersBuilder().WithGroup(groupMembers.Group).WithResources(members).Build())
+		added = true
+	}
+
+	if !added {
+		result = append(result, gm)
+	}
+
+	return result
+}
+
+// continueOnErrorSCIMService is a SCIMService that applies the changes one group, user or group members at a time,
+// the failed ones are collected and the rest of them are applied anyway.
+type continueOnErrorSCIMService struct {
+	SCIMService
+	failures *syncFailures
+}
+
+// CreateGroups returns the groups created, the failed ones are collected.
+func (c *continueOnErrorSCIMService) CreateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	return c.eachGroup(ctx, FailureOperationCreate, gr, c.SCIMService.CreateGroups)
+}
+
+// UpdateGroups returns the groups updated, the failed ones are collected.
+func (c *continueOnErrorSCIMService) UpdateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	return c.eachGroup(ctx, FailureOperationUpdate, gr, c.SCIMService.UpdateGroups)
+}
+
+// DeleteGroups deletes the groups, the failed ones are collected.
+func (c *continueOnErrorSCIMService) DeleteGroups(ctx context.Context, gr *model.GroupsResult) error {
+	_, err := c.eachGroup(ctx, FailureOperationDelete, gr, func(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+		return gr, c.SCIMService.DeleteGroups(ctx, gr)
+	})
+	return err
+}
+
+// CreateUsers returns the users created, the failed ones are collected.
+func (c *continueOnErrorSCIMService) CreateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return c.eachUser(ctx, FailureOperationCreate, ur, c.SCIMService.CreateUsers)
+}
+
+// UpdateUsers returns the users updated, the failed ones are collected.
+func (c *continueOnErrorSCIMService) UpdateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return c.eachUser(ctx, FailureOperationUpdate, ur, c.SCIMService.UpdateUsers)
+}
+
+// DeactivateUsers returns the users deactivated, the failed ones are collected.
+func (c *continueOnErrorSCIMService) DeactivateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return c.eachUser(ctx, FailureOperationDeactivate, ur, c.SCIMService.DeactivateUsers)
+}
+
+// DeleteUsers deletes the users, the failed ones are collected.
+func (c *continueOnErrorSCIMService) DeleteUsers(ctx context.Context, ur *model.UsersResult) error {
+	_, err := c.eachUser(ctx, FailureOperationDelete, ur, func(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+		return ur, c.SCIMService.DeleteUsers(ctx, ur)
+	})
+	return err
+}
+
+// CreateGroupsMembers returns the groups members created, the failed groups are collected.
+func (c *continueOnErrorSCIMService) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.GroupsMembersResult, error) {
+	return c.eachGroupMembers(ctx, FailureOperationAddMembers, gmr, c.SCIMService.CreateGroupsMembers)
+}
+
+// DeleteGroupsMembers removes the groups members, the failed groups are collected.
+func (c *continueOnErrorSCIMService) DeleteGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) error {
+	_, err := c.eachGroupMembers(ctx, FailureOperationRemoveMembers, gmr, func(ctx context.Context, gmr *model.GroupsMembersResult) (*model.GroupsMembersResult, error) {
+		return gmr, c.SCIMService.DeleteGroupsMembers(ctx, gmr)
+	})
+	return err
+}
+
+// eachGroup applies the operation to the groups one at a time, only the cancellation of the context stops it.
+func (c *continueOnErrorSCIMService) eachGroup(
+	ctx context.Context,
+	operation string,
+	gr *model.GroupsResult,
+	apply func(context.Context, *model.GroupsResult) (*model.GroupsResult, error),
+) (*model.GroupsResult, error) {
+	groups := make([]*model.Group, 0, len(gr.Resources))
+
+	for _, group := range gr.Resources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		r, err := apply(ctx, model.GroupsResultBuilder().WithResources([]*model.Group{group}).Build())
+		if err != nil {
+			c.failures.add(&SyncFailure{
+				Resource:  FailureResourceGroup,
+				Operation: operation,
+				Name:      group.Name,
+				Error:     err.Error(),
+				group:     group,
+			})
+			continue
+		}
+
+		groups = append(groups, r.Resources...)
+	}
+
+	return model.GroupsResultBuilder().WithResources(groups).Build(), nil
+}
+
+// eachUser applies the operation to the users one at a time, only the cancellation of the context stops it.
+func (c *continueOnErrorSCIMService) eachUser(
+	ctx context.Context,
+	operation string,
+	ur *model.UsersResult,
+	apply func(context.Context, *model.UsersResult) (*model.UsersResult, error),
+) (*model.UsersResult, error) {
+	users := make([]*model.User, 0, len(ur.Resources))
+
+	for _, user := range ur.Resources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		r, err := apply(ctx, model.UsersResultBuilder().WithResources([]*model.User{user}).Build())
+		if err != nil {
+			c.failures.add(&SyncFailure{
+				Resource:  FailureResourceUser,
+				Operation: operation,
+				Name:      user.Email,
+				Error:     err.Error(),
+				user:      user,
+			})
+			continue
+		}
+
+		users = append(users, r.Resources...)
+	}
+
+	return model.UsersResultBuilder().WithResources(users).Build(), nil
+}
+
+// eachGroupMembers applies the operation to the members of the groups one group at a time,
+// only the cancellation of the context stops it.
+func (c *continueOnErrorSCIMService) eachGroupMembers(
+	ctx context.Context,
+	operation string,
+	gmr *model.GroupsMembersResult,
+	apply func(context.Context, *model.GroupsMembersResult) (*model.GroupsMembersResult, error),
+) (*model.GroupsMembersResult, error) {
+	groupsMembers := make([]*model.GroupMembers, 0, len(gmr.Resources))
+
+	for _, gm := range gmr.Resources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		r, err := apply(ctx, model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{gm}).Build())
+		if err != nil {
+			c.failures.add(&SyncFailure{
+				Resource:  FailureResourceGroupMembers,
+				Operation: operation,
+				Name:      gm.Group.Name,
+				Error:     err.Error(),
+				members:   gm,
+			})
+			continue
+		}
+
+		groupsMembers = append(groupsMembers, r.Resources...)
+	}
+
+	return model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build(), nil
+}
@@ -0,0 +1,204 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContinueOnErrorSCIMService(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.TODO()
+
+	user1 := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	user2 := model.UserBuilder().WithIPID("2").WithEmail("user.2@mail.com").WithGivenName("user").WithDisplayName("user 2").WithActive(true).Build()
+	group1 := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	group2 := model.GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+
+	t.Run("Should create the users one at a time and collect the failed ones", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		failures := &syncFailures{}
+		svc := &continueOnErrorSCIMService{SCIMService: mockSCIMService, failures: failures}
+
+		created1 := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+
+		gomock.InOrder(
+			mockSCIMService.EXPECT().CreateUsers(ctx, model.UsersResultBuilder().WithResource(user1).Build()).Return(model.UsersResultBuilder().WithResource(created1).Build(), nil).Times(1),
+			mockSCIMService.EXPECT().CreateUsers(ctx, model.UsersResultBuilder().WithResource(user2).Build()).Return(nil, errors.New("family name empty")).Times(1),
+		)
+
+		created, err := svc.CreateUsers(ctx, model.UsersResultBuilder().WithResources([]*model.User{user1, user2}).Build())
+		assert.NoError(t, err)
+		assert.Equal(t, model.UsersResultBuilder().WithResource(created1).Build(), created)
+
+		list := failures.list()
+		assert.Len(t, list, 1)
+		assert.Equal(t, FailureResourceUser, list[0].Resource)
+		assert.Equal(t, FailureOperationCreate, list[0].Operation)
+		assert.Equal(t, "user.2@mail.com", list[0].Name)
+		assert.Equal(t, "family name empty", list[0].Error)
+		assert.Equal(t, user2, list[0].user)
+	})
+
+	t.Run("Should delete the groups one at a time and collect the failed ones", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		failures := &syncFailures{}
+		svc := &continueOnErrorSCIMService{SCIMService: mockSCIMService, failures: failures}
+
+		mockSCIMService.EXPECT().DeleteGroups(ctx, model.GroupsResultBuilder().WithResource(group1).Build()).Return(errors.New("test error")).Times(1)
+		mockSCIMService.EXPECT().DeleteGroups(ctx, model.GroupsResultBuilder().WithResource(group2).Build()).Return(nil).Times(1)
+
+		err := svc.DeleteGroups(ctx, model.GroupsResultBuilder().WithResources([]*model.Group{group1, group2}).Build())
+		assert.NoError(t, err)
+
+		list := failures.list()
+		assert.Len(t, list, 1)
+		assert.Equal(t, FailureResourceGroup, list[0].Resource)
+		assert.Equal(t, FailureOperationDelete, list[0].Operation)
+		assert.Equal(t, "group 1", list[0].Name)
+	})
+
+	t.Run("Should add the members one group at a time and collect the failed ones", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		failures := &syncFailures{}
+		svc := &continueOnErrorSCIMService{SCIMService: mockSCIMService, failures: failures}
+
+		member := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").Build()
+		gm1 := model.GroupMembersBuilder().WithGroup(group1).WithResource(member).Build()
+		gm2 := model.GroupMembersBuilder().WithGroup(group2).WithResource(member).Build()
+
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).Return(model.GroupsMembersResultBuilder().WithResource(gm1).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		created, err := svc.CreateGroupsMembers(ctx, model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{gm1, gm2}).Build())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, created.Items)
+
+		list := failures.list()
+		assert.Len(t, list, 1)
+		assert.Equal(t, FailureResourceGroupMembers, list[0].Resource)
+		assert.Equal(t, FailureOperationAddMembers, list[0].Operation)
+		assert.Equal(t, "group 2", list[0].Name)
+	})
+
+	t.Run("Should stop when the context is canceled", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		failures := &syncFailures{}
+		svc := &continueOnErrorSCIMService{SCIMService: mockSCIMService, failures: failures}
+
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		mockSCIMService.EXPECT().UpdateUsers(gomock.Any(), gomock.Any()).Times(0)
+
+		updated, err := svc.UpdateUsers(cctx, model.UsersResultBuilder().WithResources([]*model.User{user1, user2}).Build())
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, updated)
+		assert.Empty(t, failures.list())
+	})
+}
+
+func TestSyncFailures_Retry(t *testing.T) {
+	group1 := model.GroupBuilder().WithIPID("1").WithSCIMID("s1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	group2 := model.GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+	prevGroup1 := model.GroupBuilder().WithIPID("old").WithSCIMID("s1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+
+	user1 := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	user2 := model.UserBuilder().WithIPID("2").WithEmail("user.2@mail.com").WithGivenName("user").WithDisplayName("user 2").WithActive(true).Build()
+
+	member1 := model.MemberBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").Build()
+	member2 := model.MemberBuilder().WithIPID("2").WithEmail("user.2@mail.com").Build()
+
+	t.Run("Should return the same results without failures", func(t *testing.T) {
+		var failures *syncFailures
+
+		groups := model.GroupsResultBuilder().WithResource(group1).Build()
+		users := model.UsersResultBuilder().WithResource(user1).Build()
+		groupsMembers := model.GroupsMembersResultBuilder().Build()
+
+		g, u, gm := failures.retry(groups, users, groupsMembers, nil, nil)
+		assert.Same(t, groups, g)
+		assert.Same(t, users, u)
+		assert.Same(t, groupsMembers, gm)
+	})
+
+	t.Run("Should keep out the groups and users not created and their memberships", func(t *testing.T) {
+		failures := &syncFailures{}
+		failures.add(&SyncFailure{Resource: FailureResourceUser, Operation: FailureOperationCreate, Name: user2.Email, user: user2})
+
+		groups := model.GroupsResultBuilder().WithResource(group1).Build()
+		users := model.UsersResultBuilder().WithResource(user1).Build()
+		groupsMembers := model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(group1).WithResources([]*model.Member{member1, member2}).Build(),
+		).Build()
+
+		g, u, gm := failures.retry(groups, users, groupsMembers, model.GroupsResultBuilder().Build(), model.UsersResultBuilder().Build())
+		assert.Equal(t, groups, g)
+		assert.Equal(t, users, u)
+		assert.Equal(t, model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(group1).WithResource(member1).Build(),
+		).Build(), gm)
+	})
+
+	t.Run("Should keep the previous value of the groups not updated", func(t *testing.T) {
+		failures := &syncFailures{}
+		failures.add(&SyncFailure{Resource: FailureResourceGroup, Operation: FailureOperationUpdate, Name: group1.Name, group: group1})
+
+		groups := model.GroupsResultBuilder().WithResource(group2).Build()
+		users := model.UsersResultBuilder().Build()
+		groupsMembers := model.GroupsMembersResultBuilder().Build()
+
+		g, _, _ := failures.retry(groups, users, groupsMembers, model.GroupsResultBuilder().WithResource(prevGroup1).Build(), model.UsersResultBuilder().Build())
+		assert.Equal(t, model.GroupsResultBuilder().WithResources([]*model.Group{group2, prevGroup1}).Build(), g)
+	})
+
+	t.Run("Should keep the users not deleted nor deactivated", func(t *testing.T) {
+		failures := &syncFailures{}
+		failures.add(&SyncFailure{Resource: FailureResourceUser, Operation: FailureOperationDelete, Name: user1.Email, user: user1})
+		failures.add(&SyncFailure{Resource: FailureResourceUser, Operation: FailureOperationDeactivate, Name: user2.Email, user: user2})
+
+		g, u, _ := failures.retry(model.GroupsResultBuilder().Build(), model.UsersResultBuilder().Build(), model.GroupsMembersResultBuilder().Build(), model.GroupsResultBuilder().Build(), model.UsersResultBuilder().Build())
+		assert.Equal(t, 0, g.Items)
+		assert.Equal(t, model.UsersResultBuilder().WithResources([]*model.User{user1, user2}).Build(), u)
+	})
+
+	t.Run("Should keep out the members not added and keep the members not removed", func(t *testing.T) {
+		failures := &syncFailures{}
+		failures.add(&SyncFailure{
+			Resource:  FailureResourceGroupMembers,
+			Operation: FailureOperationAddMembers,
+			Name:      group1.Name,
+			members:   model.GroupMembersBuilder().WithGroup(group1).WithResource(member2).Build(),
+		})
+		failures.add(&SyncFailure{
+			Resource:  FailureResourceGroupMembers,
+			Operation: FailureOperationRemoveMembers,
+			Name:      group2.Name,
+			members:   model.GroupMembersBuilder().WithGroup(group2).WithResource(member1).Build(),
+		})
+
+		groupsMembers := model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(group1).WithResources([]*model.Member{member1, member2}).Build(),
+		).Build()
+
+		_, _, gm := failures.retry(model.GroupsResultBuilder().Build(), model.UsersResultBuilder().Build(), groupsMembers, model.GroupsResultBuilder().Build(), model.UsersResultBuilder().Build())
+		assert.Equal(t, model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(group1).WithResource(member1).Build(),
+			model.GroupMembersBuilder().WithGroup(group2).WithResource(member1).Build(),
+		}).Build(), gm)
+	})
+}
+
+func TestSyncFailuresError(t *testing.T) {
+	t.Run("Should return the number of failures", func(t *testing.T) {
+		err := &SyncFailuresError{Failures: []*SyncFailure{{}, {}}}
+		assert.Equal(t, "sync finished with 2 failures", err.Error())
+	})
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"time"
 
 	"github.com/slashdevops/idp-scim-sync/internal/model"
 )
@@ -17,6 +18,13 @@ type IdentityProviderService interface {
 	// GetUsers returns the users filtered by the given filter in the Identity provider side.
 	GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error)
 
+	// GetGroup returns the group of the given email or name in the Identity provider side.
+	GetGroup(ctx context.Context, group string) (*model.Group, error)
+
+	// GetUser returns the user of the given email in the Identity provider side,
+	// nil when the user is not synced, e.g. suspended or excluded.
+	GetUser(ctx context.Context, email string) (*model.User, error)
+
 	// GetGroupMembers returns the members of the given group in the Identity provider side.
 	GetGroupMembers(ctx context.Context, id string) (*model.MembersResult, error)
 
@@ -26,3 +34,10 @@ type IdentityProviderService interface {
 	// GetGroupsMembers returns the groups and their members from the Identity provider side.
 	GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error)
 }
+
+// IdentityProviderChangesSource is the interface consumed by the core services to do incremental syncs and
+// needs to be implemented by the source of the changes of the Identity Provider, e.g. its activity events.
+type IdentityProviderChangesSource interface {
+	// GetChanges returns the groups and users changed in the Identity provider side since the given time.
+	GetChanges(ctx context.Context, since time.Time) (*model.IdentityProviderChanges, error)
+}
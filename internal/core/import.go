@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
+	"github.com/slashdevops/idp-scim-sync/internal/version"
+)
+
+// ErrStateAlreadyExists is returned by the import of the state when there is the state of a previous sync
+var ErrStateAlreadyExists = errors.New("the state of a previous sync already exists")
+
+// ImportState builds the state from the groups, users and groups members that already exist in the SCIM side,
+// e.g. created by other tool, matching them with the ones of the identity provider, the groups by name or email
+// and the users by email, and stores it, so the next sync reconciles from the state instead of the SCIM side.
+// The SCIM side is not changed, the attributes and memberships in the state are the ones of the SCIM side,
+// so the next sync updates only the differences with the identity provider.
+// The state of a previous sync is only replaced when overwrite is true.
+func (ss *SyncService) ImportState(ctx context.Context, overwrite bool) error {
+	state, err := ss.getState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state.LastSync != "" && !overwrite {
+		return fmt.Errorf("%w, last sync: %s", ErrStateAlreadyExists, state.LastSync)
+	}
+
+	log.WithField("group_filter", ss.provGroupsFilter).Info("getting identity provider data")
+
+	phaseCtx, span := tracing.Start(ctx, tracer, "idp.GetGroups")
+	idpGroupsResult, err := ss.prov.GetGroups(phaseCtx, ss.provGroupsFilter)
+	endPhase(span, err, func() int { return idpGroupsResult.Items })
+	if err != nil {
+		return fmt.Errorf("error getting groups from the identity provider: %w", err)
+	}
+
+	phaseCtx, span = tracing.Start(ctx, tracer, "idp.GetGroupsMembers")
+	idpGroupsMembersResult, err := ss.prov.GetGroupsMembers(phaseCtx, idpGroupsResult)
+	endPhase(span, err, func() int { return idpGroupsMembersResult.Items })
+	if err != nil {
+		return fmt.Errorf("error getting groups members: %w", err)
+	}
+
+	phaseCtx, span = tracing.Start(ctx, tracer, "idp.GetUsersByGroupsMembers")
+	idpUsersResult, err := ss.prov.GetUsersByGroupsMembers(phaseCtx, idpGroupsMembersResult)
+	endPhase(span, err, func() int { return idpUsersResult.Items })
+	if err != nil {
+		return fmt.Errorf("error getting users from the identity provider: %w", err)
+	}
+
+	log.Info("getting SCIM data")
+
+	phaseCtx, span = tracing.Start(ctx, tracer, "scim.GetGroups")
+	scimGroupsResult, err := ss.scim.GetGroups(phaseCtx)
+	endPhase(span, err, func() int { return scimGroupsResult.Items })
+	if err != nil {
+		return fmt.Errorf("error getting groups from the SCIM service: %w", err)
+	}
+
+	phaseCtx, span = tracing.Start(ctx, tracer, "scim.GetUsers")
+	scimUsersResult, err := ss.scim.GetUsers(phaseCtx)
+	endPhase(span, err, func() int { return scimUsersResult.Items })
+	if err != nil {
+		return fmt.Errorf("error getting users from the SCIM service: %w", err)
+	}
+
+	groups := importGroups(idpGroupsResult, scimGroupsResult)
+	users := importUsers(idpUsersResult, scimUsersResult)
+
+	// the memberships are the ones of the SCIM side between the groups and users matched
+	phaseCtx, span = tracing.Start(ctx, tracer, "scim.GetGroupsMembersBruteForce")
+	groupsMembers, err := ss.scim.GetGroupsMembersBruteForce(phaseCtx, groups, users)
+	endPhase(span, err, func() int { return groupsMembers.Items })
+	if err != nil {
+		return fmt.Errorf("error getting groups members from the SCIM service: %w", err)
+	}
+
+	// without last full sync the next sync gets all the identity provider data and it is not incremental
+	newState := model.StateBuilder().
+		WithCodeVersion(version.Version).
+		WithLastSync(time.Now().Format(time.RFC3339)).
+		WithGroups(groups).
+		WithUsers(users).
+		WithGroupsMembers(groupsMembers).
+		Build()
+
+	log.WithFields(log.Fields{
+		"groups":        groups.Items,
+		"users":         users.Items,
+		"groupsMembers": groupsMembers.Items,
+	}).Info("storing the imported state")
+
+	if ss.dryRun {
+		log.Warn("dry run, the imported state is not stored")
+		return nil
+	}
+
+	phaseCtx, span = tracing.Start(ctx, tracer, "state.SetState")
+	err = ss.repo.SetState(phaseCtx, newState)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("error storing the state: %w", err)
+	}
+
+	return nil
+}
+
+// importGroups returns the groups of the SCIM side that match the ones of the identity provider by name or,
+// e.g. the groups created by ssosync, by email, with the id and email of the identity provider and the name
+// of the SCIM side, the groups matched by email are marked to be renamed by the next sync.
+// The groups that don't match are not in the result, the identity provider ones are created by the next sync
+// and the SCIM ones are not managed by the sync.
+func importGroups(idpGroupsResult, scimGroupsResult *model.GroupsResult) *model.GroupsResult {
+	scimGroups := make(map[string]*model.Group)
+
+	// ssosync names the groups with their email, so the lowercase name is the email of these groups
+	scimGroupsByLowerName := make(map[string]*model.Group)
+	for _, group := range scimGroupsResult.Resources {
+		scimGroups[group.Name] = group
+
+		if _, ok := scimGroupsByLowerName[strings.ToLower(group.Name)]; !ok {
+			scimGroupsByLowerName[strings.ToLower(group.Name)] = group
+		}
+	}
+
+	matched := make(map[string]struct{})
+	groups := make([]*model.Group, 0)
+
+	for _, idpGroup := range idpGroupsResult.Resources {
+		scimGroup, ok := scimGroups[idpGroup.Name]
+		if !ok && idpGroup.Email != "" {
+			scimGroup, ok = scimGroupsByLowerName[strings.ToLower(idpGroup.Email)]
+		}
+
+		if !ok {
+			log.WithField("name", idpGroup.Name).Info("group not in the SCIM side, it is created by the next sync")
+			continue
+		}
+
+		if _, ok := matched[scimGroup.SCIMID]; ok {
+			log.WithFields(log.Fields{
+				"name":  idpGroup.Name,
+				"email": idpGroup.Email,
+			}).Warn("the SCIM group is already matched with other group, it is created by the next sync")
+			continue
+		}
+		matched[scimGroup.SCIMID] = struct{}{}
+
+		if scimGroup.Name != idpGroup.Name {
+			log.WithFields(log.Fields{
+				"name":     idpGroup.Name,
+				"email":    idpGroup.Email,
+				"scimName": scimGroup.Name,
+			}).Warn("group matched by email, it is renamed by the next sync")
+		}
+
+		group := model.GroupBuilder().
+			WithIPID(idpGroup.IPID).
+			WithSCIMID(scimGroup.SCIMID).
+			WithName(scimGroup.Name).
+			WithEmail(idpGroup.Email).
+			Build()
+		group.Rename = scimGroup.Name != idpGroup.Name
+
+		groups = append(groups, group)
+	}
+
+	for _, group := range scimGroupsResult.Resources {
+		if _, ok := matched[group.SCIMID]; !ok {
+			log.WithField("name", group.Name).Warn("SCIM group not in the identity provider, it is not managed by the sync")
+		}
+	}
+
+	return model.GroupsResultBuilder().WithResources(groups).Build()
+}
+
+// importUsers returns the users of the SCIM side that match the ones of the identity provider by email,
+// with the id and email of the identity provider and the rest of the attributes of the SCIM side.
+// The users that don't match are not in the result, the identity provider ones are created by the next sync
+// and the SCIM ones are not managed by the sync.
+func importUsers(idpUsersResult, scimUsersResult *model.UsersResult) *model.UsersResult {
+	scimUsers := make(map[string]*model.User)
+	for _, user := range scimUsersResult.Resources {
+		scimUsers[strings.ToLower(user.Email)] = user
+	}
+
+	matched := make(map[string]struct{})
+	users := make([]*model.User, 0)
+
+	for _, idpUser := range idpUsersResult.Resources {
+		key := strings.ToLower(idpUser.Email)
+
+		scimUser, ok := scimUsers[key]
+		if !ok {
+			log.WithField("email", idpUser.Email).Info("user not in the SCIM side, it is created by the next sync")
+			continue
+		}
+		matched[key] = struct{}{}
+
+		// the attributes of the SCIM side are kept, the next sync updates them when they are different
+		users = append(users, model.UserBuilder().
+			WithIPID(idpUser.IPID).
+			WithSCIMID(scimUser.SCIMID).
+			WithGivenName(scimUser.Name.GivenName).
+			WithFamilyName(scimUser.Name.FamilyName).
+			WithDisplayName(scimUser.DisplayName).
+			WithEmail(idpUser.Email).
+			WithActive(scimUser.Active).
+			Build(),
+		)
+	}
+
+	for _, user := range scimUsersResult.Resources {
+		if _, ok := matched[strings.ToLower(user.Email)]; !ok {
+			log.WithField("email", user.Email).Warn("SCIM user not in the identity provider, it is not managed by the sync")
+		}
+	}
+
+	return model.UsersResultBuilder().WithResources(users).Build()
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncService_ImportState(t *testing.T) {
+	ctx := context.TODO()
+
+	group1 := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	group2 := model.GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+	group3 := model.GroupBuilder().WithIPID("3").WithName("group 3").WithEmail("group.3@mail.com").Build()
+	idpGroups := model.GroupsResultBuilder().WithResources([]*model.Group{group1, group2, group3}).Build()
+
+	user1 := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	user2 := model.UserBuilder().WithIPID("2").WithEmail("user.2@mail.com").WithGivenName("user").WithFamilyName("2").WithDisplayName("user 2").WithActive(true).Build()
+	user3 := model.UserBuilder().WithIPID("3").WithEmail("user.3@mail.com").WithGivenName("user").WithFamilyName("3").WithDisplayName("user 3").WithActive(true).Build()
+	idpUsers := model.UsersResultBuilder().WithResources([]*model.User{user1, user2, user3}).Build()
+
+	idpGroupsMembers := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(group1).WithResource(model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").Build()).Build(),
+		model.GroupMembersBuilder().WithGroup(group2).WithResource(model.MemberBuilder().WithIPID("2").WithEmail("user.2@mail.com").Build()).Build(),
+		model.GroupMembersBuilder().WithGroup(group3).WithResource(model.MemberBuilder().WithIPID("3").WithEmail("user.3@mail.com").Build()).Build(),
+	}).Build()
+
+	// the group 2 was created by other tool with the email as name, the group 3 and the user 3 don't exist
+	scimGroups := model.GroupsResultBuilder().WithResources([]*model.Group{
+		model.GroupBuilder().WithSCIMID("g1").WithName("group 1").Build(),
+		model.GroupBuilder().WithIPID("other-2").WithSCIMID("g2").WithName("group.2@mail.com").Build(),
+		model.GroupBuilder().WithIPID("9").WithSCIMID("g9").WithName("group 9").Build(),
+	}).Build()
+
+	scimUsers := model.UsersResultBuilder().WithResources([]*model.User{
+		model.UserBuilder().WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("old name").WithActive(true).Build(),
+		model.UserBuilder().WithIPID("other-2").WithSCIMID("u2").WithEmail("USER.2@mail.com").WithGivenName("user").WithFamilyName("2").WithDisplayName("user 2").WithActive(true).Build(),
+		model.UserBuilder().WithIPID("9").WithSCIMID("u9").WithEmail("user.9@mail.com").WithDisplayName("user 9").WithActive(true).Build(),
+	}).Build()
+
+	stateGroup1 := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	// the group 2 keeps the SCIM name, the next sync renames it
+	stateGroup2 := model.GroupBuilder().WithIPID("2").WithSCIMID("g2").WithName("group.2@mail.com").WithEmail("group.2@mail.com").Build()
+	stateGroup2.Rename = true
+	stateGroups := model.GroupsResultBuilder().WithResources([]*model.Group{stateGroup1, stateGroup2}).Build()
+
+	stateUser1 := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("old name").WithActive(true).Build()
+	stateUser2 := model.UserBuilder().WithIPID("2").WithSCIMID("u2").WithEmail("user.2@mail.com").WithGivenName("user").WithFamilyName("2").WithDisplayName("user 2").WithActive(true).Build()
+	stateUsers := model.UsersResultBuilder().WithResources([]*model.User{stateUser1, stateUser2}).Build()
+
+	// the user 2 is not member of the group 2 in the SCIM side yet
+	scimGroupsMembers := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(stateGroup1).WithResource(model.MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").Build()).Build(),
+		model.GroupMembersBuilder().WithGroup(stateGroup2).Build(),
+	}).Build()
+
+	t.Run("Should store the state of the SCIM side matched with the identity provider", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		// any other call to the SCIM service, e.g. to create or update, fails the test
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(scimGroups, nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(scimUsers, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, stateGroups, stateUsers).Return(scimGroupsMembers, nil).Times(1)
+
+		var stored *model.State
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			stored = state
+			return nil
+		}).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.ImportState(ctx, false))
+
+		assert.NotNil(t, stored)
+		assert.NotEmpty(t, stored.LastSync)
+		assert.Empty(t, stored.LastFullSync)
+		assert.Empty(t, stored.Checkpoint)
+		assert.Equal(t, stateGroups, stored.Resources.Groups)
+		assert.Equal(t, stateUsers, stored.Resources.Users)
+		assert.Equal(t, scimGroupsMembers, stored.Resources.GroupsMembers)
+		assert.Empty(t, stored.VerifyHashCodes())
+	})
+
+	t.Run("Should return error when there is the state of a previous sync", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(gomock.Any(), gomock.Any()).Times(0)
+		mockStateRepository.EXPECT().SetState(gomock.Any(), gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+
+		err = svc.ImportState(ctx, false)
+		assert.ErrorIs(t, err, ErrStateAlreadyExists)
+	})
+
+	t.Run("Should replace the state of a previous sync when overwrite", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(scimGroups, nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(scimUsers, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, stateGroups, stateUsers).Return(scimGroupsMembers, nil).Times(1)
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).Return(nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.ImportState(ctx, true))
+	})
+
+	t.Run("Should not store the state in dry run", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(scimGroups, nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(scimUsers, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, stateGroups, stateUsers).Return(scimGroupsMembers, nil).Times(1)
+		mockStateRepository.EXPECT().SetState(gomock.Any(), gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithDryRun(true))
+		assert.NoError(t, err)
+		assert.NoError(t, svc.ImportState(ctx, false))
+	})
+
+	t.Run("Should return error when the SCIM service fails", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(nil, errors.New("scim error")).Times(1)
+		mockStateRepository.EXPECT().SetState(gomock.Any(), gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+
+		err = svc.ImportState(ctx, false)
+		assert.Error(t, err)
+	})
+}
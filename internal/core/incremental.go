@@ -0,0 +1,260 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
+)
+
+// incrementalOverlap is subtracted from the last sync to get the changes of the identity provider,
+// its activity events are not available as soon as they happen.
+const incrementalOverlap = time.Hour
+
+// incremental returns true when only the changes of the identity provider since the last sync of the state
+// are needed, otherwise all the data of the identity provider is got (full refresh).
+func (ss *SyncService) incremental(state *model.State) bool {
+	if ss.idpChanges == nil || state.LastSync == "" || state.Checkpoint != "" || state.LastFullSync == "" {
+		return false
+	}
+
+	lastFullSync, err := time.Parse(time.RFC3339, state.LastFullSync)
+	if err != nil {
+		log.WithError(err).Warn("invalid last full sync in the state, getting all the identity provider data")
+		return false
+	}
+
+	if ss.fullRefreshInterval > 0 && time.Since(lastFullSync) >= ss.fullRefreshInterval {
+		log.WithFields(log.Fields{
+			"lastFullSync": state.LastFullSync,
+			"interval":     ss.fullRefreshInterval.String(),
+		}).Info("full refresh interval reached, getting all the identity provider data")
+		return false
+	}
+
+	return true
+}
+
+// targetable returns true when the state has a previous sync to apply only some changes of the identity provider.
+func targetable(state *model.State) bool {
+	return state.LastSync != "" && state.Checkpoint == ""
+}
+
+// getIdentityProviderChanges returns the groups members and users of the identity provider getting only the
+// groups and users of the changes, or the ones changed since the last sync when the changes are nil,
+// the unchanged ones are taken from the state.
+// The members of a group are got again when the group is new, renamed, changed or has a changed user,
+// and the groups without email, e.g. organizational units, are always got again.
+func (ss *SyncService) getIdentityProviderChanges(
+	ctx context.Context,
+	state *model.State,
+	idpGroupsResult *model.GroupsResult,
+	changes *model.IdentityProviderChanges,
+) (*model.GroupsMembersResult, *model.UsersResult, error) {
+	if changes == nil {
+		lastSync, err := time.Parse(time.RFC3339, state.LastSync)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing the last sync of the state: %s, %w", state.LastSync, err)
+		}
+
+		phaseCtx, span := tracing.Start(ctx, tracer, "idp.GetChanges")
+		changes, err = ss.idpChanges.GetChanges(phaseCtx, lastSync.Add(-incrementalOverlap))
+		endPhase(span, err, func() int { return len(changes.Groups) + len(changes.Users) })
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	changedGroups := emailsSet(changes.Groups)
+	changedUsers := emailsSet(changes.Users)
+
+	log.WithFields(log.Fields{
+		"lastSync": state.LastSync,
+		"groups":   len(changedGroups),
+		"users":    len(changedUsers),
+	}).Info("incremental sync, getting the identity provider changes")
+
+	stateGroupsMembers := make(map[string]*model.GroupMembers)
+	for _, groupMembers := range state.Resources.GroupsMembers.Resources {
+		stateGroupsMembers[groupMembers.Group.IPID] = groupMembers
+	}
+
+	// the users retained or deactivated are not members in the identity provider, the sync keeps the memberships
+	// of the retained ones, so they are not got again and their removal grace is not reset
+	notMembers := make(map[string]struct{})
+	for _, user := range state.Resources.Users.Resources {
+		if user.MissingSince != "" || user.DeactivatedAt != "" {
+			notMembers[strings.ToLower(user.Email)] = struct{}{}
+		}
+	}
+
+	groupsMembers := make(map[string]*model.GroupMembers)
+	changedGroupsResources := make([]*model.Group, 0)
+
+	for _, group := range idpGroupsResult.Resources {
+		stateGroupMembers, ok := stateGroupsMembers[group.IPID]
+		if !ok || groupChanged(group, stateGroupMembers, changedGroups, changedUsers) {
+			changedGroupsResources = append(changedGroupsResources, group)
+			continue
+		}
+
+		members := make([]*model.Member, 0, len(stateGroupMembers.Resources))
+		for _, member := range stateGroupMembers.Resources {
+			if _, ok := notMembers[strings.ToLower(member.Email)]; ok {
+				continue
+			}
+			members = append(members, stateMember(member))
+		}
+
+		groupsMembers[group.IPID] = model.GroupMembersBuilder().WithGroup(group).WithResources(members).Build()
+	}
+
+	if len(changedGroupsResources) > 0 {
+		phaseCtx, span := tracing.Start(ctx, tracer, "idp.GetGroupsMembers")
+		gmr, err := ss.prov.GetGroupsMembers(phaseCtx, model.GroupsResultBuilder().WithResources(changedGroupsResources).Build())
+		endPhase(span, err, func() int { return gmr.Items })
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting groups members: %w", err)
+		}
+
+		for _, groupMembers := range gmr.Resources {
+			groupsMembers[groupMembers.Group.IPID] = groupMembers
+		}
+	}
+
+	// the groups members in the same order of the groups
+	groupsMembersResources := make([]*model.GroupMembers, 0, len(groupsMembers))
+	for _, group := range idpGroupsResult.Resources {
+		if groupMembers, ok := groupsMembers[group.IPID]; ok {
+			groupsMembersResources = append(groupsMembersResources, groupMembers)
+		}
+	}
+
+	users := make([]*model.User, 0)
+	usersByEmail := stateUsers(state)
+
+	changedMembers := make([]*model.Member, 0)
+	uniqMembers := make(map[string]struct{})
+
+	// the changed members keep their groups, the users are got from the identity provider of their groups
+	changedGroupsMembersResources := make([]*model.GroupMembers, 0)
+
+	for _, groupMembers := range groupsMembersResources {
+		groupChangedMembers := make([]*model.Member, 0)
+
+		for _, member := range groupMembers.Resources {
+			email := strings.ToLower(member.Email)
+			if _, ok := uniqMembers[email]; ok {
+				continue
+			}
+			uniqMembers[email] = struct{}{}
+
+			user, ok := usersByEmail[email]
+			if _, changed := changedUsers[email]; !ok || changed {
+				groupChangedMembers = append(groupChangedMembers, member)
+				continue
+			}
+
+			users = append(users, user)
+		}
+
+		if len(groupChangedMembers) > 0 {
+			changedMembers = append(changedMembers, groupChangedMembers...)
+			changedGroupsMembersResources = append(changedGroupsMembersResources,
+				model.GroupMembersBuilder().WithGroup(groupMembers.Group).WithResources(groupChangedMembers).Build(),
+			)
+		}
+	}
+
+	if len(changedMembers) > 0 {
+		// the identity provider removes the users skipped, e.g. suspended, from the groups members given
+		changedGroupsMembers := model.GroupsMembersResultBuilder().WithResources(changedGroupsMembersResources).Build()
+
+		phaseCtx, span := tracing.Start(ctx, tracer, "idp.GetUsersByGroupsMembers")
+		ur, err := ss.prov.GetUsersByGroupsMembers(phaseCtx, changedGroupsMembers)
+		endPhase(span, err, func() int { return ur.Items })
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting users from the identity provider: %w", err)
+		}
+
+		users = append(users, ur.Resources...)
+
+		kept := make(map[string]struct{})
+		for _, groupMembers := range changedGroupsMembers.Resources {
+			for _, member := range groupMembers.Resources {
+				kept[strings.ToLower(member.Email)] = struct{}{}
+			}
+		}
+
+		skipped := make(map[string]struct{})
+		for _, member := range changedMembers {
+			if _, ok := kept[strings.ToLower(member.Email)]; !ok {
+				skipped[strings.ToLower(member.Email)] = struct{}{}
+			}
+		}
+
+		if len(skipped) > 0 {
+			groupsMembersResources = withoutMembersEmails(groupsMembersResources, skipped)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"groups": len(changedGroupsResources),
+		"users":  len(changedMembers),
+	}).Info("incremental sync, groups and users got from the identity provider")
+
+	return model.GroupsMembersResultBuilder().WithResources(groupsMembersResources).Build(),
+		model.UsersResultBuilder().WithResources(users).Build(),
+		nil
+}
+
+// groupChanged returns true when the members of the group must be got again from the identity provider.
+func groupChanged(group *model.Group, stateGroupMembers *model.GroupMembers, changedGroups, changedUsers map[string]struct{}) bool {
+	if group.Email == "" || group.Name != stateGroupMembers.Group.Name || group.Email != stateGroupMembers.Group.Email {
+		return true
+	}
+
+	if _, ok := changedGroups[strings.ToLower(group.Email)]; ok {
+		return true
+	}
+
+	for _, member := range stateGroupMembers.Resources {
+		if _, ok := changedUsers[strings.ToLower(member.Email)]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutMembersEmails returns the groups members without the members of the given emails.
+func withoutMembersEmails(groupsMembers []*model.GroupMembers, emails map[string]struct{}) []*model.GroupMembers {
+	result := make([]*model.GroupMembers, 0, len(groupsMembers))
+
+	for _, groupMembers := range groupsMembers {
+		members := make([]*model.Member, 0, len(groupMembers.Resources))
+		for _, member := range groupMembers.Resources {
+			if _, ok := emails[strings.ToLower(member.Email)]; !ok {
+				members = append(members, member)
+			}
+		}
+
+		result = append(result, model.GroupMembersBuilder().WithGroup(groupMembers.Group).WithResources(members).Build())
+	}
+
+	return result
+}
+
+// emailsSet returns the set of the emails in lower case.
+func emailsSet(emails []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(emails))
+	for _, email := range emails {
+		set[strings.ToLower(email)] = struct{}{}
+	}
+
+	return set
+}
@@ -0,0 +1,411 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeChangesSource is an event source returning the same changes and recording the time asked.
+type fakeChangesSource struct {
+	changes *model.IdentityProviderChanges
+	err     error
+	since   time.Time
+	calls   int
+}
+
+func (f *fakeChangesSource) GetChanges(ctx context.Context, since time.Time) (*model.IdentityProviderChanges, error) {
+	f.calls++
+	f.since = since
+	return f.changes, f.err
+}
+
+func TestSyncService_Incremental(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		ss     *SyncService
+		state  *model.State
+		wanted bool
+	}{
+		{
+			name:   "without changes source",
+			ss:     &SyncService{},
+			state:  model.StateBuilder().WithLastSync(now.Format(time.RFC3339)).WithLastFullSync(now.Format(time.RFC3339)).Build(),
+			wanted: false,
+		},
+		{
+			name:   "first sync",
+			ss:     &SyncService{idpChanges: &fakeChangesSource{}},
+			state:  model.StateBuilder().Build(),
+			wanted: false,
+		},
+		{
+			name:   "without last full sync",
+			ss:     &SyncService{idpChanges: &fakeChangesSource{}},
+			state:  model.StateBuilder().WithLastSync(now.Format(time.RFC3339)).Build(),
+			wanted: false,
+		},
+		{
+			name:   "full refresh interval reached",
+			ss:     &SyncService{idpChanges: &fakeChangesSource{}, fullRefreshInterval: time.Hour},
+			state:  model.StateBuilder().WithLastSync(now.Format(time.RFC3339)).WithLastFullSync(now.Add(-2 * time.Hour).Format(time.RFC3339)).Build(),
+			wanted: false,
+		},
+		{
+			name:   "before the full refresh interval",
+			ss:     &SyncService{idpChanges: &fakeChangesSource{}, fullRefreshInterval: time.Hour},
+			state:  model.StateBuilder().WithLastSync(now.Format(time.RFC3339)).WithLastFullSync(now.Add(-time.Minute).Format(time.RFC3339)).Build(),
+			wanted: true,
+		},
+		{
+			name:   "without full refresh interval",
+			ss:     &SyncService{idpChanges: &fakeChangesSource{}},
+			state:  model.StateBuilder().WithLastSync(now.Format(time.RFC3339)).WithLastFullSync(now.Add(-time.Hour * 24 * 365).Format(time.RFC3339)).Build(),
+			wanted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wanted, tt.ss.incremental(tt.state))
+		})
+	}
+}
+
+func TestSyncService_GetIdentityProviderChanges(t *testing.T) {
+	ctx := context.TODO()
+	lastSync := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	group1 := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	group2 := model.GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+	group3 := model.GroupBuilder().WithIPID("3").WithName("group 3").WithEmail("group.3@mail.com").Build()
+
+	member1 := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+	member2 := model.MemberBuilder().WithIPID("2").WithEmail("user.2@mail.com").WithStatus("ACTIVE").Build()
+	member3 := model.MemberBuilder().WithIPID("3").WithEmail("user.3@mail.com").WithStatus("ACTIVE").Build()
+	member4 := model.MemberBuilder().WithIPID("4").WithEmail("user.4@mail.com").WithStatus("ACTIVE").Build()
+
+	user1 := model.UserBuilder().WithIPID("1").WithSCIMID("s1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	user2 := model.UserBuilder().WithIPID("2").WithSCIMID("s2").WithEmail("user.2@mail.com").WithGivenName("user").WithFamilyName("2").WithDisplayName("user 2").WithActive(true).Build()
+	user3 := model.UserBuilder().WithIPID("3").WithSCIMID("s3").WithEmail("user.3@mail.com").WithGivenName("user").WithFamilyName("3").WithDisplayName("user 3").WithActive(true).Build()
+
+	state := model.StateBuilder().
+		WithLastSync(lastSync.Format(time.RFC3339)).
+		WithUsers(model.UsersResultBuilder().WithResources([]*model.User{user1, user2, user3}).Build()).
+		WithGroupsMembers(model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(group1).WithResource(member1).Build(),
+			model.GroupMembersBuilder().WithGroup(group2).WithResource(member2).Build(),
+			model.GroupMembersBuilder().WithGroup(group3).WithResource(member3).Build(),
+		}).Build()).
+		Build()
+
+	idpGroups := model.GroupsResultBuilder().WithResources([]*model.Group{group1, group2, group3}).Build()
+
+	t.Run("Should get only the groups and users changed", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+
+		// group 2 has a new member (user 4) and user 3 changed its name
+		source := &fakeChangesSource{changes: &model.IdentityProviderChanges{
+			Groups: []string{"GROUP.2@mail.com"},
+			Users:  []string{"user.3@mail.com"},
+		}}
+
+		changedGroupMembers2 := model.GroupMembersBuilder().WithGroup(group2).WithResources([]*model.Member{member2, member4}).Build()
+		changedGroupMembers3 := model.GroupMembersBuilder().WithGroup(group3).WithResource(member3).Build()
+		changedUser3 := model.UserBuilder().WithIPID("3").WithEmail("user.3@mail.com").WithGivenName("changed").WithFamilyName("3").WithDisplayName("changed 3").WithActive(true).Build()
+		user4 := model.UserBuilder().WithIPID("4").WithEmail("user.4@mail.com").WithGivenName("user").WithFamilyName("4").WithDisplayName("user 4").WithActive(true).Build()
+
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, model.GroupsResultBuilder().WithResources([]*model.Group{group2, group3}).Build()).
+			Return(model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{changedGroupMembers2, changedGroupMembers3}).Build(), nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(group2).WithResource(member4).Build(),
+			model.GroupMembersBuilder().WithGroup(group3).WithResource(member3).Build(),
+		}).Build()).Return(model.UsersResultBuilder().WithResources([]*model.User{user4, changedUser3}).Build(), nil).Times(1)
+
+		ss := &SyncService{prov: mockProviderService, idpChanges: source}
+		gmr, ur, err := ss.getIdentityProviderChanges(ctx, state, idpGroups, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, source.calls)
+		assert.True(t, lastSync.Add(-incrementalOverlap).Equal(source.since))
+
+		assert.Equal(t, 3, gmr.Items)
+		assert.Equal(t, []*model.Member{member1}, gmr.Resources[0].Resources)
+		assert.Equal(t, changedGroupMembers2, gmr.Resources[1])
+		assert.Equal(t, changedGroupMembers3, gmr.Resources[2])
+
+		assert.Equal(t, 4, ur.Items)
+		assert.Equal(t, []string{"user.1@mail.com", "user.2@mail.com", "user.4@mail.com", "user.3@mail.com"}, []string{
+			ur.Resources[0].Email, ur.Resources[1].Email, ur.Resources[2].Email, ur.Resources[3].Email,
+		})
+		assert.Empty(t, ur.Resources[0].SCIMID)
+		assert.Equal(t, "changed", ur.Resources[3].Name.GivenName)
+	})
+
+	t.Run("Should remove the memberships of the users skipped by the identity provider", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+
+		// user 1 was suspended
+		source := &fakeChangesSource{changes: &model.IdentityProviderChanges{Users: []string{"user.1@mail.com"}}}
+
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, model.GroupsResultBuilder().WithResource(group1).Build()).
+			Return(model.GroupsMembersResultBuilder().WithResource(model.GroupMembersBuilder().WithGroup(group1).WithResource(member1).Build()).Build(), nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+			gmr.Resources[0].Resources = []*model.Member{}
+			return model.UsersResultBuilder().Build(), nil
+		}).Times(1)
+
+		ss := &SyncService{prov: mockProviderService, idpChanges: source}
+		gmr, ur, err := ss.getIdentityProviderChanges(ctx, state, idpGroups, nil)
+		assert.NoError(t, err)
+
+		assert.Empty(t, gmr.Resources[0].Resources)
+		assert.Equal(t, []*model.Member{member2}, gmr.Resources[1].Resources)
+		assert.Equal(t, 2, ur.Items)
+	})
+
+	t.Run("Should not get anything without changes", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockProviderService.EXPECT().GetGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+
+		ss := &SyncService{prov: mockProviderService, idpChanges: &fakeChangesSource{changes: &model.IdentityProviderChanges{}}}
+		gmr, ur, err := ss.getIdentityProviderChanges(ctx, state, idpGroups, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, state.Resources.GroupsMembers.HashCode, gmr.HashCode)
+		assert.Equal(t, state.Resources.Users.HashCode, ur.HashCode)
+	})
+
+	t.Run("Should not get again the users retained", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockProviderService.EXPECT().GetGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+
+		// user 4 is missing in the identity provider and retained during the removal grace period
+		user4 := model.UserBuilder().WithIPID("4").WithSCIMID("s4").WithEmail("user.4@mail.com").WithGivenName("user").WithFamilyName("4").WithDisplayName("user 4").WithActive(true).Build()
+		user4.MissingSince = time.Now().Add(-time.Hour).Format(time.RFC3339)
+		user4.MissingRuns = 1
+
+		retainedState := model.StateBuilder().
+			WithLastSync(lastSync.Format(time.RFC3339)).
+			WithUsers(model.UsersResultBuilder().WithResources([]*model.User{user1, user2, user3, user4}).Build()).
+			WithGroupsMembers(model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+				model.GroupMembersBuilder().WithGroup(group1).WithResources([]*model.Member{member1, member4}).Build(),
+				model.GroupMembersBuilder().WithGroup(group2).WithResource(member2).Build(),
+				model.GroupMembersBuilder().WithGroup(group3).WithResource(member3).Build(),
+			}).Build()).
+			Build()
+
+		ss := &SyncService{prov: mockProviderService, idpChanges: &fakeChangesSource{changes: &model.IdentityProviderChanges{}}}
+		gmr, ur, err := ss.getIdentityProviderChanges(ctx, retainedState, idpGroups, nil)
+		assert.NoError(t, err)
+
+		// the sync keeps the memberships of the users retained
+		assert.Equal(t, []*model.Member{member1}, gmr.Resources[0].Resources)
+		assert.Equal(t, 3, ur.Items)
+	})
+
+	t.Run("Should return the error of the changes source", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+
+		ss := &SyncService{prov: mockProviderService, idpChanges: &fakeChangesSource{err: errors.New("test error")}}
+		gmr, ur, err := ss.getIdentityProviderChanges(ctx, state, idpGroups, nil)
+		assert.Error(t, err)
+		assert.Nil(t, gmr)
+		assert.Nil(t, ur)
+	})
+}
+
+func TestSyncService_SyncGroupsAndTheirMembers_Incremental(t *testing.T) {
+	ctx := context.TODO()
+
+	group := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	member := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+	user := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+
+	scimGroup := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	scimUser := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	scimMember := model.MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+
+	idpGroups := model.GroupsResultBuilder().WithResource(group).Build()
+	idpGroupsMembers := model.GroupsMembersResultBuilder().WithResource(model.GroupMembersBuilder().WithGroup(group).WithResource(member).Build()).Build()
+	idpUsers := model.UsersResultBuilder().WithResource(user).Build()
+
+	lastFullSync := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	state := model.StateBuilder().
+		WithLastSync(time.Now().Add(-time.Minute).Format(time.RFC3339)).
+		WithLastFullSync(lastFullSync).
+		WithGroups(model.GroupsResultBuilder().WithResource(scimGroup).Build()).
+		WithUsers(model.UsersResultBuilder().WithResource(scimUser).Build()).
+		WithGroupsMembers(model.GroupsMembersResultBuilder().WithResource(model.GroupMembersBuilder().WithGroup(scimGroup).WithResource(scimMember).Build()).Build()).
+		Build()
+
+	t.Run("Should get only the changes before the full refresh interval", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(state, nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+
+		var stored *model.State
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			stored = state
+			return nil
+		}).Times(1)
+
+		source := &fakeChangesSource{changes: &model.IdentityProviderChanges{}}
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository,
+			WithIdentityProviderChangesSource(source),
+			WithFullRefreshInterval(24*time.Hour),
+		)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncGroupsAndTheirMembers(ctx))
+
+		assert.Equal(t, 1, source.calls)
+		assert.True(t, svc.LastReport().Incremental)
+		assert.Equal(t, 0, svc.LastReport().Changes())
+
+		assert.Equal(t, lastFullSync, stored.LastFullSync)
+		assert.Equal(t, state.HashCode, stored.HashCode)
+	})
+
+	t.Run("Should get all the identity provider data when the full refresh interval is reached", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(state, nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+
+		var stored *model.State
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			stored = state
+			return nil
+		}).Times(1)
+
+		source := &fakeChangesSource{changes: &model.IdentityProviderChanges{}}
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository,
+			WithIdentityProviderChangesSource(source),
+			WithFullRefreshInterval(time.Minute),
+		)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncGroupsAndTheirMembers(ctx))
+
+		assert.Equal(t, 0, source.calls)
+		assert.False(t, svc.LastReport().Incremental)
+
+		assert.Equal(t, stored.LastSync, stored.LastFullSync)
+	})
+}
+
+func TestSyncService_SyncChanges(t *testing.T) {
+	ctx := context.TODO()
+
+	group := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	member := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+	user := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+
+	scimGroup := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	scimUser := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	scimMember := model.MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+
+	idpGroups := model.GroupsResultBuilder().WithResource(group).Build()
+	idpGroupsMembers := model.GroupsMembersResultBuilder().WithResource(model.GroupMembersBuilder().WithGroup(group).WithResource(member).Build()).Build()
+	idpUsers := model.UsersResultBuilder().WithResource(user).Build()
+
+	t.Run("Should get only the group of the changes", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		// the state has no last full sync, the incremental syncs are not enabled
+		state := model.StateBuilder().
+			WithLastSync(time.Now().Add(-time.Hour).Format(time.RFC3339)).
+			WithGroups(model.GroupsResultBuilder().WithResource(scimGroup).Build()).
+			WithUsers(model.UsersResultBuilder().WithResource(scimUser).Build()).
+			WithGroupsMembers(model.GroupsMembersResultBuilder().WithResource(model.GroupMembersBuilder().WithGroup(scimGroup).WithResource(scimMember).Build()).Build()).
+			Build()
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(state, nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).Return(nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncChanges(ctx, &model.IdentityProviderChanges{Groups: []string{"group.1@mail.com"}}))
+
+		assert.True(t, svc.LastReport().Incremental)
+		assert.Equal(t, 0, svc.LastReport().Changes())
+	})
+
+	t.Run("Should sync everything in the first sync", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithDryRun(true))
+		assert.NoError(t, err)
+
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(model.GroupsResultBuilder().WithResource(scimGroup).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().WithResource(scimUser).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, gomock.Any(), gomock.Any()).Return(
+			model.GroupsMembersResultBuilder().WithResource(model.GroupMembersBuilder().WithGroup(scimGroup).WithResource(scimMember).Build()).Build(), nil,
+		).Times(1)
+
+		assert.NoError(t, svc.SyncChanges(ctx, &model.IdentityProviderChanges{Users: []string{"user.1@mail.com"}}))
+
+		assert.False(t, svc.LastReport().Incremental)
+		assert.True(t, svc.LastReport().FirstSync)
+	})
+}
@@ -1,5 +1,7 @@
 package core
 
+import "time"
+
 // SyncServiceOption is a function that can be used to configure the SyncService
 // following the Option pattern.
 type SyncServiceOption func(*SyncService)
@@ -19,3 +21,107 @@ func WithIdentityProviderUsersFilter(filter []string) SyncServiceOption {
 		ss.provUsersFilter = filter
 	}
 }
+
+// WithUserDeprovisioningPolicy is a SyncServiceOption that can be used to
+// define what happens in the SCIM side with the users removed from the identity provider.
+// possible values: UserDeprovisioningPolicyDelete, UserDeprovisioningPolicyDeactivate
+// and UserDeprovisioningPolicyDeactivateThenDelete.
+func WithUserDeprovisioningPolicy(policy string) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.userDeprovisioningPolicy = policy
+	}
+}
+
+// WithUserDeprovisioningGracePeriod is a SyncServiceOption that can be used to
+// define how long a deactivated user is kept in the SCIM side before being deleted
+// when the UserDeprovisioningPolicyDeactivateThenDelete policy is used.
+func WithUserDeprovisioningGracePeriod(period time.Duration) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.userDeprovisioningGracePeriod = period
+	}
+}
+
+// WithRemovalGraceRuns is a SyncServiceOption that can be used to define the number of
+// consecutive syncs a group or user must be missing in the identity provider before
+// being removed from the SCIM side.
+func WithRemovalGraceRuns(runs int) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.removalGraceRuns = runs
+	}
+}
+
+// WithRemovalGracePeriod is a SyncServiceOption that can be used to define how long a group
+// or user must be missing in the identity provider before being removed from the SCIM side.
+func WithRemovalGracePeriod(period time.Duration) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.removalGracePeriod = period
+	}
+}
+
+// WithDryRun is a SyncServiceOption that can be used to run the syncs without applying
+// any change to the SCIM side and without storing the state, the changes are only logged and reported.
+func WithDryRun(dryRun bool) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.dryRun = dryRun
+	}
+}
+
+// WithContinueOnError is a SyncServiceOption that can be used to continue the syncs when a group, a user
+// or the members of a group fail, instead of aborting them on the first failure. The state is stored with
+// the operations that succeeded, the failed ones are retried by the next sync and reported.
+func WithContinueOnError(continueOnError bool) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.continueOnError = continueOnError
+	}
+}
+
+// WithCheckpoints is a SyncServiceOption that can be used to store the state of the first sync
+// after reconciling the groups and after reconciling the users, so a first sync that doesn't finish,
+// e.g. because of the AWS Lambda timeout, is resumed from the last checkpoint by the next sync.
+func WithCheckpoints(checkpoints bool) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.checkpoints = checkpoints
+	}
+}
+
+// WithDeadlineMargin is a SyncServiceOption that can be used to stop the syncs cleanly before the deadline
+// of their context, a phase of the sync is not started when the time left is less than the margin.
+func WithDeadlineMargin(margin time.Duration) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.deadlineMargin = margin
+	}
+}
+
+// WithUserDriftDetection is a SyncServiceOption that can be used to compare the users of the state
+// with the users of the SCIM side in the syncs from the state, the users with name, displayName, email
+// or active changed in the SCIM side are put back with the values of the state and reported.
+func WithUserDriftDetection(detection bool) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.userDriftDetection = detection
+	}
+}
+
+// WithUserDriftSample is a SyncServiceOption that can be used to compare only a random sample of
+// the users of the state when the user drift detection is enabled, 0 compares all of them.
+func WithUserDriftSample(sample int) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.userDriftSample = sample
+	}
+}
+
+// WithIdentityProviderChangesSource is a SyncServiceOption that can be used to do incremental syncs,
+// only the groups and users changed in the identity provider since the last sync are got from it
+// and merged with the state, until the next full refresh.
+func WithIdentityProviderChangesSource(source IdentityProviderChangesSource) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.idpChanges = source
+	}
+}
+
+// WithFullRefreshInterval is a SyncServiceOption that can be used to define how often the incremental
+// syncs get all the data of the identity provider instead of the changes, 0 disables the full refreshes.
+func WithFullRefreshInterval(interval time.Duration) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.fullRefreshInterval = interval
+	}
+}
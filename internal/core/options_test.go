@@ -3,6 +3,7 @@ package core
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 	"github.com/slashdevops/idp-scim-sync/internal/utils"
@@ -38,6 +39,8 @@ func TestWithIdentityProviderGroupsFilter(t *testing.T) {
 			provUsersFilter:  []string{},
 			scim:             scim,
 			repo:             repo,
+
+			userDeprovisioningPolicy: UserDeprovisioningPolicyDelete,
 		}
 
 		// test length
@@ -87,6 +90,8 @@ func TestWithIdentityProviderUsersFilter(t *testing.T) {
 			provUsersFilter:  filter,
 			scim:             scim,
 			repo:             repo,
+
+			userDeprovisioningPolicy: UserDeprovisioningPolicyDelete,
 		}
 
 		// test length
@@ -106,3 +111,294 @@ func TestWithIdentityProviderUsersFilter(t *testing.T) {
 		}
 	})
 }
+
+func TestWithUserDeprovisioningPolicy(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithUserDeprovisioningPolicy(UserDeprovisioningPolicyDeactivate)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithUserDeprovisioningPolicy() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, err := NewSyncService(prov, scim, repo, WithUserDeprovisioningPolicy(UserDeprovisioningPolicyDeactivateThenDelete))
+		if err != nil {
+			t.Fatalf("NewSyncService() error = %v", err)
+		}
+
+		if got.userDeprovisioningPolicy != UserDeprovisioningPolicyDeactivateThenDelete {
+			t.Errorf("got.userDeprovisioningPolicy = %s, want %s", got.userDeprovisioningPolicy, UserDeprovisioningPolicyDeactivateThenDelete)
+		}
+	})
+
+	t.Run("invalid policy return error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, err := NewSyncService(prov, scim, repo, WithUserDeprovisioningPolicy("suspend"))
+		if err != ErrUserDeprovisioningPolicyInvalid {
+			t.Errorf("NewSyncService() error = %v, want %v", err, ErrUserDeprovisioningPolicyInvalid)
+		}
+		if got != nil {
+			t.Errorf("NewSyncService() = %v, want nil", got)
+		}
+	})
+}
+
+func TestWithUserDeprovisioningGracePeriod(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithUserDeprovisioningGracePeriod(time.Hour)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithUserDeprovisioningGracePeriod() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithUserDeprovisioningGracePeriod(time.Hour))
+
+		if got.userDeprovisioningGracePeriod != time.Hour {
+			t.Errorf("got.userDeprovisioningGracePeriod = %s, want %s", got.userDeprovisioningGracePeriod, time.Hour)
+		}
+	})
+}
+
+func TestWithRemovalGraceRuns(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithRemovalGraceRuns(3)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithRemovalGraceRuns() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithRemovalGraceRuns(3))
+
+		if got.removalGraceRuns != 3 {
+			t.Errorf("got.removalGraceRuns = %d, want %d", got.removalGraceRuns, 3)
+		}
+	})
+}
+
+func TestWithRemovalGracePeriod(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithRemovalGracePeriod(time.Hour)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithRemovalGracePeriod() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithRemovalGracePeriod(time.Hour))
+
+		if got.removalGracePeriod != time.Hour {
+			t.Errorf("got.removalGracePeriod = %s, want %s", got.removalGracePeriod, time.Hour)
+		}
+	})
+}
+
+func TestWithDryRun(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithDryRun(true)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithDryRun() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithDryRun(true))
+
+		if !got.dryRun {
+			t.Errorf("got.dryRun = %t, want %t", got.dryRun, true)
+		}
+
+		if _, ok := got.scim.(*dryRunSCIMService); !ok {
+			t.Errorf("got.scim = %T, want %T", got.scim, &dryRunSCIMService{})
+		}
+	})
+}
+
+func TestWithContinueOnError(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithContinueOnError(true)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithContinueOnError() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithContinueOnError(true))
+
+		if !got.continueOnError {
+			t.Errorf("got.continueOnError = %t, want %t", got.continueOnError, true)
+		}
+
+		if got.failures == nil {
+			t.Errorf("got.failures = nil, want not nil")
+		}
+
+		if _, ok := got.scim.(*continueOnErrorSCIMService); !ok {
+			t.Errorf("got.scim = %T, want %T", got.scim, &continueOnErrorSCIMService{})
+		}
+	})
+}
+
+func TestWithCheckpoints(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithCheckpoints(true)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithCheckpoints() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithCheckpoints(true))
+
+		if !got.checkpoints {
+			t.Errorf("got.checkpoints = %t, want %t", got.checkpoints, true)
+		}
+	})
+}
+
+func TestWithDeadlineMargin(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var sso SyncServiceOption
+
+		got := WithDeadlineMargin(time.Minute)
+
+		if reflect.TypeOf(got) != reflect.TypeOf(sso) {
+			t.Errorf("WithDeadlineMargin() return %T, different type than %T", got, sso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithDeadlineMargin(time.Minute))
+
+		if got.deadlineMargin != time.Minute {
+			t.Errorf("got.deadlineMargin = %s, want %s", got.deadlineMargin, time.Minute)
+		}
+	})
+}
+
+func TestWithUserDriftDetection(t *testing.T) {
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithUserDriftDetection(true), WithUserDriftSample(10))
+
+		if !got.userDriftDetection {
+			t.Errorf("got.userDriftDetection = %t, want %t", got.userDriftDetection, true)
+		}
+
+		if got.userDriftSample != 10 {
+			t.Errorf("got.userDriftSample = %d, want %d", got.userDriftSample, 10)
+		}
+	})
+}
+
+func TestWithIdentityProviderChangesSource(t *testing.T) {
+	t.Run("validate the return values", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		scim := mocks.NewMockSCIMService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+		changes := mocks.NewMockIdentityProviderChangesSource(mockCtrl)
+
+		got, _ := NewSyncService(prov, scim, repo, WithIdentityProviderChangesSource(changes), WithFullRefreshInterval(time.Hour))
+
+		if got.idpChanges != changes {
+			t.Errorf("got.idpChanges = %v, want %v", got.idpChanges, changes)
+		}
+
+		if got.fullRefreshInterval != time.Hour {
+			t.Errorf("got.fullRefreshInterval = %s, want %s", got.fullRefreshInterval, time.Hour)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slashdevops/idp-scim-sync/internal/model"
@@ -32,8 +33,340 @@ var (
 
 	// ErrDeleteGroupsMembersResultNil is returned when the SCIM *model.GroupsMembersResult argument is nil
 	ErrDeleteGroupsMembersResultNil = fmt.Errorf("remove Groups Members Result is nil")
+
+	// ErrDeprovisioningUsersResultNil is returned when the deprovisioning *model.UsersResult argument is nil
+	ErrDeprovisioningUsersResultNil = fmt.Errorf("deprovisioning Users Result is nil")
 )
 
+// removalGraceOver returns true when a resource missing in the identity provider since missingSince
+// and during missingRuns consecutive syncs can be removed from the SCIM provider.
+// when neither graceRuns nor gracePeriod are set, the resource can be removed immediately.
+func removalGraceOver(missingSince string, missingRuns, graceRuns int, gracePeriod time.Duration, now time.Time) (bool, error) {
+	if graceRuns <= 0 && gracePeriod <= 0 {
+		return true, nil
+	}
+
+	if graceRuns > 0 && missingRuns >= graceRuns {
+		return true, nil
+	}
+
+	if gracePeriod > 0 && missingSince != "" {
+		since, err := time.Parse(time.RFC3339, missingSince)
+		if err != nil {
+			return false, fmt.Errorf("error parsing missing since time: %w", err)
+		}
+
+		if now.Sub(since) >= gracePeriod {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// retainingGroups splits the groups missing in the identity provider between the ones that must be
+// removed from the SCIM provider and the ones retained because they are still in the removal grace period.
+// the retained groups are marked as missing and need to be kept in the state, countRun is false when the sync
+// doesn't count as a run of the grace period, e.g. the targeted syncs.
+func retainingGroups(remove *model.GroupsResult, graceRuns int, gracePeriod time.Duration, countRun bool) (toRemove, retained *model.GroupsResult, e error) {
+	if remove == nil {
+		return nil, nil, ErrDeleteGroupsResultNil
+	}
+
+	now := time.Now()
+
+	removeGroups := make([]*model.Group, 0)
+	retainGroups := make([]*model.Group, 0)
+
+	for _, group := range remove.Resources {
+		if group.MissingSince == "" {
+			group.MissingSince = now.Format(time.RFC3339)
+		}
+		if countRun {
+			group.MissingRuns++
+		}
+
+		over, err := removalGraceOver(group.MissingSince, group.MissingRuns, graceRuns, gracePeriod, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking group removal grace: %s, %w", group.Name, err)
+		}
+
+		if over {
+			removeGroups = append(removeGroups, group)
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"group":        group.Name,
+			"missingSince": group.MissingSince,
+			"missingRuns":  group.MissingRuns,
+		}).Warn("group missing in the identity provider, retaining it during the removal grace period")
+
+		retainGroups = append(retainGroups, group)
+	}
+
+	toRemove = model.GroupsResultBuilder().WithResources(removeGroups).Build()
+	retained = model.GroupsResultBuilder().WithResources(retainGroups).Build()
+
+	return
+}
+
+// retainingUsers splits the users missing in the identity provider between the ones that must be
+// removed from the SCIM provider and the ones retained because they are still in the removal grace period.
+// the retained users are marked as missing and need to be kept in the state, countRun is false when the sync
+// doesn't count as a run of the grace period, e.g. the targeted syncs.
+func retainingUsers(remove *model.UsersResult, graceRuns int, gracePeriod time.Duration, countRun bool) (toRemove, retained *model.UsersResult, e error) {
+	if remove == nil {
+		return nil, nil, ErrDeleteUsersResultNil
+	}
+
+	now := time.Now()
+
+	removeUsers := make([]*model.User, 0)
+	retainUsers := make([]*model.User, 0)
+
+	for _, user := range remove.Resources {
+		if user.MissingSince == "" {
+			user.MissingSince = now.Format(time.RFC3339)
+		}
+		if countRun {
+			user.MissingRuns++
+		}
+
+		over, err := removalGraceOver(user.MissingSince, user.MissingRuns, graceRuns, gracePeriod, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking user removal grace: %s, %w", user.Email, err)
+		}
+
+		if over {
+			removeUsers = append(removeUsers, user)
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"email":        user.Email,
+			"missingSince": user.MissingSince,
+			"missingRuns":  user.MissingRuns,
+		}).Warn("user missing in the identity provider, retaining it during the removal grace period")
+
+		retainUsers = append(retainUsers, user)
+	}
+
+	toRemove = model.UsersResultBuilder().WithResources(removeUsers).Build()
+	retained = model.UsersResultBuilder().WithResources(retainUsers).Build()
+
+	return
+}
+
+// retainingGroupsMembers returns the identity provider groups members plus the memberships (taken from source)
+// of the groups and users retained during the removal grace period, so these memberships are not removed
+// from the SCIM provider until the grace period is over.
+func retainingGroupsMembers(idp, source *model.GroupsMembersResult, groups *model.GroupsResult, users *model.UsersResult) *model.GroupsMembersResult {
+	if (groups == nil || groups.Items == 0) && (users == nil || users.Items == 0) {
+		return idp
+	}
+
+	retainedGroups := make(map[string]struct{})
+	if groups != nil {
+		for _, group := range groups.Resources {
+			retainedGroups[group.Name] = struct{}{}
+		}
+	}
+
+	retainedUsers := make(map[string]struct{})
+	if users != nil {
+		for _, user := range users.Resources {
+			retainedUsers[user.Email] = struct{}{}
+		}
+	}
+
+	sourceGroupsMembers := make(map[string]*model.GroupMembers)
+	for _, groupMembers := range source.Resources {
+		sourceGroupsMembers[groupMembers.Group.Name] = groupMembers
+	}
+
+	groupsMembers := make([]*model.GroupMembers, 0)
+
+	for _, groupMembers := range idp.Resources {
+		sourceGroupMembers, ok := sourceGroupsMembers[groupMembers.Group.Name]
+		if !ok {
+			groupsMembers = append(groupsMembers, groupMembers)
+			continue
+		}
+
+		members := make([]*model.Member, 0)
+		members = append(members, groupMembers.Resources...)
+
+		for _, member := range sourceGroupMembers.Resources {
+			if _, ok := retainedUsers[member.Email]; ok {
+				members = append(members, member)
+			}
+		}
+
+		gms := model.GroupMembersBuilder().
+			WithGroup(groupMembers.Group).
+			WithResources(members).
+			Build()
+
+		groupsMembers = append(groupsMembers, gms)
+	}
+
+	for _, groupMembers := range source.Resources {
+		if _, ok := retainedGroups[groupMembers.Group.Name]; ok {
+			groupsMembers = append(groupsMembers, groupMembers)
+		}
+	}
+
+	return model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build()
+}
+
+// renamingGroups renames in the SCIM service the groups of the state marked to be renamed by the import, e.g. the
+// groups imported from ssosync with the email as name, with the name of the identity provider group of the same id,
+// and renames them in the state too, otherwise they are removed and created again because the groups are compared by name.
+// returns the groups renamed and the groups of the state not renamed, with continue on error their failures are collected.
+func renamingGroups(ctx context.Context, scim SCIMService, idp *model.GroupsResult, state *model.State) (renamed, notRenamed *model.GroupsResult, e error) {
+	if scim == nil {
+		return nil, nil, ErrSCIMServiceNil
+	}
+
+	idpGroups := make(map[string]*model.Group)
+	for _, group := range idp.Resources {
+		if group.IPID != "" {
+			idpGroups[group.IPID] = group
+		}
+	}
+
+	rename := make([]*model.Group, 0)
+	for _, group := range state.Resources.Groups.Resources {
+		if !group.Rename {
+			continue
+		}
+
+		// the groups removed from the identity provider are removed or retained as the rest of them
+		idpGroup, ok := idpGroups[group.IPID]
+		if !ok {
+			continue
+		}
+
+		// the groups taken from the state by the targeted syncs don't have the name of the identity provider
+		if idpGroup.Rename {
+			continue
+		}
+
+		if idpGroup.Name == group.Name {
+			group.Rename = false
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"from": group.Name,
+			"to":   idpGroup.Name,
+		}).Warn("group imported with other name, renaming it")
+
+		rename = append(rename, model.GroupBuilder().
+			WithIPID(idpGroup.IPID).
+			WithSCIMID(group.SCIMID).
+			WithName(idpGroup.Name).
+			WithEmail(idpGroup.Email).
+			Build(),
+		)
+	}
+
+	if len(rename) == 0 {
+		return model.GroupsResultBuilder().Build(), model.GroupsResultBuilder().Build(), nil
+	}
+
+	renamed, err := scim.UpdateGroups(ctx, model.GroupsResultBuilder().WithResources(rename).Build())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error renaming groups from SCIM provider: %w", err)
+	}
+
+	names := make(map[string]*model.Group)
+	for _, group := range renamed.Resources {
+		names[group.SCIMID] = group
+	}
+
+	// with continue on error the failed ones are not returned, they are renamed again by the next sync
+	pending := make(map[string]struct{})
+	for _, group := range rename {
+		if _, ok := names[group.SCIMID]; !ok {
+			pending[group.SCIMID] = struct{}{}
+		}
+	}
+
+	notRenamedGroups := make([]*model.Group, 0)
+	for _, group := range state.Resources.Groups.Resources {
+		if r, ok := names[group.SCIMID]; ok {
+			group.Name, group.Email, group.Rename = r.Name, r.Email, false
+		}
+		if _, ok := pending[group.SCIMID]; ok {
+			notRenamedGroups = append(notRenamedGroups, group)
+		}
+	}
+
+	for _, groupMembers := range state.Resources.GroupsMembers.Resources {
+		if groupMembers.Group == nil {
+			continue
+		}
+
+		if r, ok := names[groupMembers.Group.SCIMID]; ok {
+			groupMembers.Group.Name, groupMembers.Group.Email = r.Name, r.Email
+		}
+	}
+
+	state.RecalculateHashCodes()
+
+	return renamed, model.GroupsResultBuilder().WithResources(notRenamedGroups).Build(), nil
+}
+
+// withStateNames returns the identity provider groups and groups members with the name of the groups of the
+// state not renamed, so these groups are compared with the ones of the state in this sync, the groups keep
+// the mark to be renamed by the next sync.
+func withStateNames(notRenamed, idpGroups *model.GroupsResult, idpGroupsMembers *model.GroupsMembersResult) (*model.GroupsResult, *model.GroupsMembersResult) {
+	if notRenamed.Items == 0 {
+		return idpGroups, idpGroupsMembers
+	}
+
+	stateGroups := make(map[string]*model.Group)
+	for _, group := range notRenamed.Resources {
+		stateGroups[group.IPID] = group
+	}
+
+	withStateName := func(group *model.Group) *model.Group {
+		stateGroup, ok := stateGroups[group.IPID]
+		if !ok {
+			return group
+		}
+
+		g := model.GroupBuilder().
+			WithIPID(group.IPID).
+			WithSCIMID(group.SCIMID).
+			WithName(stateGroup.Name).
+			WithEmail(group.Email).
+			Build()
+		g.Rename = true
+
+		return g
+	}
+
+	groups := make([]*model.Group, 0, len(idpGroups.Resources))
+	for _, group := range idpGroups.Resources {
+		groups = append(groups, withStateName(group))
+	}
+
+	groupsMembers := make([]*model.GroupMembers, 0, len(idpGroupsMembers.Resources))
+	for _, groupMembers := range idpGroupsMembers.Resources {
+		groupsMembers = append(groupsMembers, model.GroupMembersBuilder().
+			WithGroup(withStateName(groupMembers.Group)).
+			WithResources(groupMembers.Resources).
+			Build(),
+		)
+	}
+
+	return model.GroupsResultBuilder().WithResources(groups).Build(),
+		model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build()
+}
+
 // reconcilingGroups creates, update and removes from groups in SCIM service
 // returns the lists of groups created and updated in the SCIM provider
 // with the ids of these groups.
@@ -140,6 +473,93 @@ func reconcilingUsers(ctx context.Context, scim SCIMService, create, update, rem
 	return
 }
 
+// deprovisioningUsers applies the user deprovisioning policy to the users removed from the identity provider.
+// returns the users that must be deleted in the SCIM provider and the users that were (or remain)
+// deactivated in the SCIM provider, these last ones need to be kept in the state to track their
+// deactivation time.
+func deprovisioningUsers(ctx context.Context, scim SCIMService, policy string, gracePeriod time.Duration, remove *model.UsersResult) (toDelete, deactivated *model.UsersResult, e error) {
+	if scim == nil {
+		return nil, nil, ErrSCIMServiceNil
+	}
+	if remove == nil {
+		return nil, nil, ErrDeprovisioningUsersResultNil
+	}
+
+	if policy == UserDeprovisioningPolicyDelete || remove.Items == 0 {
+		return remove, model.UsersResultBuilder().Build(), nil
+	}
+
+	now := time.Now()
+
+	deleteUsers := make([]*model.User, 0)
+	deactivateUsers := make([]*model.User, 0)
+	keepUsers := make([]*model.User, 0)
+
+	for _, user := range remove.Resources {
+		if user.DeactivatedAt == "" {
+			// the user is already inactive in the SCIM side, only the deactivation time is needed
+			if !user.Active {
+				user.DeactivatedAt = now.Format(time.RFC3339)
+				keepUsers = append(keepUsers, user)
+			} else {
+				deactivateUsers = append(deactivateUsers, user)
+			}
+			continue
+		}
+
+		if policy == UserDeprovisioningPolicyDeactivateThenDelete {
+			deactivatedAt, err := time.Parse(time.RFC3339, user.DeactivatedAt)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error parsing user deactivation time: %s, %w", user.Email, err)
+			}
+
+			if now.Sub(deactivatedAt) >= gracePeriod {
+				log.WithFields(log.Fields{
+					"email":         user.Email,
+					"deactivatedAt": user.DeactivatedAt,
+					"gracePeriod":   gracePeriod.String(),
+				}).Info("user deprovisioning grace period is over")
+
+				deleteUsers = append(deleteUsers, user)
+				continue
+			}
+		}
+
+		keepUsers = append(keepUsers, user)
+	}
+
+	if len(deactivateUsers) == 0 {
+		log.Info("no users to be deactivated")
+	} else {
+		log.WithField("quantity", len(deactivateUsers)).Warn("deactivating users")
+		usersDeactivated, err := scim.DeactivateUsers(ctx, model.UsersResultBuilder().WithResources(deactivateUsers).Build())
+		if err != nil {
+			return nil, nil, fmt.Errorf("error deactivating users from SCIM provider: %w", err)
+		}
+
+		// the users deactivated are new entities, the missing counters of the removal grace period
+		// are carried from the removed ones, the same as the ones kept.
+		missing := make(map[string]*model.User, len(deactivateUsers))
+		for _, user := range deactivateUsers {
+			missing[user.Email] = user
+		}
+
+		for _, user := range usersDeactivated.Resources {
+			user.DeactivatedAt = now.Format(time.RFC3339)
+			if removed, ok := missing[user.Email]; ok {
+				user.MissingSince = removed.MissingSince
+				user.MissingRuns = removed.MissingRuns
+			}
+			keepUsers = append(keepUsers, user)
+		}
+	}
+
+	toDelete = model.UsersResultBuilder().WithResources(deleteUsers).Build()
+	deactivated = model.UsersResultBuilder().WithResources(keepUsers).Build()
+
+	return
+}
+
 // reconcilingGroupsMembers creates and removes the members of the groups in SCIM provider
 // returns the lists of groups members created in the SCIM provider
 // with the ids of these groups members.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 	"github.com/slashdevops/idp-scim-sync/internal/model"
@@ -457,3 +458,422 @@ func TestReconcilingGroupsMembers(t *testing.T) {
 		assert.Nil(t, gmrc)
 	})
 }
+
+func TestDeprovisioningUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.TODO()
+
+	t.Run("Should return the same users to delete when policy is delete", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", Active: true}}}
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDelete, 0, remove)
+		assert.NoError(t, err)
+		assert.Equal(t, remove, del)
+		assert.Equal(t, 0, deactivated.Items)
+	})
+
+	t.Run("Should deactivate the users when policy is deactivate", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", Active: true}}}
+		result := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", Active: false}}}
+
+		mockSCIMService.EXPECT().DeactivateUsers(ctx, gomock.Any()).Return(result, nil).Times(1)
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivate, 0, remove)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, del.Items)
+		assert.Equal(t, 1, deactivated.Items)
+		assert.False(t, deactivated.Resources[0].Active)
+		assert.NotEmpty(t, deactivated.Resources[0].DeactivatedAt)
+	})
+
+	t.Run("Should keep the missing counters of the users deactivated", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		missingSince := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", Active: true, MissingSince: missingSince, MissingRuns: 3}}}
+		result := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", Active: false}}}
+
+		mockSCIMService.EXPECT().DeactivateUsers(ctx, gomock.Any()).Return(result, nil).Times(1)
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivate, 0, remove)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, del.Items)
+		assert.Equal(t, 1, deactivated.Items)
+		assert.Equal(t, missingSince, deactivated.Resources[0].MissingSince)
+		assert.Equal(t, 3, deactivated.Resources[0].MissingRuns)
+	})
+
+	t.Run("Should not call the SCIM service when the users are already inactive", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", Active: false}}}
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivate, 0, remove)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, del.Items)
+		assert.Equal(t, 1, deactivated.Items)
+		assert.NotEmpty(t, deactivated.Resources[0].DeactivatedAt)
+	})
+
+	t.Run("Should keep deactivated users forever when policy is deactivate", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		deactivatedAt := time.Now().Add(-24 * time.Hour * 365).Format(time.RFC3339)
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", DeactivatedAt: deactivatedAt}}}
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivate, time.Hour, remove)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, del.Items)
+		assert.Equal(t, 1, deactivated.Items)
+		assert.Equal(t, deactivatedAt, deactivated.Resources[0].DeactivatedAt)
+	})
+
+	t.Run("Should delete the users when the grace period is over", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		expired := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+		recent := time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
+		remove := &model.UsersResult{Items: 2, Resources: []*model.User{
+			{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", DeactivatedAt: expired},
+			{IPID: "2", SCIMID: "2", Email: "user.2@mail.com", DeactivatedAt: recent},
+		}}
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivateThenDelete, 24*time.Hour, remove)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, del.Items)
+		assert.Equal(t, "user.1@mail.com", del.Resources[0].Email)
+		assert.Equal(t, 1, deactivated.Items)
+		assert.Equal(t, "user.2@mail.com", deactivated.Resources[0].Email)
+	})
+
+	t.Run("Should return error when DeactivateUsers return error", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", Active: true}}}
+
+		mockSCIMService.EXPECT().DeactivateUsers(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivateThenDelete, time.Hour, remove)
+		assert.Error(t, err)
+		assert.Nil(t, del)
+		assert.Nil(t, deactivated)
+	})
+
+	t.Run("Should return error when deactivation time is invalid", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", DeactivatedAt: "yesterday"}}}
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivateThenDelete, time.Hour, remove)
+		assert.Error(t, err)
+		assert.Nil(t, del)
+		assert.Nil(t, deactivated)
+	})
+
+	t.Run("Should return error when remove users is nil", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+
+		del, deactivated, err := deprovisioningUsers(ctx, mockSCIMService, UserDeprovisioningPolicyDeactivate, time.Hour, nil)
+		assert.ErrorIs(t, err, ErrDeprovisioningUsersResultNil)
+		assert.Nil(t, del)
+		assert.Nil(t, deactivated)
+	})
+}
+
+func TestRenamingGroups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.TODO()
+
+	// the group 2 was imported from ssosync with the email as name, the group 3 was renamed in the SCIM side
+	renameState := func() *model.State {
+		group1 := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+		group2 := model.GroupBuilder().WithIPID("2").WithSCIMID("g2").WithName("group.2@mail.com").WithEmail("group.2@mail.com").Build()
+		group2.Rename = true
+		group3 := model.GroupBuilder().WithIPID("3").WithSCIMID("g3").WithName("other name").WithEmail("group.3@mail.com").Build()
+
+		return model.StateBuilder().
+			WithGroups(model.GroupsResultBuilder().WithResources([]*model.Group{group1, group2, group3}).Build()).
+			WithUsers(model.UsersResultBuilder().Build()).
+			WithGroupsMembers(model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+				model.GroupMembersBuilder().WithGroup(group1).Build(),
+				model.GroupMembersBuilder().WithGroup(group2).WithResource(model.MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").Build()).Build(),
+			}).Build()).
+			Build()
+	}
+
+	idp := model.GroupsResultBuilder().WithResources([]*model.Group{
+		model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build(),
+		model.GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group.2@mail.com").Build(),
+		model.GroupBuilder().WithIPID("3").WithName("group 3").WithEmail("group.3@mail.com").Build(),
+	}).Build()
+
+	rename := model.GroupsResultBuilder().WithResources([]*model.Group{
+		model.GroupBuilder().WithIPID("2").WithSCIMID("g2").WithName("group 2").WithEmail("group.2@mail.com").Build(),
+	}).Build()
+
+	t.Run("Should rename only the groups marked by the import in the SCIM service and in the state", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockSCIMService.EXPECT().UpdateGroups(ctx, rename).Return(rename, nil).Times(1)
+
+		state := renameState()
+		renamed, notRenamed, err := renamingGroups(ctx, mockSCIMService, idp, state)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, renamed.Items)
+		assert.Equal(t, 0, notRenamed.Items)
+
+		assert.Equal(t, "group 2", state.Resources.Groups.Resources[1].Name)
+		assert.False(t, state.Resources.Groups.Resources[1].Rename)
+		assert.Equal(t, "group 2", state.Resources.GroupsMembers.Resources[1].Group.Name)
+		assert.Equal(t, "other name", state.Resources.Groups.Resources[2].Name)
+		assert.Empty(t, state.VerifyHashCodes())
+	})
+
+	t.Run("Should not call the SCIM service without groups to rename", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockSCIMService.EXPECT().UpdateGroups(gomock.Any(), gomock.Any()).Times(0)
+
+		state := renameState()
+		state.Resources.Groups.Resources[1].Name = "group 2"
+
+		renamed, notRenamed, err := renamingGroups(ctx, mockSCIMService, idp, state)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, renamed.Items)
+		assert.Equal(t, 0, notRenamed.Items)
+		assert.False(t, state.Resources.Groups.Resources[1].Rename)
+	})
+
+	t.Run("Should return the groups not renamed with continue on error", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockSCIMService.EXPECT().UpdateGroups(ctx, rename).Return(model.GroupsResultBuilder().Build(), nil).Times(1)
+
+		state := renameState()
+		renamed, notRenamed, err := renamingGroups(ctx, mockSCIMService, idp, state)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, renamed.Items)
+		assert.Equal(t, 1, notRenamed.Items)
+		assert.Equal(t, "group.2@mail.com", notRenamed.Resources[0].Name)
+		assert.True(t, state.Resources.Groups.Resources[1].Rename)
+	})
+
+	t.Run("Should return error when the SCIM service returns error", func(t *testing.T) {
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockSCIMService.EXPECT().UpdateGroups(ctx, rename).Return(nil, errors.New("test error")).Times(1)
+
+		renamed, notRenamed, err := renamingGroups(ctx, mockSCIMService, idp, renameState())
+		assert.Error(t, err)
+		assert.Nil(t, renamed)
+		assert.Nil(t, notRenamed)
+	})
+
+	t.Run("Should return error when SCIM service is nil", func(t *testing.T) {
+		renamed, notRenamed, err := renamingGroups(ctx, nil, idp, renameState())
+		assert.Error(t, err)
+		assert.Nil(t, renamed)
+		assert.Nil(t, notRenamed)
+	})
+}
+
+func TestWithStateNames(t *testing.T) {
+	group1 := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	group2 := model.GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+	member := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").Build()
+
+	idpGroups := model.GroupsResultBuilder().WithResources([]*model.Group{group1, group2}).Build()
+	idpGroupsMembers := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(group2).WithResource(member).Build(),
+	}).Build()
+
+	t.Run("Should return the same groups without groups not renamed", func(t *testing.T) {
+		groups, groupsMembers := withStateNames(model.GroupsResultBuilder().Build(), idpGroups, idpGroupsMembers)
+		assert.Same(t, idpGroups, groups)
+		assert.Same(t, idpGroupsMembers, groupsMembers)
+	})
+
+	t.Run("Should use the state name of the groups not renamed", func(t *testing.T) {
+		stateGroup2 := model.GroupBuilder().WithIPID("2").WithSCIMID("g2").WithName("group.2@mail.com").WithEmail("group.2@mail.com").Build()
+		stateGroup2.Rename = true
+
+		groups, groupsMembers := withStateNames(model.GroupsResultBuilder().WithResource(stateGroup2).Build(), idpGroups, idpGroupsMembers)
+		assert.Equal(t, "group 1", groups.Resources[0].Name)
+		assert.Equal(t, "group.2@mail.com", groups.Resources[1].Name)
+		assert.True(t, groups.Resources[1].Rename)
+		assert.Equal(t, "group.2@mail.com", groupsMembers.Resources[0].Group.Name)
+		assert.Equal(t, []*model.Member{member}, groupsMembers.Resources[0].Resources)
+
+		// the groups are equal to the ones of the state, they are not removed and created again
+		create, _, _, remove, err := model.GroupsOperations(groups, model.GroupsResultBuilder().WithResource(stateGroup2).Build())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, create.Items)
+		assert.Equal(t, "group 1", create.Resources[0].Name)
+		assert.Equal(t, 0, remove.Items)
+	})
+}
+
+func TestRetainingGroups(t *testing.T) {
+	t.Run("Should return error when remove is nil", func(t *testing.T) {
+		toRemove, retained, err := retainingGroups(nil, 0, 0, true)
+		assert.Error(t, err)
+		assert.Nil(t, toRemove)
+		assert.Nil(t, retained)
+	})
+
+	t.Run("Should remove the groups when no grace is configured", func(t *testing.T) {
+		remove := &model.GroupsResult{Items: 1, Resources: []*model.Group{{IPID: "1", SCIMID: "1", Name: "group 1"}}}
+
+		toRemove, retained, err := retainingGroups(remove, 0, 0, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, toRemove.Items)
+		assert.Equal(t, 0, retained.Items)
+	})
+
+	t.Run("Should retain the groups until the grace runs are reached", func(t *testing.T) {
+		remove := &model.GroupsResult{Items: 1, Resources: []*model.Group{{IPID: "1", SCIMID: "1", Name: "group 1"}}}
+
+		toRemove, retained, err := retainingGroups(remove, 2, 0, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, toRemove.Items)
+		assert.Equal(t, 1, retained.Items)
+		assert.Equal(t, 1, retained.Resources[0].MissingRuns)
+		assert.NotEmpty(t, retained.Resources[0].MissingSince)
+
+		toRemove, retained, err = retainingGroups(retained, 2, 0, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, toRemove.Items)
+		assert.Equal(t, 0, retained.Items)
+	})
+
+	t.Run("Should remove the groups when the grace period is over", func(t *testing.T) {
+		missingSince := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+		remove := &model.GroupsResult{Items: 2, Resources: []*model.Group{
+			{IPID: "1", SCIMID: "1", Name: "group 1", MissingSince: missingSince, MissingRuns: 1},
+			{IPID: "2", SCIMID: "2", Name: "group 2"},
+		}}
+
+		toRemove, retained, err := retainingGroups(remove, 0, 24*time.Hour, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, toRemove.Items)
+		assert.Equal(t, "group 1", toRemove.Resources[0].Name)
+		assert.Equal(t, 1, retained.Items)
+		assert.Equal(t, "group 2", retained.Resources[0].Name)
+	})
+
+	t.Run("Should not count the run when the sync doesn't count", func(t *testing.T) {
+		missingSince := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		remove := &model.GroupsResult{Items: 1, Resources: []*model.Group{{IPID: "1", SCIMID: "1", Name: "group 1", MissingSince: missingSince, MissingRuns: 1}}}
+
+		toRemove, retained, err := retainingGroups(remove, 2, 0, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, toRemove.Items)
+		assert.Equal(t, 1, retained.Items)
+		assert.Equal(t, 1, retained.Resources[0].MissingRuns)
+		assert.Equal(t, missingSince, retained.Resources[0].MissingSince)
+	})
+
+	t.Run("Should return error when missing since is invalid", func(t *testing.T) {
+		remove := &model.GroupsResult{Items: 1, Resources: []*model.Group{{IPID: "1", SCIMID: "1", Name: "group 1", MissingSince: "invalid"}}}
+
+		toRemove, retained, err := retainingGroups(remove, 0, time.Hour, true)
+		assert.Error(t, err)
+		assert.Nil(t, toRemove)
+		assert.Nil(t, retained)
+	})
+}
+
+func TestRetainingUsers(t *testing.T) {
+	t.Run("Should return error when remove is nil", func(t *testing.T) {
+		toRemove, retained, err := retainingUsers(nil, 0, 0, true)
+		assert.Error(t, err)
+		assert.Nil(t, toRemove)
+		assert.Nil(t, retained)
+	})
+
+	t.Run("Should remove the users when no grace is configured", func(t *testing.T) {
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com"}}}
+
+		toRemove, retained, err := retainingUsers(remove, 0, 0, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, toRemove.Items)
+		assert.Equal(t, 0, retained.Items)
+	})
+
+	t.Run("Should retain the users until the grace runs or period are reached", func(t *testing.T) {
+		missingSince := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		remove := &model.UsersResult{Items: 2, Resources: []*model.User{
+			{IPID: "1", SCIMID: "1", Email: "user.1@mail.com", MissingSince: missingSince, MissingRuns: 2},
+			{IPID: "2", SCIMID: "2", Email: "user.2@mail.com", MissingSince: missingSince, MissingRuns: 1},
+		}}
+
+		toRemove, retained, err := retainingUsers(remove, 3, 24*time.Hour, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, toRemove.Items)
+		assert.Equal(t, "user.1@mail.com", toRemove.Resources[0].Email)
+		assert.Equal(t, 1, retained.Items)
+		assert.Equal(t, "user.2@mail.com", retained.Resources[0].Email)
+		assert.Equal(t, 2, retained.Resources[0].MissingRuns)
+		assert.Equal(t, missingSince, retained.Resources[0].MissingSince)
+	})
+
+	t.Run("Should not count the run when the sync doesn't count", func(t *testing.T) {
+		remove := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "1", SCIMID: "1", Email: "user.1@mail.com"}}}
+
+		toRemove, retained, err := retainingUsers(remove, 1, 0, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, toRemove.Items)
+		assert.Equal(t, 1, retained.Items)
+		assert.Equal(t, 0, retained.Resources[0].MissingRuns)
+		assert.NotEmpty(t, retained.Resources[0].MissingSince)
+	})
+}
+
+func TestRetainingGroupsMembers(t *testing.T) {
+	idp := &model.GroupsMembersResult{Items: 1, Resources: []*model.GroupMembers{
+		{
+			Items: 1,
+			Group: &model.Group{IPID: "1", Name: "group 1"},
+			Resources: []*model.Member{
+				{IPID: "1", Email: "user.1@mail.com"},
+			},
+		},
+	}}
+
+	source := &model.GroupsMembersResult{Items: 2, Resources: []*model.GroupMembers{
+		{
+			Items: 2,
+			Group: &model.Group{IPID: "1", SCIMID: "1", Name: "group 1"},
+			Resources: []*model.Member{
+				{IPID: "1", SCIMID: "1", Email: "user.1@mail.com"},
+				{IPID: "2", SCIMID: "2", Email: "user.2@mail.com"},
+			},
+		},
+		{
+			Items: 1,
+			Group: &model.Group{IPID: "2", SCIMID: "2", Name: "group 2"},
+			Resources: []*model.Member{
+				{IPID: "1", SCIMID: "1", Email: "user.1@mail.com"},
+			},
+		},
+	}}
+
+	t.Run("Should return the idp groups members when nothing is retained", func(t *testing.T) {
+		got := retainingGroupsMembers(idp, source, &model.GroupsResult{}, &model.UsersResult{})
+		assert.Equal(t, idp, got)
+	})
+
+	t.Run("Should keep the memberships of the retained groups and users", func(t *testing.T) {
+		groups := &model.GroupsResult{Items: 1, Resources: []*model.Group{{IPID: "2", SCIMID: "2", Name: "group 2"}}}
+		users := &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "2", SCIMID: "2", Email: "user.2@mail.com"}}}
+
+		got := retainingGroupsMembers(idp, source, groups, users)
+		assert.Equal(t, 2, got.Items)
+		assert.Equal(t, "group 1", got.Resources[0].Group.Name)
+		assert.Equal(t, 2, got.Resources[0].Items)
+		assert.Equal(t, "group 2", got.Resources[1].Group.Name)
+		assert.Equal(t, 1, got.Resources[1].Items)
+	})
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"time"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+)
+
+// OperationsReport is the number of resources of each operation of a sync.
+type OperationsReport struct {
+	Create     int `json:"create"`
+	Update     int `json:"update"`
+	Delete     int `json:"delete"`
+	Equal      int `json:"equal"`
+	Deactivate int `json:"deactivate,omitempty"`
+	Retain     int `json:"retain,omitempty"`
+	Rename     int `json:"rename,omitempty"`
+}
+
+// SyncReport is the summary of a sync.
+type SyncReport struct {
+	RunID      string `json:"runId,omitempty"`
+	DryRun     bool   `json:"dryRun"`
+	FirstSync  bool   `json:"firstSync"`
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+
+	// Incremental is true when only the changes of the identity provider since the last sync were got
+	Incremental bool `json:"incremental,omitempty"`
+
+	Groups        OperationsReport `json:"groups"`
+	Users         OperationsReport `json:"users"`
+	GroupsMembers OperationsReport `json:"groupsMembers"`
+
+	Failures []*SyncFailure `json:"failures,omitempty"`
+
+	UsersDrift []*UserDrift `json:"usersDrift,omitempty"`
+}
+
+// Changes returns the number of resources changed, created, updated, deleted, deactivated or renamed.
+func (r OperationsReport) Changes() int {
+	return r.Create + r.Update + r.Delete + r.Deactivate + r.Rename
+}
+
+// Changes returns the number of groups, users and groups members changed by the sync,
+// including the users corrected after changing in the SCIM side.
+func (r *SyncReport) Changes() int {
+	if r == nil {
+		return 0
+	}
+
+	corrected := 0
+	for _, drift := range r.UsersDrift {
+		if !drift.Missing {
+			corrected++
+		}
+	}
+
+	return r.Groups.Changes() + r.Users.Changes() + r.GroupsMembers.Changes() + corrected
+}
+
+// newSyncReport returns the report of a sync started now.
+func newSyncReport(dryRun bool) *SyncReport {
+	return &SyncReport{
+		DryRun:    dryRun,
+		StartedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+// finish records the end and the result of the sync.
+func (r *SyncReport) finish(err error) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+	r.FinishedAt = now.Format(time.RFC3339)
+	r.Success = err == nil
+
+	if started, e := time.Parse(time.RFC3339, r.StartedAt); e == nil {
+		r.Duration = now.Sub(started).String()
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// groups records the groups operations, the report could be nil when the sync is not reported.
+func (r *SyncReport) groups(create, update, equal, remove, retain *model.GroupsResult) {
+	if r == nil {
+		return
+	}
+
+	r.Groups = OperationsReport{
+		Create: create.Items,
+		Update: update.Items,
+		Equal:  equal.Items,
+		Delete: remove.Items,
+		Retain: retain.Items,
+	}
+}
+
+// groupsRenamed records the groups renamed before comparing them, the report could be nil when the sync is not reported.
+func (r *SyncReport) groupsRenamed(renamed *model.GroupsResult) {
+	if r == nil {
+		return
+	}
+
+	r.Groups.Rename = renamed.Items
+}
+
+// users records the users operations, the report could be nil when the sync is not reported.
+func (r *SyncReport) users(create, update, equal, remove, deactivate, retain *model.UsersResult) {
+	if r == nil {
+		return
+	}
+
+	r.Users = OperationsReport{
+		Create:     create.Items,
+		Update:     update.Items,
+		Equal:      equal.Items,
+		Delete:     remove.Items,
+		Deactivate: deactivate.Items,
+		Retain:     retain.Items,
+	}
+}
+
+// groupsMembers records the groups members operations as number of members, the report could be nil when the sync is not reported.
+func (r *SyncReport) groupsMembers(create, equal, remove *model.GroupsMembersResult) {
+	if r == nil {
+		return
+	}
+
+	r.GroupsMembers = OperationsReport{
+		Create: countMembers(create),
+		Equal:  countMembers(equal),
+		Delete: countMembers(remove),
+	}
+}
+
+// usersDrift records the users changed in the SCIM side, the report could be nil when the sync is not reported.
+func (r *SyncReport) usersDrift(drifts []*UserDrift) {
+	if r == nil {
+		return
+	}
+
+	r.UsersDrift = drifts
+}
+
+// countMembers returns the number of members of all the groups.
+func countMembers(gmr *model.GroupsMembersResult) int {
+	count := 0
+	for _, gm := range gmr.Resources {
+		count += len(gm.Resources)
+	}
+	return count
+}
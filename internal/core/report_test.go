@@ -0,0 +1,34 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncReport_Changes(t *testing.T) {
+	t.Run("Should count the changed resources", func(t *testing.T) {
+		r := &SyncReport{
+			Groups:        OperationsReport{Create: 1, Update: 2, Equal: 10, Retain: 3, Rename: 1},
+			Users:         OperationsReport{Delete: 1, Deactivate: 2, Equal: 10},
+			GroupsMembers: OperationsReport{Create: 3, Delete: 1},
+		}
+		assert.Equal(t, 11, r.Changes())
+	})
+
+	t.Run("Should count the users corrected after changing in the SCIM side", func(t *testing.T) {
+		r := &SyncReport{
+			Users:      OperationsReport{Update: 1},
+			UsersDrift: []*UserDrift{{Attributes: []string{"active"}}, {Missing: true}},
+		}
+		assert.Equal(t, 2, r.Changes())
+	})
+
+	t.Run("Should return zero without changes", func(t *testing.T) {
+		r := &SyncReport{Groups: OperationsReport{Equal: 10}}
+		assert.Equal(t, 0, r.Changes())
+
+		var nilReport *SyncReport
+		assert.Equal(t, 0, nilReport.Changes())
+	})
+}
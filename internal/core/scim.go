@@ -26,12 +26,18 @@ type SCIMService interface {
 	// GetUsers returns a list of all users from the SCIM service.
 	GetUsers(ctx context.Context) (*model.UsersResult, error)
 
+	// GetUser returns the user of the SCIM service with the SCIM id, nil when the user doesn't exist.
+	GetUser(ctx context.Context, scimID string) (*model.User, error)
+
 	// CreateUsers create users in the SCIM Service given a list of users.
 	CreateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error)
 
 	// UpdateUsers updates users in the SCIM Service given a list of users.
 	UpdateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error)
 
+	// DeactivateUsers deactivates users in the SCIM Service given a list of users.
+	DeactivateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error)
+
 	// DeleteUsers deletes users in the SCIM Service given a list of users.
 	DeleteUsers(ctx context.Context, ur *model.UsersResult) error
 
@@ -4,15 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/audit"
 	"github.com/slashdevops/idp-scim-sync/internal/model"
 	"github.com/slashdevops/idp-scim-sync/internal/repository"
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
 	"github.com/slashdevops/idp-scim-sync/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates the spans of the sync and of its phases
+var tracer = otel.Tracer("github.com/slashdevops/idp-scim-sync/internal/core")
+
 var (
 	// ErrIdentityProviderServiceNil is returned when the Identity Provider Service is nil
 	ErrIdentityProviderServiceNil = errors.New("identity provider service cannot be nil")
@@ -22,6 +31,21 @@ var (
 
 	// ErrStateRepositoryNil is returned when the State Repository is nil
 	ErrStateRepositoryNil = errors.New("state repository cannot be nil")
+
+	// ErrUserDeprovisioningPolicyInvalid is returned when the user deprovisioning policy is unknown
+	ErrUserDeprovisioningPolicyInvalid = errors.New("user deprovisioning policy is invalid")
+)
+
+const (
+	// UserDeprovisioningPolicyDelete deletes the users in the SCIM side as soon as they are removed from the identity provider.
+	UserDeprovisioningPolicyDelete = "delete"
+
+	// UserDeprovisioningPolicyDeactivate deactivates (active=false) the users in the SCIM side when they are removed from the identity provider.
+	UserDeprovisioningPolicyDeactivate = "deactivate"
+
+	// UserDeprovisioningPolicyDeactivateThenDelete deactivates the users in the SCIM side when they are removed from the identity provider
+	// and deletes them once the grace period is over.
+	UserDeprovisioningPolicyDeactivateThenDelete = "deactivate-then-delete"
 )
 
 // SyncService represent the sync service and the core of the sync process
@@ -31,6 +55,41 @@ type SyncService struct {
 	prov             IdentityProviderService
 	scim             SCIMService
 	repo             StateRepository
+
+	userDeprovisioningPolicy      string
+	userDeprovisioningGracePeriod time.Duration
+
+	removalGraceRuns   int
+	removalGracePeriod time.Duration
+
+	dryRun bool
+
+	// failures collects the failed operations of the sync when it continues on error, nil otherwise
+	continueOnError bool
+	failures        *syncFailures
+
+	// checkpoints stores the state of the first sync after each phase and deadlineMargin
+	// stops the syncs before the deadline of their context
+	checkpoints    bool
+	deadlineMargin time.Duration
+
+	// userDriftDetection compares the users of the state with the SCIM side in the syncs from the state,
+	// all of them or a random sample of userDriftSample users
+	userDriftDetection bool
+	userDriftSample    int
+
+	// idpChanges gets the changes of the identity provider since the last sync for the incremental syncs,
+	// all the data is got when it is nil or every fullRefreshInterval
+	idpChanges          IdentityProviderChangesSource
+	fullRefreshInterval time.Duration
+
+	// report is the report of the sync in progress, targeted is true when it syncs only some groups or users,
+	// e.g. from the identity provider events, these syncs don't count as runs of the removal grace period
+	report   *SyncReport
+	targeted bool
+
+	mu         sync.Mutex
+	lastReport *SyncReport
 }
 
 // NewSyncService creates a new sync service.
@@ -51,34 +110,147 @@ func NewSyncService(prov IdentityProviderService, scim SCIMService, repo StateRe
 		provUsersFilter:  []string{}, // fill in with the opts
 		scim:             scim,
 		repo:             repo,
+
+		userDeprovisioningPolicy: UserDeprovisioningPolicyDelete,
 	}
 
 	for _, opt := range opts {
 		opt(ss)
 	}
 
+	switch ss.userDeprovisioningPolicy {
+	case UserDeprovisioningPolicyDelete, UserDeprovisioningPolicyDeactivate, UserDeprovisioningPolicyDeactivateThenDelete:
+	default:
+		return nil, ErrUserDeprovisioningPolicyInvalid
+	}
+
+	if ss.continueOnError {
+		ss.failures = &syncFailures{}
+		ss.scim = &continueOnErrorSCIMService{SCIMService: ss.scim, failures: ss.failures}
+	}
+
+	if ss.dryRun {
+		ss.scim = &dryRunSCIMService{SCIMService: ss.scim}
+	}
+
 	return ss, nil
 }
 
 // SyncGroupsAndTheirMembers the default sync method tha syncs groups and their members
 func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
+	return ss.sync(ctx, func(ctx context.Context) error {
+		return ss.syncGroupsAndTheirMembers(ctx, nil)
+	})
+}
+
+// SyncChanges syncs only the groups and users of the given changes of the identity provider, e.g. received
+// from its events, the rest are taken from the state. It syncs everything when there is no previous sync.
+func (ss *SyncService) SyncChanges(ctx context.Context, changes *model.IdentityProviderChanges) error {
+	if changes == nil {
+		changes = &model.IdentityProviderChanges{}
+	}
+
+	return ss.sync(ctx, func(ctx context.Context) error {
+		return ss.syncGroupsAndTheirMembers(ctx, changes)
+	})
+}
+
+// sync runs one of the syncs recording its report.
+func (ss *SyncService) sync(ctx context.Context, run func(ctx context.Context) error) error {
+	ss.report = newSyncReport(ss.dryRun)
+	ss.targeted = false
+	ss.failures.reset()
+	// the run id correlates the report with the audit records of the sync
+	ss.report.RunID = audit.RunID(ctx)
+
+	ctx, span := tracing.Start(ctx, tracer, "SyncGroupsAndTheirMembers", trace.WithAttributes(
+		attribute.Bool("sync.dry_run", ss.dryRun),
+		attribute.String("sync.run_id", ss.report.RunID),
+	))
+
+	if ss.dryRun {
+		log.Warn("dry run, the changes are not applied to the SCIM service and the state is not stored")
+	}
+
+	err := run(ctx)
+	ss.report.finish(err)
+
+	span.SetAttributes(
+		attribute.Bool("sync.first", ss.report.FirstSync),
+		attribute.Bool("sync.incremental", ss.report.Incremental),
+	)
+	tracing.End(span, err)
+
+	ss.mu.Lock()
+	ss.lastReport = ss.report
+	ss.mu.Unlock()
+
+	return err
+}
+
+// LastReport returns the report of the last sync, nil when there is no sync yet.
+func (ss *SyncService) LastReport() *SyncReport {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.lastReport == nil {
+		return nil
+	}
+
+	report := *ss.lastReport
+	return &report
+}
+
+func (ss *SyncService) syncGroupsAndTheirMembers(ctx context.Context, changes *model.IdentityProviderChanges) error {
 	log.WithFields(log.Fields{
 		"group_filter": ss.provGroupsFilter,
 	}).Info("getting identity provider data")
 
-	idpGroupsResult, err := ss.prov.GetGroups(ctx, ss.provGroupsFilter)
-	if err != nil {
-		return fmt.Errorf("error getting groups from the identity provider: %w", err)
+	var (
+		state *model.State
+		err   error
+	)
+
+	// the state is needed first to know if the changes since the last sync are enough
+	if ss.idpChanges != nil || changes != nil {
+		if state, err = ss.getState(ctx); err != nil {
+			return err
+		}
 	}
 
-	idpGroupsMembersResult, err := ss.prov.GetGroupsMembers(ctx, idpGroupsResult)
+	phaseCtx, span := tracing.Start(ctx, tracer, "idp.GetGroups")
+	idpGroupsResult, err := ss.prov.GetGroups(phaseCtx, ss.provGroupsFilter)
+	endPhase(span, err, func() int { return idpGroupsResult.Items })
 	if err != nil {
-		return fmt.Errorf("error getting groups members: %w", err)
+		return fmt.Errorf("error getting groups from the identity provider: %w", err)
 	}
 
-	idpUsersResult, err := ss.prov.GetUsersByGroupsMembers(ctx, idpGroupsMembersResult)
-	if err != nil {
-		return fmt.Errorf("error getting users from the identity provider: %w", err)
+	var (
+		idpGroupsMembersResult *model.GroupsMembersResult
+		idpUsersResult         *model.UsersResult
+	)
+
+	if state != nil && (changes != nil && targetable(state) || ss.incremental(state)) {
+		ss.report.Incremental = true
+		ss.targeted = changes != nil
+		idpGroupsMembersResult, idpUsersResult, err = ss.getIdentityProviderChanges(ctx, state, idpGroupsResult, changes)
+		if err != nil {
+			return fmt.Errorf("error getting the changes from the identity provider: %w", err)
+		}
+	} else {
+		phaseCtx, span = tracing.Start(ctx, tracer, "idp.GetGroupsMembers")
+		idpGroupsMembersResult, err = ss.prov.GetGroupsMembers(phaseCtx, idpGroupsResult)
+		endPhase(span, err, func() int { return idpGroupsMembersResult.Items })
+		if err != nil {
+			return fmt.Errorf("error getting groups members: %w", err)
+		}
+
+		phaseCtx, span = tracing.Start(ctx, tracer, "idp.GetUsersByGroupsMembers")
+		idpUsersResult, err = ss.prov.GetUsersByGroupsMembers(phaseCtx, idpGroupsMembersResult)
+		endPhase(span, err, func() int { return idpUsersResult.Items })
+		if err != nil {
+			return fmt.Errorf("error getting users from the identity provider: %w", err)
+		}
 	}
 
 	if idpUsersResult.Items == 0 {
@@ -102,24 +274,29 @@ func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
 			}).Warn("there are no groups with members in the identity provider")
 	}
 
-	log.Info("getting state data")
-	state, err := ss.repo.GetState(ctx)
-	if err != nil {
-		var nsk *types.NoSuchKey
-		var StateFileEmpty *repository.ErrStateFileEmpty
-
-		if errors.As(err, &nsk) || errors.As(err, &StateFileEmpty) {
-			log.Warn("no state file found in the state repository, creating a new one")
-			state = model.StateBuilder().Build()
-		} else {
-			return fmt.Errorf("error getting state data from the repository: %w", err)
+	if state == nil {
+		if state, err = ss.getState(ctx); err != nil {
+			return err
 		}
 	}
 
+	return ss.reconcile(ctx, state, idpGroupsResult, idpUsersResult, idpGroupsMembersResult)
+}
+
+// reconcile reconciles the SCIM side with the given data of the identity provider, from the SCIM side in the
+// first sync and from the state in the next ones, then stores the new state.
+func (ss *SyncService) reconcile(
+	ctx context.Context,
+	state *model.State,
+	idpGroupsResult *model.GroupsResult,
+	idpUsersResult *model.UsersResult,
+	idpGroupsMembersResult *model.GroupsMembersResult,
+) error {
 	var (
 		totalGroupsResult        *model.GroupsResult
 		totalUsersResult         *model.UsersResult
 		totalGroupsMembersResult *model.GroupsMembersResult
+		err                      error
 	)
 
 	// first time syncing
@@ -132,25 +309,33 @@ func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
 		// - Groups names are equals on both sides, update only the external id (coming from the identity provider)
 		// - Users emails are equals on both sides, update only the external id (coming from the identity provider)
 		log.Warn("syncing from scim service, first time syncing")
-		totalGroupsResult, totalUsersResult, totalGroupsMembersResult, err = scimSync(
-			ctx, ss.scim,
+		if state.Checkpoint != "" {
+			log.WithField("checkpoint", state.Checkpoint).Warn("resuming the first sync from the checkpoint")
+		}
+		ss.report.FirstSync = true
+		phaseCtx, span := tracing.Start(ctx, tracer, "reconcile.SCIMSync", trace.WithAttributes(attribute.String("checkpoint", state.Checkpoint)))
+		totalGroupsResult, totalUsersResult, totalGroupsMembersResult, err = ss.scimSync(
+			phaseCtx,
+			state,
 			idpGroupsResult,
 			idpUsersResult,
 			idpGroupsMembersResult,
 		)
+		tracing.End(span, err)
 		if err != nil {
 			return fmt.Errorf("error doing the first sync: %w", err)
 		}
 	} else {
 		log.Warn("syncing from state, it's not the first time syncing")
-		totalGroupsResult, totalUsersResult, totalGroupsMembersResult, err = stateSync(
-			ctx,
+		phaseCtx, span := tracing.Start(ctx, tracer, "reconcile.StateSync")
+		totalGroupsResult, totalUsersResult, totalGroupsMembersResult, err = ss.stateSync(
+			phaseCtx,
 			state,
-			ss.scim,
 			idpGroupsResult,
 			idpUsersResult,
 			idpGroupsMembersResult,
 		)
+		tracing.End(span, err)
 		if err != nil {
 			return fmt.Errorf("error syncing state: %w", err)
 		}
@@ -158,9 +343,18 @@ func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
 
 	// after be sure all the SCIM side is aligned with the identity provider side
 	// we can update the state with the last data coming from the reconciliation
+	lastSync := time.Now().Format(time.RFC3339)
+
+	// the incremental syncs keep the time of the last full refresh
+	lastFullSync := lastSync
+	if ss.report.Incremental {
+		lastFullSync = state.LastFullSync
+	}
+
 	newState := model.StateBuilder().
 		WithCodeVersion(version.Version).
-		WithLastSync(time.Now().Format(time.RFC3339)).
+		WithLastSync(lastSync).
+		WithLastFullSync(lastFullSync).
 		WithGroups(totalGroupsResult).
 		WithUsers(totalUsersResult).
 		WithGroupsMembers(totalGroupsMembersResult).
@@ -172,12 +366,61 @@ func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
 		"users":    totalUsersResult.Items,
 	}).Info("storing the new state")
 
-	if err := ss.repo.SetState(ctx, newState); err != nil {
+	if ss.dryRun {
+		log.Warn("dry run, the new state is not stored")
+		return nil
+	}
+
+	phaseCtx, span := tracing.Start(ctx, tracer, "state.SetState")
+	err = ss.repo.SetState(phaseCtx, newState)
+	tracing.End(span, err)
+	if err != nil {
 		return fmt.Errorf("error storing the state: %w", err)
 	}
 
+	// the state is stored with the operations that succeeded, the failed ones are retried by the next sync
+	if failures := ss.failures.list(); len(failures) > 0 {
+		ss.report.Failures = failures
+		return &SyncFailuresError{Failures: failures}
+	}
+
 	log.WithFields(log.Fields{
 		"date": time.Now().Format(time.RFC3339),
 	}).Info("sync completed")
 	return nil
 }
+
+// getState returns the state of the repository, a new one when there is no state file yet.
+func (ss *SyncService) getState(ctx context.Context) (*model.State, error) {
+	log.Info("getting state data")
+	ctx, span := tracing.Start(ctx, tracer, "state.GetState")
+	state, err := ss.repo.GetState(ctx)
+	if err != nil {
+		var nsk *types.NoSuchKey
+		var StateFileEmpty *repository.ErrStateFileEmpty
+
+		if errors.As(err, &nsk) || errors.As(err, &StateFileEmpty) {
+			log.Warn("no state file found in the state repository, creating a new one")
+			state = model.StateBuilder().Build()
+		} else {
+			tracing.End(span, err)
+			return nil, fmt.Errorf("error getting state data from the repository: %w", err)
+		}
+	} else if issues := state.VerifyHashCodes(); len(issues) > 0 {
+		// the states stored before the hash codes were the same in every process have other hash codes,
+		// they are calculated again once so they are not all different from the identity provider ones
+		log.WithField("issues", len(issues)).Warn("hash codes of the state calculated by a previous version, calculating them again")
+		state.RecalculateHashCodes()
+	}
+	span.End()
+
+	return state, nil
+}
+
+// endPhase ends the span of a phase getting resources, with the number of resources when there is no error.
+func endPhase(span trace.Span, err error, items func() int) {
+	if err == nil {
+		span.SetAttributes(attribute.Int("items", items()))
+	}
+	tracing.End(span, err)
+}
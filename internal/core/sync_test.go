@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -19,6 +20,9 @@ import (
 	"github.com/slashdevops/idp-scim-sync/pkg/aws"
 	"github.com/slashdevops/idp-scim-sync/pkg/google"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	admin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/option"
 )
@@ -561,3 +565,322 @@ func createService(
 
 	return svc
 }
+
+func TestSyncService_SyncGroupsAndTheirMembers_DryRun(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Should report the changes without applying them nor storing the state", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		group := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+		user := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		member := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+
+		idpGroups := model.GroupsResultBuilder().WithResource(group).Build()
+		idpUsers := model.UsersResultBuilder().WithResource(user).Build()
+		idpGroupsMembers := model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(group).WithResource(member).Build(),
+		).Build()
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(model.GroupsResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, gomock.Any(), gomock.Any()).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithDryRun(true))
+		assert.NoError(t, err)
+		assert.Nil(t, svc.LastReport())
+
+		err = svc.SyncGroupsAndTheirMembers(ctx)
+		assert.NoError(t, err)
+
+		report := svc.LastReport()
+		assert.NotNil(t, report)
+		assert.True(t, report.DryRun)
+		assert.True(t, report.FirstSync)
+		assert.True(t, report.Success)
+		assert.Equal(t, OperationsReport{Create: 1}, report.Groups)
+		assert.Equal(t, OperationsReport{Create: 1}, report.Users)
+		assert.Equal(t, OperationsReport{Create: 1}, report.GroupsMembers)
+	})
+
+	t.Run("Should report the error of the sync", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+
+		err = svc.SyncGroupsAndTheirMembers(ctx)
+		assert.Error(t, err)
+
+		report := svc.LastReport()
+		assert.NotNil(t, report)
+		assert.False(t, report.DryRun)
+		assert.False(t, report.Success)
+		assert.Contains(t, report.Error, "test error")
+	})
+}
+
+func TestSyncService_SyncGroupsAndTheirMembers_Tracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	defaultTracer := tracer
+	tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	defer func() { tracer = defaultTracer }()
+
+	t.Run("Should trace the phases of the sync", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		group := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+		idpGroups := model.GroupsResultBuilder().WithResource(group).Build()
+		empty := model.GroupsMembersResultBuilder().Build()
+
+		mockProviderService.EXPECT().GetGroups(gomock.Any(), gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(gomock.Any(), idpGroups).Return(empty, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(gomock.Any(), empty).Return(model.UsersResultBuilder().Build(), nil).Times(1)
+		mockStateRepository.EXPECT().GetState(gomock.Any()).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(gomock.Any()).Return(model.GroupsResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(gomock.Any()).Return(model.UsersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(gomock.Any(), gomock.Any(), gomock.Any()).Return(empty, nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithDryRun(true))
+		assert.NoError(t, err)
+
+		assert.NoError(t, svc.SyncGroupsAndTheirMembers(context.TODO()))
+
+		spans := sr.Ended()
+		names := make([]string, 0, len(spans))
+		for _, s := range spans {
+			names = append(names, s.Name())
+		}
+		assert.Equal(t, []string{
+			"idp.GetGroups",
+			"idp.GetGroupsMembers",
+			"idp.GetUsersByGroupsMembers",
+			"state.GetState",
+			"reconcile.SCIMSync",
+			"SyncGroupsAndTheirMembers",
+		}, names)
+
+		root := spans[len(spans)-1]
+		for _, s := range spans[:len(spans)-1] {
+			assert.Equal(t, root.SpanContext().SpanID(), s.Parent().SpanID())
+		}
+		assert.Contains(t, root.Attributes(), attribute.Bool("sync.dry_run", true))
+		assert.Contains(t, root.Attributes(), attribute.Bool("sync.first", true))
+		assert.Contains(t, spans[0].Attributes(), attribute.Int("items", 1))
+	})
+}
+
+func TestSyncService_SyncGroupsAndTheirMembers_ContinueOnError(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Should store the state of the succeeded operations and return the failures", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		group := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+		user1 := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		user2 := model.UserBuilder().WithIPID("2").WithEmail("user.2@mail.com").WithGivenName("user").WithDisplayName("user 2").WithActive(true).Build()
+		member1 := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+		member2 := model.MemberBuilder().WithIPID("2").WithEmail("user.2@mail.com").WithStatus("ACTIVE").Build()
+
+		scimGroup := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+		scimUser1 := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		scimMember1 := model.MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+
+		idpGroups := model.GroupsResultBuilder().WithResource(group).Build()
+		idpUsers := model.UsersResultBuilder().WithResources([]*model.User{user1, user2}).Build()
+		idpGroupsMembers := model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(group).WithResources([]*model.Member{member1, member2}).Build(),
+		).Build()
+		scimGroupsMembers := model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(scimGroup).WithResources([]*model.Member{scimMember1, member2}).Build(),
+		).Build()
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetGroups(ctx).Return(model.GroupsResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateGroups(ctx, gomock.Any()).Return(model.GroupsResultBuilder().WithResource(scimGroup).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().GetUsers(ctx).Return(model.UsersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateUsers(ctx, model.UsersResultBuilder().WithResource(user1).Build()).Return(model.UsersResultBuilder().WithResource(scimUser1).Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateUsers(ctx, model.UsersResultBuilder().WithResource(user2).Build()).Return(nil, errors.New("family name empty")).Times(1)
+		mockSCIMService.EXPECT().GetGroupsMembersBruteForce(ctx, gomock.Any(), gomock.Any()).Return(model.GroupsMembersResultBuilder().Build(), nil).Times(1)
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).Return(scimGroupsMembers, nil).Times(1)
+
+		var stored *model.State
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			stored = state
+			return nil
+		}).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithContinueOnError(true))
+		assert.NoError(t, err)
+
+		err = svc.SyncGroupsAndTheirMembers(ctx)
+		assert.Error(t, err)
+
+		var sfe *SyncFailuresError
+		assert.True(t, errors.As(err, &sfe))
+		assert.Len(t, sfe.Failures, 1)
+
+		// the user not created and its membership are retried by the next sync
+		assert.NotNil(t, stored)
+		assert.Equal(t, model.GroupsResultBuilder().WithResource(scimGroup).Build(), stored.Resources.Groups)
+		assert.Equal(t, model.UsersResultBuilder().WithResource(scimUser1).Build(), stored.Resources.Users)
+		assert.Equal(t, model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(scimGroup).WithResource(scimMember1).Build(),
+		).Build(), stored.Resources.GroupsMembers)
+
+		report := svc.LastReport()
+		assert.False(t, report.Success)
+		assert.Equal(t, "sync finished with 1 failures", report.Error)
+		assert.Len(t, report.Failures, 1)
+		assert.Equal(t, "user.2@mail.com", report.Failures[0].Name)
+	})
+
+	t.Run("Should keep the groups not renamed to rename them in the next sync", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		group := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+		user := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		member := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+
+		// the group was imported from ssosync with the email as name
+		stateGroup := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group.1@mail.com").WithEmail("group.1@mail.com").Build()
+		stateGroup.Rename = true
+		stateUser := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+		stateMember := model.MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+
+		idpGroups := model.GroupsResultBuilder().WithResource(group).Build()
+		idpUsers := model.UsersResultBuilder().WithResource(user).Build()
+		idpGroupsMembers := model.GroupsMembersResultBuilder().WithResource(
+			model.GroupMembersBuilder().WithGroup(group).WithResource(member).Build(),
+		).Build()
+
+		state := model.StateBuilder().
+			WithLastSync("2022-01-01T00:00:00Z").
+			WithGroups(model.GroupsResultBuilder().WithResource(stateGroup).Build()).
+			WithUsers(model.UsersResultBuilder().WithResource(stateUser).Build()).
+			WithGroupsMembers(model.GroupsMembersResultBuilder().WithResource(
+				model.GroupMembersBuilder().WithGroup(stateGroup).WithResource(stateMember).Build(),
+			).Build()).
+			Build()
+
+		mockProviderService.EXPECT().GetGroups(ctx, gomock.Any()).Return(idpGroups, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, idpGroups).Return(idpGroupsMembers, nil).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, idpGroupsMembers).Return(idpUsers, nil).Times(1)
+		mockStateRepository.EXPECT().GetState(ctx).Return(state, nil).Times(1)
+		mockSCIMService.EXPECT().UpdateGroups(ctx, gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		var stored *model.State
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, state *model.State) error {
+			stored = state
+			return nil
+		}).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithContinueOnError(true))
+		assert.NoError(t, err)
+
+		err = svc.SyncGroupsAndTheirMembers(ctx)
+
+		var sfe *SyncFailuresError
+		assert.True(t, errors.As(err, &sfe))
+		assert.Len(t, sfe.Failures, 1)
+		assert.Equal(t, FailureOperationUpdate, sfe.Failures[0].Operation)
+
+		// the group is not removed and created again, it keeps the SCIM name and the mark to be renamed
+		assert.NotNil(t, stored)
+		assert.Equal(t, 1, stored.Resources.Groups.Items)
+		assert.Equal(t, "g1", stored.Resources.Groups.Resources[0].SCIMID)
+		assert.Equal(t, "group.1@mail.com", stored.Resources.Groups.Resources[0].Name)
+		assert.True(t, stored.Resources.Groups.Resources[0].Rename)
+		assert.Equal(t, OperationsReport{Equal: 1}, svc.LastReport().Groups)
+	})
+}
+
+func TestSyncService_GetState(t *testing.T) {
+	ctx := context.TODO()
+
+	user := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithDisplayName("user 1").WithActive(true).Build()
+	state := func() *model.State {
+		return model.StateBuilder().
+			WithGroups(model.GroupsResultBuilder().Build()).
+			WithUsers(model.UsersResultBuilder().WithResource(user).Build()).
+			WithGroupsMembers(model.GroupsMembersResultBuilder().Build()).
+			Build()
+	}
+
+	t.Run("Should calculate again the hash codes of a state stored by a previous version", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		stored := state()
+		stored.HashCode = "previous"
+		stored.Resources.Users.HashCode = "previous"
+		stored.Resources.Users.Resources[0].HashCode = "previous"
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(stored, nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+
+		got, err := svc.getState(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, state(), got)
+	})
+
+	t.Run("Should keep the hash codes of the state when they are right", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		stored := state()
+		mockStateRepository.EXPECT().GetState(ctx).Return(stored, nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+
+		got, err := svc.getState(ctx)
+		assert.NoError(t, err)
+		assert.Same(t, stored, got)
+		assert.Equal(t, state(), got)
+	})
+}
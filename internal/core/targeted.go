@@ -0,0 +1,337 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
+)
+
+var (
+	// ErrPreviousSyncRequired is returned by the syncs of a single group or user when the state has no previous full sync
+	ErrPreviousSyncRequired = errors.New("a previous full sync is required")
+
+	// ErrGroupEmpty is returned when the group to sync is empty
+	ErrGroupEmpty = errors.New("group cannot be empty")
+
+	// ErrUserEmailEmpty is returned when the email of the user to sync is empty
+	ErrUserEmailEmpty = errors.New("user email cannot be empty")
+
+	// ErrUserNotMember is returned when the user to sync is not a member of the groups of the state
+	ErrUserNotMember = errors.New("user is not a member of the synced groups")
+
+	// ErrUserEmailChanged is returned when the primary email of the user to sync changed
+	ErrUserEmailChanged = errors.New("user primary email changed")
+)
+
+// SyncGroup syncs only the given group of the identity provider, by email or name, its members and their new users,
+// the rest of the groups and users are taken from the state, so only the group is reconciled with the SCIM side.
+func (ss *SyncService) SyncGroup(ctx context.Context, group string) error {
+	return ss.sync(ctx, func(ctx context.Context) error {
+		return ss.syncGroup(ctx, group)
+	})
+}
+
+// SyncUser syncs only the given user of the identity provider, by email, the groups, their members and the rest
+// of the users are taken from the state, so only the user is reconciled with the SCIM side.
+// The memberships of the user are removed when it is not synced anymore, e.g. suspended or excluded.
+func (ss *SyncService) SyncUser(ctx context.Context, email string) error {
+	return ss.sync(ctx, func(ctx context.Context) error {
+		return ss.syncUser(ctx, email)
+	})
+}
+
+func (ss *SyncService) syncGroup(ctx context.Context, group string) error {
+	if group == "" {
+		return ErrGroupEmpty
+	}
+
+	state, err := ss.targetState(ctx)
+	if err != nil {
+		return err
+	}
+
+	phaseCtx, span := tracing.Start(ctx, tracer, "idp.GetGroup")
+	idpGroup, err := ss.prov.GetGroup(phaseCtx, group)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("error getting the group from the identity provider: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"id":    idpGroup.IPID,
+		"name":  idpGroup.Name,
+		"email": idpGroup.Email,
+	}).Info("syncing only the group")
+
+	phaseCtx, span = tracing.Start(ctx, tracer, "idp.GetGroupsMembers")
+	gmr, err := ss.prov.GetGroupsMembers(phaseCtx, model.GroupsResultBuilder().WithResource(idpGroup).Build())
+	endPhase(span, err, func() int { return gmr.Items })
+	if err != nil {
+		return fmt.Errorf("error getting group members: %w", err)
+	}
+
+	groupMembers := model.GroupMembersBuilder().WithGroup(idpGroup).Build()
+	if gmr.Items > 0 {
+		groupMembers = gmr.Resources[0]
+	}
+
+	groups, groupsMembers := stateGroupsMembers(state)
+	users := stateUsers(state)
+
+	// the members without user in the state are got from the identity provider
+	newMembers := make([]*model.Member, 0)
+	for _, member := range groupMembers.Resources {
+		if _, ok := users[strings.ToLower(member.Email)]; !ok {
+			newMembers = append(newMembers, member)
+		}
+	}
+
+	if len(newMembers) > 0 {
+		// the identity provider removes the users skipped, e.g. suspended, from the groups members given
+		newGroupMembers := model.GroupMembersBuilder().WithGroup(idpGroup).WithResources(newMembers).Build()
+		newGroupsMembers := model.GroupsMembersResultBuilder().WithResource(newGroupMembers).Build()
+
+		phaseCtx, span = tracing.Start(ctx, tracer, "idp.GetUsersByGroupsMembers")
+		ur, err := ss.prov.GetUsersByGroupsMembers(phaseCtx, newGroupsMembers)
+		endPhase(span, err, func() int { return ur.Items })
+		if err != nil {
+			return fmt.Errorf("error getting users from the identity provider: %w", err)
+		}
+
+		for _, user := range ur.Resources {
+			users[strings.ToLower(user.Email)] = user
+		}
+
+		kept := make(map[string]struct{})
+		for _, member := range newGroupsMembers.Resources[0].Resources {
+			kept[strings.ToLower(member.Email)] = struct{}{}
+		}
+
+		skipped := make(map[string]struct{})
+		for _, member := range newMembers {
+			if _, ok := kept[strings.ToLower(member.Email)]; !ok {
+				skipped[strings.ToLower(member.Email)] = struct{}{}
+			}
+		}
+
+		if len(skipped) > 0 {
+			groupMembers = withoutMembersEmails([]*model.GroupMembers{groupMembers}, skipped)[0]
+		}
+	}
+
+	found := false
+	for idx, g := range groups {
+		if g.IPID == idpGroup.IPID {
+			groups[idx] = idpGroup
+			groupsMembers[idx] = groupMembers
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		if stateGroupRetained(state, idpGroup.IPID) {
+			log.WithField("name", idpGroup.Name).Info("the group retained is in the identity provider again")
+		} else {
+			log.WithFields(log.Fields{
+				"name":         idpGroup.Name,
+				"group_filter": ss.provGroupsFilter,
+			}).Warn("the group is not in the state, the next full sync removes it when it doesn't match the groups filter")
+		}
+
+		groups = append(groups, idpGroup)
+		groupsMembers = append(groupsMembers, groupMembers)
+	}
+
+	ss.report.Incremental = true
+	ss.targeted = true
+
+	return ss.reconcile(
+		ctx,
+		state,
+		model.GroupsResultBuilder().WithResources(groups).Build(),
+		model.UsersResultBuilder().WithResources(usersOfGroupsMembers(groupsMembers, users)).Build(),
+		model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build(),
+	)
+}
+
+func (ss *SyncService) syncUser(ctx context.Context, email string) error {
+	if email == "" {
+		return ErrUserEmailEmpty
+	}
+
+	state, err := ss.targetState(ctx)
+	if err != nil {
+		return err
+	}
+
+	groups, groupsMembers := stateGroupsMembers(state)
+	users := stateUsers(state)
+
+	key := strings.ToLower(email)
+	if _, ok := users[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrUserNotMember, email)
+	}
+
+	phaseCtx, span := tracing.Start(ctx, tracer, "idp.GetUser")
+	idpUser, err := ss.prov.GetUser(phaseCtx, email)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("error getting the user from the identity provider: %w", err)
+	}
+
+	if idpUser == nil {
+		log.WithField("email", email).Warn("the user is not synced anymore, removing its memberships")
+
+		delete(users, key)
+		groupsMembers = withoutMembersEmails(groupsMembers, map[string]struct{}{key: {}})
+	} else {
+		if !strings.EqualFold(idpUser.Email, email) {
+			return fmt.Errorf("%w: %s is %s now, sync its groups instead", ErrUserEmailChanged, email, idpUser.Email)
+		}
+
+		log.WithFields(log.Fields{
+			"id":    idpUser.IPID,
+			"email": idpUser.Email,
+		}).Info("syncing only the user")
+
+		users[key] = idpUser
+	}
+
+	ss.report.Incremental = true
+	ss.targeted = true
+
+	return ss.reconcile(
+		ctx,
+		state,
+		model.GroupsResultBuilder().WithResources(groups).Build(),
+		model.UsersResultBuilder().WithResources(usersOfGroupsMembers(groupsMembers, users)).Build(),
+		model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build(),
+	)
+}
+
+// targetState returns the state of a previous full sync to sync only some groups or users.
+func (ss *SyncService) targetState(ctx context.Context) (*model.State, error) {
+	state, err := ss.getState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !targetable(state) {
+		return nil, ErrPreviousSyncRequired
+	}
+
+	return state, nil
+}
+
+// stateGroupsMembers returns the groups and their members of the state as the identity provider returns them,
+// the groups members are in the same order of the groups.
+// The groups retained are not in the identity provider, as the users retained, so the sync keeps them retained.
+func stateGroupsMembers(state *model.State) ([]*model.Group, []*model.GroupMembers) {
+	stateMembers := make(map[string][]*model.Member)
+	for _, groupMembers := range state.Resources.GroupsMembers.Resources {
+		stateMembers[groupMembers.Group.IPID] = groupMembers.Resources
+	}
+
+	groups := make([]*model.Group, 0, len(state.Resources.Groups.Resources))
+	groupsMembers := make([]*model.GroupMembers, 0, len(state.Resources.Groups.Resources))
+
+	for _, stateGroup := range state.Resources.Groups.Resources {
+		if stateGroup.MissingSince != "" {
+			continue
+		}
+
+		group := model.GroupBuilder().
+			WithIPID(stateGroup.IPID).
+			WithName(stateGroup.Name).
+			WithEmail(stateGroup.Email).
+			Build()
+
+		// the name of the identity provider is unknown, the group is renamed by a sync that gets it
+		group.Rename = stateGroup.Rename
+
+		members := make([]*model.Member, 0, len(stateMembers[group.IPID]))
+		for _, member := range stateMembers[group.IPID] {
+			members = append(members, stateMember(member))
+		}
+
+		groups = append(groups, group)
+		groupsMembers = append(groupsMembers, model.GroupMembersBuilder().WithGroup(group).WithResources(members).Build())
+	}
+
+	return groups, groupsMembers
+}
+
+// stateGroupRetained returns true when the group of the given id is retained in the state.
+func stateGroupRetained(state *model.State, ipid string) bool {
+	for _, group := range state.Resources.Groups.Resources {
+		if group.IPID == ipid {
+			return group.MissingSince != ""
+		}
+	}
+
+	return false
+}
+
+// stateUsers returns the users of the state that are members in the identity provider, indexed by their emails
+// in lower case, as the identity provider returns them.
+func stateUsers(state *model.State) map[string]*model.User {
+	users := make(map[string]*model.User)
+
+	for _, user := range state.Resources.Users.Resources {
+		// the users retained or deactivated are not members in the identity provider
+		if user.MissingSince == "" && user.DeactivatedAt == "" {
+			users[strings.ToLower(user.Email)] = stateUser(user)
+		}
+	}
+
+	return users
+}
+
+// stateUser returns the user of the state as the identity provider returns it.
+func stateUser(user *model.User) *model.User {
+	return model.UserBuilder().
+		WithIPID(user.IPID).
+		WithGivenName(user.Name.GivenName).
+		WithFamilyName(user.Name.FamilyName).
+		WithDisplayName(user.DisplayName).
+		WithEmail(user.Email).
+		WithActive(user.Active).
+		Build()
+}
+
+// stateMember returns the member of the state as the identity provider returns it.
+func stateMember(member *model.Member) *model.Member {
+	return model.MemberBuilder().
+		WithIPID(member.IPID).
+		WithEmail(member.Email).
+		WithStatus(member.Status).
+		Build()
+}
+
+// usersOfGroupsMembers returns the users of the groups members once, in the order of the groups members.
+func usersOfGroupsMembers(groupsMembers []*model.GroupMembers, users map[string]*model.User) []*model.User {
+	result := make([]*model.User, 0, len(users))
+	uniq := make(map[string]struct{})
+
+	for _, groupMembers := range groupsMembers {
+		for _, member := range groupMembers.Resources {
+			email := strings.ToLower(member.Email)
+			if _, ok := uniq[email]; ok {
+				continue
+			}
+			uniq[email] = struct{}{}
+
+			if user, ok := users[email]; ok {
+				result = append(result, user)
+			}
+		}
+	}
+
+	return result
+}
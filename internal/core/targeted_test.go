@@ -0,0 +1,325 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// targetedSyncState returns the state of a previous sync with the groups 1 and 2, the user 1 is member of
+// both groups and the user 2 only of the group 2.
+func targetedSyncState() *model.State {
+	group1 := model.GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	group2 := model.GroupBuilder().WithIPID("2").WithSCIMID("g2").WithName("group 2").WithEmail("group.2@mail.com").Build()
+
+	user1 := model.UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+	user2 := model.UserBuilder().WithIPID("2").WithSCIMID("u2").WithEmail("user.2@mail.com").WithGivenName("user").WithFamilyName("2").WithDisplayName("user 2").WithActive(true).Build()
+
+	member1 := model.MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+	member2 := model.MemberBuilder().WithIPID("2").WithSCIMID("u2").WithEmail("user.2@mail.com").WithStatus("ACTIVE").Build()
+
+	return model.StateBuilder().
+		WithLastSync(time.Now().Add(-time.Hour).Format(time.RFC3339)).
+		WithLastFullSync(time.Now().Add(-time.Hour).Format(time.RFC3339)).
+		WithGroups(model.GroupsResultBuilder().WithResources([]*model.Group{group1, group2}).Build()).
+		WithUsers(model.UsersResultBuilder().WithResources([]*model.User{user1, user2}).Build()).
+		WithGroupsMembers(model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(group1).WithResource(member1).Build(),
+			model.GroupMembersBuilder().WithGroup(group2).WithResources([]*model.Member{member1, member2}).Build(),
+		}).Build()).
+		Build()
+}
+
+func TestSyncService_SyncGroup(t *testing.T) {
+	ctx := context.TODO()
+
+	group1 := model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build()
+	member1 := model.MemberBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+	member3 := model.MemberBuilder().WithIPID("3").WithEmail("user.3@mail.com").WithStatus("ACTIVE").Build()
+	user3 := model.UserBuilder().WithIPID("3").WithEmail("user.3@mail.com").WithGivenName("user").WithFamilyName("3").WithDisplayName("user 3").WithActive(true).Build()
+
+	t.Run("Should sync only the group and its new members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		state := targetedSyncState()
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(state, nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(gomock.Any(), gomock.Any()).Times(0)
+		mockProviderService.EXPECT().GetGroup(ctx, "group 1").Return(group1, nil).Times(1)
+		mockProviderService.EXPECT().GetGroupsMembers(ctx, model.GroupsResultBuilder().WithResource(group1).Build()).Return(
+			model.GroupsMembersResultBuilder().WithResource(
+				model.GroupMembersBuilder().WithGroup(group1).WithResources([]*model.Member{member1, member3}).Build(),
+			).Build(), nil,
+		).Times(1)
+		mockProviderService.EXPECT().GetUsersByGroupsMembers(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+				// only the new members are got
+				assert.Equal(t, 1, gmr.Items)
+				assert.Equal(t, []*model.Member{member3}, gmr.Resources[0].Resources)
+				return model.UsersResultBuilder().WithResource(user3).Build(), nil
+			},
+		).Times(1)
+
+		mockSCIMService.EXPECT().CreateUsers(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+				assert.Equal(t, 1, ur.Items)
+				assert.Equal(t, "user.3@mail.com", ur.Resources[0].Email)
+
+				created := model.UserBuilder().WithIPID("3").WithSCIMID("u3").WithEmail("user.3@mail.com").
+					WithGivenName("user").WithFamilyName("3").WithDisplayName("user 3").WithActive(true).Build()
+				return model.UsersResultBuilder().WithResource(created).Build(), nil
+			},
+		).Times(1)
+		mockSCIMService.EXPECT().CreateGroupsMembers(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, gmr *model.GroupsMembersResult) (*model.GroupsMembersResult, error) {
+				assert.Equal(t, 1, gmr.Items)
+				assert.Equal(t, "group 1", gmr.Resources[0].Group.Name)
+				assert.Equal(t, 1, gmr.Resources[0].Items)
+				assert.Equal(t, "user.3@mail.com", gmr.Resources[0].Resources[0].Email)
+				return gmr, nil
+			},
+		).Times(1)
+
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, newState *model.State) error {
+				assert.Equal(t, 2, newState.Resources.Groups.Items)
+				assert.Equal(t, 3, newState.Resources.Users.Items)
+				assert.Equal(t, state.LastFullSync, newState.LastFullSync)
+
+				// the group 2 is not changed
+				assert.Equal(t, state.Resources.GroupsMembers.Resources[1].HashCode, newState.Resources.GroupsMembers.Resources[1].HashCode)
+				return nil
+			},
+		).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncGroup(ctx, "group 1"))
+
+		assert.True(t, svc.LastReport().Incremental)
+		assert.False(t, svc.LastReport().FirstSync)
+	})
+
+	t.Run("Should return an error without a previous sync", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(model.StateBuilder().Build(), nil).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+
+		err = svc.SyncGroup(ctx, "group.1@mail.com")
+		assert.ErrorIs(t, err, ErrPreviousSyncRequired)
+		assert.False(t, svc.LastReport().Success)
+	})
+
+	t.Run("Should return an error when the group cannot be got", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroup(ctx, "group.3@mail.com").Return(nil, errors.New("test error")).Times(1)
+		mockStateRepository.EXPECT().SetState(gomock.Any(), gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.Error(t, svc.SyncGroup(ctx, "group.3@mail.com"))
+	})
+
+	t.Run("Should return an error without group", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		svc, err := NewSyncService(mocks.NewMockIdentityProviderService(mockCtrl), mocks.NewMockSCIMService(mockCtrl), mocks.NewMockStateRepository(mockCtrl))
+		assert.NoError(t, err)
+		assert.ErrorIs(t, svc.SyncGroup(ctx, ""), ErrGroupEmpty)
+	})
+}
+
+func TestSyncService_SyncUser(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Should sync only the user", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		user2 := model.UserBuilder().WithIPID("2").WithEmail("user.2@mail.com").WithGivenName("user").WithFamilyName("two").WithDisplayName("user two").WithActive(true).Build()
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetGroups(gomock.Any(), gomock.Any()).Times(0)
+		mockProviderService.EXPECT().GetGroupsMembers(gomock.Any(), gomock.Any()).Times(0)
+		mockProviderService.EXPECT().GetUser(ctx, "user.2@mail.com").Return(user2, nil).Times(1)
+
+		mockSCIMService.EXPECT().UpdateUsers(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+				assert.Equal(t, 1, ur.Items)
+				assert.Equal(t, "u2", ur.Resources[0].SCIMID)
+				assert.Equal(t, "two", ur.Resources[0].Name.FamilyName)
+				return ur, nil
+			},
+		).Times(1)
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, newState *model.State) error {
+				assert.Equal(t, 2, newState.Resources.Groups.Items)
+				assert.Equal(t, 2, newState.Resources.Users.Items)
+				return nil
+			},
+		).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncUser(ctx, "user.2@mail.com"))
+
+		assert.True(t, svc.LastReport().Incremental)
+	})
+
+	t.Run("Should keep the groups and users retained without counting the run", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		missingSince := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+		state := targetedSyncState()
+		state.Resources.Groups.Resources[1].MissingSince = missingSince
+		state.Resources.Groups.Resources[1].MissingRuns = 1
+		state.Resources.Users.Resources[1].MissingSince = missingSince
+		state.Resources.Users.Resources[1].MissingRuns = 1
+		state.RecalculateHashCodes()
+
+		user1 := model.UserBuilder().WithIPID("1").WithEmail("user.1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build()
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(state, nil).Times(1)
+		mockProviderService.EXPECT().GetUser(ctx, "user.1@mail.com").Return(user1, nil).Times(1)
+
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, newState *model.State) error {
+				assert.Equal(t, 2, newState.Resources.Groups.Items)
+				assert.Equal(t, 2, newState.Resources.Users.Items)
+
+				for _, group := range newState.Resources.Groups.Resources {
+					if group.Name == "group 2" {
+						assert.Equal(t, missingSince, group.MissingSince)
+						assert.Equal(t, 1, group.MissingRuns)
+					}
+				}
+
+				for _, user := range newState.Resources.Users.Resources {
+					if user.Email == "user.2@mail.com" {
+						assert.Equal(t, missingSince, user.MissingSince)
+						assert.Equal(t, 1, user.MissingRuns)
+					}
+				}
+				return nil
+			},
+		).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository, WithRemovalGraceRuns(2))
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncUser(ctx, "user.1@mail.com"))
+
+		assert.Equal(t, 1, svc.LastReport().Groups.Retain)
+		assert.Equal(t, 1, svc.LastReport().Users.Retain)
+	})
+
+	t.Run("Should remove the memberships of the user not synced anymore", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetUser(ctx, "User.2@mail.com").Return(nil, nil).Times(1)
+
+		mockSCIMService.EXPECT().DeleteGroupsMembers(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, gmr *model.GroupsMembersResult) error {
+				assert.Equal(t, 1, gmr.Items)
+				assert.Equal(t, "group 2", gmr.Resources[0].Group.Name)
+				assert.Equal(t, "user.2@mail.com", gmr.Resources[0].Resources[0].Email)
+				return nil
+			},
+		).Times(1)
+		mockSCIMService.EXPECT().DeleteUsers(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, ur *model.UsersResult) error {
+				assert.Equal(t, 1, ur.Items)
+				assert.Equal(t, "u2", ur.Resources[0].SCIMID)
+				return nil
+			},
+		).Times(1)
+		mockStateRepository.EXPECT().SetState(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, newState *model.State) error {
+				assert.Equal(t, 1, newState.Resources.Users.Items)
+				assert.Equal(t, "user.1@mail.com", newState.Resources.Users.Resources[0].Email)
+				return nil
+			},
+		).Times(1)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SyncUser(ctx, "User.2@mail.com"))
+	})
+
+	t.Run("Should return an error when the user is not a member of the groups", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, svc.SyncUser(ctx, "user.3@mail.com"), ErrUserNotMember)
+	})
+
+	t.Run("Should return an error when the primary email of the user changed", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProviderService := mocks.NewMockIdentityProviderService(mockCtrl)
+		mockSCIMService := mocks.NewMockSCIMService(mockCtrl)
+		mockStateRepository := mocks.NewMockStateRepository(mockCtrl)
+
+		renamed := model.UserBuilder().WithIPID("2").WithEmail("user.two@mail.com").WithGivenName("user").WithFamilyName("2").WithActive(true).Build()
+
+		mockStateRepository.EXPECT().GetState(ctx).Return(targetedSyncState(), nil).Times(1)
+		mockProviderService.EXPECT().GetUser(ctx, "user.2@mail.com").Return(renamed, nil).Times(1)
+		mockStateRepository.EXPECT().SetState(gomock.Any(), gomock.Any()).Times(0)
+
+		svc, err := NewSyncService(mockProviderService, mockSCIMService, mockStateRepository)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, svc.SyncUser(ctx, "user.2@mail.com"), ErrUserEmailChanged)
+	})
+}
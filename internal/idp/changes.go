@@ -0,0 +1,82 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	reports "google.golang.org/api/admin/reports/v1"
+)
+
+// This implement core.IdentityProviderChangesSource interface
+
+// ErrReportsServiceNil is returned when the GoogleReportsService is nil.
+var ErrReportsServiceNil = errors.New("provider: reports service is nil")
+
+// changesApplications are the applications of the activities with the changes of the groups, their members and the users,
+// admin for the changes done in the Admin console or the Directory API and groups for the changes done by the groups owners.
+var changesApplications = []string{"admin", "groups"}
+
+//go:generate go run github.com/golang/mock/mockgen@v1.6.0 -package=mocks -destination=../../mocks/idp/changes_mocks.go -source=changes.go GoogleReportsService
+
+// GoogleReportsService is the interface that wraps the Google Reports Service methods.
+type GoogleReportsService interface {
+	ListActivities(ctx context.Context, application string, startTime time.Time) ([]*reports.Activity, error)
+}
+
+// ChangesSource is the source of the groups and users changed in Google Workspace, it implements the
+// core.IdentityProviderChangesSource interface using the activity events of the Reports API.
+type ChangesSource struct {
+	rs []GoogleReportsService
+}
+
+// NewChangesSource returns a new instance of the changes source of the Google Workspace tenants of the reports services.
+func NewChangesSource(rs ...GoogleReportsService) (*ChangesSource, error) {
+	if len(rs) == 0 {
+		return nil, ErrReportsServiceNil
+	}
+
+	for _, r := range rs {
+		if r == nil {
+			return nil, ErrReportsServiceNil
+		}
+	}
+
+	return &ChangesSource{rs: rs}, nil
+}
+
+// GetChanges returns the emails of the groups and users of the activity events since the given time,
+// the groups with members added or removed and the users created, changed or deleted.
+func (c *ChangesSource) GetChanges(ctx context.Context, since time.Time) (*model.IdentityProviderChanges, error) {
+	b := model.IdentityProviderChangesBuilder()
+
+	for idx, rs := range c.rs {
+		for _, application := range changesApplications {
+			activities, err := rs.ListActivities(ctx, application, since)
+			if err != nil {
+				return nil, fmt.Errorf("idp: error listing activities of tenant %d, application: %s, error: %w", idx, application, err)
+			}
+
+			for _, activity := range activities {
+				for _, event := range activity.Events {
+					for _, parameter := range event.Parameters {
+						b.WithParameter(parameter.Name, parameter.Value)
+					}
+				}
+			}
+		}
+	}
+
+	changes := b.Build()
+
+	log.WithFields(log.Fields{
+		"since":  since.Format(time.RFC3339),
+		"groups": len(changes.Groups),
+		"users":  len(changes.Users),
+	}).Info("idp: changes got from the activities")
+
+	return changes, nil
+}
@@ -0,0 +1,143 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	reports "google.golang.org/api/admin/reports/v1"
+)
+
+func TestNewChangesSource(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return ChangesSource and no error", func(t *testing.T) {
+		svc, err := NewChangesSource(mocks.NewMockGoogleReportsService(mockCtrl))
+
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("Should return an error if no ReportsService is provided", func(t *testing.T) {
+		svc, err := NewChangesSource()
+		assert.ErrorIs(t, err, ErrReportsServiceNil)
+		assert.Nil(t, svc)
+
+		svc, err = NewChangesSource(nil)
+		assert.ErrorIs(t, err, ErrReportsServiceNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestChangesSource_GetChanges(t *testing.T) {
+	ctx := context.TODO()
+	since := time.Date(2022, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should return the groups and users of the activities", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockRS := mocks.NewMockGoogleReportsService(mockCtrl)
+
+		adminActivities := []*reports.Activity{
+			{
+				Events: []*reports.ActivityEvents{
+					{
+						Type: "GROUP_SETTINGS",
+						Name: "ADD_GROUP_MEMBER",
+						Parameters: []*reports.ActivityEventsParameters{
+							{Name: "USER_EMAIL", Value: "User.1@mail.com"},
+							{Name: "GROUP_EMAIL", Value: "group.1@mail.com"},
+						},
+					},
+				},
+			},
+			{
+				Events: []*reports.ActivityEvents{
+					{
+						Type: "USER_SETTINGS",
+						Name: "CHANGE_LAST_NAME",
+						Parameters: []*reports.ActivityEventsParameters{
+							{Name: "USER_EMAIL", Value: "user.2@mail.com"},
+							{Name: "NEW_VALUE", Value: "2"},
+						},
+					},
+				},
+			},
+		}
+
+		groupsActivities := []*reports.Activity{
+			{
+				Events: []*reports.ActivityEvents{
+					{
+						Type: "user_change",
+						Name: "remove_user",
+						Parameters: []*reports.ActivityEventsParameters{
+							{Name: "user_email", Value: "user.1@mail.com"},
+							{Name: "group_email", Value: "group.2@mail.com"},
+						},
+					},
+				},
+			},
+		}
+
+		mockRS.EXPECT().ListActivities(ctx, "admin", since).Return(adminActivities, nil).Times(1)
+		mockRS.EXPECT().ListActivities(ctx, "groups", since).Return(groupsActivities, nil).Times(1)
+
+		svc, err := NewChangesSource(mockRS)
+		assert.NoError(t, err)
+
+		got, err := svc.GetChanges(ctx, since)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.IdentityProviderChanges{
+			Groups: []string{"group.1@mail.com", "group.2@mail.com"},
+			Users:  []string{"user.1@mail.com", "user.2@mail.com"},
+		}, got)
+	})
+
+	t.Run("Should merge the changes of the tenants", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		tenant1 := mocks.NewMockGoogleReportsService(mockCtrl)
+		tenant2 := mocks.NewMockGoogleReportsService(mockCtrl)
+
+		activity := func(email string) []*reports.Activity {
+			return []*reports.Activity{{Events: []*reports.ActivityEvents{{
+				Parameters: []*reports.ActivityEventsParameters{{Name: "USER_EMAIL", Value: email}},
+			}}}}
+		}
+
+		tenant1.EXPECT().ListActivities(ctx, gomock.Any(), since).Return(activity("user.1@mail.com"), nil).Times(2)
+		tenant2.EXPECT().ListActivities(ctx, gomock.Any(), since).Return(activity("user.2@other.com"), nil).Times(2)
+
+		svc, err := NewChangesSource(tenant1, tenant2)
+		assert.NoError(t, err)
+
+		got, err := svc.GetChanges(ctx, since)
+		assert.NoError(t, err)
+		assert.Empty(t, got.Groups)
+		assert.Equal(t, []string{"user.1@mail.com", "user.2@other.com"}, got.Users)
+	})
+
+	t.Run("Should return an error when the activities cannot be listed", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockRS := mocks.NewMockGoogleReportsService(mockCtrl)
+		mockRS.EXPECT().ListActivities(ctx, "admin", since).Return(nil, errors.New("test error")).Times(1)
+
+		svc, err := NewChangesSource(mockRS)
+		assert.NoError(t, err)
+
+		got, err := svc.GetChanges(ctx, since)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
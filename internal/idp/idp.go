@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slashdevops/idp-scim-sync/internal/model"
@@ -22,32 +23,115 @@ var (
 
 	// ErrGroupResultNil is returned when the group result is nil.
 	ErrGroupResultNil = errors.New("provider: group result is nil")
+
+	// ErrInactiveUsersPolicyInvalid is returned when the inactive users policy is not valid.
+	ErrInactiveUsersPolicyInvalid = errors.New("provider: inactive users policy is not valid")
+
+	// ErrGroupNotFound is returned when there is no group with the given name.
+	ErrGroupNotFound = errors.New("provider: group not found")
+
+	// ErrUserEmailNil is returned when the user email is empty.
+	ErrUserEmailNil = errors.New("provider: user email is nil")
+)
+
+const (
+	// InactiveUsersPolicyDrop drops the suspended and archived users and the non ACTIVE group members.
+	InactiveUsersPolicyDrop = "drop"
+
+	// InactiveUsersPolicyKeepInactive keeps the suspended and archived users and the non ACTIVE group members,
+	// the users are marked as inactive.
+	InactiveUsersPolicyKeepInactive = "keep-inactive"
+
+	// InactiveUsersPolicyKeepActive keeps the suspended and archived users and the non ACTIVE group members,
+	// the users are marked as active.
+	InactiveUsersPolicyKeepActive = "keep-active"
+
+	// memberStatusActive is the status of the active group members in the Google Directory API.
+	memberStatusActive = "ACTIVE"
 )
 
 //go:generate go run github.com/golang/mock/mockgen@v1.6.0 -package=mocks -destination=../../mocks/idp/idp_mocks.go -source=idp.go GoogleProviderService
 
 // GoogleProviderService is the interface that wraps the Google Provider Service methods.
 type GoogleProviderService interface {
-	ListUsers(ctx context.Context, query []string) ([]*admin.User, error)
+	ListUsers(ctx context.Context, query []string, opts ...google.ListUsersOption) ([]*admin.User, error)
 	ListGroups(ctx context.Context, query []string) ([]*admin.Group, error)
 	ListGroupMembers(ctx context.Context, groupID string, queries ...google.GetGroupMembersOption) ([]*admin.Member, error)
 	GetUser(ctx context.Context, userID string) (*admin.User, error)
+	GetGroup(ctx context.Context, groupID string) (*admin.Group, error)
+	CheckAuth(ctx context.Context) error
 }
 
 // IdentityProvider is the Identity Provider service that implements the core.IdentityProvider interface and consumes the pkg.google methods.
 type IdentityProvider struct {
 	ps GoogleProviderService
+
+	inactiveUsersPolicy string
+	userIncludeRules    []UserRule
+	userExcludeRules    []UserRule
+	orgUnitGroups       []OrgUnitGroup
 }
 
 // NewIdentityProvider returns a new instance of the Identity Provider service.
-func NewIdentityProvider(gps GoogleProviderService) (*IdentityProvider, error) {
+func NewIdentityProvider(gps GoogleProviderService, opts ...IdentityProviderOption) (*IdentityProvider, error) {
 	if gps == nil {
 		return nil, ErrDirectoryServiceNil
 	}
 
-	return &IdentityProvider{
-		ps: gps,
-	}, nil
+	i := &IdentityProvider{
+		ps:                  gps,
+		inactiveUsersPolicy: InactiveUsersPolicyDrop,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	switch i.inactiveUsersPolicy {
+	case InactiveUsersPolicyDrop, InactiveUsersPolicyKeepInactive, InactiveUsersPolicyKeepActive:
+	default:
+		return nil, ErrInactiveUsersPolicyInvalid
+	}
+
+	for _, rule := range i.userIncludeRules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid include rule: %w", err)
+		}
+	}
+
+	for _, rule := range i.userExcludeRules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid exclude rule: %w", err)
+		}
+	}
+
+	return i, nil
+}
+
+// userActive returns the active value of the user according to the inactive users policy
+// and if the user must be dropped.
+func (i *IdentityProvider) userActive(usr *admin.User) (active bool, drop bool) {
+	if !usr.Suspended && !usr.Archived {
+		return true, false
+	}
+
+	switch i.inactiveUsersPolicy {
+	case InactiveUsersPolicyDrop:
+		return false, true
+	case InactiveUsersPolicyKeepActive:
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// Check checks the Identity Provider is reachable with the given credentials.
+func (i *IdentityProvider) Check(ctx context.Context) error {
+	if err := i.ps.CheckAuth(ctx); err != nil {
+		return fmt.Errorf("idp: error checking the identity provider: %w", err)
+	}
+
+	return nil
 }
 
 // GetGroups returns a list of groups from the Identity Provider API.
@@ -86,6 +170,24 @@ func (i *IdentityProvider) GetGroups(ctx context.Context, filter []string) (*mod
 		}
 	}
 
+	for _, oug := range i.orgUnitGroups {
+		if _, ok := uniqueGroups[oug.Name]; ok {
+			log.WithFields(log.Fields{
+				"name":        oug.Name,
+				"orgUnitPath": oug.OrgUnitPath,
+			}).Warning("idp: group already exists with the same name, this organizational unit group will be avoided, please make your groups uniques by name!")
+			continue
+		}
+		uniqueGroups[oug.Name] = struct{}{}
+
+		e := model.GroupBuilder().
+			WithIPID(orgUnitGroupIPID(oug.OrgUnitPath)).
+			WithName(oug.Name).
+			Build()
+
+		syncGroups = append(syncGroups, e)
+	}
+
 	syncResult := model.GroupsResultBuilder().WithResources(syncGroups).Build()
 
 	return syncResult, nil
@@ -104,13 +206,33 @@ func (i *IdentityProvider) GetUsers(ctx context.Context, filter []string) (*mode
 	}
 
 	for _, usr := range pUsers {
+		active, drop := i.userActive(usr)
+		if drop {
+			log.WithFields(log.Fields{
+				"id":        usr.Id,
+				"email":     usr.PrimaryEmail,
+				"suspended": usr.Suspended,
+				"archived":  usr.Archived,
+			}).Warn("idp: skipping user because is suspended or archived")
+			continue
+		}
+
+		if reason, excluded := i.excludedByRules(usr); excluded {
+			log.WithFields(log.Fields{
+				"id":     usr.Id,
+				"email":  usr.PrimaryEmail,
+				"reason": reason,
+			}).Warn("idp: excluding user by rules")
+			continue
+		}
+
 		e := model.UserBuilder().
 			WithIPID(usr.Id).
 			WithGivenName(usr.Name.GivenName).
 			WithFamilyName(usr.Name.FamilyName).
 			WithDisplayName(fmt.Sprintf("%s %s", usr.Name.GivenName, usr.Name.FamilyName)).
 			WithEmail(usr.PrimaryEmail).
-			WithActive(!usr.Suspended).
+			WithActive(active).
 			Build()
 
 		syncUsers = append(syncUsers, e)
@@ -121,6 +243,93 @@ func (i *IdentityProvider) GetUsers(ctx context.Context, filter []string) (*mode
 	return uResult, nil
 }
 
+// GetGroup returns a group from the Identity Provider API given its email or its name,
+// the organizational unit groups are only found by their names.
+func (i *IdentityProvider) GetGroup(ctx context.Context, group string) (*model.Group, error) {
+	if group == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	if strings.Contains(group, "@") {
+		grp, err := i.ps.GetGroup(ctx, group)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group: %w", err)
+		}
+
+		return model.GroupBuilder().WithIPID(grp.Id).WithName(grp.Name).WithEmail(grp.Email).Build(), nil
+	}
+
+	for _, oug := range i.orgUnitGroups {
+		if oug.Name == group {
+			return model.GroupBuilder().WithIPID(orgUnitGroupIPID(oug.OrgUnitPath)).WithName(oug.Name).Build(), nil
+		}
+	}
+
+	pGroups, err := i.ps.ListGroups(ctx, []string{fmt.Sprintf("name:'%s'", strings.ReplaceAll(group, "'", "\\'"))})
+	if err != nil {
+		return nil, fmt.Errorf("idp: error listing groups: %w", err)
+	}
+
+	// the query matches the names case insensitive, the group names are case sensitive
+	for _, grp := range pGroups {
+		if grp.Name == group {
+			return model.GroupBuilder().WithIPID(grp.Id).WithName(grp.Name).WithEmail(grp.Email).Build(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, group)
+}
+
+// GetUser returns a user from the Identity Provider API given its email,
+// nil when the user is not synced, i.e. suspended or archived and dropped, or excluded by the rules.
+func (i *IdentityProvider) GetUser(ctx context.Context, email string) (*model.User, error) {
+	if email == "" {
+		return nil, ErrUserEmailNil
+	}
+
+	u, err := i.ps.GetUser(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting user: %s, error: %w", email, err)
+	}
+
+	return i.syncUser(u), nil
+}
+
+// syncUser returns the user to sync of the given Google user according to the inactive users policy and
+// the users rules, nil when the user is skipped.
+func (i *IdentityProvider) syncUser(u *admin.User) *model.User {
+	active, drop := i.userActive(u)
+	if drop {
+		log.WithFields(log.Fields{
+			"id":        u.Id,
+			"email":     u.PrimaryEmail,
+			"suspended": u.Suspended,
+			"archived":  u.Archived,
+		}).Warn("idp: skipping user and its memberships because is suspended or archived")
+
+		return nil
+	}
+
+	if reason, excluded := i.excludedByRules(u); excluded {
+		log.WithFields(log.Fields{
+			"id":     u.Id,
+			"email":  u.PrimaryEmail,
+			"reason": reason,
+		}).Warn("idp: excluding user and its memberships by rules")
+
+		return nil
+	}
+
+	return model.UserBuilder().
+		WithIPID(u.Id).
+		WithGivenName(u.Name.GivenName).
+		WithFamilyName(u.Name.FamilyName).
+		WithDisplayName(fmt.Sprintf("%s %s", u.Name.GivenName, u.Name.FamilyName)).
+		WithEmail(u.PrimaryEmail).
+		WithActive(active).
+		Build()
+}
+
 // GetGroupMembers returns a list of members from the Identity Provider API.
 func (i *IdentityProvider) GetGroupMembers(ctx context.Context, groupID string) (*model.MembersResult, error) {
 	if groupID == "" {
@@ -129,7 +338,14 @@ func (i *IdentityProvider) GetGroupMembers(ctx context.Context, groupID string)
 
 	syncMembers := make([]*model.Member, 0)
 
-	pMembers, err := i.ps.ListGroupMembers(ctx, groupID, google.WithIncludeDerivedMembership(true))
+	var pMembers []*admin.Member
+	var err error
+
+	if orgUnitPath, ok := orgUnitPathFromIPID(groupID); ok {
+		pMembers, err = i.listOrgUnitMembers(ctx, orgUnitPath)
+	} else {
+		pMembers, err = i.ps.ListGroupMembers(ctx, groupID, google.WithIncludeDerivedMembership(true))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("idp: error listing group members: %w", err)
 	}
@@ -144,6 +360,16 @@ func (i *IdentityProvider) GetGroupMembers(ctx context.Context, groupID string)
 			continue
 		}
 
+		if member.Status != memberStatusActive && i.inactiveUsersPolicy == InactiveUsersPolicyDrop {
+			log.WithFields(log.Fields{
+				"id":     member.Id,
+				"email":  member.Email,
+				"group":  groupID,
+				"status": member.Status,
+			}).Warn("idp: skipping member because its status is not ACTIVE")
+			continue
+		}
+
 		e := model.MemberBuilder().
 			WithIPID(member.Id).
 			WithEmail(member.Email).
@@ -159,25 +385,30 @@ func (i *IdentityProvider) GetGroupMembers(ctx context.Context, groupID string)
 }
 
 // GetUsersByGroupsMembers returns a list of users from the Identity Provider API.
+//
+// When the inactive users policy drops the suspended and archived users, their memberships are also
+// removed from the gmr argument, so the users and the groups members are consistent.
 func (i *IdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
 	pUsers := make([]*model.User, 0)
 	uniqUsers := make(map[string]struct{})
+	dropUsers := make(map[string]struct{})
 
 	for _, groupMembers := range gmr.Resources {
 		for _, member := range groupMembers.Resources {
+			if _, ok := dropUsers[member.Email]; ok {
+				continue
+			}
+
 			u, err := i.ps.GetUser(ctx, member.Email)
 			if err != nil {
 				return nil, fmt.Errorf("idp: error getting user: %+v, email: %s, error: %w", member.IPID, member.Email, err)
 			}
 
-			e := model.UserBuilder().
-				WithIPID(u.Id).
-				WithGivenName(u.Name.GivenName).
-				WithFamilyName(u.Name.FamilyName).
-				WithDisplayName(fmt.Sprintf("%s %s", u.Name.GivenName, u.Name.FamilyName)).
-				WithEmail(u.PrimaryEmail).
-				WithActive(!u.Suspended).
-				Build()
+			e := i.syncUser(u)
+			if e == nil {
+				dropUsers[member.Email] = struct{}{}
+				continue
+			}
 
 			if _, ok := uniqUsers[e.Email]; !ok {
 				uniqUsers[e.Email] = struct{}{}
@@ -186,6 +417,14 @@ func (i *IdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *mod
 		}
 	}
 
+	if len(dropUsers) > 0 {
+		log.WithFields(log.Fields{
+			"users": len(dropUsers),
+		}).Info("idp: users and their memberships excluded from the sync")
+
+		dropGroupsMembers(gmr, dropUsers)
+	}
+
 	pUsersResult := model.UsersResultBuilder().WithResources(pUsers).Build()
 
 	return pUsersResult, nil
@@ -228,3 +467,23 @@ func (i *IdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.Group
 
 	return groupsMembersResult, nil
 }
+
+// dropGroupsMembers removes from the groups members the members with the given emails
+// and recalculates the hash codes.
+func dropGroupsMembers(gmr *model.GroupsMembersResult, emails map[string]struct{}) {
+	for _, groupMembers := range gmr.Resources {
+		members := make([]*model.Member, 0)
+
+		for _, member := range groupMembers.Resources {
+			if _, ok := emails[member.Email]; !ok {
+				members = append(members, member)
+			}
+		}
+
+		groupMembers.Items = len(members)
+		groupMembers.Resources = members
+		groupMembers.SetHashCode()
+	}
+
+	gmr.SetHashCode()
+}
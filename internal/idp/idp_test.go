@@ -32,6 +32,178 @@ func TestNewGoogleIdentityProvider(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, svc)
 	})
+
+	t.Run("Should use the drop inactive users policy by default", func(t *testing.T) {
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		svc, err := NewIdentityProvider(mockDS)
+
+		assert.NoError(t, err)
+		assert.Equal(t, InactiveUsersPolicyDrop, svc.inactiveUsersPolicy)
+	})
+
+	t.Run("Should return an error if the inactive users policy is not valid", func(t *testing.T) {
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy("suspend"))
+
+		assert.ErrorIs(t, err, ErrInactiveUsersPolicyInvalid)
+		assert.Nil(t, svc)
+	})
+
+	t.Run("Should return an error if a user rule is not valid", func(t *testing.T) {
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		svc, err := NewIdentityProvider(mockDS, WithUserExcludeRules([]UserRule{{}}))
+
+		assert.ErrorIs(t, err, ErrUserRuleEmpty)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestInactiveUsersPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	googleUsers := []*admin.User{
+		{PrimaryEmail: "user.1@mail.com", Id: "1", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}},
+		{PrimaryEmail: "user.2@mail.com", Id: "2", Name: &admin.UserName{GivenName: "user", FamilyName: "2"}, Suspended: true},
+		{PrimaryEmail: "user.3@mail.com", Id: "3", Name: &admin.UserName{GivenName: "user", FamilyName: "3"}, Archived: true},
+	}
+
+	t.Run("Should drop the suspended and archived users", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListUsers(ctx, gomock.Eq([]string{""})).Return(googleUsers, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy(InactiveUsersPolicyDrop))
+		assert.NoError(t, err)
+
+		got, err := svc.GetUsers(ctx, []string{""})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Items)
+		assert.Equal(t, "user.1@mail.com", got.Resources[0].Email)
+	})
+
+	t.Run("Should keep the suspended and archived users as inactive", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListUsers(ctx, gomock.Eq([]string{""})).Return(googleUsers, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy(InactiveUsersPolicyKeepInactive))
+		assert.NoError(t, err)
+
+		got, err := svc.GetUsers(ctx, []string{""})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, got.Items)
+		assert.True(t, got.Resources[0].Active)
+		assert.False(t, got.Resources[1].Active)
+		assert.False(t, got.Resources[2].Active)
+	})
+
+	t.Run("Should keep the suspended and archived users as active", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListUsers(ctx, gomock.Eq([]string{""})).Return(googleUsers, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy(InactiveUsersPolicyKeepActive))
+		assert.NoError(t, err)
+
+		got, err := svc.GetUsers(ctx, []string{""})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, got.Items)
+		assert.True(t, got.Resources[0].Active)
+		assert.True(t, got.Resources[1].Active)
+		assert.True(t, got.Resources[2].Active)
+	})
+
+	t.Run("Should drop the non ACTIVE group members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		googleGroupMembers := []*admin.Member{
+			{Email: "user.1@mail.com", Id: "1", Status: "ACTIVE"},
+			{Email: "user.2@mail.com", Id: "2", Status: "SUSPENDED"},
+		}
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroupMembers(ctx, gomock.Eq("1"), gomock.Any()).Return(googleGroupMembers, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy(InactiveUsersPolicyDrop))
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroupMembers(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Items)
+		assert.Equal(t, "user.1@mail.com", got.Resources[0].Email)
+	})
+
+	t.Run("Should keep the non ACTIVE group members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		googleGroupMembers := []*admin.Member{
+			{Email: "user.1@mail.com", Id: "1", Status: "ACTIVE"},
+			{Email: "user.2@mail.com", Id: "2", Status: "SUSPENDED"},
+		}
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroupMembers(ctx, gomock.Eq("1"), gomock.Any()).Return(googleGroupMembers, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy(InactiveUsersPolicyKeepInactive))
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroupMembers(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+	})
+
+	t.Run("Should drop the suspended users and their memberships from groups members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		gomock.InOrder(
+			mockDS.EXPECT().GetUser(ctx, gomock.Eq("user.1@mail.com")).Return(googleUsers[0], nil).Times(1),
+			mockDS.EXPECT().GetUser(ctx, gomock.Eq("user.2@mail.com")).Return(googleUsers[1], nil).Times(1),
+		)
+
+		gmr := &model.GroupsMembersResult{
+			Items: 2,
+			Resources: []*model.GroupMembers{
+				{
+					Items: 2,
+					Group: &model.Group{IPID: "1", Name: "group 1", Email: "group1@mail.com"},
+					Resources: []*model.Member{
+						{IPID: "1", Email: "user.1@mail.com", Status: "ACTIVE"},
+						{IPID: "2", Email: "user.2@mail.com", Status: "ACTIVE"},
+					},
+				},
+				{
+					Items: 1,
+					Group: &model.Group{IPID: "2", Name: "group 2", Email: "group2@mail.com"},
+					Resources: []*model.Member{
+						{IPID: "2", Email: "user.2@mail.com", Status: "ACTIVE"},
+					},
+				},
+			},
+		}
+
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy(InactiveUsersPolicyDrop))
+		assert.NoError(t, err)
+
+		got, err := svc.GetUsersByGroupsMembers(ctx, gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Items)
+		assert.Equal(t, "user.1@mail.com", got.Resources[0].Email)
+
+		assert.Equal(t, 1, gmr.Resources[0].Items)
+		assert.Equal(t, "user.1@mail.com", gmr.Resources[0].Resources[0].Email)
+		assert.Equal(t, 0, gmr.Resources[1].Items)
+		assert.NotEmpty(t, gmr.HashCode)
+	})
 }
 
 func TestGetGroups(t *testing.T) {
@@ -662,3 +834,195 @@ func TestGetGroupsMembers(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRules(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should exclude the users and their memberships from groups members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		googleUser1 := &admin.User{PrimaryEmail: "user.1@mail.com", Id: "1", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}}
+		googleUser2 := &admin.User{PrimaryEmail: "break-glass@mail.com", Id: "2", Name: &admin.UserName{GivenName: "break", FamilyName: "glass"}}
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		gomock.InOrder(
+			mockDS.EXPECT().GetUser(ctx, gomock.Eq("user.1@mail.com")).Return(googleUser1, nil).Times(1),
+			mockDS.EXPECT().GetUser(ctx, gomock.Eq("break-glass@mail.com")).Return(googleUser2, nil).Times(1),
+		)
+
+		gmr := &model.GroupsMembersResult{
+			Items: 1,
+			Resources: []*model.GroupMembers{
+				{
+					Items: 2,
+					Group: &model.Group{IPID: "1", Name: "group 1", Email: "group1@mail.com"},
+					Resources: []*model.Member{
+						{IPID: "1", Email: "user.1@mail.com", Status: "ACTIVE"},
+						{IPID: "2", Email: "break-glass@mail.com", Status: "ACTIVE"},
+					},
+				},
+			},
+		}
+
+		svc, err := NewIdentityProvider(mockDS, WithUserExcludeRules([]UserRule{{Email: "break-glass@*"}}))
+		assert.NoError(t, err)
+
+		got, err := svc.GetUsersByGroupsMembers(ctx, gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Items)
+		assert.Equal(t, "user.1@mail.com", got.Resources[0].Email)
+
+		assert.Equal(t, 1, gmr.Resources[0].Items)
+		assert.Equal(t, "user.1@mail.com", gmr.Resources[0].Resources[0].Email)
+	})
+
+	t.Run("Should exclude the users not matching the include rules", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		googleUsers := []*admin.User{
+			{PrimaryEmail: "user.1@mail.com", Id: "1", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}},
+			{PrimaryEmail: "guest.2@external.com", Id: "2", Name: &admin.UserName{GivenName: "guest", FamilyName: "2"}},
+		}
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListUsers(ctx, gomock.Eq([]string{""})).Return(googleUsers, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithUserIncludeRules([]UserRule{{Domain: "mail.com"}}))
+		assert.NoError(t, err)
+
+		got, err := svc.GetUsers(ctx, []string{""})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Items)
+		assert.Equal(t, "user.1@mail.com", got.Resources[0].Email)
+	})
+}
+
+func TestGetGroup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should return the group by email", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ds := mocks.NewMockGoogleProviderService(mockCtrl)
+		ds.EXPECT().GetGroup(ctx, "group.1@mail.com").Return(&admin.Group{Id: "1", Name: "group 1", Email: "group.1@mail.com"}, nil).Times(1)
+
+		svc, err := NewIdentityProvider(ds)
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroup(ctx, "group.1@mail.com")
+		assert.NoError(t, err)
+		assert.Equal(t, model.GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group.1@mail.com").Build(), got)
+	})
+
+	t.Run("Should return the group by name", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ds := mocks.NewMockGoogleProviderService(mockCtrl)
+		ds.EXPECT().ListGroups(ctx, []string{"name:'Group \\'1\\''"}).Return([]*admin.Group{
+			{Id: "2", Name: "group '1'", Email: "group.2@mail.com"},
+			{Id: "1", Name: "Group '1'", Email: "group.1@mail.com"},
+		}, nil).Times(1)
+
+		svc, err := NewIdentityProvider(ds)
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroup(ctx, "Group '1'")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", got.IPID)
+	})
+
+	t.Run("Should return the organizational unit group by name", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ds := mocks.NewMockGoogleProviderService(mockCtrl)
+
+		svc, err := NewIdentityProvider(ds, WithOrgUnitGroups([]OrgUnitGroup{{Name: "engineering", OrgUnitPath: "/Engineering"}}))
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroup(ctx, "engineering")
+		assert.NoError(t, err)
+		assert.Equal(t, orgUnitGroupIPID("/Engineering"), got.IPID)
+		assert.Empty(t, got.Email)
+	})
+
+	t.Run("Should return an error when there is no group with the name", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ds := mocks.NewMockGoogleProviderService(mockCtrl)
+		ds.EXPECT().ListGroups(ctx, gomock.Any()).Return([]*admin.Group{}, nil).Times(1)
+
+		svc, err := NewIdentityProvider(ds)
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroup(ctx, "group 1")
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+		assert.Nil(t, got)
+
+		got, err = svc.GetGroup(ctx, "")
+		assert.ErrorIs(t, err, ErrGroupIDNil)
+		assert.Nil(t, got)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should return the user", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ds := mocks.NewMockGoogleProviderService(mockCtrl)
+		ds.EXPECT().GetUser(ctx, "user.1@mail.com").Return(
+			&admin.User{Id: "1", PrimaryEmail: "user.1@mail.com", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}}, nil,
+		).Times(1)
+
+		svc, err := NewIdentityProvider(ds)
+		assert.NoError(t, err)
+
+		got, err := svc.GetUser(ctx, "user.1@mail.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", got.IPID)
+		assert.Equal(t, "user 1", got.DisplayName)
+		assert.True(t, got.Active)
+	})
+
+	t.Run("Should return nil when the user is not synced", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ds := mocks.NewMockGoogleProviderService(mockCtrl)
+		ds.EXPECT().GetUser(ctx, "user.1@mail.com").Return(
+			&admin.User{Id: "1", PrimaryEmail: "user.1@mail.com", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}, Suspended: true}, nil,
+		).Times(1)
+
+		svc, err := NewIdentityProvider(ds)
+		assert.NoError(t, err)
+
+		got, err := svc.GetUser(ctx, "user.1@mail.com")
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should return an error when the user cannot be got", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ds := mocks.NewMockGoogleProviderService(mockCtrl)
+		ds.EXPECT().GetUser(ctx, "user.1@mail.com").Return(nil, errors.New("test error")).Times(1)
+
+		svc, err := NewIdentityProvider(ds)
+		assert.NoError(t, err)
+
+		_, err = svc.GetUser(ctx, "user.1@mail.com")
+		assert.Error(t, err)
+
+		_, err = svc.GetUser(ctx, "")
+		assert.ErrorIs(t, err, ErrUserEmailNil)
+	})
+}
@@ -0,0 +1,284 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+)
+
+// This implement core.IdentityProviderService interface
+
+var (
+	// ErrIdentityProvidersEmpty is returned when no identity provider is given to the MultiIdentityProvider.
+	ErrIdentityProvidersEmpty = errors.New("provider: identity providers are empty")
+
+	// ErrGroupOwnerNotFound is returned when the tenant of a group is unknown, GetGroups must be called first.
+	ErrGroupOwnerNotFound = errors.New("provider: group tenant not found")
+)
+
+// MultiIdentityProvider is the Identity Provider service that merges the groups and users of several
+// Google Workspace tenants (customers or domains), each one read through its own IdentityProvider.
+//
+// The first tenant wins when a group name, group email or user email exists in more than one tenant,
+// these conflicts are reported and the conflicting resources of the other tenants are avoided.
+type MultiIdentityProvider struct {
+	providers []*IdentityProvider
+
+	// groupOwners is the tenant of each group returned by GetGroups, indexed by the group IPID.
+	groupOwners map[string]*IdentityProvider
+}
+
+// NewMultiIdentityProvider returns a new instance of the Identity Provider service merging the given tenants.
+func NewMultiIdentityProvider(providers ...*IdentityProvider) (*MultiIdentityProvider, error) {
+	if len(providers) == 0 {
+		return nil, ErrIdentityProvidersEmpty
+	}
+
+	for _, p := range providers {
+		if p == nil {
+			return nil, ErrDirectoryServiceNil
+		}
+	}
+
+	return &MultiIdentityProvider{
+		providers:   providers,
+		groupOwners: make(map[string]*IdentityProvider),
+	}, nil
+}
+
+// Check checks all the tenants are reachable with their credentials.
+func (m *MultiIdentityProvider) Check(ctx context.Context) error {
+	for idx, p := range m.providers {
+		if err := p.Check(ctx); err != nil {
+			return fmt.Errorf("idp: error checking tenant %d: %w", idx, err)
+		}
+	}
+
+	return nil
+}
+
+// GetGroups returns the groups of all the tenants.
+//
+// This method checks the names and emails of the groups and avoid the groups of the next tenants
+// when they are already in a previous one.
+func (m *MultiIdentityProvider) GetGroups(ctx context.Context, filter []string) (*model.GroupsResult, error) {
+	names := make(map[string]int)
+	emails := make(map[string]int)
+	syncGroups := make([]*model.Group, 0)
+
+	m.groupOwners = make(map[string]*IdentityProvider)
+
+	for idx, p := range m.providers {
+		gr, err := p.GetGroups(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting groups of tenant %d: %w", idx, err)
+		}
+
+		for _, grp := range gr.Resources {
+			if owner, ok := names[grp.Name]; ok {
+				log.WithFields(log.Fields{
+					"name":        grp.Name,
+					"email":       grp.Email,
+					"tenant":      idx,
+					"firstTenant": owner,
+				}).Warn("idp: group name conflict between tenants, this group will be avoided")
+				continue
+			}
+
+			if owner, ok := emails[grp.Email]; ok && grp.Email != "" {
+				log.WithFields(log.Fields{
+					"name":        grp.Name,
+					"email":       grp.Email,
+					"tenant":      idx,
+					"firstTenant": owner,
+				}).Warn("idp: group email conflict between tenants, this group will be avoided")
+				continue
+			}
+
+			if _, ok := m.groupOwners[grp.IPID]; ok {
+				log.WithFields(log.Fields{
+					"id":     grp.IPID,
+					"name":   grp.Name,
+					"tenant": idx,
+				}).Warn("idp: group id conflict between tenants, this group will be avoided")
+				continue
+			}
+
+			names[grp.Name] = idx
+			emails[grp.Email] = idx
+			m.groupOwners[grp.IPID] = p
+
+			syncGroups = append(syncGroups, grp)
+		}
+	}
+
+	return model.GroupsResultBuilder().WithResources(syncGroups).Build(), nil
+}
+
+// GetGroup returns the group of the first tenant that has it, given its email or its name.
+func (m *MultiIdentityProvider) GetGroup(ctx context.Context, group string) (*model.Group, error) {
+	if group == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	var err error
+	for idx, p := range m.providers {
+		var grp *model.Group
+		if grp, err = p.GetGroup(ctx, group); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"group":  group,
+				"tenant": idx,
+			}).Debug("idp: group not got from the tenant")
+			continue
+		}
+
+		m.groupOwners[grp.IPID] = p
+		return grp, nil
+	}
+
+	return nil, fmt.Errorf("idp: error getting group %s from the tenants: %w", group, err)
+}
+
+// GetUser returns the user of the first tenant that has it, given its email,
+// nil when the user is not synced, i.e. suspended or archived and dropped, or excluded by the rules.
+func (m *MultiIdentityProvider) GetUser(ctx context.Context, email string) (*model.User, error) {
+	if email == "" {
+		return nil, ErrUserEmailNil
+	}
+
+	var err error
+	for idx, p := range m.providers {
+		var usr *model.User
+		if usr, err = p.GetUser(ctx, email); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"email":  email,
+				"tenant": idx,
+			}).Debug("idp: user not got from the tenant")
+			continue
+		}
+
+		return usr, nil
+	}
+
+	return nil, fmt.Errorf("idp: error getting user %s from the tenants: %w", email, err)
+}
+
+// GetUsers returns the users of all the tenants.
+//
+// The users of the next tenants are avoided when their emails are already in a previous one.
+func (m *MultiIdentityProvider) GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error) {
+	merged := model.UsersResultBuilder().Build()
+
+	for idx, p := range m.providers {
+		ur, err := p.GetUsers(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting users of tenant %d: %w", idx, err)
+		}
+
+		merged = mergeTenantUsers(merged, ur, idx)
+	}
+
+	return merged, nil
+}
+
+// GetGroupMembers returns the members of the given group from its tenant.
+func (m *MultiIdentityProvider) GetGroupMembers(ctx context.Context, groupID string) (*model.MembersResult, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	owner, ok := m.groupOwners[groupID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrGroupOwnerNotFound, groupID)
+	}
+
+	return owner.GetGroupMembers(ctx, groupID)
+}
+
+// GetGroupsMembers returns the members of the groups, each group is read from its tenant.
+func (m *MultiIdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error) {
+	if gr == nil {
+		return nil, ErrGroupResultNil
+	}
+
+	groupsMembers := make([]*model.GroupMembers, 0)
+
+	for _, group := range gr.Resources {
+		owner, ok := m.groupOwners[group.IPID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrGroupOwnerNotFound, group.Name)
+		}
+
+		gmr, err := owner.GetGroupsMembers(ctx, model.GroupsResultBuilder().WithResource(group).Build())
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group members: %w", err)
+		}
+
+		groupsMembers = append(groupsMembers, gmr.Resources...)
+	}
+
+	return model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build(), nil
+}
+
+// GetUsersByGroupsMembers returns the users of the groups members, each user is read from the tenant of its group.
+//
+// The users of the next tenants are avoided when their emails are already in a previous one.
+func (m *MultiIdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+	merged := model.UsersResultBuilder().Build()
+
+	for idx, p := range m.providers {
+		tenantGroupsMembers := make([]*model.GroupMembers, 0)
+
+		for _, groupMembers := range gmr.Resources {
+			if m.groupOwners[groupMembers.Group.IPID] == p {
+				tenantGroupsMembers = append(tenantGroupsMembers, groupMembers)
+			}
+		}
+
+		if len(tenantGroupsMembers) == 0 {
+			continue
+		}
+
+		ur, err := p.GetUsersByGroupsMembers(ctx, model.GroupsMembersResultBuilder().WithResources(tenantGroupsMembers).Build())
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting users of tenant %d: %w", idx, err)
+		}
+
+		merged = mergeTenantUsers(merged, ur, idx)
+	}
+
+	// the tenants could remove excluded members from the groups members
+	gmr.SetHashCode()
+
+	return merged, nil
+}
+
+// mergeTenantUsers adds the users of a tenant to the merged ones, avoiding the users whose emails already exist.
+func mergeTenantUsers(merged, tenant *model.UsersResult, idx int) *model.UsersResult {
+	users := make(map[string]*model.User)
+	for _, usr := range merged.Resources {
+		users[usr.Email] = usr
+	}
+
+	resources := merged.Resources
+
+	for _, usr := range tenant.Resources {
+		if existing, ok := users[usr.Email]; ok {
+			if existing.IPID != usr.IPID {
+				log.WithFields(log.Fields{
+					"email":  usr.Email,
+					"tenant": idx,
+				}).Warn("idp: user email conflict between tenants, this user will be avoided")
+			}
+			continue
+		}
+
+		users[usr.Email] = usr
+		resources = append(resources, usr)
+	}
+
+	return model.UsersResultBuilder().WithResources(resources).Build()
+}
@@ -0,0 +1,192 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestNewMultiIdentityProvider(t *testing.T) {
+	t.Run("Should return an error when no providers are given", func(t *testing.T) {
+		svc, err := NewMultiIdentityProvider()
+		assert.ErrorIs(t, err, ErrIdentityProvidersEmpty)
+		assert.Nil(t, svc)
+	})
+
+	t.Run("Should return an error when a provider is nil", func(t *testing.T) {
+		svc, err := NewMultiIdentityProvider(nil)
+		assert.ErrorIs(t, err, ErrDirectoryServiceNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestMultiIdentityProvider(t *testing.T) {
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockDS1 := mocks.NewMockGoogleProviderService(mockCtrl)
+	mockDS2 := mocks.NewMockGoogleProviderService(mockCtrl)
+
+	tenant1, err := NewIdentityProvider(mockDS1)
+	assert.NoError(t, err)
+	tenant2, err := NewIdentityProvider(mockDS2)
+	assert.NoError(t, err)
+
+	svc, err := NewMultiIdentityProvider(tenant1, tenant2)
+	assert.NoError(t, err)
+
+	t.Run("Should return an error when the group tenant is unknown", func(t *testing.T) {
+		got, err := svc.GetGroupMembers(ctx, "1")
+		assert.ErrorIs(t, err, ErrGroupOwnerNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should merge the groups and avoid the conflicting ones", func(t *testing.T) {
+		mockDS1.EXPECT().ListGroups(ctx, gomock.Eq([]string{""})).Return([]*admin.Group{
+			{Id: "1", Name: "group 1", Email: "group.1@tenant1.com"},
+			{Id: "2", Name: "group 2", Email: "group.2@tenant1.com"},
+		}, nil).Times(1)
+		mockDS2.EXPECT().ListGroups(ctx, gomock.Eq([]string{""})).Return([]*admin.Group{
+			{Id: "3", Name: "group 1", Email: "group.1@tenant2.com"}, // name conflict
+			{Id: "4", Name: "group 4", Email: "group.2@tenant1.com"}, // email conflict
+			{Id: "5", Name: "group 5", Email: "group.5@tenant2.com"},
+		}, nil).Times(1)
+
+		got, err := svc.GetGroups(ctx, []string{""})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, got.Items)
+		assert.Equal(t, "group 1", got.Resources[0].Name)
+		assert.Equal(t, "1", got.Resources[0].IPID)
+		assert.Equal(t, "group 2", got.Resources[1].Name)
+		assert.Equal(t, "group 5", got.Resources[2].Name)
+		assert.NotEmpty(t, got.HashCode)
+	})
+
+	t.Run("Should read the members and users from the tenant of each group", func(t *testing.T) {
+		gr := &model.GroupsResult{Items: 2, Resources: []*model.Group{
+			{IPID: "1", Name: "group 1", Email: "group.1@tenant1.com"},
+			{IPID: "5", Name: "group 5", Email: "group.5@tenant2.com"},
+		}}
+
+		mockDS1.EXPECT().ListGroupMembers(ctx, "1", gomock.Any()).Return([]*admin.Member{
+			{Id: "u1", Email: "user.1@tenant1.com", Status: "ACTIVE"},
+		}, nil).Times(1)
+		mockDS2.EXPECT().ListGroupMembers(ctx, "5", gomock.Any()).Return([]*admin.Member{
+			{Id: "u2", Email: "user.2@tenant2.com", Status: "ACTIVE"},
+			{Id: "u3", Email: "user.1@tenant1.com", Status: "ACTIVE"},
+		}, nil).Times(1)
+
+		gmr, err := svc.GetGroupsMembers(ctx, gr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, gmr.Items)
+		assert.Equal(t, 1, gmr.Resources[0].Items)
+		assert.Equal(t, 2, gmr.Resources[1].Items)
+
+		mockDS1.EXPECT().GetUser(ctx, "user.1@tenant1.com").Return(&admin.User{Id: "u1", PrimaryEmail: "user.1@tenant1.com", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}}, nil).Times(1)
+		gomock.InOrder(
+			mockDS2.EXPECT().GetUser(ctx, "user.2@tenant2.com").Return(&admin.User{Id: "u2", PrimaryEmail: "user.2@tenant2.com", Name: &admin.UserName{GivenName: "user", FamilyName: "2"}}, nil).Times(1),
+			mockDS2.EXPECT().GetUser(ctx, "user.1@tenant1.com").Return(&admin.User{Id: "u3", PrimaryEmail: "user.1@tenant1.com", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}}, nil).Times(1),
+		)
+
+		got, err := svc.GetUsersByGroupsMembers(ctx, gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+		assert.Equal(t, "u1", got.Resources[0].IPID)
+		assert.Equal(t, "u2", got.Resources[1].IPID)
+	})
+}
+
+func TestMultiIdentityProvider_Check(t *testing.T) {
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockDS1 := mocks.NewMockGoogleProviderService(mockCtrl)
+	mockDS2 := mocks.NewMockGoogleProviderService(mockCtrl)
+
+	tenant1, err := NewIdentityProvider(mockDS1)
+	assert.NoError(t, err)
+	tenant2, err := NewIdentityProvider(mockDS2)
+	assert.NoError(t, err)
+
+	svc, err := NewMultiIdentityProvider(tenant1, tenant2)
+	assert.NoError(t, err)
+
+	t.Run("Should check all the tenants", func(t *testing.T) {
+		mockDS1.EXPECT().CheckAuth(ctx).Return(nil).Times(1)
+		mockDS2.EXPECT().CheckAuth(ctx).Return(nil).Times(1)
+
+		assert.NoError(t, svc.Check(ctx))
+	})
+
+	t.Run("Should return the error of the failing tenant", func(t *testing.T) {
+		mockDS1.EXPECT().CheckAuth(ctx).Return(nil).Times(1)
+		mockDS2.EXPECT().CheckAuth(ctx).Return(errors.New("test error")).Times(1)
+
+		err := svc.Check(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "tenant 1")
+	})
+}
+
+func TestMultiIdentityProvider_GetGroupAndGetUser(t *testing.T) {
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockDS1 := mocks.NewMockGoogleProviderService(mockCtrl)
+	mockDS2 := mocks.NewMockGoogleProviderService(mockCtrl)
+
+	tenant1, err := NewIdentityProvider(mockDS1)
+	assert.NoError(t, err)
+	tenant2, err := NewIdentityProvider(mockDS2)
+	assert.NoError(t, err)
+
+	svc, err := NewMultiIdentityProvider(tenant1, tenant2)
+	assert.NoError(t, err)
+
+	t.Run("Should return the group of the tenant that has it and read its members from it", func(t *testing.T) {
+		mockDS1.EXPECT().GetGroup(ctx, "group.5@tenant2.com").Return(nil, errors.New("not found")).Times(1)
+		mockDS2.EXPECT().GetGroup(ctx, "group.5@tenant2.com").Return(&admin.Group{Id: "5", Name: "group 5", Email: "group.5@tenant2.com"}, nil).Times(1)
+
+		got, err := svc.GetGroup(ctx, "group.5@tenant2.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "5", got.IPID)
+
+		mockDS2.EXPECT().ListGroupMembers(ctx, "5", gomock.Any()).Return([]*admin.Member{}, nil).Times(1)
+
+		members, err := svc.GetGroupMembers(ctx, "5")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, members.Items)
+	})
+
+	t.Run("Should return an error when no tenant has the group", func(t *testing.T) {
+		mockDS1.EXPECT().GetGroup(ctx, "group.6@tenant2.com").Return(nil, errors.New("not found")).Times(1)
+		mockDS2.EXPECT().GetGroup(ctx, "group.6@tenant2.com").Return(nil, errors.New("not found")).Times(1)
+
+		got, err := svc.GetGroup(ctx, "group.6@tenant2.com")
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should return the user of the first tenant that has it", func(t *testing.T) {
+		mockDS1.EXPECT().GetUser(ctx, "user.2@tenant2.com").Return(nil, errors.New("not found")).Times(1)
+		mockDS2.EXPECT().GetUser(ctx, "user.2@tenant2.com").Return(
+			&admin.User{Id: "u2", PrimaryEmail: "user.2@tenant2.com", Name: &admin.UserName{GivenName: "user", FamilyName: "2"}}, nil,
+		).Times(1)
+
+		got, err := svc.GetUser(ctx, "user.2@tenant2.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "u2", got.IPID)
+	})
+}
@@ -0,0 +1,39 @@
+package idp
+
+// IdentityProviderOption is a function that can be used to configure the IdentityProvider
+// following the Option pattern.
+type IdentityProviderOption func(*IdentityProvider)
+
+// WithInactiveUsersPolicy is an IdentityProviderOption that can be used to define
+// what happens with the suspended and archived users and the non ACTIVE group members.
+// possible values: InactiveUsersPolicyDrop, InactiveUsersPolicyKeepInactive
+// and InactiveUsersPolicyKeepActive.
+func WithInactiveUsersPolicy(policy string) IdentityProviderOption {
+	return func(i *IdentityProvider) {
+		i.inactiveUsersPolicy = policy
+	}
+}
+
+// WithUserIncludeRules is an IdentityProviderOption that can be used to define the rules
+// a user must match (any of them) to be synced.
+func WithUserIncludeRules(rules []UserRule) IdentityProviderOption {
+	return func(i *IdentityProvider) {
+		i.userIncludeRules = rules
+	}
+}
+
+// WithUserExcludeRules is an IdentityProviderOption that can be used to define the rules
+// that exclude a user from the sync, even when it is member of a synced group.
+func WithUserExcludeRules(rules []UserRule) IdentityProviderOption {
+	return func(i *IdentityProvider) {
+		i.userExcludeRules = rules
+	}
+}
+
+// WithOrgUnitGroups is an IdentityProviderOption that can be used to sync organizational units
+// as synthetic groups, the members of these groups are the users of the organizational units.
+func WithOrgUnitGroups(groups []OrgUnitGroup) IdentityProviderOption {
+	return func(i *IdentityProvider) {
+		i.orgUnitGroups = groups
+	}
+}
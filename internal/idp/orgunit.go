@@ -0,0 +1,90 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// orgUnitGroupIPIDPrefix is the prefix of the IPID of the synthetic groups created from organizational units,
+// this is used to distinguish them from the Google Groups IDs.
+const orgUnitGroupIPIDPrefix = "orgunit:"
+
+// ErrOrgUnitGroupInvalid is returned when an organizational unit group is not valid.
+var ErrOrgUnitGroupInvalid = errors.New("provider: organizational unit group is not valid, expected [name=]/org/unit/path")
+
+// OrgUnitGroup represents an organizational unit synced as a synthetic group,
+// the members of the group are the users of the organizational unit and its sub organizational units.
+type OrgUnitGroup struct {
+	// Name is the name of the group in the SCIM side.
+	Name string
+
+	// OrgUnitPath is the full path of the organizational unit, e.g. "/Engineering/Backend".
+	OrgUnitPath string
+}
+
+// ParseOrgUnitGroup parses an organizational unit group in the form "name=/org/unit/path" or "/org/unit/path",
+// when the name is not provided, the organizational unit path is used as the group name.
+func ParseOrgUnitGroup(s string) (OrgUnitGroup, error) {
+	name, orgUnitPath, ok := strings.Cut(s, "=")
+	if !ok {
+		name, orgUnitPath = s, s
+	}
+
+	name = strings.TrimSpace(name)
+	orgUnitPath = strings.TrimSpace(orgUnitPath)
+
+	if name == "" || !strings.HasPrefix(orgUnitPath, "/") {
+		return OrgUnitGroup{}, fmt.Errorf("%w: %s", ErrOrgUnitGroupInvalid, s)
+	}
+
+	return OrgUnitGroup{Name: name, OrgUnitPath: orgUnitPath}, nil
+}
+
+// orgUnitGroupIPID returns the IPID of the synthetic group of an organizational unit.
+func orgUnitGroupIPID(orgUnitPath string) string {
+	return orgUnitGroupIPIDPrefix + orgUnitPath
+}
+
+// orgUnitPathFromIPID returns the organizational unit path of a synthetic group IPID.
+func orgUnitPathFromIPID(ipid string) (string, bool) {
+	if !strings.HasPrefix(ipid, orgUnitGroupIPIDPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(ipid, orgUnitGroupIPIDPrefix), true
+}
+
+// listOrgUnitMembers returns the users of an organizational unit, and its sub organizational units, as group members.
+// the suspended and archived users are returned with the corresponding status, the same way Google does for the groups.
+func (i *IdentityProvider) listOrgUnitMembers(ctx context.Context, orgUnitPath string) ([]*admin.Member, error) {
+	pUsers, err := i.ps.ListUsers(ctx, nil, google.WithOrgUnitPath(orgUnitPath))
+	if err != nil {
+		return nil, fmt.Errorf("idp: error listing organizational unit users: %s, %w", orgUnitPath, err)
+	}
+
+	members := make([]*admin.Member, 0, len(pUsers))
+
+	for _, usr := range pUsers {
+		status := memberStatusActive
+		switch {
+		case usr.Archived:
+			status = "ARCHIVED"
+		case usr.Suspended:
+			status = "SUSPENDED"
+		}
+
+		members = append(members, &admin.Member{
+			Id:     usr.Id,
+			Email:  usr.PrimaryEmail,
+			Status: status,
+			Type:   "USER",
+		})
+	}
+
+	return members, nil
+}
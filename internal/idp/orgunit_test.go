@@ -0,0 +1,89 @@
+package idp
+
+import (
+	"context"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestParseOrgUnitGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    OrgUnitGroup
+		wantErr bool
+	}{
+		{name: "Should use the path as name", s: "/Engineering", want: OrgUnitGroup{Name: "/Engineering", OrgUnitPath: "/Engineering"}},
+		{name: "Should use the given name", s: "Backend=/Engineering/Backend", want: OrgUnitGroup{Name: "Backend", OrgUnitPath: "/Engineering/Backend"}},
+		{name: "Should return error when the path is not absolute", s: "Backend=Engineering", wantErr: true},
+		{name: "Should return error when the name is empty", s: "=/Engineering", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOrgUnitGroup(tt.s)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrOrgUnitGroupInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOrgUnitGroups(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should add the organizational units as groups", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		googleGroups := []*admin.Group{
+			{Email: "group1@mail.com", Id: "1", Name: "group 1"},
+		}
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroups(ctx, gomock.Eq([]string{""})).Return(googleGroups, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithOrgUnitGroups([]OrgUnitGroup{
+			{Name: "Backend", OrgUnitPath: "/Engineering/Backend"},
+			{Name: "group 1", OrgUnitPath: "/Sales"},
+		}))
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroups(ctx, []string{""})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+		assert.Equal(t, "1", got.Resources[0].IPID)
+		assert.Equal(t, "orgunit:/Engineering/Backend", got.Resources[1].IPID)
+		assert.Equal(t, "Backend", got.Resources[1].Name)
+	})
+
+	t.Run("Should return the organizational unit users as members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		googleUsers := []*admin.User{
+			{PrimaryEmail: "user.1@mail.com", Id: "1"},
+			{PrimaryEmail: "user.2@mail.com", Id: "2", Suspended: true},
+		}
+
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListUsers(ctx, gomock.Nil(), gomock.Any()).Return(googleUsers, nil).Times(1)
+
+		svc, err := NewIdentityProvider(mockDS, WithInactiveUsersPolicy(InactiveUsersPolicyKeepInactive))
+		assert.NoError(t, err)
+
+		got, err := svc.GetGroupMembers(ctx, "orgunit:/Engineering")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+		assert.Equal(t, "user.1@mail.com", got.Resources[0].Email)
+		assert.Equal(t, "ACTIVE", got.Resources[0].Status)
+		assert.Equal(t, "SUSPENDED", got.Resources[1].Status)
+	})
+}
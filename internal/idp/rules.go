@@ -0,0 +1,193 @@
+package idp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+var (
+	// ErrUserRuleEmpty is returned when a user rule doesn't have any condition.
+	ErrUserRuleEmpty = errors.New("provider: user rule is empty")
+
+	// ErrUserRuleEmailInvalid is returned when the email glob pattern of a user rule is not valid.
+	ErrUserRuleEmailInvalid = errors.New("provider: user rule email pattern is not valid")
+
+	// ErrUserRuleCustomAttributeInvalid is returned when the custom attribute of a user rule is not in the form "schema.field=value".
+	ErrUserRuleCustomAttributeInvalid = errors.New("provider: user rule custom attribute is not valid, expected schema.field=value")
+)
+
+// UserRule represents a rule used to include or exclude users from the sync.
+// All the non empty fields of the rule must match the user for the rule to match.
+type UserRule struct {
+	// Email is a glob pattern matched against the user primary email, e.g. "break-glass-*@mydomain.com".
+	Email string
+
+	// Domain is matched against the domain of the user primary email, e.g. "mydomain.com".
+	Domain string
+
+	// OrgUnitPath is matched against the user organizational unit path, including its sub organizational units, e.g. "/Contractors".
+	OrgUnitPath string
+
+	// CustomAttribute is a Google custom attribute expression in the form "schema.field=value", e.g. "AWS.Provision=false".
+	CustomAttribute string
+}
+
+// String returns a human readable representation of the rule.
+func (r UserRule) String() string {
+	conditions := make([]string, 0)
+
+	if r.Email != "" {
+		conditions = append(conditions, fmt.Sprintf("email=%s", r.Email))
+	}
+	if r.Domain != "" {
+		conditions = append(conditions, fmt.Sprintf("domain=%s", r.Domain))
+	}
+	if r.OrgUnitPath != "" {
+		conditions = append(conditions, fmt.Sprintf("orgUnitPath=%s", r.OrgUnitPath))
+	}
+	if r.CustomAttribute != "" {
+		conditions = append(conditions, fmt.Sprintf("customAttribute=%s", r.CustomAttribute))
+	}
+
+	return strings.Join(conditions, ",")
+}
+
+// Validate checks the rule has at least one condition and all of them are well formed.
+func (r UserRule) Validate() error {
+	if r.Email == "" && r.Domain == "" && r.OrgUnitPath == "" && r.CustomAttribute == "" {
+		return ErrUserRuleEmpty
+	}
+
+	if r.Email != "" {
+		if _, err := path.Match(r.Email, ""); err != nil {
+			return fmt.Errorf("%w: %s", ErrUserRuleEmailInvalid, r.Email)
+		}
+	}
+
+	if r.CustomAttribute != "" {
+		if _, _, _, err := parseCustomAttribute(r.CustomAttribute); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match returns true when all the conditions of the rule match the user.
+func (r UserRule) Match(usr *admin.User) bool {
+	email := strings.ToLower(usr.PrimaryEmail)
+
+	if r.Email != "" {
+		if ok, _ := path.Match(strings.ToLower(r.Email), email); !ok {
+			return false
+		}
+	}
+
+	if r.Domain != "" {
+		at := strings.LastIndex(email, "@")
+		if at < 0 || !strings.EqualFold(email[at+1:], r.Domain) {
+			return false
+		}
+	}
+
+	if r.OrgUnitPath != "" && !matchOrgUnitPath(r.OrgUnitPath, usr.OrgUnitPath) {
+		return false
+	}
+
+	if r.CustomAttribute != "" && !matchCustomAttribute(r.CustomAttribute, usr) {
+		return false
+	}
+
+	return true
+}
+
+// matchOrgUnitPath returns true when the user organizational unit path is the rule one or one of its sub organizational units.
+func matchOrgUnitPath(rule, orgUnitPath string) bool {
+	rule = strings.TrimSuffix(rule, "/")
+	if rule == "" {
+		return true
+	}
+
+	return strings.EqualFold(orgUnitPath, rule) || strings.HasPrefix(strings.ToLower(orgUnitPath), strings.ToLower(rule)+"/")
+}
+
+// parseCustomAttribute splits a custom attribute expression in the form "schema.field=value".
+func parseCustomAttribute(expr string) (schema, field, value string, err error) {
+	attr, value, ok := strings.Cut(expr, "=")
+	if !ok {
+		return "", "", "", fmt.Errorf("%w: %s", ErrUserRuleCustomAttributeInvalid, expr)
+	}
+
+	schema, field, ok = strings.Cut(attr, ".")
+	if !ok || schema == "" || field == "" {
+		return "", "", "", fmt.Errorf("%w: %s", ErrUserRuleCustomAttributeInvalid, expr)
+	}
+
+	return schema, field, value, nil
+}
+
+// matchCustomAttribute returns true when the user custom attribute has the value of the expression.
+// multi-valued custom attributes match when any of their values is the expected one.
+func matchCustomAttribute(expr string, usr *admin.User) bool {
+	schema, field, value, err := parseCustomAttribute(expr)
+	if err != nil {
+		return false
+	}
+
+	raw, ok := usr.CustomSchemas[schema]
+	if !ok {
+		return false
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false
+	}
+
+	fieldValue, ok := fields[field]
+	if !ok {
+		return false
+	}
+
+	switch v := fieldValue.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				item = m["value"]
+			}
+			if fmt.Sprint(item) == value {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(v) == value
+	}
+}
+
+// excludedByRules returns the reason why the user is excluded by the include and exclude rules.
+// a user is excluded when it matches any exclude rule or when there are include rules and it doesn't match any of them.
+func (i *IdentityProvider) excludedByRules(usr *admin.User) (string, bool) {
+	for _, rule := range i.userExcludeRules {
+		if rule.Match(usr) {
+			return fmt.Sprintf("matches exclude rule: %s", rule), true
+		}
+	}
+
+	if len(i.userIncludeRules) == 0 {
+		return "", false
+	}
+
+	for _, rule := range i.userIncludeRules {
+		if rule.Match(usr) {
+			return "", false
+		}
+	}
+
+	return "doesn't match any include rule", true
+}
@@ -0,0 +1,120 @@
+package idp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestUserRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    UserRule
+		wantErr error
+	}{
+		{name: "Should return error when the rule is empty", rule: UserRule{}, wantErr: ErrUserRuleEmpty},
+		{name: "Should return error when the email pattern is invalid", rule: UserRule{Email: "[admin*"}, wantErr: ErrUserRuleEmailInvalid},
+		{name: "Should return error when the custom attribute has no value", rule: UserRule{CustomAttribute: "AWS.Provision"}, wantErr: ErrUserRuleCustomAttributeInvalid},
+		{name: "Should return error when the custom attribute has no field", rule: UserRule{CustomAttribute: "AWS=false"}, wantErr: ErrUserRuleCustomAttributeInvalid},
+		{name: "Should return no error when the rule is valid", rule: UserRule{Email: "admin-*@mail.com", Domain: "mail.com", OrgUnitPath: "/IT", CustomAttribute: "AWS.Provision=false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserRuleMatch(t *testing.T) {
+	usr := &admin.User{
+		PrimaryEmail: "Break-Glass.1@Mail.com",
+		OrgUnitPath:  "/IT/Admins",
+		CustomSchemas: map[string]googleapi.RawMessage{
+			"AWS":     googleapi.RawMessage(`{"Provision":false,"Teams":[{"type":"work","value":"platform"},{"type":"work","value":"security"}]}`),
+			"Finance": googleapi.RawMessage(`{"CostCenter":"1234"}`),
+		},
+	}
+
+	tests := []struct {
+		name string
+		rule UserRule
+		want bool
+	}{
+		{name: "Should match the email glob case insensitive", rule: UserRule{Email: "break-glass*@mail.com"}, want: true},
+		{name: "Should not match the email glob", rule: UserRule{Email: "admin*@mail.com"}, want: false},
+		{name: "Should match the domain", rule: UserRule{Domain: "mail.com"}, want: true},
+		{name: "Should not match the domain", rule: UserRule{Domain: "external.com"}, want: false},
+		{name: "Should match the parent org unit path", rule: UserRule{OrgUnitPath: "/IT"}, want: true},
+		{name: "Should match the same org unit path", rule: UserRule{OrgUnitPath: "/IT/Admins/"}, want: true},
+		{name: "Should not match a similar org unit path", rule: UserRule{OrgUnitPath: "/IT/Adm"}, want: false},
+		{name: "Should match the custom attribute", rule: UserRule{CustomAttribute: "AWS.Provision=false"}, want: true},
+		{name: "Should match the multi-valued custom attribute", rule: UserRule{CustomAttribute: "AWS.Teams=security"}, want: true},
+		{name: "Should not match the custom attribute value", rule: UserRule{CustomAttribute: "Finance.CostCenter=9999"}, want: false},
+		{name: "Should not match the custom attribute schema", rule: UserRule{CustomAttribute: "HR.Type=guest"}, want: false},
+		{name: "Should match when all the conditions match", rule: UserRule{Domain: "mail.com", OrgUnitPath: "/IT"}, want: true},
+		{name: "Should not match when any condition doesn't match", rule: UserRule{Domain: "mail.com", OrgUnitPath: "/Sales"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rule.Match(usr))
+		})
+	}
+}
+
+func TestExcludedByRules(t *testing.T) {
+	usr1 := &admin.User{PrimaryEmail: "user.1@mail.com", OrgUnitPath: "/"}
+	usr2 := &admin.User{PrimaryEmail: "guest.2@external.com", OrgUnitPath: "/Guests"}
+	usr3 := &admin.User{PrimaryEmail: "break-glass@mail.com", OrgUnitPath: "/"}
+
+	t.Run("Should not exclude any user when there are no rules", func(t *testing.T) {
+		i := &IdentityProvider{}
+
+		for _, usr := range []*admin.User{usr1, usr2, usr3} {
+			_, excluded := i.excludedByRules(usr)
+			assert.False(t, excluded)
+		}
+	})
+
+	t.Run("Should exclude the users matching any exclude rule", func(t *testing.T) {
+		i := &IdentityProvider{
+			userExcludeRules: []UserRule{{Email: "break-glass@*"}, {OrgUnitPath: "/Guests"}},
+		}
+
+		_, excluded := i.excludedByRules(usr1)
+		assert.False(t, excluded)
+
+		reason, excluded := i.excludedByRules(usr2)
+		assert.True(t, excluded)
+		assert.Equal(t, "matches exclude rule: orgUnitPath=/Guests", reason)
+
+		reason, excluded = i.excludedByRules(usr3)
+		assert.True(t, excluded)
+		assert.Equal(t, "matches exclude rule: email=break-glass@*", reason)
+	})
+
+	t.Run("Should exclude the users not matching any include rule", func(t *testing.T) {
+		i := &IdentityProvider{
+			userIncludeRules: []UserRule{{Domain: "mail.com"}},
+			userExcludeRules: []UserRule{{Email: "break-glass@*"}},
+		}
+
+		_, excluded := i.excludedByRules(usr1)
+		assert.False(t, excluded)
+
+		reason, excluded := i.excludedByRules(usr2)
+		assert.True(t, excluded)
+		assert.Equal(t, "doesn't match any include rule", reason)
+
+		_, excluded = i.excludedByRules(usr3)
+		assert.True(t, excluded)
+	})
+}
@@ -0,0 +1,279 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EMF units
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_MetricDatum.html
+const (
+	emfUnitCount        = "Count"
+	emfUnitMilliseconds = "Milliseconds"
+	emfUnitNone         = "None"
+)
+
+// emfDocument is a CloudWatch Embedded Metric Format document, the metrics values and dimensions
+// are members of the root node together with the metadata.
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+type emfDocument map[string]interface{}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string          `json:"Namespace"`
+	Dimensions [][]string      `json:"Dimensions"`
+	Metrics    []emfMetricInfo `json:"Metrics"`
+}
+
+type emfMetricInfo struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// emfMetric is a metric of a document
+type emfMetric struct {
+	name  string
+	unit  string
+	value interface{}
+}
+
+// apiStats are the requests of an API since the last flush
+type apiStats struct {
+	requests  int
+	errors    int
+	throttles int
+	retries   int
+	duration  time.Duration
+}
+
+// EMFRecorder is a Recorder that writes the metrics as CloudWatch Embedded Metric Format (EMF) documents,
+// one JSON document per line, used when running as AWS Lambda where the logs lines are ingested by CloudWatch Logs.
+//
+// The metrics are accumulated until Flush is called, usually at the end of each sync.
+type EMFRecorder struct {
+	namespace string
+	w         io.Writer
+	now       func() time.Time
+
+	mu             sync.Mutex
+	syncs          []syncRun
+	operations     map[string]map[string]int
+	apis           map[string]*apiStats
+	tokenExpiresAt time.Time
+}
+
+type syncRun struct {
+	duration time.Duration
+	success  bool
+}
+
+// NewEMFRecorder returns a Recorder writing the EMF documents of the namespace to w.
+func NewEMFRecorder(namespace string, w io.Writer) *EMFRecorder {
+	e := &EMFRecorder{
+		namespace: namespace,
+		w:         w,
+		now:       time.Now,
+	}
+	e.reset()
+
+	return e
+}
+
+// SyncRun records a finished sync.
+func (e *EMFRecorder) SyncRun(duration time.Duration, success bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.syncs = append(e.syncs, syncRun{duration: duration, success: success})
+}
+
+// Operations records the number of resources of an entity in an operation of the last sync.
+func (e *EMFRecorder) Operations(entity, operation string, count int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.operations[entity]; !ok {
+		e.operations[entity] = make(map[string]int)
+	}
+	e.operations[entity][operation] = count
+}
+
+// APIRequest records a request to an API, the 429 responses are counted as throttles
+// and the failed requests and 5xx responses as errors.
+func (e *EMFRecorder) APIRequest(api, method, resource string, statusCode int, duration time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stats := e.api(api)
+	stats.requests++
+	stats.duration += duration
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		stats.throttles++
+	case statusCode == 0 || statusCode >= http.StatusInternalServerError:
+		stats.errors++
+	}
+}
+
+// APIRetry records a retry of a request to an API.
+func (e *EMFRecorder) APIRetry(api string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.api(api).retries++
+}
+
+// SCIMAccessTokenExpiry records the expiry time of the AWS SSO SCIM access token.
+func (e *EMFRecorder) SCIMAccessTokenExpiry(expiresAt time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.tokenExpiresAt = expiresAt
+}
+
+// Flush writes the EMF documents of the metrics recorded since the last flush and resets them.
+//
+// The documents are:
+//   - the syncs, without dimensions: SyncDuration, SyncSuccess, SyncFailure and SCIMAccessTokenDaysToExpiry.
+//   - the operations of each entity, with the Entity dimension: Create, Update, Delete, Equal, Deactivate, Retain and Rename.
+//   - the requests of each API, with the API dimension: Requests, Errors, Throttles, Retries and RequestsDuration.
+func (e *EMFRecorder) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.reset()
+
+	now := e.now()
+	docs := make([]emfDocument, 0)
+
+	for _, run := range e.syncs {
+		success, failure := 0, 1
+		if run.success {
+			success, failure = 1, 0
+		}
+
+		metrics := []emfMetric{
+			{name: "SyncDuration", unit: emfUnitMilliseconds, value: run.duration.Milliseconds()},
+			{name: "SyncSuccess", unit: emfUnitCount, value: success},
+			{name: "SyncFailure", unit: emfUnitCount, value: failure},
+		}
+
+		if !e.tokenExpiresAt.IsZero() {
+			days := int(e.tokenExpiresAt.Sub(now).Hours() / 24)
+			metrics = append(metrics, emfMetric{name: "SCIMAccessTokenDaysToExpiry", unit: emfUnitNone, value: days})
+		}
+
+		docs = append(docs, e.document(now, nil, metrics))
+	}
+
+	entities := make([]string, 0, len(e.operations))
+	for entity := range e.operations {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+
+	for _, entity := range entities {
+		ops := e.operations[entity]
+
+		metrics := make([]emfMetric, 0, len(ops))
+		for _, op := range []string{OperationCreate, OperationUpdate, OperationDelete, OperationEqual, OperationDeactivate, OperationRetain, OperationRename} {
+			if count, ok := ops[op]; ok {
+				metrics = append(metrics, emfMetric{name: emfName(op), unit: emfUnitCount, value: count})
+			}
+		}
+
+		docs = append(docs, e.document(now, map[string]string{"Entity": entity}, metrics))
+	}
+
+	apis := make([]string, 0, len(e.apis))
+	for api := range e.apis {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+
+	for _, api := range apis {
+		stats := e.apis[api]
+
+		docs = append(docs, e.document(now, map[string]string{"API": api}, []emfMetric{
+			{name: "Requests", unit: emfUnitCount, value: stats.requests},
+			{name: "Errors", unit: emfUnitCount, value: stats.errors},
+			{name: "Throttles", unit: emfUnitCount, value: stats.throttles},
+			{name: "Retries", unit: emfUnitCount, value: stats.retries},
+			{name: "RequestsDuration", unit: emfUnitMilliseconds, value: stats.duration.Milliseconds()},
+		}))
+	}
+
+	enc := json.NewEncoder(e.w)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("metrics: error writing emf document: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// document returns the EMF document of the metrics with the given dimensions.
+func (e *EMFRecorder) document(now time.Time, dimensions map[string]string, metrics []emfMetric) emfDocument {
+	doc := emfDocument{}
+
+	dimensionSet := make([]string, 0, len(dimensions))
+	for name, value := range dimensions {
+		dimensionSet = append(dimensionSet, name)
+		doc[name] = value
+	}
+	sort.Strings(dimensionSet)
+
+	infos := make([]emfMetricInfo, 0, len(metrics))
+	for _, m := range metrics {
+		infos = append(infos, emfMetricInfo{Name: m.name, Unit: m.unit})
+		doc[m.name] = m.value
+	}
+
+	doc["_aws"] = emfMetadata{
+		Timestamp: now.UnixMilli(),
+		CloudWatchMetrics: []emfMetricDirective{
+			{
+				Namespace:  e.namespace,
+				Dimensions: [][]string{dimensionSet},
+				Metrics:    infos,
+			},
+		},
+	}
+
+	return doc
+}
+
+func (e *EMFRecorder) api(api string) *apiStats {
+	if _, ok := e.apis[api]; !ok {
+		e.apis[api] = &apiStats{}
+	}
+
+	return e.apis[api]
+}
+
+func (e *EMFRecorder) reset() {
+	e.syncs = nil
+	e.operations = make(map[string]map[string]int)
+	e.apis = make(map[string]*apiStats)
+	e.tokenExpiresAt = time.Time{}
+}
+
+// emfName returns the metric name of the operation, e.g. Create for create.
+func emfName(operation string) string {
+	if operation == "" {
+		return operation
+	}
+
+	return strings.ToUpper(operation[:1]) + operation[1:]
+}
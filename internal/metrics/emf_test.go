@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEMFRecorder_Flush(t *testing.T) {
+	now := time.Date(2023, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should write the EMF documents of the sync", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := NewEMFRecorder("idp-scim-sync", &buf)
+		e.now = func() time.Time { return now }
+
+		e.SCIMAccessTokenExpiry(now.Add(20 * 24 * time.Hour))
+		e.APIRequest(APISCIM, http.MethodPost, "Users", http.StatusCreated, 100*time.Millisecond)
+		e.APIRequest(APISCIM, http.MethodGet, "Groups", http.StatusTooManyRequests, 50*time.Millisecond)
+		e.APIRequest(APISCIM, http.MethodGet, "Groups", http.StatusInternalServerError, 50*time.Millisecond)
+		e.APIRetry(APISCIM)
+		e.APIRetry(APISCIM)
+		e.APIRequest(APIGoogle, http.MethodGet, "users", 0, 200*time.Millisecond)
+
+		RecordSyncReport(e, &core.SyncReport{
+			Success:       true,
+			Groups:        core.OperationsReport{Create: 2, Equal: 3},
+			Users:         core.OperationsReport{Update: 1, Deactivate: 4},
+			GroupsMembers: core.OperationsReport{Delete: 5},
+		}, 1500*time.Millisecond)
+
+		assert.NoError(t, e.Flush())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, 6, len(lines))
+
+		assert.JSONEq(t, `{
+			"_aws": {"Timestamp": 1673344800000, "CloudWatchMetrics": [{
+				"Namespace": "idp-scim-sync",
+				"Dimensions": [[]],
+				"Metrics": [
+					{"Name": "SyncDuration", "Unit": "Milliseconds"},
+					{"Name": "SyncSuccess", "Unit": "Count"},
+					{"Name": "SyncFailure", "Unit": "Count"},
+					{"Name": "SCIMAccessTokenDaysToExpiry", "Unit": "None"}
+				]
+			}]},
+			"SyncDuration": 1500,
+			"SyncSuccess": 1,
+			"SyncFailure": 0,
+			"SCIMAccessTokenDaysToExpiry": 20
+		}`, lines[0])
+
+		assert.JSONEq(t, `{
+			"_aws": {"Timestamp": 1673344800000, "CloudWatchMetrics": [{
+				"Namespace": "idp-scim-sync",
+				"Dimensions": [["Entity"]],
+				"Metrics": [
+					{"Name": "Create", "Unit": "Count"},
+					{"Name": "Update", "Unit": "Count"},
+					{"Name": "Delete", "Unit": "Count"},
+					{"Name": "Equal", "Unit": "Count"},
+					{"Name": "Deactivate", "Unit": "Count"},
+					{"Name": "Retain", "Unit": "Count"},
+					{"Name": "Rename", "Unit": "Count"}
+				]
+			}]},
+			"Entity": "groups",
+			"Create": 2, "Update": 0, "Delete": 0, "Equal": 3, "Deactivate": 0, "Retain": 0, "Rename": 0
+		}`, lines[1])
+
+		assert.Contains(t, lines[2], `"Entity":"groups_members"`)
+		assert.Contains(t, lines[2], `"Delete":5`)
+		assert.Contains(t, lines[3], `"Entity":"users"`)
+		assert.Contains(t, lines[3], `"Deactivate":4`)
+
+		assert.JSONEq(t, `{
+			"_aws": {"Timestamp": 1673344800000, "CloudWatchMetrics": [{
+				"Namespace": "idp-scim-sync",
+				"Dimensions": [["API"]],
+				"Metrics": [
+					{"Name": "Requests", "Unit": "Count"},
+					{"Name": "Errors", "Unit": "Count"},
+					{"Name": "Throttles", "Unit": "Count"},
+					{"Name": "Retries", "Unit": "Count"},
+					{"Name": "RequestsDuration", "Unit": "Milliseconds"}
+				]
+			}]},
+			"API": "google",
+			"Requests": 1, "Errors": 1, "Throttles": 0, "Retries": 0, "RequestsDuration": 200
+		}`, lines[4])
+
+		assert.JSONEq(t, `{
+			"_aws": {"Timestamp": 1673344800000, "CloudWatchMetrics": [{
+				"Namespace": "idp-scim-sync",
+				"Dimensions": [["API"]],
+				"Metrics": [
+					{"Name": "Requests", "Unit": "Count"},
+					{"Name": "Errors", "Unit": "Count"},
+					{"Name": "Throttles", "Unit": "Count"},
+					{"Name": "Retries", "Unit": "Count"},
+					{"Name": "RequestsDuration", "Unit": "Milliseconds"}
+				]
+			}]},
+			"API": "scim",
+			"Requests": 3, "Errors": 1, "Throttles": 1, "Retries": 2, "RequestsDuration": 200
+		}`, lines[5])
+	})
+
+	t.Run("Should write the failed sync and reset the metrics", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := NewEMFRecorder("idp-scim-sync", &buf)
+		e.now = func() time.Time { return now }
+
+		e.SyncRun(time.Second, false)
+		assert.NoError(t, e.Flush())
+
+		assert.JSONEq(t, `{
+			"_aws": {"Timestamp": 1673344800000, "CloudWatchMetrics": [{
+				"Namespace": "idp-scim-sync",
+				"Dimensions": [[]],
+				"Metrics": [
+					{"Name": "SyncDuration", "Unit": "Milliseconds"},
+					{"Name": "SyncSuccess", "Unit": "Count"},
+					{"Name": "SyncFailure", "Unit": "Count"}
+				]
+			}]},
+			"SyncDuration": 1000,
+			"SyncSuccess": 0,
+			"SyncFailure": 1
+		}`, buf.String())
+
+		buf.Reset()
+		assert.NoError(t, e.Flush())
+		assert.Equal(t, "", buf.String())
+	})
+}
+
+func TestMultiRecorder(t *testing.T) {
+	t.Run("Should record the metrics in all the recorders", func(t *testing.T) {
+		var buf1, buf2 bytes.Buffer
+		e1 := NewEMFRecorder("ns1", &buf1)
+		e2 := NewEMFRecorder("ns2", &buf2)
+
+		m := MultiRecorder{e1, e2}
+		m.SyncRun(time.Second, true)
+
+		assert.NoError(t, e1.Flush())
+		assert.NoError(t, e2.Flush())
+		assert.Contains(t, buf1.String(), `"Namespace":"ns1"`)
+		assert.Contains(t, buf2.String(), `"Namespace":"ns2"`)
+	})
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+)
+
+const (
+	// APIGoogle is the name of the Google Workspace Directory API in the metrics.
+	APIGoogle = "google"
+
+	// APISCIM is the name of the AWS SSO SCIM API in the metrics.
+	APISCIM = "scim"
+
+	// EntityGroups, EntityUsers and EntityGroupsMembers are the entities of the sync operations.
+	EntityGroups        = "groups"
+	EntityUsers         = "users"
+	EntityGroupsMembers = "groups_members"
+
+	// OperationCreate, OperationUpdate, OperationDelete, OperationEqual, OperationDeactivate, OperationRetain
+	// and OperationRename are the operations of the sync on the entities.
+	OperationCreate     = "create"
+	OperationUpdate     = "update"
+	OperationDelete     = "delete"
+	OperationEqual      = "equal"
+	OperationDeactivate = "deactivate"
+	OperationRetain     = "retain"
+	OperationRename     = "rename"
+)
+
+// Recorder records the metrics of the syncs and of the requests to the APIs.
+type Recorder interface {
+	// SyncRun records a finished sync.
+	SyncRun(duration time.Duration, success bool)
+
+	// Operations records the number of resources of an entity in an operation of the last sync.
+	Operations(entity, operation string, count int)
+
+	// APIRequest records a request to an API, the status code is 0 when the request failed.
+	APIRequest(api, method, resource string, statusCode int, duration time.Duration)
+
+	// APIRetry records a retry of a request to an API.
+	APIRetry(api string)
+
+	// SCIMAccessTokenExpiry records the expiry time of the AWS SSO SCIM access token.
+	SCIMAccessTokenExpiry(expiresAt time.Time)
+}
+
+// RecordSyncReport records the duration, the result and the operations of the sync report.
+func RecordSyncReport(r Recorder, report *core.SyncReport, duration time.Duration) {
+	if report == nil {
+		return
+	}
+
+	r.SyncRun(duration, report.Success)
+
+	recordOperations(r, EntityGroups, report.Groups)
+	recordOperations(r, EntityUsers, report.Users)
+	recordOperations(r, EntityGroupsMembers, report.GroupsMembers)
+}
+
+func recordOperations(r Recorder, entity string, ops core.OperationsReport) {
+	r.Operations(entity, OperationCreate, ops.Create)
+	r.Operations(entity, OperationUpdate, ops.Update)
+	r.Operations(entity, OperationDelete, ops.Delete)
+	r.Operations(entity, OperationEqual, ops.Equal)
+	r.Operations(entity, OperationDeactivate, ops.Deactivate)
+	r.Operations(entity, OperationRetain, ops.Retain)
+	r.Operations(entity, OperationRename, ops.Rename)
+}
+
+// NoopRecorder is a Recorder that discards the metrics.
+type NoopRecorder struct{}
+
+// NewNoopRecorder returns a Recorder that discards the metrics, used when the metrics are disabled.
+func NewNoopRecorder() *NoopRecorder {
+	return &NoopRecorder{}
+}
+
+// SyncRun discards the sync run.
+func (NoopRecorder) SyncRun(duration time.Duration, success bool) {}
+
+// Operations discards the operations.
+func (NoopRecorder) Operations(entity, operation string, count int) {}
+
+// APIRequest discards the request.
+func (NoopRecorder) APIRequest(api, method, resource string, statusCode int, duration time.Duration) {
+}
+
+// APIRetry discards the retry.
+func (NoopRecorder) APIRetry(api string) {}
+
+// SCIMAccessTokenExpiry discards the expiry.
+func (NoopRecorder) SCIMAccessTokenExpiry(expiresAt time.Time) {}
+
+// MultiRecorder is a Recorder that records the metrics in several recorders, e.g. CloudWatch EMF and a Pushgateway.
+type MultiRecorder []Recorder
+
+// SyncRun records a finished sync in all the recorders.
+func (m MultiRecorder) SyncRun(duration time.Duration, success bool) {
+	for _, r := range m {
+		r.SyncRun(duration, success)
+	}
+}
+
+// Operations records the operations in all the recorders.
+func (m MultiRecorder) Operations(entity, operation string, count int) {
+	for _, r := range m {
+		r.Operations(entity, operation, count)
+	}
+}
+
+// APIRequest records the request in all the recorders.
+func (m MultiRecorder) APIRequest(api, method, resource string, statusCode int, duration time.Duration) {
+	for _, r := range m {
+		r.APIRequest(api, method, resource, statusCode, duration)
+	}
+}
+
+// APIRetry records the retry in all the recorders.
+func (m MultiRecorder) APIRetry(api string) {
+	for _, r := range m {
+		r.APIRetry(api)
+	}
+}
+
+// SCIMAccessTokenExpiry records the expiry in all the recorders.
+func (m MultiRecorder) SCIMAccessTokenExpiry(expiresAt time.Time) {
+	for _, r := range m {
+		r.SCIMAccessTokenExpiry(expiresAt)
+	}
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// namespace is the prefix of all the metrics names
+const namespace = "idpscim"
+
+// ErrPushgatewayURLEmpty is returned when the metrics are pushed without a Pushgateway url.
+var ErrPushgatewayURLEmpty = errors.New("metrics: pushgateway url cannot be empty")
+
+// PrometheusRecorder is a Recorder of Prometheus metrics, exposed through its handler or pushed to a Pushgateway.
+type PrometheusRecorder struct {
+	registry *prometheus.Registry
+
+	syncDuration       prometheus.Histogram
+	syncRuns           *prometheus.CounterVec
+	syncLastSuccess    prometheus.Gauge
+	operations         *prometheus.GaugeVec
+	apiRequests        *prometheus.CounterVec
+	apiRequestDuration *prometheus.HistogramVec
+	apiRetries         *prometheus.CounterVec
+	tokenExpiry        prometheus.Gauge
+}
+
+// NewPrometheusRecorder returns a Recorder with its own Prometheus registry, which includes the Go and process collectors.
+func NewPrometheusRecorder() *PrometheusRecorder {
+	p := &PrometheusRecorder{
+		registry: prometheus.NewRegistry(),
+		syncDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "sync_duration_seconds",
+			Help:      "Duration of the syncs.",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+		}),
+		syncRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sync_runs_total",
+			Help:      "Number of syncs by result.",
+		}, []string{"result"}),
+		syncLastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sync_last_success_timestamp_seconds",
+			Help:      "Time of the last successful sync.",
+		}),
+		operations: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sync_operations",
+			Help:      "Number of resources by entity and operation in the last sync.",
+		}, []string{"entity", "operation"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_requests_total",
+			Help:      "Number of requests to the APIs by status code, 0 when the request failed.",
+		}, []string{"api", "method", "resource", "code"}),
+		apiRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Duration of the requests to the APIs.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"api", "method", "resource"}),
+		apiRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_retries_total",
+			Help:      "Number of retried requests to the APIs.",
+		}, []string{"api"}),
+		tokenExpiry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "scim_access_token_expiry_timestamp_seconds",
+			Help:      "Expiry time of the AWS SSO SCIM access token.",
+		}),
+	}
+
+	p.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		p.syncDuration,
+		p.syncRuns,
+		p.syncLastSuccess,
+		p.operations,
+		p.apiRequests,
+		p.apiRequestDuration,
+		p.apiRetries,
+		p.tokenExpiry,
+	)
+
+	return p
+}
+
+// SyncRun records a finished sync.
+func (p *PrometheusRecorder) SyncRun(duration time.Duration, success bool) {
+	p.syncDuration.Observe(duration.Seconds())
+
+	if success {
+		p.syncRuns.WithLabelValues("success").Inc()
+		p.syncLastSuccess.SetToCurrentTime()
+		return
+	}
+
+	p.syncRuns.WithLabelValues("failure").Inc()
+}
+
+// Operations records the number of resources of an entity in an operation of the last sync.
+func (p *PrometheusRecorder) Operations(entity, operation string, count int) {
+	p.operations.WithLabelValues(entity, operation).Set(float64(count))
+}
+
+// APIRequest records a request to an API.
+func (p *PrometheusRecorder) APIRequest(api, method, resource string, statusCode int, duration time.Duration) {
+	p.apiRequests.WithLabelValues(api, method, resource, strconv.Itoa(statusCode)).Inc()
+	p.apiRequestDuration.WithLabelValues(api, method, resource).Observe(duration.Seconds())
+}
+
+// APIRetry records a retry of a request to an API.
+func (p *PrometheusRecorder) APIRetry(api string) {
+	p.apiRetries.WithLabelValues(api).Inc()
+}
+
+// SCIMAccessTokenExpiry records the expiry time of the AWS SSO SCIM access token.
+func (p *PrometheusRecorder) SCIMAccessTokenExpiry(expiresAt time.Time) {
+	p.tokenExpiry.Set(float64(expiresAt.Unix()))
+}
+
+// Handler returns the handler exposing the metrics in the Prometheus format.
+func (p *PrometheusRecorder) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{Registry: p.registry})
+}
+
+// Push pushes the metrics to the Pushgateway of the url, replacing the metrics of the job.
+func (p *PrometheusRecorder) Push(ctx context.Context, url, job string) error {
+	if url == "" {
+		return ErrPushgatewayURLEmpty
+	}
+
+	if err := push.New(url, job).Gatherer(p.registry).PushContext(ctx); err != nil {
+		return fmt.Errorf("metrics: error pushing to the pushgateway: %w", err)
+	}
+
+	return nil
+}
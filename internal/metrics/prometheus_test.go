@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusRecorder(t *testing.T) {
+	t.Run("Should record the sync report", func(t *testing.T) {
+		p := NewPrometheusRecorder()
+
+		RecordSyncReport(p, &core.SyncReport{
+			Success:       true,
+			Groups:        core.OperationsReport{Create: 2, Equal: 3},
+			Users:         core.OperationsReport{Update: 1, Deactivate: 4},
+			GroupsMembers: core.OperationsReport{Delete: 5},
+		}, 3*time.Second)
+		RecordSyncReport(p, &core.SyncReport{Success: false}, time.Second)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(p.syncRuns.WithLabelValues("success")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(p.syncRuns.WithLabelValues("failure")))
+		assert.Equal(t, 1, testutil.CollectAndCount(p.syncDuration))
+		assert.Greater(t, testutil.ToFloat64(p.syncLastSuccess), float64(0))
+
+		// the operations are the ones of the last sync
+		assert.Equal(t, float64(0), testutil.ToFloat64(p.operations.WithLabelValues(EntityGroups, OperationCreate)))
+		assert.Equal(t, 21, testutil.CollectAndCount(p.operations))
+	})
+
+	t.Run("Should record the operations of the last sync", func(t *testing.T) {
+		p := NewPrometheusRecorder()
+
+		RecordSyncReport(p, &core.SyncReport{
+			Success:       true,
+			Groups:        core.OperationsReport{Create: 2, Equal: 3},
+			Users:         core.OperationsReport{Update: 1, Deactivate: 4},
+			GroupsMembers: core.OperationsReport{Delete: 5},
+		}, 3*time.Second)
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(p.operations.WithLabelValues(EntityGroups, OperationCreate)))
+		assert.Equal(t, float64(3), testutil.ToFloat64(p.operations.WithLabelValues(EntityGroups, OperationEqual)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(p.operations.WithLabelValues(EntityUsers, OperationUpdate)))
+		assert.Equal(t, float64(4), testutil.ToFloat64(p.operations.WithLabelValues(EntityUsers, OperationDeactivate)))
+		assert.Equal(t, float64(5), testutil.ToFloat64(p.operations.WithLabelValues(EntityGroupsMembers, OperationDelete)))
+	})
+
+	t.Run("Should record the API requests and retries", func(t *testing.T) {
+		p := NewPrometheusRecorder()
+
+		p.APIRequest(APISCIM, http.MethodGet, "Users", http.StatusOK, 100*time.Millisecond)
+		p.APIRequest(APISCIM, http.MethodGet, "Users", http.StatusOK, 200*time.Millisecond)
+		p.APIRequest(APIGoogle, http.MethodGet, "groups", 0, time.Second)
+		p.APIRetry(APISCIM)
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(p.apiRequests.WithLabelValues(APISCIM, http.MethodGet, "Users", "200")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(p.apiRequests.WithLabelValues(APIGoogle, http.MethodGet, "groups", "0")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(p.apiRetries.WithLabelValues(APISCIM)))
+		assert.Equal(t, 2, testutil.CollectAndCount(p.apiRequestDuration))
+	})
+
+	t.Run("Should expose the metrics", func(t *testing.T) {
+		p := NewPrometheusRecorder()
+		p.SCIMAccessTokenExpiry(time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC))
+
+		rec := httptest.NewRecorder()
+		p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "idpscim_scim_access_token_expiry_timestamp_seconds 1.6733088e+09")
+		assert.Contains(t, rec.Body.String(), "go_goroutines")
+	})
+}
+
+func TestPrometheusRecorder_Push(t *testing.T) {
+	t.Run("Should push the metrics of the job", func(t *testing.T) {
+		var gotPath, gotBody string
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			gotPath = r.URL.Path
+			body, _ := io.ReadAll(r.Body)
+			gotBody = string(body)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer svr.Close()
+
+		p := NewPrometheusRecorder()
+		p.SyncRun(time.Second, true)
+
+		err := p.Push(context.Background(), svr.URL, "idpscim")
+		assert.NoError(t, err)
+		assert.Equal(t, "/metrics/job/idpscim", gotPath)
+		assert.True(t, strings.Contains(gotBody, "idpscim_sync_runs_total"))
+	})
+
+	t.Run("Should return an error without url", func(t *testing.T) {
+		p := NewPrometheusRecorder()
+
+		err := p.Push(context.Background(), "", "idpscim")
+		assert.ErrorIs(t, err, ErrPushgatewayURLEmpty)
+	})
+
+	t.Run("Should return an error when the pushgateway fails", func(t *testing.T) {
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer svr.Close()
+
+		p := NewPrometheusRecorder()
+
+		err := p.Push(context.Background(), svr.URL, "idpscim")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,8 @@
+package model
+
+// IdentityProviderChanges are the groups and users changed in the Identity Provider since a time,
+// identified by their emails.
+type IdentityProviderChanges struct {
+	Groups []string `json:"groups"`
+	Users  []string `json:"users"`
+}
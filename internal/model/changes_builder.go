@@ -0,0 +1,86 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// groupEmailParameter is the parameter of the Google Workspace activity events with the email of the group changed
+	groupEmailParameter = "GROUP_EMAIL"
+
+	// userEmailParameter is the parameter of the Google Workspace activity events with the email of the user changed
+	userEmailParameter = "USER_EMAIL"
+)
+
+// IdentityProviderChangesBuilderChoice is the builder of IdentityProviderChanges entity.
+type IdentityProviderChangesBuilderChoice struct {
+	groups map[string]struct{}
+	users  map[string]struct{}
+}
+
+// IdentityProviderChangesBuilder creates a new IdentityProviderChangesBuilderChoice entity.
+func IdentityProviderChangesBuilder() *IdentityProviderChangesBuilderChoice {
+	return &IdentityProviderChangesBuilderChoice{
+		groups: make(map[string]struct{}),
+		users:  make(map[string]struct{}),
+	}
+}
+
+// WithGroup adds the group of the given email.
+func (b *IdentityProviderChangesBuilderChoice) WithGroup(email string) *IdentityProviderChangesBuilderChoice {
+	if email != "" {
+		b.groups[strings.ToLower(email)] = struct{}{}
+	}
+	return b
+}
+
+// WithUser adds the user of the given email.
+func (b *IdentityProviderChangesBuilderChoice) WithUser(email string) *IdentityProviderChangesBuilderChoice {
+	if email != "" {
+		b.users[strings.ToLower(email)] = struct{}{}
+	}
+	return b
+}
+
+// WithChanges adds the groups and users of the given changes.
+func (b *IdentityProviderChangesBuilderChoice) WithChanges(changes *IdentityProviderChanges) *IdentityProviderChangesBuilderChoice {
+	for _, group := range changes.Groups {
+		b.WithGroup(group)
+	}
+	for _, user := range changes.Users {
+		b.WithUser(user)
+	}
+	return b
+}
+
+// WithParameter adds the group or the user of a parameter of the Google Workspace activity events,
+// GROUP_EMAIL or USER_EMAIL, the other parameters are ignored.
+func (b *IdentityProviderChangesBuilderChoice) WithParameter(name, value string) *IdentityProviderChangesBuilderChoice {
+	switch {
+	case strings.EqualFold(name, groupEmailParameter):
+		b.WithGroup(value)
+	case strings.EqualFold(name, userEmailParameter):
+		b.WithUser(value)
+	}
+	return b
+}
+
+// Build returns the IdentityProviderChanges entity, with the emails of the groups and users lowercase, unique and sorted.
+func (b *IdentityProviderChangesBuilderChoice) Build() *IdentityProviderChanges {
+	return &IdentityProviderChanges{
+		Groups: sortedKeys(b.groups),
+		Users:  sortedKeys(b.users),
+	}
+}
+
+// sortedKeys returns the keys of the set sorted.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
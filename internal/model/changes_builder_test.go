@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityProviderChangesBuilder(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		c := IdentityProviderChangesBuilder().Build()
+
+		assert.Equal(t, &IdentityProviderChanges{Groups: []string{}, Users: []string{}}, c)
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		c := IdentityProviderChangesBuilder().
+			WithParameter("GROUP_EMAIL", "Group.2@mail.com").
+			WithParameter("group_email", "group.1@mail.com").
+			WithParameter("GROUP_EMAIL", "group.2@mail.com").
+			WithParameter("USER_EMAIL", "User.1@mail.com").
+			WithParameter("USER_EMAIL", "").
+			WithParameter("GROUP_ID", "1").
+			Build()
+
+		assert.Equal(t, []string{"group.1@mail.com", "group.2@mail.com"}, c.Groups)
+		assert.Equal(t, []string{"user.1@mail.com"}, c.Users)
+	})
+
+	t.Run("groups, users and changes", func(t *testing.T) {
+		c := IdentityProviderChangesBuilder().
+			WithGroup("Group.1@mail.com").
+			WithUser("").
+			WithChanges(&IdentityProviderChanges{Groups: []string{"group.1@mail.com"}, Users: []string{"user.1@mail.com"}}).
+			Build()
+
+		assert.Equal(t, []string{"group.1@mail.com"}, c.Groups)
+		assert.Equal(t, []string{"user.1@mail.com"}, c.Users)
+	})
+}
@@ -0,0 +1,108 @@
+package model
+
+import "errors"
+
+// ErrDiffResourcesNil is returned when the resources of one of the sides to compare are nil
+var ErrDiffResourcesNil = errors.New("resources to compare are nil")
+
+// GroupsDiff represents the differences between the groups of two sides.
+type GroupsDiff struct {
+	Create *GroupsResult `json:"create"`
+	Update *GroupsResult `json:"update"`
+	Equal  *GroupsResult `json:"equal"`
+	Remove *GroupsResult `json:"remove"`
+}
+
+// UsersDiff represents the differences between the users of two sides.
+type UsersDiff struct {
+	Create *UsersResult `json:"create"`
+	Update *UsersResult `json:"update"`
+	Equal  *UsersResult `json:"equal"`
+	Remove *UsersResult `json:"remove"`
+}
+
+// GroupsMembersDiff represents the differences between the groups members of two sides.
+type GroupsMembersDiff struct {
+	Create *GroupsMembersResult `json:"create"`
+	Equal  *GroupsMembersResult `json:"equal"`
+	Remove *GroupsMembersResult `json:"remove"`
+}
+
+// ResourcesDiff represents the differences between the resources of two sides,
+// e.g. the Identity Provider, the SCIM side or the state.
+type ResourcesDiff struct {
+	Groups        *GroupsDiff        `json:"groups"`
+	Users         *UsersDiff         `json:"users"`
+	GroupsMembers *GroupsMembersDiff `json:"groupsMembers"`
+}
+
+// Empty returns true when both sides have the same resources.
+func (rd *ResourcesDiff) Empty() bool {
+	return rd.Groups.Create.Items == 0 && rd.Groups.Update.Items == 0 && rd.Groups.Remove.Items == 0 &&
+		rd.Users.Create.Items == 0 && rd.Users.Update.Items == 0 && rd.Users.Remove.Items == 0 &&
+		rd.GroupsMembers.Create.Items == 0 && rd.GroupsMembers.Remove.Items == 0
+}
+
+// ResourcesDifferences returns the differences between the resources of two sides using the same operations
+// of the sync, "from" is taken as the Identity Provider and "to" as the SCIM side or the state, so
+// create: resources that exist in "from" but not in "to"
+// update: resources that exist in both sides but their attributes are different
+// equal: resources that exist in both sides and their attributes are equal
+// remove: resources that exist in "to" but not in "from"
+//
+// NOTE: as the operations do, the SCIM ids of "to" are filled in the resources of "from"
+func ResourcesDifferences(from, to *StateResources) (*ResourcesDiff, error) {
+	if from == nil || to == nil {
+		return nil, ErrDiffResourcesNil
+	}
+
+	gCreate, gUpdate, gEqual, gRemove, err := GroupsOperations(from.Groups, to.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	uCreate, uUpdate, uEqual, uRemove, err := UsersOperations(from.Users, to.Users)
+	if err != nil {
+		return nil, err
+	}
+
+	mCreate, mEqual, mRemove, err := MembersOperations(from.GroupsMembers, to.GroupsMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := &ResourcesDiff{
+		Groups:        &GroupsDiff{Create: gCreate, Update: gUpdate, Equal: gEqual, Remove: gRemove},
+		Users:         &UsersDiff{Create: uCreate, Update: uUpdate, Equal: uEqual, Remove: uRemove},
+		GroupsMembers: &GroupsMembersDiff{Create: mCreate, Equal: mEqual, Remove: mRemove},
+	}
+
+	return rd, nil
+}
+
+// UserChangedFields returns the names of the attributes, as they are in JSON, that are different between
+// the two users, the ids of the SCIM side and the values only kept in the state are not compared.
+func UserChangedFields(from, to *User) []string {
+	fields := make([]string, 0)
+
+	if from.IPID != to.IPID {
+		fields = append(fields, "ipid")
+	}
+	if from.Name.GivenName != to.Name.GivenName {
+		fields = append(fields, "name.givenName")
+	}
+	if from.Name.FamilyName != to.Name.FamilyName {
+		fields = append(fields, "name.familyName")
+	}
+	if from.DisplayName != to.DisplayName {
+		fields = append(fields, "displayName")
+	}
+	if from.Active != to.Active {
+		fields = append(fields, "active")
+	}
+	if from.Email != to.Email {
+		fields = append(fields, "email")
+	}
+
+	return fields
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func diffResources(groups []*Group, users []*User, groupsMembers []*GroupMembers) *StateResources {
+	return &StateResources{
+		Groups:        GroupsResultBuilder().WithResources(groups).Build(),
+		Users:         UsersResultBuilder().WithResources(users).Build(),
+		GroupsMembers: GroupsMembersResultBuilder().WithResources(groupsMembers).Build(),
+	}
+}
+
+func TestResourcesDifferences(t *testing.T) {
+	t.Run("Should return error when one of the sides is nil", func(t *testing.T) {
+		rd, err := ResourcesDifferences(nil, diffResources(nil, nil, nil))
+		assert.ErrorIs(t, err, ErrDiffResourcesNil)
+		assert.Nil(t, rd)
+
+		rd, err = ResourcesDifferences(diffResources(nil, nil, nil), nil)
+		assert.ErrorIs(t, err, ErrDiffResourcesNil)
+		assert.Nil(t, rd)
+	})
+
+	t.Run("Should return error when the resources of one of the sides are nil", func(t *testing.T) {
+		rd, err := ResourcesDifferences(&StateResources{}, diffResources(nil, nil, nil))
+		assert.ErrorIs(t, err, ErrIdentityProviderGroupsNil)
+		assert.Nil(t, rd)
+	})
+
+	t.Run("Should return empty differences when both sides are equal", func(t *testing.T) {
+		group := GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group1@mail.com").Build()
+		user := UserBuilder().WithIPID("1").WithEmail("user1@mail.com").WithDisplayName("user 1").WithActive(true).Build()
+		member := MemberBuilder().WithIPID("1").WithEmail("user1@mail.com").Build()
+
+		from := diffResources(
+			[]*Group{group},
+			[]*User{user},
+			[]*GroupMembers{GroupMembersBuilder().WithGroup(group).WithResources([]*Member{member}).Build()},
+		)
+
+		scimGroup := GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group1@mail.com").Build()
+		scimUser := UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user1@mail.com").WithDisplayName("user 1").WithActive(true).Build()
+		scimMember := MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user1@mail.com").Build()
+
+		to := diffResources(
+			[]*Group{scimGroup},
+			[]*User{scimUser},
+			[]*GroupMembers{GroupMembersBuilder().WithGroup(scimGroup).WithResources([]*Member{scimMember}).Build()},
+		)
+
+		rd, err := ResourcesDifferences(from, to)
+		assert.NoError(t, err)
+		assert.True(t, rd.Empty())
+
+		assert.Equal(t, 1, rd.Groups.Equal.Items)
+		assert.Equal(t, "g1", rd.Groups.Equal.Resources[0].SCIMID)
+		assert.Equal(t, 1, rd.Users.Equal.Items)
+		assert.Equal(t, "u1", rd.Users.Equal.Resources[0].SCIMID)
+		assert.Equal(t, 1, rd.GroupsMembers.Equal.Items)
+	})
+
+	t.Run("Should return the differences between both sides", func(t *testing.T) {
+		group1 := GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group1@mail.com").Build()
+		group2 := GroupBuilder().WithIPID("2").WithName("group 2").WithEmail("group2@mail.com").Build()
+		user1 := UserBuilder().WithIPID("1").WithEmail("user1@mail.com").WithDisplayName("user 1").WithActive(true).Build()
+		user2 := UserBuilder().WithIPID("2").WithEmail("user2@mail.com").WithDisplayName("user 2").WithActive(true).Build()
+
+		from := diffResources(
+			[]*Group{group1, group2},
+			[]*User{user1, user2},
+			[]*GroupMembers{
+				GroupMembersBuilder().WithGroup(group1).WithResources([]*Member{
+					MemberBuilder().WithIPID("1").WithEmail("user1@mail.com").Build(),
+				}).Build(),
+				GroupMembersBuilder().WithGroup(group2).WithResources([]*Member{
+					MemberBuilder().WithIPID("2").WithEmail("user2@mail.com").Build(),
+				}).Build(),
+			},
+		)
+
+		scimGroup1 := GroupBuilder().WithIPID("changed").WithSCIMID("g1").WithName("group 1").WithEmail("group1@mail.com").Build()
+		scimGroup3 := GroupBuilder().WithIPID("3").WithSCIMID("g3").WithName("group 3").WithEmail("group3@mail.com").Build()
+		scimUser1 := UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user1@mail.com").WithDisplayName("changed").WithActive(true).Build()
+		scimUser3 := UserBuilder().WithIPID("3").WithSCIMID("u3").WithEmail("user3@mail.com").WithDisplayName("user 3").WithActive(true).Build()
+
+		to := diffResources(
+			[]*Group{scimGroup1, scimGroup3},
+			[]*User{scimUser1, scimUser3},
+			[]*GroupMembers{
+				GroupMembersBuilder().WithGroup(scimGroup1).WithResources([]*Member{
+					MemberBuilder().WithIPID("3").WithSCIMID("u3").WithEmail("user3@mail.com").Build(),
+				}).Build(),
+			},
+		)
+
+		rd, err := ResourcesDifferences(from, to)
+		assert.NoError(t, err)
+		assert.False(t, rd.Empty())
+
+		assert.Equal(t, "group 2", rd.Groups.Create.Resources[0].Name)
+		assert.Equal(t, "group 1", rd.Groups.Update.Resources[0].Name)
+		assert.Equal(t, "g1", rd.Groups.Update.Resources[0].SCIMID)
+		assert.Equal(t, 0, rd.Groups.Equal.Items)
+		assert.Equal(t, "group 3", rd.Groups.Remove.Resources[0].Name)
+
+		assert.Equal(t, "user2@mail.com", rd.Users.Create.Resources[0].Email)
+		assert.Equal(t, "user1@mail.com", rd.Users.Update.Resources[0].Email)
+		assert.Equal(t, 0, rd.Users.Equal.Items)
+		assert.Equal(t, "user3@mail.com", rd.Users.Remove.Resources[0].Email)
+
+		assert.Equal(t, 2, rd.GroupsMembers.Create.Items)
+		assert.Equal(t, 1, rd.GroupsMembers.Remove.Items)
+		assert.Equal(t, "group 1", rd.GroupsMembers.Remove.Resources[0].Group.Name)
+		assert.Equal(t, "user3@mail.com", rd.GroupsMembers.Remove.Resources[0].Resources[0].Email)
+	})
+}
+
+func TestUserChangedFields(t *testing.T) {
+	t.Run("Should return no fields when the users are equal except the SCIM id", func(t *testing.T) {
+		from := UserBuilder().WithIPID("1").WithEmail("user@mail.com").WithGivenName("user").WithActive(true).Build()
+		to := UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user@mail.com").WithGivenName("user").WithActive(true).Build()
+
+		assert.Empty(t, UserChangedFields(from, to))
+	})
+
+	t.Run("Should return the changed fields", func(t *testing.T) {
+		from := UserBuilder().WithIPID("1").WithEmail("user@mail.com").WithGivenName("user").WithFamilyName("family").WithActive(true).Build()
+		to := UserBuilder().WithIPID("2").WithEmail("user@mail.com").WithGivenName("changed").WithFamilyName("family").WithDisplayName("user").Build()
+
+		assert.Equal(t, []string{"ipid", "name.givenName", "displayName", "active"}, UserChangedFields(from, to))
+	})
+}
@@ -14,6 +14,16 @@ type Group struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	HashCode string `json:"hashCode"`
+
+	// MissingSince is the time (RFC3339) when the group was first seen missing in the Identity Provider.
+	// MissingRuns is the number of consecutive syncs the group has been missing in the Identity Provider.
+	// both are only kept in the state while the group is retained during the removal grace period.
+	MissingSince string `json:"missingSince,omitempty"`
+	MissingRuns  int    `json:"missingRuns,omitempty"`
+
+	// Rename is true when the group was imported from the SCIM side with other name than the Identity Provider one,
+	// e.g. created by ssosync with the email as name, the next sync renames it in the SCIM side.
+	Rename bool `json:"rename,omitempty"`
 }
 
 // GobEncode implements the gob.GobEncoder interface for Group entity.
@@ -57,6 +67,16 @@ func (gr *GroupsResult) MarshalJSON() ([]byte, error) {
 	return json.MarshalIndent(*gr, "", "  ")
 }
 
+// GobEncode implements the gob.GobEncoder interface for GroupsResult entity.
+// only the items and the encoding of the resources are used in the hash calculation.
+func (gr *GroupsResult) GobEncode() ([]byte, error) {
+	resources := make([]gob.GobEncoder, 0, len(gr.Resources))
+	for _, group := range gr.Resources {
+		resources = append(resources, group)
+	}
+	return gobEncodeResources(gr.Items, resources...)
+}
+
 // SetHashCode is a helper function to avoid errors when calculating hash code.
 // this method discards fields that are not used in the hash calculation.
 // only fields coming from the Identity Provider are used.
@@ -9,12 +9,23 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// Hash returns a sha256 hash of value pass as argument
+// Hash returns a sha256 hash of value pass as argument.
+// The gob encoding of a type includes the id that gob assigns to it the first time it is encoded in the process,
+// so the values that implement gob.GobEncoder are hashed by their own encoding, that only has builtin types
+// (see gobEncode), to have the same hash codes in every process, e.g. a sync and idpscimcli state verify-hash.
 func Hash(value interface{}) string {
 	if value == nil {
 		log.Fatal("value is nil")
 	}
 
+	if e, ok := value.(gob.GobEncoder); ok {
+		data, err := e.GobEncode()
+		if err != nil {
+			log.Panic(err)
+		}
+		return fmt.Sprintf("%x", sha256.Sum256(data))
+	}
+
 	buf := new(bytes.Buffer)
 	enc := gob.NewEncoder(buf)
 	if err := enc.Encode(value); err != nil {
@@ -23,3 +34,29 @@ func Hash(value interface{}) string {
 
 	return fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
 }
+
+// gobEncode encodes the values one after the other, they must be of gob builtin types, e.g. string, int, bool
+// or []byte, whose type ids are the same in every process.
+func gobEncode(values ...interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := gob.NewEncoder(buf)
+	for _, value := range values {
+		if err := enc.Encode(value); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// gobEncodeResources encodes the items and the encoding of every resource of a result entity.
+func gobEncodeResources(items int, resources ...gob.GobEncoder) ([]byte, error) {
+	values := []interface{}{items}
+	for _, resource := range resources {
+		data, err := resource.GobEncode()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, data)
+	}
+	return gobEncode(values...)
+}
@@ -234,3 +234,38 @@ func TestHash(t *testing.T) {
 		})
 	}
 }
+
+func TestHash_SameInEveryProcess(t *testing.T) {
+	// the hash codes stored in the state must be the same calculated by any other process,
+	// no matter the types gob encoded before in it.
+	Hash(CustomStruct{Name: "John"})
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			name: "group",
+			got:  GroupBuilder().WithIPID("1").WithName("group 1").WithEmail("group1@mail.com").Build().HashCode,
+			want: "2b37e3a24781937cd973f96c42946f404d7d5afcff4788c840a7fdf3c140662b",
+		},
+		{
+			name: "user",
+			got:  UserBuilder().WithIPID("1").WithEmail("user1@mail.com").WithGivenName("user").WithFamilyName("1").WithDisplayName("user 1").WithActive(true).Build().HashCode,
+			want: "04d1d6369d3abd8e55dcafa4193ee2b77d5c6b9ce6ae81f6aa1bab5ac56cefed",
+		},
+		{
+			name: "state",
+			got:  checkState().HashCode,
+			want: "02794e6fb479fdadf68b9297f3af315f105fcd0a7e051a45252c7734c80c24c9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("hash code = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -46,6 +46,16 @@ type MembersResult struct {
 	Resources []*Member `json:"resources"`
 }
 
+// GobEncode implements the gob.GobEncoder interface for MembersResult entity.
+// only the items and the encoding of the resources are used in the hash calculation.
+func (mr *MembersResult) GobEncode() ([]byte, error) {
+	resources := make([]gob.GobEncoder, 0, len(mr.Resources))
+	for _, member := range mr.Resources {
+		resources = append(resources, member)
+	}
+	return gobEncodeResources(mr.Items, resources...)
+}
+
 // SetHashCode is a helper function to avoid errors when calculating hash code.
 // this method discards fields that are not used in the hash calculation.
 // only fields coming from the Identity Provider are used.
@@ -75,6 +85,19 @@ type GroupMembers struct {
 	Resources []*Member `json:"resources"`
 }
 
+// GobEncode implements the gob.GobEncoder interface for GroupMembers entity.
+// only the items, the encoding of the group and the encoding of the members are used in the hash calculation.
+func (gm *GroupMembers) GobEncode() ([]byte, error) {
+	resources := make([]gob.GobEncoder, 0, len(gm.Resources)+1)
+	if gm.Group != nil {
+		resources = append(resources, gm.Group)
+	}
+	for _, member := range gm.Resources {
+		resources = append(resources, member)
+	}
+	return gobEncodeResources(gm.Items, resources...)
+}
+
 // SetHashCode is a helper function to avoid errors when calculating hash code.
 // this method discards fields that are not used in the hash calculation.
 // only fields coming from the Identity Provider are used.
@@ -113,6 +136,16 @@ func (gmr *GroupsMembersResult) MarshalJSON() ([]byte, error) {
 	return json.MarshalIndent(*gmr, "", "  ")
 }
 
+// GobEncode implements the gob.GobEncoder interface for GroupsMembersResult entity.
+// only the items and the encoding of the resources are used in the hash calculation.
+func (gmr *GroupsMembersResult) GobEncode() ([]byte, error) {
+	resources := make([]gob.GobEncoder, 0, len(gmr.Resources))
+	for _, groupMembers := range gmr.Resources {
+		resources = append(resources, groupMembers)
+	}
+	return gobEncodeResources(gmr.Items, resources...)
+}
+
 // SetHashCode is a helper function to avoid errors when calculating hash code.
 // this method discards fields that are not used in the hash calculation.
 // only fields coming from the Identity Provider are used.
@@ -132,5 +165,5 @@ func (gmr *GroupsMembersResult) SetHashCode() {
 		return copyStruct.Resources[i].HashCode < copyStruct.Resources[j].HashCode
 	})
 
-	gmr.HashCode = Hash(copyStruct)
+	gmr.HashCode = Hash(&copyStruct)
 }
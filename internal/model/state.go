@@ -5,6 +5,12 @@ import "encoding/json"
 const (
 	// StateSchemaVersion is the current schema version for the state file.
 	StateSchemaVersion = "1.0.0"
+
+	// CheckpointGroups is the checkpoint of a first sync stopped after reconciling the groups.
+	CheckpointGroups = "groups"
+
+	// CheckpointUsers is the checkpoint of a first sync stopped after reconciling the groups and the users.
+	CheckpointUsers = "users"
 )
 
 // StateResources is a list of resources in the state, groups, users and groups and their users.
@@ -21,6 +27,13 @@ type State struct {
 	LastSync      string          `json:"lastSync"`
 	HashCode      string          `json:"hashCode"`
 	Resources     *StateResources `json:"resources"`
+
+	// Checkpoint is the last phase done by a first sync that didn't finish, the next sync resumes from it.
+	Checkpoint string `json:"checkpoint,omitempty"`
+
+	// LastFullSync is the time (RFC3339) of the last sync that got all the data of the Identity Provider,
+	// the incremental syncs only get the changes since the last sync until the next full refresh.
+	LastFullSync string `json:"lastFullSync,omitempty"`
 }
 
 // MarshalJSON marshals the State to JSON.
@@ -68,6 +81,15 @@ func (s *State) MarshalJSON() ([]byte, error) {
 	return json.MarshalIndent(*s, "", "  ")
 }
 
+// GobEncode implements the gob.GobEncoder interface for State entity.
+// only the encoding of the resources is used in the hash calculation.
+func (s *State) GobEncode() ([]byte, error) {
+	if s.Resources == nil {
+		return gobEncodeResources(0)
+	}
+	return gobEncodeResources(0, s.Resources.Groups, s.Resources.Users, s.Resources.GroupsMembers)
+}
+
 // SetHashCode is a helper function to avoid errors when calculating hash code.
 // this method discards fields that are not used in the hash calculation.
 // only fields coming from the Identity Provider are used.
@@ -159,5 +181,5 @@ func (s *State) SetHashCode() {
 		},
 	}
 
-	s.HashCode = Hash(copyState)
+	s.HashCode = Hash(&copyState)
 }
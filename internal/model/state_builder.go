@@ -43,6 +43,18 @@ func (b *StateBuilderChoice) WithLastSync(lastSync string) *StateBuilderChoice {
 	return b
 }
 
+// WithCheckpoint sets the Checkpoint field of the State entity.
+func (b *StateBuilderChoice) WithCheckpoint(checkpoint string) *StateBuilderChoice {
+	b.s.Checkpoint = checkpoint
+	return b
+}
+
+// WithLastFullSync sets the LastFullSync field of the State entity.
+func (b *StateBuilderChoice) WithLastFullSync(lastFullSync string) *StateBuilderChoice {
+	b.s.LastFullSync = lastFullSync
+	return b
+}
+
 // WithGroups sets the Groups field of the StateResources entity inside the State entity.
 func (b *StateBuilderChoice) WithGroups(groups *GroupsResult) *StateBuilderChoice {
 	b.s.Resources.Groups = groups
@@ -152,4 +152,18 @@ func TestStateBuilder(t *testing.T) {
 		assert.Equal(t, 1, sb.Resources.GroupsMembers.Items)
 		assert.Equal(t, 1, len(sb.Resources.GroupsMembers.Resources))
 	})
+
+	t.Run("checkpoint doesn't change the hash code", func(t *testing.T) {
+		sb := StateBuilder().WithCheckpoint(CheckpointGroups).Build()
+
+		assert.Equal(t, CheckpointGroups, sb.Checkpoint)
+		assert.Equal(t, StateBuilder().Build().HashCode, sb.HashCode)
+	})
+
+	t.Run("last full sync doesn't change the hash code", func(t *testing.T) {
+		sb := StateBuilder().WithLastFullSync("2022-01-01T00:00:00Z").Build()
+
+		assert.Equal(t, "2022-01-01T00:00:00Z", sb.LastFullSync)
+		assert.Equal(t, StateBuilder().Build().HashCode, sb.HashCode)
+	})
 }
@@ -0,0 +1,379 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseState returns the State of the JSON data, unlike json.Unmarshal it fails when the data has fields
+// that are not in the State entity, e.g. fields renamed or misspelled.
+func ParseState(data []byte) (*State, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var state State
+	if err := dec.Decode(&state); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// Validate returns the issues found in the State entity, e.g. missing resources, wrong items counts,
+// duplicated groups or users, or members of groups that are not in the users.
+// An empty result means the state is valid.
+func (s *State) Validate() []string {
+	issues := make([]string, 0)
+
+	if s.SchemaVersion != StateSchemaVersion {
+		issues = append(issues, fmt.Sprintf("schemaVersion: %q is not the current schema version %q", s.SchemaVersion, StateSchemaVersion))
+	}
+
+	if _, err := time.Parse(time.RFC3339, s.LastSync); s.LastSync != "" && err != nil {
+		issues = append(issues, fmt.Sprintf("lastSync: %q is not a RFC3339 time", s.LastSync))
+	}
+
+	if _, err := time.Parse(time.RFC3339, s.LastFullSync); s.LastFullSync != "" && err != nil {
+		issues = append(issues, fmt.Sprintf("lastFullSync: %q is not a RFC3339 time", s.LastFullSync))
+	}
+
+	if s.Checkpoint != "" && s.Checkpoint != CheckpointGroups && s.Checkpoint != CheckpointUsers {
+		issues = append(issues, fmt.Sprintf("checkpoint: %q is not a valid checkpoint", s.Checkpoint))
+	}
+
+	if s.Resources == nil {
+		return append(issues, "resources: missing")
+	}
+
+	groups := make(map[string]struct{})
+	if s.Resources.Groups == nil {
+		issues = append(issues, "resources.groups: missing")
+	} else {
+		if s.Resources.Groups.Items != len(s.Resources.Groups.Resources) {
+			issues = append(issues, fmt.Sprintf("resources.groups: items %d, but it has %d groups", s.Resources.Groups.Items, len(s.Resources.Groups.Resources)))
+		}
+
+		for idx, group := range s.Resources.Groups.Resources {
+			path := fmt.Sprintf("resources.groups.resources[%d]", idx)
+
+			if group == nil {
+				issues = append(issues, path+": null group")
+				continue
+			}
+			if group.Name == "" {
+				issues = append(issues, path+": group without name")
+			}
+			if group.IPID == "" {
+				issues = append(issues, fmt.Sprintf("%s: group %q without ipid", path, group.Name))
+			}
+			if group.SCIMID == "" {
+				issues = append(issues, fmt.Sprintf("%s: group %q without scimid", path, group.Name))
+			}
+			if _, ok := groups[group.Name]; ok {
+				issues = append(issues, fmt.Sprintf("%s: group %q is duplicated", path, group.Name))
+			}
+
+			groups[group.Name] = struct{}{}
+		}
+	}
+
+	users := make(map[string]struct{})
+	if s.Resources.Users == nil {
+		issues = append(issues, "resources.users: missing")
+	} else {
+		if s.Resources.Users.Items != len(s.Resources.Users.Resources) {
+			issues = append(issues, fmt.Sprintf("resources.users: items %d, but it has %d users", s.Resources.Users.Items, len(s.Resources.Users.Resources)))
+		}
+
+		for idx, user := range s.Resources.Users.Resources {
+			path := fmt.Sprintf("resources.users.resources[%d]", idx)
+
+			if user == nil {
+				issues = append(issues, path+": null user")
+				continue
+			}
+			if user.Email == "" {
+				issues = append(issues, path+": user without email")
+			}
+			if user.IPID == "" {
+				issues = append(issues, fmt.Sprintf("%s: user %q without ipid", path, user.Email))
+			}
+			if user.SCIMID == "" {
+				issues = append(issues, fmt.Sprintf("%s: user %q without scimid", path, user.Email))
+			}
+			if _, ok := users[user.Email]; ok {
+				issues = append(issues, fmt.Sprintf("%s: user %q is duplicated", path, user.Email))
+			}
+
+			users[user.Email] = struct{}{}
+		}
+	}
+
+	if s.Resources.GroupsMembers == nil {
+		return append(issues, "resources.groupsMembers: missing")
+	}
+
+	if s.Resources.GroupsMembers.Items != len(s.Resources.GroupsMembers.Resources) {
+		issues = append(issues, fmt.Sprintf("resources.groupsMembers: items %d, but it has %d groups", s.Resources.GroupsMembers.Items, len(s.Resources.GroupsMembers.Resources)))
+	}
+
+	for idx, groupMembers := range s.Resources.GroupsMembers.Resources {
+		path := fmt.Sprintf("resources.groupsMembers.resources[%d]", idx)
+
+		if groupMembers == nil || groupMembers.Group == nil {
+			issues = append(issues, path+": group members without group")
+			continue
+		}
+		if _, ok := groups[groupMembers.Group.Name]; !ok && s.Resources.Groups != nil {
+			issues = append(issues, fmt.Sprintf("%s: group %q is not in the groups", path, groupMembers.Group.Name))
+		}
+		if groupMembers.Items != len(groupMembers.Resources) {
+			issues = append(issues, fmt.Sprintf("%s: items %d, but it has %d members", path, groupMembers.Items, len(groupMembers.Resources)))
+		}
+
+		members := make(map[string]struct{})
+		for midx, member := range groupMembers.Resources {
+			mpath := fmt.Sprintf("%s.resources[%d]", path, midx)
+
+			if member == nil {
+				issues = append(issues, mpath+": null member")
+				continue
+			}
+			if _, ok := users[member.Email]; !ok && s.Resources.Users != nil {
+				issues = append(issues, fmt.Sprintf("%s: member %q of group %q is not in the users", mpath, member.Email, groupMembers.Group.Name))
+			}
+			if _, ok := members[member.Email]; ok {
+				issues = append(issues, fmt.Sprintf("%s: member %q of group %q is duplicated", mpath, member.Email, groupMembers.Group.Name))
+			}
+
+			members[member.Email] = struct{}{}
+		}
+	}
+
+	return issues
+}
+
+// VerifyHashCodes recalculates the hash codes of the State entity and its resources and returns
+// the ones that are different from the stored, e.g. because the state was edited by hand or corrupted.
+// An empty result means all the hash codes are right, the State entity is not changed.
+func (s *State) VerifyHashCodes() []string {
+	if s.Resources == nil || s.Resources.Groups == nil || s.Resources.Users == nil || s.Resources.GroupsMembers == nil {
+		return []string{"resources: missing, the hash codes cannot be verified"}
+	}
+
+	issues := make([]string, 0)
+
+	check := func(path, stored, calculated string) {
+		if stored != calculated {
+			issues = append(issues, fmt.Sprintf("%s: hashCode %q, but it is %q", path, stored, calculated))
+		}
+	}
+
+	groups := make([]*Group, 0, len(s.Resources.Groups.Resources))
+	for idx, group := range s.Resources.Groups.Resources {
+		if group == nil {
+			continue
+		}
+
+		g := *group
+		g.SetHashCode()
+		check(fmt.Sprintf("resources.groups.resources[%d] (%s)", idx, group.Name), group.HashCode, g.HashCode)
+
+		groups = append(groups, &g)
+	}
+
+	gr := GroupsResult{Items: s.Resources.Groups.Items, Resources: groups}
+	gr.SetHashCode()
+	check("resources.groups", s.Resources.Groups.HashCode, gr.HashCode)
+
+	users := make([]*User, 0, len(s.Resources.Users.Resources))
+	for idx, user := range s.Resources.Users.Resources {
+		if user == nil {
+			continue
+		}
+
+		u := *user
+		u.SetHashCode()
+		check(fmt.Sprintf("resources.users.resources[%d] (%s)", idx, user.Email), user.HashCode, u.HashCode)
+
+		users = append(users, &u)
+	}
+
+	ur := UsersResult{Items: s.Resources.Users.Items, Resources: users}
+	ur.SetHashCode()
+	check("resources.users", s.Resources.Users.HashCode, ur.HashCode)
+
+	groupsMembers := make([]*GroupMembers, 0, len(s.Resources.GroupsMembers.Resources))
+	for idx, groupMembers := range s.Resources.GroupsMembers.Resources {
+		if groupMembers == nil || groupMembers.Group == nil {
+			continue
+		}
+		path := fmt.Sprintf("resources.groupsMembers.resources[%d] (%s)", idx, groupMembers.Group.Name)
+
+		g := *groupMembers.Group
+		g.SetHashCode()
+		check(path+".group", groupMembers.Group.HashCode, g.HashCode)
+
+		members := make([]*Member, 0, len(groupMembers.Resources))
+		for midx, member := range groupMembers.Resources {
+			if member == nil {
+				continue
+			}
+
+			m := *member
+			m.SetHashCode()
+			check(fmt.Sprintf("%s.resources[%d] (%s)", path, midx, member.Email), member.HashCode, m.HashCode)
+
+			members = append(members, &m)
+		}
+
+		gm := GroupMembers{Items: groupMembers.Items, Group: &g, Resources: members}
+		gm.SetHashCode()
+		check(path, groupMembers.HashCode, gm.HashCode)
+
+		groupsMembers = append(groupsMembers, &gm)
+	}
+
+	gmr := GroupsMembersResult{Items: s.Resources.GroupsMembers.Items, Resources: groupsMembers}
+	gmr.SetHashCode()
+	check("resources.groupsMembers", s.Resources.GroupsMembers.HashCode, gmr.HashCode)
+
+	state := State{Resources: &StateResources{Groups: &gr, Users: &ur, GroupsMembers: &gmr}}
+	state.SetHashCode()
+	check("hashCode", s.HashCode, state.HashCode)
+
+	return issues
+}
+
+// RecalculateHashCodes calculates again the hash codes of the State entity and its resources in place,
+// e.g. after changing the values of its resources or when they were calculated by a previous version.
+func (s *State) RecalculateHashCodes() {
+	if s.Resources == nil || s.Resources.Groups == nil || s.Resources.Users == nil || s.Resources.GroupsMembers == nil {
+		s.SetHashCode()
+		return
+	}
+
+	for _, group := range s.Resources.Groups.Resources {
+		if group != nil {
+			group.SetHashCode()
+		}
+	}
+	s.Resources.Groups.SetHashCode()
+
+	for _, user := range s.Resources.Users.Resources {
+		if user != nil {
+			user.SetHashCode()
+		}
+	}
+	s.Resources.Users.SetHashCode()
+
+	for _, groupMembers := range s.Resources.GroupsMembers.Resources {
+		if groupMembers == nil {
+			continue
+		}
+
+		if groupMembers.Group != nil {
+			groupMembers.Group.SetHashCode()
+		}
+
+		for _, member := range groupMembers.Resources {
+			if member != nil {
+				member.SetHashCode()
+			}
+		}
+		groupMembers.SetHashCode()
+	}
+	s.Resources.GroupsMembers.SetHashCode()
+
+	s.SetHashCode()
+}
+
+// RemoveGroup removes the group of the given name or email, and its members, from the State entity
+// so the next sync creates it again, the hash codes are calculated again.
+// It returns false when the group is not in the state.
+func (s *State) RemoveGroup(group string) bool {
+	if s.Resources == nil || s.Resources.Groups == nil {
+		return false
+	}
+
+	matches := func(g *Group) bool {
+		return g != nil && (g.Name == group || (g.Email != "" && strings.EqualFold(g.Email, group)))
+	}
+
+	groups := make([]*Group, 0, len(s.Resources.Groups.Resources))
+	for _, g := range s.Resources.Groups.Resources {
+		if g != nil && !matches(g) {
+			groups = append(groups, g)
+		}
+	}
+
+	if len(groups) == len(s.Resources.Groups.Resources) {
+		return false
+	}
+
+	s.Resources.Groups = GroupsResultBuilder().WithResources(groups).Build()
+
+	if s.Resources.GroupsMembers != nil {
+		groupsMembers := make([]*GroupMembers, 0, len(s.Resources.GroupsMembers.Resources))
+		for _, groupMembers := range s.Resources.GroupsMembers.Resources {
+			if groupMembers != nil && !matches(groupMembers.Group) {
+				groupsMembers = append(groupsMembers, groupMembers)
+			}
+		}
+
+		s.Resources.GroupsMembers = GroupsMembersResultBuilder().WithResources(groupsMembers).Build()
+	}
+
+	s.SetHashCode()
+
+	return true
+}
+
+// RemoveUser removes the user of the given email, and its memberships, from the State entity
+// so the next sync creates it and adds it to its groups again, the hash codes are calculated again.
+// It returns false when the user is not in the state.
+func (s *State) RemoveUser(email string) bool {
+	if s.Resources == nil || s.Resources.Users == nil {
+		return false
+	}
+
+	users := make([]*User, 0, len(s.Resources.Users.Resources))
+	for _, user := range s.Resources.Users.Resources {
+		if user != nil && !strings.EqualFold(user.Email, email) {
+			users = append(users, user)
+		}
+	}
+
+	if len(users) == len(s.Resources.Users.Resources) {
+		return false
+	}
+
+	s.Resources.Users = UsersResultBuilder().WithResources(users).Build()
+
+	if s.Resources.GroupsMembers != nil {
+		groupsMembers := make([]*GroupMembers, 0, len(s.Resources.GroupsMembers.Resources))
+		for _, groupMembers := range s.Resources.GroupsMembers.Resources {
+			if groupMembers == nil {
+				continue
+			}
+
+			members := make([]*Member, 0, len(groupMembers.Resources))
+			for _, member := range groupMembers.Resources {
+				if member != nil && !strings.EqualFold(member.Email, email) {
+					members = append(members, member)
+				}
+			}
+
+			groupsMembers = append(groupsMembers, GroupMembersBuilder().WithGroup(groupMembers.Group).WithResources(members).Build())
+		}
+
+		s.Resources.GroupsMembers = GroupsMembersResultBuilder().WithResources(groupsMembers).Build()
+	}
+
+	s.SetHashCode()
+
+	return true
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func checkState() *State {
+	group1 := GroupBuilder().WithIPID("1").WithSCIMID("g1").WithName("group 1").WithEmail("group1@mail.com").Build()
+	group2 := GroupBuilder().WithIPID("2").WithSCIMID("g2").WithName("group 2").WithEmail("group2@mail.com").Build()
+	user1 := UserBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user1@mail.com").WithDisplayName("user 1").WithActive(true).Build()
+	user2 := UserBuilder().WithIPID("2").WithSCIMID("u2").WithEmail("user2@mail.com").WithDisplayName("user 2").WithActive(true).Build()
+
+	return StateBuilder().
+		WithCodeVersion("0.0.1").
+		WithLastSync("2022-01-01T00:00:00Z").
+		WithGroups(GroupsResultBuilder().WithResources([]*Group{group1, group2}).Build()).
+		WithUsers(UsersResultBuilder().WithResources([]*User{user1, user2}).Build()).
+		WithGroupsMembers(GroupsMembersResultBuilder().WithResources([]*GroupMembers{
+			GroupMembersBuilder().WithGroup(group1).WithResources([]*Member{
+				MemberBuilder().WithIPID("1").WithSCIMID("u1").WithEmail("user1@mail.com").Build(),
+				MemberBuilder().WithIPID("2").WithSCIMID("u2").WithEmail("user2@mail.com").Build(),
+			}).Build(),
+			GroupMembersBuilder().WithGroup(group2).WithResources([]*Member{
+				MemberBuilder().WithIPID("2").WithSCIMID("u2").WithEmail("user2@mail.com").Build(),
+			}).Build(),
+		}).Build()).
+		Build()
+}
+
+func TestParseState(t *testing.T) {
+	t.Run("Should return the state", func(t *testing.T) {
+		data, err := json.Marshal(checkState())
+		assert.NoError(t, err)
+
+		state, err := ParseState(data)
+		assert.NoError(t, err)
+		assert.Equal(t, checkState(), state)
+	})
+
+	t.Run("Should return error when the JSON has unknown fields", func(t *testing.T) {
+		state, err := ParseState([]byte(`{"schemaVersion": "1.0.0", "resources": {"groupsUsers": {}}}`))
+		assert.Error(t, err)
+		assert.Nil(t, state)
+	})
+
+	t.Run("Should return error when the JSON is not a state", func(t *testing.T) {
+		state, err := ParseState([]byte(`{"resources": []}`))
+		assert.Error(t, err)
+		assert.Nil(t, state)
+	})
+}
+
+func TestState_Validate(t *testing.T) {
+	t.Run("Should return no issues when the state is valid", func(t *testing.T) {
+		assert.Empty(t, checkState().Validate())
+	})
+
+	t.Run("Should return the issues of the state without resources", func(t *testing.T) {
+		state := &State{SchemaVersion: "0.0.1", LastSync: "yesterday", Checkpoint: "members"}
+
+		assert.Equal(t, []string{
+			`schemaVersion: "0.0.1" is not the current schema version "1.0.0"`,
+			`lastSync: "yesterday" is not a RFC3339 time`,
+			`checkpoint: "members" is not a valid checkpoint`,
+			"resources: missing",
+		}, state.Validate())
+	})
+
+	t.Run("Should return the issues of the resources", func(t *testing.T) {
+		state := checkState()
+		state.Resources.Groups.Items = 3
+		state.Resources.Groups.Resources[1].SCIMID = ""
+		state.Resources.Users.Resources[1].Email = "user1@mail.com"
+		state.Resources.GroupsMembers.Resources[1].Group = GroupBuilder().WithIPID("3").WithSCIMID("g3").WithName("group 3").Build()
+
+		assert.Equal(t, []string{
+			"resources.groups: items 3, but it has 2 groups",
+			`resources.groups.resources[1]: group "group 2" without scimid`,
+			`resources.users.resources[1]: user "user1@mail.com" is duplicated`,
+			`resources.groupsMembers.resources[0].resources[1]: member "user2@mail.com" of group "group 1" is not in the users`,
+			`resources.groupsMembers.resources[1]: group "group 3" is not in the groups`,
+			`resources.groupsMembers.resources[1].resources[0]: member "user2@mail.com" of group "group 3" is not in the users`,
+		}, state.Validate())
+	})
+}
+
+func TestState_VerifyHashCodes(t *testing.T) {
+	t.Run("Should return no issues when the hash codes are right", func(t *testing.T) {
+		data, err := json.Marshal(checkState())
+		assert.NoError(t, err)
+
+		state, err := ParseState(data)
+		assert.NoError(t, err)
+		assert.Empty(t, state.VerifyHashCodes())
+	})
+
+	t.Run("Should return the hash codes of the resources changed", func(t *testing.T) {
+		state := checkState()
+		state.Resources.Users.Resources[0].DisplayName = "changed"
+
+		issues := state.VerifyHashCodes()
+		assert.Len(t, issues, 3)
+		assert.Contains(t, issues[0], "resources.users.resources[0] (user1@mail.com)")
+		assert.Contains(t, issues[1], "resources.users:")
+		assert.Contains(t, issues[2], "hashCode:")
+	})
+
+	t.Run("Should not change the state", func(t *testing.T) {
+		state := checkState()
+		state.HashCode = "tampered"
+
+		issues := state.VerifyHashCodes()
+		assert.Len(t, issues, 1)
+		assert.Equal(t, "tampered", state.HashCode)
+	})
+
+	t.Run("Should return an issue when the resources are missing", func(t *testing.T) {
+		assert.Len(t, (&State{}).VerifyHashCodes(), 1)
+	})
+}
+
+func TestState_RecalculateHashCodes(t *testing.T) {
+	t.Run("Should replace the hash codes of the resources changed", func(t *testing.T) {
+		state := checkState()
+		state.HashCode = "stale"
+		state.Resources.Groups.Resources[0].HashCode = "stale"
+		state.Resources.Users.HashCode = "stale"
+		state.Resources.Users.Resources[1].HashCode = "stale"
+		state.Resources.GroupsMembers.Resources[0].Resources[0].HashCode = "stale"
+
+		state.RecalculateHashCodes()
+		assert.Empty(t, state.VerifyHashCodes())
+		assert.Equal(t, checkState(), state)
+	})
+
+	t.Run("Should not fail when the resources are missing", func(t *testing.T) {
+		state := &State{}
+		state.RecalculateHashCodes()
+		assert.NotEmpty(t, state.HashCode)
+	})
+}
+
+func TestState_RemoveGroup(t *testing.T) {
+	t.Run("Should remove the group by name and its members", func(t *testing.T) {
+		state := checkState()
+
+		assert.True(t, state.RemoveGroup("group 1"))
+		assert.Equal(t, 1, state.Resources.Groups.Items)
+		assert.Equal(t, "group 2", state.Resources.Groups.Resources[0].Name)
+		assert.Equal(t, 1, state.Resources.GroupsMembers.Items)
+		assert.Equal(t, "group 2", state.Resources.GroupsMembers.Resources[0].Group.Name)
+		assert.Equal(t, 2, state.Resources.Users.Items)
+		assert.Empty(t, state.Validate())
+		assert.Empty(t, state.VerifyHashCodes())
+	})
+
+	t.Run("Should remove the group by email", func(t *testing.T) {
+		state := checkState()
+
+		assert.True(t, state.RemoveGroup("GROUP2@mail.com"))
+		assert.Equal(t, 1, state.Resources.Groups.Items)
+		assert.Equal(t, "group 1", state.Resources.Groups.Resources[0].Name)
+		assert.Empty(t, state.VerifyHashCodes())
+	})
+
+	t.Run("Should return false when the group is not in the state", func(t *testing.T) {
+		state := checkState()
+		hashCode := state.HashCode
+
+		assert.False(t, state.RemoveGroup("group 3"))
+		assert.Equal(t, hashCode, state.HashCode)
+	})
+}
+
+func TestState_RemoveUser(t *testing.T) {
+	t.Run("Should remove the user and its memberships", func(t *testing.T) {
+		state := checkState()
+		hashCode := state.HashCode
+
+		assert.True(t, state.RemoveUser("User2@mail.com"))
+		assert.Equal(t, 1, state.Resources.Users.Items)
+		assert.Equal(t, "user1@mail.com", state.Resources.Users.Resources[0].Email)
+		assert.Equal(t, 2, state.Resources.GroupsMembers.Items)
+		assert.Equal(t, 1, state.Resources.GroupsMembers.Resources[0].Items)
+		assert.Equal(t, 0, state.Resources.GroupsMembers.Resources[1].Items)
+		assert.NotEqual(t, hashCode, state.HashCode)
+		assert.Empty(t, state.Validate())
+		assert.Empty(t, state.VerifyHashCodes())
+	})
+
+	t.Run("Should return false when the user is not in the state", func(t *testing.T) {
+		assert.False(t, checkState().RemoveUser("user3@mail.com"))
+	})
+}
@@ -22,6 +22,16 @@ type User struct {
 	Active      bool   `json:"active"`
 	Email       string `json:"email"`
 	HashCode    string `json:"hashCode"`
+
+	// DeactivatedAt is the time (RFC3339) when the user was deactivated in the SCIM side
+	// because it was removed from the Identity Provider, it is only kept in the state.
+	DeactivatedAt string `json:"deactivatedAt,omitempty"`
+
+	// MissingSince is the time (RFC3339) when the user was first seen missing in the Identity Provider.
+	// MissingRuns is the number of consecutive syncs the user has been missing in the Identity Provider.
+	// both are only kept in the state while the user is retained during the removal grace period.
+	MissingSince string `json:"missingSince,omitempty"`
+	MissingRuns  int    `json:"missingRuns,omitempty"`
 }
 
 // GobEncode implements the gob.GobEncoder interface for User entity.
@@ -34,7 +44,10 @@ func (u *User) GobEncode() ([]byte, error) {
 	if err := enc.Encode(u.IPID); err != nil {
 		panic(err)
 	}
-	if err := enc.Encode(u.Name); err != nil {
+	if err := enc.Encode(u.Name.FamilyName); err != nil {
+		panic(err)
+	}
+	if err := enc.Encode(u.Name.GivenName); err != nil {
 		panic(err)
 	}
 	if err := enc.Encode(u.DisplayName); err != nil {
@@ -71,6 +84,16 @@ func (ur *UsersResult) MarshalJSON() ([]byte, error) {
 	return json.MarshalIndent(*ur, "", "  ")
 }
 
+// GobEncode implements the gob.GobEncoder interface for UsersResult entity.
+// only the items and the encoding of the resources are used in the hash calculation.
+func (ur *UsersResult) GobEncode() ([]byte, error) {
+	resources := make([]gob.GobEncoder, 0, len(ur.Resources))
+	for _, user := range ur.Resources {
+		resources = append(resources, user)
+	}
+	return gobEncodeResources(ur.Items, resources...)
+}
+
 // SetHashCode is a helper function to avoid errors when calculating hash code.
 // this method discards fields that are not used in the hash calculation.
 // only fields coming from the Identity Provider are used.
@@ -54,6 +54,12 @@ func (b *UserBuilderChoice) WithEmail(email string) *UserBuilderChoice {
 	return b
 }
 
+// WithDeactivatedAt sets the DeactivatedAt field of the User entity.
+func (b *UserBuilderChoice) WithDeactivatedAt(deactivatedAt string) *UserBuilderChoice {
+	b.u.DeactivatedAt = deactivatedAt
+	return b
+}
+
 // Build returns the User entity.
 func (b *UserBuilderChoice) Build() *User {
 	u := b.u
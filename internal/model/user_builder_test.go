@@ -77,6 +77,23 @@ func TestUserBuilder(t *testing.T) {
 		assert.Equal(t, "", ub.u.Email)
 		assert.Equal(t, u.HashCode, ub.u.HashCode)
 	})
+
+	t.Run("deactivated at doesn't change the hash code", func(t *testing.T) {
+		ub := UserBuilder().
+			WithIPID("ipid").
+			WithEmail("email").
+			WithDeactivatedAt("2022-12-01T00:00:00Z").
+			Build()
+
+		u := &User{
+			IPID:  "ipid",
+			Email: "email",
+		}
+		u.SetHashCode()
+
+		assert.Equal(t, "2022-12-01T00:00:00Z", ub.DeactivatedAt)
+		assert.Equal(t, u.HashCode, ub.HashCode)
+	})
 }
 
 func TestUsersResultBuilder(t *testing.T) {
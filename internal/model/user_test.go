@@ -110,7 +110,10 @@ func TestUser_GobEncode(t *testing.T) {
 			if err := enc.Encode(tt.u.IPID); err != nil {
 				panic(err)
 			}
-			if err := enc.Encode(tt.u.Name); err != nil {
+			if err := enc.Encode(tt.u.Name.FamilyName); err != nil {
+				panic(err)
+			}
+			if err := enc.Encode(tt.u.Name.GivenName); err != nil {
 				panic(err)
 			}
 			if err := enc.Encode(tt.u.DisplayName); err != nil {
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultHTTPTimeout is the timeout of the notifications sent over HTTP
+const defaultHTTPTimeout = 10 * time.Second
+
+var (
+	// ErrURLEmpty is returned when the url of a webhook is empty.
+	ErrURLEmpty = errors.New("notify: url cannot be empty")
+
+	// ErrPublisherNil is returned when the SNS publisher is nil.
+	ErrPublisherNil = errors.New("notify: publisher cannot be nil")
+
+	// ErrTopicARNEmpty is returned when the SNS topic ARN is empty.
+	ErrTopicARNEmpty = errors.New("notify: topic arn cannot be empty")
+)
+
+// SlackNotifier sends the notifications to a Slack incoming webhook.
+// reference: https://api.slack.com/messaging/webhooks
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackNotifier returns a new SlackNotifier posting to the incoming webhook url.
+func NewSlackNotifier(url string, opts ...HTTPNotifierOption) (*SlackNotifier, error) {
+	if url == "" {
+		return nil, ErrURLEmpty
+	}
+
+	o := newHTTPNotifierOptions(opts...)
+
+	return &SlackNotifier{url: url, client: o.client}, nil
+}
+
+// slackMessage is the payload of the Slack incoming webhooks
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify posts the subject, in bold, and the text of the message.
+func (n *SlackNotifier) Notify(ctx context.Context, msg *Message) error {
+	text := msg.Text
+	if msg.Subject != "" {
+		text = fmt.Sprintf("*%s*\n%s", msg.Subject, msg.Text)
+	}
+
+	return postJSON(ctx, n.client, n.url, nil, &slackMessage{Text: text})
+}
+
+// WebhookNotifier sends the notifications as JSON documents to a generic webhook,
+// the document has the subject, the text and the report of the sync.
+type WebhookNotifier struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+}
+
+// NewWebhookNotifier returns a new WebhookNotifier posting to the url.
+func NewWebhookNotifier(url string, opts ...HTTPNotifierOption) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, ErrURLEmpty
+	}
+
+	o := newHTTPNotifierOptions(opts...)
+
+	return &WebhookNotifier{url: url, client: o.client, headers: o.headers}, nil
+}
+
+// Notify posts the message as JSON.
+func (n *WebhookNotifier) Notify(ctx context.Context, msg *Message) error {
+	return postJSON(ctx, n.client, n.url, n.headers, msg)
+}
+
+// SNSPublisher is the interface to publish messages in an AWS SNS topic, implemented by aws.SNSService.
+type SNSPublisher interface {
+	Publish(ctx context.Context, topicARN, subject, message string) error
+}
+
+// SNSNotifier publishes the notifications in an AWS SNS topic.
+type SNSNotifier struct {
+	publisher SNSPublisher
+	topicARN  string
+}
+
+// NewSNSNotifier returns a new SNSNotifier publishing in the topic.
+func NewSNSNotifier(publisher SNSPublisher, topicARN string) (*SNSNotifier, error) {
+	if publisher == nil {
+		return nil, ErrPublisherNil
+	}
+	if topicARN == "" {
+		return nil, ErrTopicARNEmpty
+	}
+
+	return &SNSNotifier{publisher: publisher, topicARN: topicARN}, nil
+}
+
+// Notify publishes the text of the message with its subject.
+func (n *SNSNotifier) Notify(ctx context.Context, msg *Message) error {
+	if err := n.publisher.Publish(ctx, n.topicARN, msg.Subject, msg.Text); err != nil {
+		return fmt.Errorf("notify: error publishing in sns: %w", err)
+	}
+
+	return nil
+}
+
+// postJSON posts the value as JSON, any status code other than 2xx is an error.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("notify: error marshalling message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify: error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// drain the body to reuse the connection
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notify: error sending request, status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/aws"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	t.Run("Should post the message to the webhook", func(t *testing.T) {
+		var got map[string]interface{}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+			body, _ := io.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(body, &got))
+		}))
+		defer srv.Close()
+
+		n, err := NewSlackNotifier(srv.URL)
+		assert.NoError(t, err)
+
+		assert.NoError(t, n.Notify(context.Background(), &Message{Subject: "subject", Text: "text"}))
+		assert.Equal(t, map[string]interface{}{"text": "*subject*\ntext"}, got)
+	})
+
+	t.Run("Should return an error when the webhook fails", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer srv.Close()
+
+		n, err := NewSlackNotifier(srv.URL)
+		assert.NoError(t, err)
+
+		err = n.Notify(context.Background(), &Message{Text: "text"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("Should return an error when the url is empty", func(t *testing.T) {
+		n, err := NewSlackNotifier("")
+		assert.ErrorIs(t, err, ErrURLEmpty)
+		assert.Nil(t, n)
+	})
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	t.Run("Should post the message and the report as JSON", func(t *testing.T) {
+		var got Message
+		var gotAuth string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Get("Authorization")
+
+			body, _ := io.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(body, &got))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		n, err := NewWebhookNotifier(srv.URL, WithHeaders(map[string]string{"Authorization": "Bearer secret"}))
+		assert.NoError(t, err)
+
+		msg := &Message{Subject: "subject", Text: "text", Report: &core.SyncReport{Success: true, Groups: core.OperationsReport{Create: 1}}}
+		assert.NoError(t, n.Notify(context.Background(), msg))
+		assert.Equal(t, "Bearer secret", gotAuth)
+		assert.Equal(t, "subject", got.Subject)
+		assert.Equal(t, "text", got.Text)
+		assert.Equal(t, 1, got.Report.Groups.Create)
+	})
+
+	t.Run("Should return an error when the url is empty", func(t *testing.T) {
+		n, err := NewWebhookNotifier("")
+		assert.ErrorIs(t, err, ErrURLEmpty)
+		assert.Nil(t, n)
+	})
+}
+
+func TestSNSNotifier_Notify(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	topicARN := "arn:aws:sns:us-east-1:123456789012:idpscim"
+
+	t.Run("Should publish the message in the topic", func(t *testing.T) {
+		mockSNSClientAPI := mocks.NewMockSNSClientAPI(mockCtrl)
+		mockSNSClientAPI.EXPECT().Publish(context.Background(), &sns.PublishInput{
+			TopicArn: awssdk.String(topicARN),
+			Subject:  awssdk.String("subject"),
+			Message:  awssdk.String("text"),
+		}).Return(&sns.PublishOutput{}, nil).Times(1)
+
+		svc, err := aws.NewSNSService(mockSNSClientAPI)
+		assert.NoError(t, err)
+
+		n, err := NewSNSNotifier(svc, topicARN)
+		assert.NoError(t, err)
+
+		assert.NoError(t, n.Notify(context.Background(), &Message{Subject: "subject", Text: "text"}))
+	})
+
+	t.Run("Should return an error when the publish fails", func(t *testing.T) {
+		mockSNSClientAPI := mocks.NewMockSNSClientAPI(mockCtrl)
+		mockSNSClientAPI.EXPECT().Publish(context.Background(), gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		svc, err := aws.NewSNSService(mockSNSClientAPI)
+		assert.NoError(t, err)
+
+		n, err := NewSNSNotifier(svc, topicARN)
+		assert.NoError(t, err)
+
+		err = n.Notify(context.Background(), &Message{Text: "text"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "test error")
+	})
+
+	t.Run("Should return an error without publisher or topic", func(t *testing.T) {
+		n, err := NewSNSNotifier(nil, topicARN)
+		assert.ErrorIs(t, err, ErrPublisherNil)
+		assert.Nil(t, n)
+
+		svc, err := aws.NewSNSService(mocks.NewMockSNSClientAPI(mockCtrl))
+		assert.NoError(t, err)
+
+		n, err = NewSNSNotifier(svc, "")
+		assert.ErrorIs(t, err, ErrTopicARNEmpty)
+		assert.Nil(t, n)
+	})
+}
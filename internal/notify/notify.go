@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+)
+
+const (
+	// DefaultThreshold is the default minimum number of changes of a successful sync to be notified,
+	// so the syncs without changes are not notified. The failed syncs are always notified.
+	DefaultThreshold = 1
+
+	// DefaultSubjectTemplate is the default template of the subject of the notifications.
+	DefaultSubjectTemplate = `idp-scim-sync: {{if .Report.Success}}sync{{if .Report.DryRun}} dry run{{end}} with {{.Changes}} changes{{else}}sync failed{{end}}`
+
+	// DefaultTextTemplate is the default template of the text of the notifications.
+	DefaultTextTemplate = `{{if .Report.Success}}The sync{{if .Report.DryRun}} dry run{{end}} finished with {{.Changes}} changes in {{.Report.Duration}}.{{else}}The sync failed after {{.Report.Duration}}: {{.Report.Error}}{{end}}
+Groups: {{.Report.Groups.Create}} created, {{.Report.Groups.Update}} updated, {{.Report.Groups.Delete}} deleted
+Users: {{.Report.Users.Create}} created, {{.Report.Users.Update}} updated, {{.Report.Users.Deactivate}} deactivated, {{.Report.Users.Delete}} deleted
+Groups members: {{.Report.GroupsMembers.Create}} added, {{.Report.GroupsMembers.Delete}} removed`
+)
+
+var (
+	// ErrNotifierNil is returned when a notifier is nil.
+	ErrNotifierNil = errors.New("notify: notifier cannot be nil")
+
+	// ErrTemplateInvalid is returned when a message template cannot be parsed.
+	ErrTemplateInvalid = errors.New("notify: invalid template")
+
+	// ErrReportNil is returned when the sync report to notify is nil.
+	ErrReportNil = errors.New("notify: report cannot be nil")
+)
+
+// Message is the notification of the outcome of a sync.
+type Message struct {
+	Subject string           `json:"subject"`
+	Text    string           `json:"text"`
+	Report  *core.SyncReport `json:"report"`
+}
+
+// Notifier sends the notifications to a destination, e.g. a chat or a topic.
+type Notifier interface {
+	Notify(ctx context.Context, msg *Message) error
+}
+
+// TemplateData is the data available in the templates of the messages.
+type TemplateData struct {
+	// Report is the report of the sync
+	Report *core.SyncReport
+
+	// Changes is the number of groups, users and groups members changed by the sync
+	Changes int
+}
+
+// Dispatcher renders the messages of the sync reports and sends them to all the notifiers.
+type Dispatcher struct {
+	notifiers []Notifier
+	threshold int
+
+	subjectTemplate string
+	textTemplate    string
+
+	subject *template.Template
+	text    *template.Template
+}
+
+// NewDispatcher returns a new Dispatcher sending the notifications to the given notifiers.
+func NewDispatcher(notifiers []Notifier, opts ...DispatcherOption) (*Dispatcher, error) {
+	for _, n := range notifiers {
+		if n == nil {
+			return nil, ErrNotifierNil
+		}
+	}
+
+	d := &Dispatcher{
+		notifiers:       notifiers,
+		threshold:       DefaultThreshold,
+		subjectTemplate: DefaultSubjectTemplate,
+		textTemplate:    DefaultTextTemplate,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	var err error
+	if d.subject, err = template.New("subject").Parse(d.subjectTemplate); err != nil {
+		return nil, fmt.Errorf("%w: subject: %v", ErrTemplateInvalid, err)
+	}
+	if d.text, err = template.New("text").Parse(d.textTemplate); err != nil {
+		return nil, fmt.Errorf("%w: text: %v", ErrTemplateInvalid, err)
+	}
+
+	return d, nil
+}
+
+// Notify sends the notification of the sync report to all the notifiers when the sync failed
+// or its changes reach the threshold, all the notifiers are tried even when some of them fail.
+func (d *Dispatcher) Notify(ctx context.Context, report *core.SyncReport) error {
+	if report == nil {
+		return ErrReportNil
+	}
+
+	data := &TemplateData{
+		Report:  report,
+		Changes: report.Changes(),
+	}
+
+	if report.Success && data.Changes < d.threshold {
+		log.WithFields(log.Fields{
+			"changes":   data.Changes,
+			"threshold": d.threshold,
+		}).Debug("notify: sync changes under the threshold, skipping notification")
+		return nil
+	}
+
+	msg, err := d.message(data)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, n := range d.notifiers {
+		if err := n.Notify(ctx, msg); err != nil {
+			log.WithError(err).Warn("notify: error sending notification")
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("notify: %d of %d notifiers failed: %s", len(errs), len(d.notifiers), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// message renders the message of the template data.
+func (d *Dispatcher) message(data *TemplateData) (*Message, error) {
+	var subject, text bytes.Buffer
+
+	if err := d.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("notify: error rendering subject: %w", err)
+	}
+	if err := d.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("notify: error rendering text: %w", err)
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+		Report:  data.Report,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a Notifier recording the notified messages
+type recorder struct {
+	messages []*Message
+	err      error
+}
+
+func (r *recorder) Notify(ctx context.Context, msg *Message) error {
+	r.messages = append(r.messages, msg)
+	return r.err
+}
+
+func TestNewDispatcher(t *testing.T) {
+	t.Run("Should return a dispatcher with the default options", func(t *testing.T) {
+		d, err := NewDispatcher([]Notifier{&recorder{}})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultThreshold, d.threshold)
+		assert.Equal(t, DefaultSubjectTemplate, d.subjectTemplate)
+		assert.Equal(t, DefaultTextTemplate, d.textTemplate)
+	})
+
+	t.Run("Should return an error when a notifier is nil", func(t *testing.T) {
+		d, err := NewDispatcher([]Notifier{&recorder{}, nil})
+		assert.ErrorIs(t, err, ErrNotifierNil)
+		assert.Nil(t, d)
+	})
+
+	t.Run("Should return an error when a template is invalid", func(t *testing.T) {
+		d, err := NewDispatcher([]Notifier{&recorder{}}, WithTextTemplate("{{.Report"))
+		assert.ErrorIs(t, err, ErrTemplateInvalid)
+		assert.Nil(t, d)
+
+		d, err = NewDispatcher([]Notifier{&recorder{}}, WithSubjectTemplate("{{end}}"))
+		assert.ErrorIs(t, err, ErrTemplateInvalid)
+		assert.Nil(t, d)
+	})
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	changed := &core.SyncReport{
+		Success:       true,
+		Duration:      "1.5s",
+		Groups:        core.OperationsReport{Create: 1, Equal: 3},
+		Users:         core.OperationsReport{Create: 2, Deactivate: 1},
+		GroupsMembers: core.OperationsReport{Create: 2, Delete: 1},
+	}
+
+	t.Run("Should notify the changes with the default templates", func(t *testing.T) {
+		r := &recorder{}
+		d, err := NewDispatcher([]Notifier{r})
+		assert.NoError(t, err)
+
+		assert.NoError(t, d.Notify(context.Background(), changed))
+		assert.Equal(t, 1, len(r.messages))
+
+		msg := r.messages[0]
+		assert.Equal(t, "idp-scim-sync: sync with 7 changes", msg.Subject)
+		assert.Equal(t, `The sync finished with 7 changes in 1.5s.
+Groups: 1 created, 0 updated, 0 deleted
+Users: 2 created, 0 updated, 1 deactivated, 0 deleted
+Groups members: 2 added, 1 removed`, msg.Text)
+		assert.Equal(t, changed, msg.Report)
+	})
+
+	t.Run("Should not notify the syncs under the threshold", func(t *testing.T) {
+		r := &recorder{}
+		d, err := NewDispatcher([]Notifier{r})
+		assert.NoError(t, err)
+
+		assert.NoError(t, d.Notify(context.Background(), &core.SyncReport{Success: true, Groups: core.OperationsReport{Equal: 10}}))
+		assert.Equal(t, 0, len(r.messages))
+
+		d, err = NewDispatcher([]Notifier{r}, WithThreshold(10))
+		assert.NoError(t, err)
+
+		assert.NoError(t, d.Notify(context.Background(), changed))
+		assert.Equal(t, 0, len(r.messages))
+	})
+
+	t.Run("Should notify all the syncs with threshold zero", func(t *testing.T) {
+		r := &recorder{}
+		d, err := NewDispatcher([]Notifier{r}, WithThreshold(0))
+		assert.NoError(t, err)
+
+		assert.NoError(t, d.Notify(context.Background(), &core.SyncReport{Success: true}))
+		assert.Equal(t, 1, len(r.messages))
+	})
+
+	t.Run("Should always notify the failed syncs", func(t *testing.T) {
+		r := &recorder{}
+		d, err := NewDispatcher([]Notifier{r}, WithThreshold(100))
+		assert.NoError(t, err)
+
+		assert.NoError(t, d.Notify(context.Background(), &core.SyncReport{Success: false, Duration: "2s", Error: "test error"}))
+		assert.Equal(t, 1, len(r.messages))
+		assert.Equal(t, "idp-scim-sync: sync failed", r.messages[0].Subject)
+		assert.Contains(t, r.messages[0].Text, "The sync failed after 2s: test error")
+	})
+
+	t.Run("Should render the custom templates", func(t *testing.T) {
+		r := &recorder{}
+		d, err := NewDispatcher([]Notifier{r},
+			WithSubjectTemplate("{{if .Report.DryRun}}[dry run] {{end}}{{.Changes}} changes"),
+			WithTextTemplate("{{.Report.Groups.Create}} groups created"),
+		)
+		assert.NoError(t, err)
+
+		report := *changed
+		report.DryRun = true
+		assert.NoError(t, d.Notify(context.Background(), &report))
+		assert.Equal(t, "[dry run] 7 changes", r.messages[0].Subject)
+		assert.Equal(t, "1 groups created", r.messages[0].Text)
+	})
+
+	t.Run("Should try all the notifiers and return their errors", func(t *testing.T) {
+		failing := &recorder{err: errors.New("test error")}
+		ok := &recorder{}
+		d, err := NewDispatcher([]Notifier{failing, ok})
+		assert.NoError(t, err)
+
+		err = d.Notify(context.Background(), changed)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "1 of 2 notifiers failed: test error")
+		assert.Equal(t, 1, len(failing.messages))
+		assert.Equal(t, 1, len(ok.messages))
+	})
+
+	t.Run("Should return an error when the report is nil", func(t *testing.T) {
+		d, err := NewDispatcher([]Notifier{&recorder{}})
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, d.Notify(context.Background(), nil), ErrReportNil)
+	})
+}
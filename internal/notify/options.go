@@ -0,0 +1,72 @@
+package notify
+
+import "net/http"
+
+// DispatcherOption is a function that can be used to configure the Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithThreshold sets the minimum number of changes of a successful sync to be notified, 0 notifies all the syncs.
+func WithThreshold(threshold int) DispatcherOption {
+	return func(d *Dispatcher) {
+		if threshold >= 0 {
+			d.threshold = threshold
+		}
+	}
+}
+
+// WithSubjectTemplate sets the text/template of the subject of the notifications, the data is a TemplateData.
+func WithSubjectTemplate(tmpl string) DispatcherOption {
+	return func(d *Dispatcher) {
+		if tmpl != "" {
+			d.subjectTemplate = tmpl
+		}
+	}
+}
+
+// WithTextTemplate sets the text/template of the text of the notifications, the data is a TemplateData.
+func WithTextTemplate(tmpl string) DispatcherOption {
+	return func(d *Dispatcher) {
+		if tmpl != "" {
+			d.textTemplate = tmpl
+		}
+	}
+}
+
+type httpNotifierOptions struct {
+	client  *http.Client
+	headers map[string]string
+}
+
+func newHTTPNotifierOptions(opts ...HTTPNotifierOption) *httpNotifierOptions {
+	o := &httpNotifierOptions{
+		client:  &http.Client{Timeout: defaultHTTPTimeout},
+		headers: make(map[string]string),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// HTTPNotifierOption is a function that can be used to configure the notifiers sending the notifications over HTTP.
+type HTTPNotifierOption func(*httpNotifierOptions)
+
+// WithHTTPClient sets the HTTP client used to send the notifications.
+func WithHTTPClient(client *http.Client) HTTPNotifierOption {
+	return func(o *httpNotifierOptions) {
+		if client != nil {
+			o.client = client
+		}
+	}
+}
+
+// WithHeaders sets additional headers of the requests, e.g. the authentication of the webhook.
+func WithHeaders(headers map[string]string) HTTPNotifierOption {
+	return func(o *httpNotifierOptions) {
+		for k, v := range headers {
+			o.headers[k] = v
+		}
+	}
+}
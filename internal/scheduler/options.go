@@ -0,0 +1,50 @@
+package scheduler
+
+import "time"
+
+// SchedulerOption is a function that configures the Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithCron sets the cron schedule of the runs, in the standard cron format (minute hour day-of-month month day-of-week)
+// or a descriptor like "@hourly" or "@every 15m".
+func WithCron(spec string) SchedulerOption {
+	return func(s *Scheduler) {
+		s.cronSpec = spec
+	}
+}
+
+// WithInterval sets the interval between the end of a run and the start of the next one.
+func WithInterval(interval time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.interval = interval
+	}
+}
+
+// WithJitter sets the maximum random delay added to the start of each run,
+// this avoids several instances calling the APIs at the same time.
+func WithJitter(jitter time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.jitter = jitter
+	}
+}
+
+// WithRunOnStart runs the job as soon as the scheduler starts, instead of waiting for the first scheduled time.
+func WithRunOnStart(runOnStart bool) SchedulerOption {
+	return func(s *Scheduler) {
+		s.runOnStart = runOnStart
+	}
+}
+
+// WithShutdownTimeout sets the maximum time to wait for the run in progress when the scheduler stops, 0 waits forever.
+func WithShutdownTimeout(timeout time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithStatusHandler sets a function called with the status of the scheduler after each run.
+func WithStatusHandler(handler func(Status)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.onStatus = handler
+	}
+}
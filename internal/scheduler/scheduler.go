@@ -0,0 +1,285 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrJobNil is returned when the job is nil.
+	ErrJobNil = errors.New("scheduler: job cannot be nil")
+
+	// ErrScheduleNil is returned when neither a cron schedule nor an interval is given.
+	ErrScheduleNil = errors.New("scheduler: a cron schedule or an interval is required")
+
+	// ErrScheduleInvalid is returned when the cron schedule or the interval is not valid.
+	ErrScheduleInvalid = errors.New("scheduler: schedule is not valid")
+
+	// ErrRunInProgress is returned when a run is requested while another one is in progress.
+	ErrRunInProgress = errors.New("scheduler: a run is already in progress")
+)
+
+// Job is the function run by the scheduler.
+type Job func(ctx context.Context) error
+
+// Status is the status of the scheduler and its last run.
+type Status struct {
+	Running        bool      `json:"running"`
+	Runs           int       `json:"runs"`
+	Failures       int       `json:"failures"`
+	LastStartedAt  time.Time `json:"lastStartedAt,omitempty"`
+	LastFinishedAt time.Time `json:"lastFinishedAt,omitempty"`
+	LastDuration   string    `json:"lastDuration,omitempty"`
+	LastSuccess    bool      `json:"lastSuccess"`
+	LastError      string    `json:"lastError,omitempty"`
+	NextRunAt      time.Time `json:"nextRunAt,omitempty"`
+}
+
+// Scheduler runs a job on a cron schedule or at a fixed interval, without overlapping runs.
+type Scheduler struct {
+	job             Job
+	cronSpec        string
+	interval        time.Duration
+	jitter          time.Duration
+	runOnStart      bool
+	shutdownTimeout time.Duration
+	onStatus        func(Status)
+
+	schedule cron.Schedule
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	runCtx  context.Context
+	running bool
+	status  Status
+}
+
+// NewScheduler returns a new Scheduler of the given job.
+func NewScheduler(job Job, opts ...SchedulerOption) (*Scheduler, error) {
+	if job == nil {
+		return nil, ErrJobNil
+	}
+
+	s := &Scheduler{
+		job:    job,
+		runCtx: context.Background(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	switch {
+	case s.cronSpec != "" && s.interval != 0:
+		return nil, fmt.Errorf("%w: the cron schedule and the interval are exclusive", ErrScheduleInvalid)
+	case s.cronSpec != "":
+		schedule, err := cron.ParseStandard(s.cronSpec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrScheduleInvalid, s.cronSpec, err)
+		}
+		s.schedule = schedule
+	case s.interval < 0:
+		return nil, fmt.Errorf("%w: negative interval %s", ErrScheduleInvalid, s.interval)
+	case s.interval > 0:
+		s.schedule = intervalSchedule(s.interval)
+	default:
+		return nil, ErrScheduleNil
+	}
+
+	if s.jitter < 0 {
+		return nil, fmt.Errorf("%w: negative jitter %s", ErrScheduleInvalid, s.jitter)
+	}
+
+	return s, nil
+}
+
+// Run runs the job on its schedule until the context is done.
+//
+// When the context is done, no new run is started and the run in progress, if any, is waited to finish,
+// the run is canceled when it doesn't finish before the shutdown timeout (0 waits forever).
+// The runs don't use the given context, so they are not interrupted in the middle of an operation.
+func (s *Scheduler) Run(ctx context.Context) error {
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	defer cancelRuns()
+
+	s.mu.Lock()
+	s.runCtx = runCtx
+	s.mu.Unlock()
+
+	next := s.nextRun(time.Now(), s.runOnStart)
+
+	for {
+		s.setNextRunAt(next)
+
+		log.WithField("nextRunAt", next.Format(time.RFC3339)).Info("scheduler: next run scheduled")
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return s.shutdown(cancelRuns)
+		case <-timer.C:
+		}
+
+		done, err := s.Trigger()
+		if err != nil {
+			log.WithError(err).Warn("scheduler: scheduled run skipped")
+		} else {
+			select {
+			case <-ctx.Done():
+				return s.shutdown(cancelRuns)
+			case <-done:
+			}
+		}
+
+		// the next run is computed once the current one finished, this avoids piling up runs
+		next = s.nextRun(time.Now(), false)
+	}
+}
+
+// Trigger starts a run of the job out of the schedule, the returned channel is closed when the run finishes.
+// ErrRunInProgress is returned when a run is in progress.
+func (s *Scheduler) Trigger() (<-chan struct{}, error) {
+	return s.TriggerJob(s.job)
+}
+
+// TriggerJob starts a run of the given job instead of the scheduled one, e.g. a dry run,
+// the run is recorded in the status and it doesn't overlap with the scheduled runs.
+func (s *Scheduler) TriggerJob(job Job) (<-chan struct{}, error) {
+	if job == nil {
+		return nil, ErrJobNil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return nil, ErrRunInProgress
+	}
+
+	s.running = true
+	s.status.Running = true
+	s.status.LastStartedAt = time.Now()
+	s.wg.Add(1)
+
+	done := make(chan struct{})
+	go func(ctx context.Context) {
+		defer s.wg.Done()
+		defer close(done)
+
+		s.finish(job(ctx))
+	}(s.runCtx)
+
+	return done, nil
+}
+
+// Status returns the status of the scheduler and its last run.
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// finish records the result of the run.
+func (s *Scheduler) finish(err error) {
+	s.mu.Lock()
+
+	s.running = false
+	s.status.Running = false
+	s.status.Runs++
+	s.status.LastFinishedAt = time.Now()
+	s.status.LastDuration = s.status.LastFinishedAt.Sub(s.status.LastStartedAt).String()
+	s.status.LastSuccess = err == nil
+	s.status.LastError = ""
+
+	if err != nil {
+		s.status.Failures++
+		s.status.LastError = err.Error()
+	}
+
+	status := s.status
+	s.mu.Unlock()
+
+	fields := log.Fields{
+		"duration": status.LastDuration,
+		"runs":     status.Runs,
+		"failures": status.Failures,
+	}
+	if err != nil {
+		log.WithFields(fields).WithError(err).Error("scheduler: run failed")
+	} else {
+		log.WithFields(fields).Info("scheduler: run completed")
+	}
+
+	if s.onStatus != nil {
+		s.onStatus(status)
+	}
+}
+
+// shutdown waits the runs in progress, canceling them after the shutdown timeout.
+func (s *Scheduler) shutdown(cancelRuns context.CancelFunc) error {
+	s.setNextRunAt(time.Time{})
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	if s.Status().Running {
+		log.WithField("timeout", s.shutdownTimeout.String()).Info("scheduler: shutting down, waiting for the run in progress")
+	}
+
+	if s.shutdownTimeout <= 0 {
+		<-done
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(s.shutdownTimeout):
+		log.Warn("scheduler: shutdown timeout reached, canceling the run in progress")
+		cancelRuns()
+		<-done
+		return fmt.Errorf("scheduler: run canceled after the shutdown timeout of %s", s.shutdownTimeout)
+	}
+}
+
+// nextRun returns the time of the next run after now, delayed by a random jitter.
+func (s *Scheduler) nextRun(now time.Time, immediate bool) time.Time {
+	next := now
+	if !immediate {
+		next = s.schedule.Next(now)
+	}
+
+	if s.jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(s.jitter))))
+	}
+
+	return next
+}
+
+// intervalSchedule is a cron.Schedule that runs at a fixed interval.
+type intervalSchedule time.Duration
+
+// Next returns the time after the interval.
+func (i intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+func (s *Scheduler) setNextRunAt(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.NextRunAt = next
+}
@@ -0,0 +1,232 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewScheduler(t *testing.T) {
+	job := func(ctx context.Context) error { return nil }
+
+	t.Run("Should return a scheduler with a cron schedule", func(t *testing.T) {
+		s, err := NewScheduler(job, WithCron("*/15 * * * *"))
+		assert.NoError(t, err)
+		assert.NotNil(t, s)
+
+		next := s.schedule.Next(time.Date(2022, 1, 10, 10, 1, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 1, 10, 10, 15, 0, 0, time.UTC), next)
+	})
+
+	t.Run("Should return a scheduler with an interval", func(t *testing.T) {
+		s, err := NewScheduler(job, WithInterval(time.Minute), WithJitter(time.Second))
+		assert.NoError(t, err)
+		assert.NotNil(t, s)
+
+		now := time.Date(2022, 1, 10, 10, 1, 0, 0, time.UTC)
+		next := s.nextRun(now, false)
+		assert.True(t, !next.Before(now.Add(time.Minute)) && next.Before(now.Add(time.Minute+time.Second)))
+	})
+
+	t.Run("Should return an error when the job is nil", func(t *testing.T) {
+		s, err := NewScheduler(nil, WithInterval(time.Minute))
+		assert.ErrorIs(t, err, ErrJobNil)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should return an error without schedule", func(t *testing.T) {
+		s, err := NewScheduler(job)
+		assert.ErrorIs(t, err, ErrScheduleNil)
+		assert.Nil(t, s)
+	})
+
+	t.Run("Should return an error with an invalid schedule", func(t *testing.T) {
+		_, err := NewScheduler(job, WithCron("every minute"))
+		assert.ErrorIs(t, err, ErrScheduleInvalid)
+
+		_, err = NewScheduler(job, WithCron("@hourly"), WithInterval(time.Minute))
+		assert.ErrorIs(t, err, ErrScheduleInvalid)
+
+		_, err = NewScheduler(job, WithInterval(-time.Minute))
+		assert.ErrorIs(t, err, ErrScheduleInvalid)
+
+		_, err = NewScheduler(job, WithInterval(time.Minute), WithJitter(-time.Second))
+		assert.ErrorIs(t, err, ErrScheduleInvalid)
+	})
+}
+
+func TestScheduler_Run(t *testing.T) {
+	t.Run("Should run the job on the schedule and record its status", func(t *testing.T) {
+		var runs int32
+		job := func(ctx context.Context) error {
+			if atomic.AddInt32(&runs, 1) == 2 {
+				return errors.New("test error")
+			}
+			return nil
+		}
+
+		statuses := make(chan Status, 10)
+		s, err := NewScheduler(job, WithInterval(10*time.Millisecond), WithRunOnStart(true), WithStatusHandler(func(st Status) { statuses <- st }))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() { errCh <- s.Run(ctx) }()
+
+		first := <-statuses
+		assert.True(t, first.LastSuccess)
+		assert.Equal(t, 1, first.Runs)
+
+		second := <-statuses
+		assert.False(t, second.LastSuccess)
+		assert.Equal(t, "test error", second.LastError)
+		assert.Equal(t, 1, second.Failures)
+
+		cancel()
+		assert.NoError(t, <-errCh)
+	})
+
+	t.Run("Should not overlap runs", func(t *testing.T) {
+		release := make(chan struct{})
+		started := make(chan struct{})
+		var running, maxRunning int32
+
+		job := func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			if n > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, n)
+			}
+			started <- struct{}{}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return nil
+		}
+
+		s, err := NewScheduler(job, WithInterval(time.Millisecond), WithRunOnStart(true))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() { errCh <- s.Run(ctx) }()
+
+		<-started
+		assert.True(t, s.Status().Running)
+
+		_, err = s.Trigger()
+		assert.ErrorIs(t, err, ErrRunInProgress)
+
+		cancel()
+		close(release)
+		assert.NoError(t, <-errCh)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+		assert.Equal(t, 1, s.Status().Runs)
+	})
+
+	t.Run("Should wait the run in progress on shutdown without canceling it", func(t *testing.T) {
+		started := make(chan struct{})
+		var canceled int32
+
+		job := func(ctx context.Context) error {
+			close(started)
+			select {
+			case <-ctx.Done():
+				atomic.StoreInt32(&canceled, 1)
+			case <-time.After(50 * time.Millisecond):
+			}
+			return nil
+		}
+
+		s, err := NewScheduler(job, WithInterval(time.Hour), WithRunOnStart(true), WithShutdownTimeout(time.Second))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() { errCh <- s.Run(ctx) }()
+
+		<-started
+		cancel()
+
+		assert.NoError(t, <-errCh)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&canceled))
+		assert.True(t, s.Status().LastSuccess)
+	})
+
+	t.Run("Should cancel the run in progress after the shutdown timeout", func(t *testing.T) {
+		started := make(chan struct{})
+
+		job := func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		s, err := NewScheduler(job, WithInterval(time.Hour), WithRunOnStart(true), WithShutdownTimeout(10*time.Millisecond))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() { errCh <- s.Run(ctx) }()
+
+		<-started
+		cancel()
+
+		assert.Error(t, <-errCh)
+		assert.False(t, s.Status().LastSuccess)
+		assert.ErrorContains(t, errors.New(s.Status().LastError), context.Canceled.Error())
+	})
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	t.Run("Should run the job out of the schedule", func(t *testing.T) {
+		var runs int32
+		s, err := NewScheduler(func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}, WithCron("@yearly"))
+		assert.NoError(t, err)
+
+		done, err := s.Trigger()
+		assert.NoError(t, err)
+		<-done
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+		assert.Equal(t, 1, s.Status().Runs)
+		assert.True(t, s.Status().LastSuccess)
+		assert.False(t, s.Status().Running)
+	})
+}
+
+func TestScheduler_TriggerJob(t *testing.T) {
+	t.Run("Should run the given job instead of the scheduled one", func(t *testing.T) {
+		var scheduled, given int32
+		s, err := NewScheduler(func(ctx context.Context) error {
+			atomic.AddInt32(&scheduled, 1)
+			return nil
+		}, WithCron("@yearly"))
+		assert.NoError(t, err)
+
+		done, err := s.TriggerJob(func(ctx context.Context) error {
+			atomic.AddInt32(&given, 1)
+			return nil
+		})
+		assert.NoError(t, err)
+		<-done
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(&scheduled))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&given))
+		assert.Equal(t, 1, s.Status().Runs)
+	})
+
+	t.Run("Should return an error when the job is nil", func(t *testing.T) {
+		s, err := NewScheduler(func(ctx context.Context) error { return nil }, WithCron("@yearly"))
+		assert.NoError(t, err)
+
+		done, err := s.TriggerJob(nil)
+		assert.ErrorIs(t, err, ErrJobNil)
+		assert.Nil(t, done)
+	})
+}
@@ -0,0 +1,128 @@
+package scim
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/audit"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+)
+
+// writeAudit writes the audit record of a mutation with the outcome of its request, when the provider has an audit sink.
+// A failure writing the record doesn't fail the mutation, it was already done.
+func (s *Provider) writeAudit(ctx context.Context, r *audit.Record, err error) {
+	if s.auditSink == nil {
+		return
+	}
+
+	r.Time = time.Now().UTC()
+	r.RunID = audit.RunID(ctx)
+	r.Outcome = audit.OutcomeSuccess
+	if err != nil {
+		r.Outcome = audit.OutcomeFailure
+		r.Error = err.Error()
+	}
+
+	if werr := s.auditSink.Write(ctx, r); werr != nil {
+		log.WithError(werr).WithFields(log.Fields{
+			"operation":     r.Operation,
+			"resource_type": r.ResourceType,
+			"scimid":        r.SCIMID,
+		}).Error("scim: error writing audit record")
+	}
+}
+
+// auditUserBefore returns the attributes of the user before its mutation, only when the provider has an audit sink.
+// A failure getting the user doesn't stop the mutation, the record has no values before it then.
+func (s *Provider) auditUserBefore(ctx context.Context, scimID string) map[string]interface{} {
+	if s.auditSink == nil {
+		return nil
+	}
+
+	u, err := s.scim.GetUser(ctx, scimID)
+	if err != nil {
+		log.WithError(err).WithField("scimid", scimID).Warn("scim: error getting user for the audit record")
+		return nil
+	}
+
+	return userAttributes((*aws.User)(u))
+}
+
+// auditGroupBefore returns the attributes of the group before its mutation, only when the provider has an audit sink.
+// A failure getting the group doesn't stop the mutation, the record has no values before it then.
+func (s *Provider) auditGroupBefore(ctx context.Context, group *model.Group) map[string]interface{} {
+	if s.auditSink == nil {
+		return nil
+	}
+
+	// https://docs.aws.amazon.com/singlesignon/latest/developerguide/listgroups.html
+	lgr, err := s.scim.ListGroups(ctx, fmt.Sprintf("displayName eq %q", group.Name))
+	if err != nil {
+		log.WithError(err).WithField("scimid", group.SCIMID).Warn("scim: error getting group for the audit record")
+		return nil
+	}
+
+	for _, g := range lgr.Resources {
+		if g.ID == group.SCIMID {
+			return groupAttributes(g)
+		}
+	}
+
+	return nil
+}
+
+// userAttributes returns the audited attributes of a SCIM user.
+func userAttributes(u *aws.User) map[string]interface{} {
+	attrs := map[string]interface{}{
+		"userName":    u.UserName,
+		"displayName": u.DisplayName,
+		"givenName":   u.Name.GivenName,
+		"familyName":  u.Name.FamilyName,
+		"externalId":  u.ExternalID,
+		"active":      u.Active,
+	}
+
+	if len(u.Emails) > 0 {
+		attrs["email"] = u.Emails[0].Value
+	}
+
+	return attrs
+}
+
+// userModelAttributes returns the audited attributes of a user of the model, as they are in SCIM.
+func userModelAttributes(u *model.User) map[string]interface{} {
+	return map[string]interface{}{
+		"userName":    u.Email,
+		"displayName": u.DisplayName,
+		"givenName":   u.Name.GivenName,
+		"familyName":  u.Name.FamilyName,
+		"externalId":  u.IPID,
+		"active":      u.Active,
+		"email":       u.Email,
+	}
+}
+
+// groupAttributes returns the audited attributes of a SCIM group.
+func groupAttributes(g *aws.Group) map[string]interface{} {
+	return map[string]interface{}{
+		"displayName": g.DisplayName,
+		"externalId":  g.ExternalID,
+	}
+}
+
+// membersAttributes returns the audited attributes of the members of a group patch request.
+func membersAttributes(pgr *aws.PatchGroupRequest) map[string]interface{} {
+	members := make([]string, 0)
+	for _, op := range pgr.Patch.Operations {
+		if pvs, ok := op.Value.([]patchValue); ok {
+			for _, pv := range pvs {
+				members = append(members, pv.Value)
+			}
+		}
+	}
+
+	return map[string]interface{}{"members": members}
+}
@@ -0,0 +1,234 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/slashdevops/idp-scim-sync/internal/audit"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/scim"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorderSink is an audit sink recording the records written, failing with err.
+type recorderSink struct {
+	records []*audit.Record
+	err     error
+}
+
+func (s *recorderSink) Write(ctx context.Context, r *audit.Record) error {
+	s.records = append(s.records, r)
+	return s.err
+}
+
+func (s *recorderSink) Flush(ctx context.Context) error {
+	return s.err
+}
+
+func TestProviderAudit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	user := &model.User{
+		IPID:        "idp-1",
+		SCIMID:      "scim-1",
+		Name:        model.Name{FamilyName: "Doe", GivenName: "Jane"},
+		DisplayName: "Jane Doe",
+		Email:       "jane@mail.com",
+		Active:      true,
+	}
+
+	group := &model.Group{
+		IPID:   "idp-g1",
+		SCIMID: "scim-g1",
+		Name:   "group 1",
+		Email:  "group.1@mail.com",
+	}
+
+	t.Run("Should write the create record with the run id and the scim id", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := audit.ContextWithRunID(context.TODO(), "run-1")
+
+		sink := &recorderSink{}
+
+		mockSCIM.EXPECT().CreateOrGetUser(ctx, gomock.Any()).Return(&aws.CreateUserResponse{ID: "scim-1"}, nil).Times(1)
+
+		svc, _ := NewProvider(mockSCIM, WithAuditSink(sink))
+		_, err := svc.CreateUsers(ctx, &model.UsersResult{Items: 1, Resources: []*model.User{{IPID: "idp-1", DisplayName: "Jane Doe", Email: "jane@mail.com", Active: true}}})
+		assert.NoError(t, err)
+
+		assert.Len(t, sink.records, 1)
+		assert.Equal(t, "run-1", sink.records[0].RunID)
+		assert.Equal(t, audit.OperationCreate, sink.records[0].Operation)
+		assert.Equal(t, audit.ResourceTypeUser, sink.records[0].ResourceType)
+		assert.Equal(t, "scim-1", sink.records[0].SCIMID)
+		assert.Equal(t, "idp-1", sink.records[0].IPID)
+		assert.Nil(t, sink.records[0].Before)
+		assert.Equal(t, "jane@mail.com", sink.records[0].After["userName"])
+		assert.Equal(t, audit.OutcomeSuccess, sink.records[0].Outcome)
+		assert.False(t, sink.records[0].Time.IsZero())
+	})
+
+	t.Run("Should write the update record with the values before the update", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		sink := &recorderSink{}
+
+		before := &aws.GetUserResponse{
+			ID:          "scim-1",
+			ExternalID:  "idp-1",
+			UserName:    "jane@mail.com",
+			DisplayName: "Jane Smith",
+			Name:        aws.Name{FamilyName: "Smith", GivenName: "Jane"},
+			Emails:      []*aws.Email{{Value: "jane@mail.com"}},
+			Active:      true,
+		}
+
+		gomock.InOrder(
+			mockSCIM.EXPECT().GetUser(ctx, "scim-1").Return(before, nil).Times(1),
+			mockSCIM.EXPECT().PutUser(ctx, gomock.Any()).Return(&aws.PutUserResponse{ID: "scim-1"}, nil).Times(1),
+		)
+
+		svc, _ := NewProvider(mockSCIM, WithAuditSink(sink))
+		_, err := svc.UpdateUsers(ctx, &model.UsersResult{Items: 1, Resources: []*model.User{user}})
+		assert.NoError(t, err)
+
+		assert.Len(t, sink.records, 1)
+		assert.Equal(t, audit.OperationUpdate, sink.records[0].Operation)
+		assert.Equal(t, "Smith", sink.records[0].Before["familyName"])
+		assert.Equal(t, "Doe", sink.records[0].After["familyName"])
+		assert.Equal(t, audit.OutcomeSuccess, sink.records[0].Outcome)
+	})
+
+	t.Run("Should write the failure record and return the error", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		sink := &recorderSink{}
+
+		mockSCIM.EXPECT().GetUser(ctx, "scim-1").Return(nil, errors.New("not found")).Times(1)
+		mockSCIM.EXPECT().PatchUser(ctx, gomock.Any()).Return(errors.New("test error")).Times(1)
+
+		svc, _ := NewProvider(mockSCIM, WithAuditSink(sink))
+		_, err := svc.DeactivateUsers(ctx, &model.UsersResult{Items: 1, Resources: []*model.User{user}})
+		assert.Error(t, err)
+
+		assert.Len(t, sink.records, 1)
+		assert.Equal(t, audit.OperationDeactivate, sink.records[0].Operation)
+		assert.Nil(t, sink.records[0].Before)
+		assert.Equal(t, false, sink.records[0].After["active"])
+		assert.Equal(t, audit.OutcomeFailure, sink.records[0].Outcome)
+		assert.Equal(t, "test error", sink.records[0].Error)
+	})
+
+	t.Run("Should write the delete records of users and groups", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		sink := &recorderSink{}
+
+		mockSCIM.EXPECT().DeleteUser(ctx, "scim-1").Return(nil).Times(1)
+		mockSCIM.EXPECT().DeleteGroup(ctx, "scim-g1").Return(nil).Times(1)
+
+		svc, _ := NewProvider(mockSCIM, WithAuditSink(sink))
+		assert.NoError(t, svc.DeleteUsers(ctx, &model.UsersResult{Items: 1, Resources: []*model.User{user}}))
+		assert.NoError(t, svc.DeleteGroups(ctx, &model.GroupsResult{Items: 1, Resources: []*model.Group{group}}))
+
+		assert.Len(t, sink.records, 2)
+		assert.Equal(t, audit.OperationDelete, sink.records[0].Operation)
+		assert.Equal(t, audit.ResourceTypeUser, sink.records[0].ResourceType)
+		assert.Equal(t, "Jane Doe", sink.records[0].Before["displayName"])
+		assert.Nil(t, sink.records[0].After)
+		assert.Equal(t, audit.OperationDelete, sink.records[1].Operation)
+		assert.Equal(t, audit.ResourceTypeGroup, sink.records[1].ResourceType)
+		assert.Equal(t, "scim-g1", sink.records[1].SCIMID)
+		assert.Equal(t, "group 1", sink.records[1].Before["displayName"])
+	})
+
+	t.Run("Should write the update record of a group with the values before the update", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		sink := &recorderSink{}
+
+		lgr := &aws.ListGroupsResponse{
+			Resources: []*aws.Group{
+				{ID: "other", DisplayName: "group 1", ExternalID: "other"},
+				{ID: "scim-g1", DisplayName: "group 1", ExternalID: "idp-old"},
+			},
+		}
+
+		mockSCIM.EXPECT().ListGroups(ctx, `displayName eq "group 1"`).Return(lgr, nil).Times(1)
+		mockSCIM.EXPECT().PatchGroup(ctx, gomock.Any()).Return(nil).Times(1)
+
+		svc, _ := NewProvider(mockSCIM, WithAuditSink(sink))
+		_, err := svc.UpdateGroups(ctx, &model.GroupsResult{Items: 1, Resources: []*model.Group{group}})
+		assert.NoError(t, err)
+
+		assert.Len(t, sink.records, 1)
+		assert.Equal(t, audit.OperationUpdate, sink.records[0].Operation)
+		assert.Equal(t, "idp-old", sink.records[0].Before["externalId"])
+		assert.Equal(t, "idp-g1", sink.records[0].After["externalId"])
+	})
+
+	t.Run("Should write one record per patch request of the members", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		sink := &recorderSink{}
+
+		mockSCIM.EXPECT().PatchGroup(ctx, gomock.Any()).Return(nil).Times(2)
+
+		gmr := &model.GroupsMembersResult{
+			Items: 1,
+			Resources: []*model.GroupMembers{
+				{
+					Items:     MaxPatchGroupMembersPerRequest + 1,
+					Group:     group,
+					Resources: groupMembersGenerator(MaxPatchGroupMembersPerRequest+1, true, true),
+				},
+			},
+		}
+
+		svc, _ := NewProvider(mockSCIM, WithAuditSink(sink))
+		assert.NoError(t, svc.DeleteGroupsMembers(ctx, gmr))
+
+		assert.Len(t, sink.records, 2)
+		for _, r := range sink.records {
+			assert.Equal(t, audit.OperationRemoveMembers, r.Operation)
+			assert.Equal(t, "scim-g1", r.SCIMID)
+			assert.Nil(t, r.After)
+		}
+		assert.Len(t, sink.records[0].Before["members"], MaxPatchGroupMembersPerRequest)
+		assert.Equal(t, []string{"101"}, sink.records[1].Before["members"])
+	})
+
+	t.Run("Should not fail the mutation when the audit record cannot be written", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		sink := &recorderSink{err: errors.New("sink error")}
+
+		mockSCIM.EXPECT().DeleteUser(ctx, "scim-1").Return(nil).Times(1)
+
+		svc, _ := NewProvider(mockSCIM, WithAuditSink(sink))
+		assert.NoError(t, svc.DeleteUsers(ctx, &model.UsersResult{Items: 1, Resources: []*model.User{user}}))
+		assert.Len(t, sink.records, 1)
+	})
+
+	t.Run("Should not read the values before the updates without audit sink", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		mockSCIM.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+		mockSCIM.EXPECT().PutUser(ctx, gomock.Any()).Return(&aws.PutUserResponse{ID: "scim-1"}, nil).Times(1)
+
+		svc, _ := NewProvider(mockSCIM)
+		_, err := svc.UpdateUsers(ctx, &model.UsersResult{Items: 1, Resources: []*model.User{user}})
+		assert.NoError(t, err)
+	})
+}
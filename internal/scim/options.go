@@ -0,0 +1,14 @@
+package scim
+
+import "github.com/slashdevops/idp-scim-sync/internal/audit"
+
+// ProviderOption is a function that can be used to configure the Provider
+// using the functional options pattern.
+type ProviderOption func(*Provider)
+
+// WithAuditSink sets the sink of the audit records of the mutations of the SCIM resources.
+func WithAuditSink(sink audit.Sink) ProviderOption {
+	return func(p *Provider) {
+		p.auditSink = sink
+	}
+}
@@ -2,14 +2,24 @@ package scim
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 
+	"github.com/slashdevops/idp-scim-sync/internal/audit"
 	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
 	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// tracer creates the spans of the operations over the groups, the SCIM requests are their children
+var tracer = otel.Tracer("github.com/slashdevops/idp-scim-sync/internal/scim")
+
 // This implement core.SCIMService interface
 
 //go:generate go run github.com/golang/mock/mockgen@v1.6.0 -package=mocks -destination=../../mocks/scim/scim_mocks.go -source=scim.go AWSSCIMProvider
@@ -28,6 +38,9 @@ type AWSSCIMProvider interface {
 	// PutUser updates a user in SCIM Provider
 	PutUser(ctx context.Context, usr *aws.PutUserRequest) (*aws.PutUserResponse, error)
 
+	// PatchUser patches a user in SCIM Provider
+	PatchUser(ctx context.Context, pur *aws.PatchUserRequest) error
+
 	// DeleteUser deletes a user in SCIM Provider
 	DeleteUser(ctx context.Context, id string) error
 
@@ -61,16 +74,23 @@ var ErrSCIMProviderNil = fmt.Errorf("scim: Provider is nil")
 
 // Provider represents a SCIM provider
 type Provider struct {
-	scim AWSSCIMProvider
+	scim      AWSSCIMProvider
+	auditSink audit.Sink
 }
 
 // NewProvider creates a new SCIM provider
-func NewProvider(scim AWSSCIMProvider) (*Provider, error) {
+func NewProvider(scim AWSSCIMProvider, opts ...ProviderOption) (*Provider, error) {
 	if scim == nil {
 		return nil, ErrSCIMProviderNil
 	}
 
-	return &Provider{scim: scim}, nil
+	p := &Provider{scim: scim}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
 }
 
 // GetGroups returns groups from SCIM Provider
@@ -116,8 +136,22 @@ func (s *Provider) CreateGroups(ctx context.Context, gr *model.GroupsResult) (*m
 			"group": group.Name,
 		}).Warn("creating group")
 
+		gctx, span := startGroupSpan(ctx, "scim.CreateGroup", group.Name)
 		// TODO: r, err := s.scim.CreateGroup(ctx, groupRequest)
-		r, err := s.scim.CreateOrGetGroup(ctx, groupRequest)
+		r, err := s.scim.CreateOrGetGroup(gctx, groupRequest)
+		tracing.End(span, err)
+
+		record := &audit.Record{
+			Operation:    audit.OperationCreate,
+			ResourceType: audit.ResourceTypeGroup,
+			IPID:         group.IPID,
+			After:        groupAttributes((*aws.Group)(groupRequest)),
+		}
+		if err == nil {
+			record.SCIMID = r.ID
+		}
+		s.writeAudit(ctx, record, err)
+
 		if err != nil {
 			return nil, fmt.Errorf("scim: error creating group: %w", err)
 		}
@@ -152,9 +186,11 @@ func (s *Provider) UpdateGroups(ctx context.Context, gr *model.GroupsResult) (*m
 				Operations: []*aws.Operation{
 					{
 						OP: "replace",
+						// the displayName is replaced too to rename the groups imported with other name
 						Value: map[string]string{
-							"id":         group.SCIMID,
-							"externalId": group.IPID,
+							"id":          group.SCIMID,
+							"externalId":  group.IPID,
+							"displayName": group.Name,
 						},
 					},
 				},
@@ -173,7 +209,22 @@ func (s *Provider) UpdateGroups(ctx context.Context, gr *model.GroupsResult) (*m
 			"email": group.Email,
 		}).Warn("updating group")
 
-		if err := s.scim.PatchGroup(ctx, groupRequest); err != nil {
+		before := s.auditGroupBefore(ctx, group)
+
+		gctx, span := startGroupSpan(ctx, "scim.UpdateGroup", group.Name)
+		err := s.scim.PatchGroup(gctx, groupRequest)
+		tracing.End(span, err)
+
+		s.writeAudit(ctx, &audit.Record{
+			Operation:    audit.OperationUpdate,
+			ResourceType: audit.ResourceTypeGroup,
+			SCIMID:       group.SCIMID,
+			IPID:         group.IPID,
+			Before:       before,
+			After:        groupAttributes(&aws.Group{DisplayName: group.Name, ExternalID: group.IPID}),
+		}, err)
+
+		if err != nil {
 			return nil, fmt.Errorf("scim: error updating groups: %w", err)
 		}
 
@@ -208,7 +259,19 @@ func (s *Provider) DeleteGroups(ctx context.Context, gr *model.GroupsResult) err
 			"email": group.Email,
 		}).Trace("deleting group")
 
-		if err := s.scim.DeleteGroup(ctx, group.SCIMID); err != nil {
+		gctx, span := startGroupSpan(ctx, "scim.DeleteGroup", group.Name)
+		err := s.scim.DeleteGroup(gctx, group.SCIMID)
+		tracing.End(span, err)
+
+		s.writeAudit(ctx, &audit.Record{
+			Operation:    audit.OperationDelete,
+			ResourceType: audit.ResourceTypeGroup,
+			SCIMID:       group.SCIMID,
+			IPID:         group.IPID,
+			Before:       groupAttributes(&aws.Group{DisplayName: group.Name, ExternalID: group.IPID}),
+		}, err)
+
+		if err != nil {
 			return fmt.Errorf("scim: error deleting group: %s, %w", group.SCIMID, err)
 		}
 	}
@@ -242,6 +305,37 @@ func (s *Provider) GetUsers(ctx context.Context) (*model.UsersResult, error) {
 	return usersResult, nil
 }
 
+// GetUser returns the user of the SCIM Provider with the SCIM id, nil when the user doesn't exist.
+func (s *Provider) GetUser(ctx context.Context, scimID string) (*model.User, error) {
+	user, err := s.scim.GetUser(ctx, scimID)
+	if err != nil {
+		httpErr := new(aws.HTTPResponseError)
+
+		// http.StatusNotFound is 404
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scim: error getting user: %s, %w", scimID, err)
+	}
+
+	var email string
+	if len(user.Emails) > 0 {
+		email = user.Emails[0].Value
+	}
+
+	e := model.UserBuilder().
+		WithIPID(user.ExternalID).
+		WithSCIMID(user.ID).
+		WithGivenName(user.Name.GivenName).
+		WithFamilyName(user.Name.FamilyName).
+		WithDisplayName(user.DisplayName).
+		WithEmail(email).
+		WithActive(user.Active).
+		Build()
+
+	return e, nil
+}
+
 // CreateUsers creates users in SCIM Provider
 func (s *Provider) CreateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
 	users := make([]*model.User, 0)
@@ -278,6 +372,18 @@ func (s *Provider) CreateUsers(ctx context.Context, ur *model.UsersResult) (*mod
 
 		// TODO: r, err := s.scim.CreateUser(ctx, userRequest)
 		r, err := s.scim.CreateOrGetUser(ctx, userRequest)
+
+		record := &audit.Record{
+			Operation:    audit.OperationCreate,
+			ResourceType: audit.ResourceTypeUser,
+			IPID:         user.IPID,
+			After:        userAttributes((*aws.User)(userRequest)),
+		}
+		if err == nil {
+			record.SCIMID = r.ID
+		}
+		s.writeAudit(ctx, record, err)
+
 		if err != nil {
 			return nil, fmt.Errorf("scim: error creating user: %w", err)
 		}
@@ -336,7 +442,19 @@ func (s *Provider) UpdateUsers(ctx context.Context, ur *model.UsersResult) (*mod
 			"email": user.Email,
 		}).Warn("updating user")
 
+		before := s.auditUserBefore(ctx, user.SCIMID)
+
 		r, err := s.scim.PutUser(ctx, userRequest)
+
+		s.writeAudit(ctx, &audit.Record{
+			Operation:    audit.OperationUpdate,
+			ResourceType: audit.ResourceTypeUser,
+			SCIMID:       user.SCIMID,
+			IPID:         user.IPID,
+			Before:       before,
+			After:        userAttributes((*aws.User)(userRequest)),
+		}, err)
+
 		if err != nil {
 			return nil, fmt.Errorf("scim: error updating user: %w", err)
 		}
@@ -359,6 +477,76 @@ func (s *Provider) UpdateUsers(ctx context.Context, ur *model.UsersResult) (*mod
 	return usersResult, nil
 }
 
+// DeactivateUsers deactivates users in SCIM Provider given a list of users
+// the users are not deleted, only the attribute active is set to false.
+func (s *Provider) DeactivateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	users := make([]*model.User, 0)
+
+	for _, user := range ur.Resources {
+		patchUserRequest := &aws.PatchUserRequest{
+			User: aws.User{
+				ID:       user.SCIMID,
+				UserName: user.Email,
+			},
+			Patch: aws.Patch{
+				Schemas: []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+				Operations: []*aws.Operation{
+					{
+						OP:    "replace",
+						Path:  "active",
+						Value: false,
+					},
+				},
+			},
+		}
+
+		log.WithFields(log.Fields{
+			"user":   user.DisplayName,
+			"email":  user.Email,
+			"scimid": user.SCIMID,
+			"idpid":  user.IPID,
+		}).Trace("deactivating user (details)")
+
+		log.WithFields(log.Fields{
+			"user":  user.DisplayName,
+			"email": user.Email,
+		}).Warn("deactivating user")
+
+		before := s.auditUserBefore(ctx, user.SCIMID)
+
+		err := s.scim.PatchUser(ctx, patchUserRequest)
+
+		s.writeAudit(ctx, &audit.Record{
+			Operation:    audit.OperationDeactivate,
+			ResourceType: audit.ResourceTypeUser,
+			SCIMID:       user.SCIMID,
+			IPID:         user.IPID,
+			Before:       before,
+			After:        map[string]interface{}{"active": false},
+		}, err)
+
+		if err != nil {
+			return nil, fmt.Errorf("scim: error deactivating user: %s, %w", user.SCIMID, err)
+		}
+
+		e := model.UserBuilder().
+			WithIPID(user.IPID).
+			WithSCIMID(user.SCIMID).
+			WithGivenName(user.Name.GivenName).
+			WithFamilyName(user.Name.FamilyName).
+			WithDisplayName(user.DisplayName).
+			WithEmail(user.Email).
+			WithActive(false).
+			Build()
+
+		users = append(users, e)
+	}
+
+	usersResult := model.UsersResultBuilder().WithResources(users).Build()
+
+	return usersResult, nil
+}
+
 // DeleteUsers deletes users in SCIM Provider given a list of users
 func (s *Provider) DeleteUsers(ctx context.Context, ur *model.UsersResult) error {
 	for _, user := range ur.Resources {
@@ -374,7 +562,17 @@ func (s *Provider) DeleteUsers(ctx context.Context, ur *model.UsersResult) error
 			"email": user.Email,
 		}).Warn("deleting user")
 
-		if err := s.scim.DeleteUser(ctx, user.SCIMID); err != nil {
+		err := s.scim.DeleteUser(ctx, user.SCIMID)
+
+		s.writeAudit(ctx, &audit.Record{
+			Operation:    audit.OperationDelete,
+			ResourceType: audit.ResourceTypeUser,
+			SCIMID:       user.SCIMID,
+			IPID:         user.IPID,
+			Before:       userModelAttributes(user),
+		}, err)
+
+		if err != nil {
 			return fmt.Errorf("scim: error deleting user: %s, %w", user.SCIMID, err)
 		}
 	}
@@ -446,10 +644,8 @@ func (s *Provider) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMem
 			}).Warnf("group with more than %d members, sending multiple requests", MaxPatchGroupMembersPerRequest)
 		}
 
-		for _, patchGroupRequest := range patchOperations {
-			if err := s.scim.PatchGroup(ctx, patchGroupRequest); err != nil {
-				return nil, fmt.Errorf("scim: error patching group: %w", err)
-			}
+		if err := s.patchGroupMembers(ctx, "scim.AddGroupMembers", audit.OperationAddMembers, groupMembers.Group, len(membersIDValue), patchOperations); err != nil {
+			return nil, err
 		}
 	}
 
@@ -491,16 +687,53 @@ func (s *Provider) DeleteGroupsMembers(ctx context.Context, gmr *model.GroupsMem
 			}).Warnf("group with more than %d members, sending multiple requests", MaxPatchGroupMembersPerRequest)
 		}
 
-		for _, patchGroupRequest := range patchOperations {
-			if err := s.scim.PatchGroup(ctx, patchGroupRequest); err != nil {
-				return fmt.Errorf("scim: error patching group: %w", err)
-			}
+		if err := s.patchGroupMembers(ctx, "scim.RemoveGroupMembers", audit.OperationRemoveMembers, groupMembers.Group, len(membersIDValue), patchOperations); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// patchGroupMembers sends the patch requests adding or removing the members of a group, traced in the same span,
+// every request has its own audit record.
+func (s *Provider) patchGroupMembers(ctx context.Context, spanName, operation string, group *model.Group, members int, patchOperations []*aws.PatchGroupRequest) (err error) {
+	ctx, span := startGroupSpan(ctx, spanName, group.Name)
+	span.SetAttributes(
+		attribute.Int("group.members", members),
+		attribute.Int("scim.requests", len(patchOperations)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	for _, patchGroupRequest := range patchOperations {
+		err := s.scim.PatchGroup(ctx, patchGroupRequest)
+
+		record := &audit.Record{
+			Operation:    operation,
+			ResourceType: audit.ResourceTypeGroup,
+			SCIMID:       group.SCIMID,
+			IPID:         group.IPID,
+		}
+		if operation == audit.OperationRemoveMembers {
+			record.Before = membersAttributes(patchGroupRequest)
+		} else {
+			record.After = membersAttributes(patchGroupRequest)
+		}
+		s.writeAudit(ctx, record, err)
+
+		if err != nil {
+			return fmt.Errorf("scim: error patching group: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// startGroupSpan starts the span of an operation over a group.
+func startGroupSpan(ctx context.Context, name, groupName string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, tracer, name, trace.WithAttributes(attribute.String("group.name", groupName)))
+}
+
 // GetGroupsMembers returns a list of groups and their members from the SCIM Provider
 // NOTE: this method doesn't work because unfortunately the SCIM API doesn't support
 // list the members of a group, or get a group and their members at the same time
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -304,8 +305,9 @@ func TestUpdateGroups(t *testing.T) {
 					{
 						OP: "replace",
 						Value: map[string]string{
-							"id":         "1",
-							"externalId": "1",
+							"id":          "1",
+							"externalId":  "1",
+							"displayName": "group 1",
 						},
 					},
 				},
@@ -351,8 +353,9 @@ func TestUpdateGroups(t *testing.T) {
 					{
 						OP: "replace",
 						Value: map[string]string{
-							"id":         "1",
-							"externalId": "1",
+							"id":          "1",
+							"externalId":  "1",
+							"displayName": "group 1",
 						},
 					},
 				},
@@ -393,8 +396,9 @@ func TestUpdateGroups(t *testing.T) {
 					{
 						OP: "replace",
 						Value: map[string]string{
-							"id":         "1",
-							"externalId": "1",
+							"id":          "1",
+							"externalId":  "1",
+							"displayName": "group 1",
 						},
 					},
 				},
@@ -411,8 +415,9 @@ func TestUpdateGroups(t *testing.T) {
 					{
 						OP: "replace",
 						Value: map[string]string{
-							"id":         "2",
-							"externalId": "2",
+							"id":          "2",
+							"externalId":  "2",
+							"displayName": "group 2",
 						},
 					},
 				},
@@ -632,6 +637,60 @@ func TestGetUsers(t *testing.T) {
 	})
 }
 
+func TestGetUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return a error", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		mockSCIM.EXPECT().GetUser(context.TODO(), "1").Return(nil, errors.New("test error"))
+
+		svc, _ := NewProvider(mockSCIM)
+		u, err := svc.GetUser(context.TODO(), "1")
+
+		assert.Error(t, err)
+		assert.Nil(t, u)
+	})
+
+	t.Run("Should return nil and no error when the user doesn't exist", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		mockSCIM.EXPECT().GetUser(context.TODO(), "1").Return(nil, &aws.HTTPResponseError{StatusCode: http.StatusNotFound})
+
+		svc, _ := NewProvider(mockSCIM)
+		u, err := svc.GetUser(context.TODO(), "1")
+
+		assert.NoError(t, err)
+		assert.Nil(t, u)
+	})
+
+	t.Run("Should return the user and no error", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		user := &aws.GetUserResponse{
+			ID:          "1",
+			ExternalID:  "idp-1",
+			Name:        aws.Name{FamilyName: "1", GivenName: "user"},
+			DisplayName: "user 1",
+			Emails:      []*aws.Email{{Value: "user.1@mail.com", Type: "work", Primary: true}},
+			Active:      true,
+		}
+		mockSCIM.EXPECT().GetUser(context.TODO(), "1").Return(user, nil)
+
+		svc, _ := NewProvider(mockSCIM)
+		u, err := svc.GetUser(context.TODO(), "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserBuilder().
+			WithIPID("idp-1").
+			WithSCIMID("1").
+			WithGivenName("user").
+			WithFamilyName("1").
+			WithDisplayName("user 1").
+			WithEmail("user.1@mail.com").
+			WithActive(true).
+			Build(), u)
+	})
+}
+
 func TestCreateUsers(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1020,6 +1079,94 @@ func TestUpdateUsers(t *testing.T) {
 	})
 }
 
+func TestDeactivateUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should do nothing with empty UsersResult", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		empty := &model.UsersResult{}
+
+		svc, _ := NewProvider(mockSCIM)
+		ur, err := svc.DeactivateUsers(context.TODO(), empty)
+		assert.NoError(t, err)
+		assert.NotNil(t, ur)
+		assert.Equal(t, 0, ur.Items)
+	})
+
+	t.Run("Should call PatchUser 1 time and no return error", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		pur := &aws.PatchUserRequest{
+			User: aws.User{
+				ID:       "1",
+				UserName: "user.1@mail.com",
+			},
+			Patch: aws.Patch{
+				Schemas: []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+				Operations: []*aws.Operation{
+					{
+						OP:    "replace",
+						Path:  "active",
+						Value: false,
+					},
+				},
+			},
+		}
+
+		mockSCIM.EXPECT().PatchUser(ctx, pur).Return(nil).Times(1)
+
+		usr := &model.UsersResult{
+			Items: 1,
+			Resources: []*model.User{
+				{
+					IPID:        "1",
+					SCIMID:      "1",
+					Name:        model.Name{FamilyName: "1", GivenName: "user"},
+					DisplayName: "user 1",
+					Email:       "user.1@mail.com",
+					Active:      true,
+				},
+			},
+		}
+
+		svc, _ := NewProvider(mockSCIM)
+		ur, err := svc.DeactivateUsers(ctx, usr)
+		assert.NoError(t, err)
+		assert.NotNil(t, ur)
+		assert.Equal(t, 1, ur.Items)
+		assert.Equal(t, "1", ur.Resources[0].SCIMID)
+		assert.False(t, ur.Resources[0].Active)
+	})
+
+	t.Run("Should call PatchUser 1 time and return error", func(t *testing.T) {
+		mockSCIM := mocks.NewMockAWSSCIMProvider(mockCtrl)
+		ctx := context.TODO()
+
+		mockSCIM.EXPECT().PatchUser(ctx, gomock.Any()).Return(errors.New("test error")).Times(1)
+
+		usr := &model.UsersResult{
+			Items: 1,
+			Resources: []*model.User{
+				{
+					IPID:        "1",
+					SCIMID:      "1",
+					Name:        model.Name{FamilyName: "1", GivenName: "user"},
+					DisplayName: "user 1",
+					Email:       "user.1@mail.com",
+					Active:      true,
+				},
+			},
+		}
+
+		svc, _ := NewProvider(mockSCIM)
+		ur, err := svc.DeactivateUsers(ctx, usr)
+		assert.Error(t, err)
+		assert.Nil(t, ur)
+	})
+}
+
 func TestDeleteUsers(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+)
+
+var (
+	// ErrSecretsManagerServiceNil is returned when the AWS Secrets Manager service is nil.
+	ErrSecretsManagerServiceNil = errors.New("secrets: aws secrets manager service cannot be nil")
+
+	// ErrSSMServiceNil is returned when the AWS SSM service is nil.
+	ErrSSMServiceNil = errors.New("secrets: aws ssm service cannot be nil")
+)
+
+// SecretsManagerProvider is the secrets provider of the AWS Secrets Manager secrets.
+// the key is the secret name or arn.
+type SecretsManagerProvider struct {
+	svc *aws.SecretsManagerService
+}
+
+// NewSecretsManagerProvider returns a new SecretsManagerProvider.
+func NewSecretsManagerProvider(svc *aws.SecretsManagerService) (*SecretsManagerProvider, error) {
+	if svc == nil {
+		return nil, ErrSecretsManagerServiceNil
+	}
+
+	return &SecretsManagerProvider{svc: svc}, nil
+}
+
+// GetSecret returns the current value of the secret.
+func (p *SecretsManagerProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	return p.svc.GetSecretValue(ctx, key)
+}
+
+// GetSecretTags returns the tags of the secret.
+func (p *SecretsManagerProvider) GetSecretTags(ctx context.Context, key string) (map[string]string, error) {
+	return p.svc.GetSecretTags(ctx, key)
+}
+
+// SSMProvider is the secrets provider of the AWS Systems Manager Parameter Store parameters.
+// the key is the parameter name, the leading "/" of the hierarchical names is optional,
+// so "secret://ssm/idpscim/token" references the parameter "/idpscim/token".
+type SSMProvider struct {
+	svc *aws.SSMService
+}
+
+// NewSSMProvider returns a new SSMProvider.
+func NewSSMProvider(svc *aws.SSMService) (*SSMProvider, error) {
+	if svc == nil {
+		return nil, ErrSSMServiceNil
+	}
+
+	return &SSMProvider{svc: svc}, nil
+}
+
+// GetSecret returns the decrypted value of the parameter.
+func (p *SSMProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	if strings.Contains(key, "/") && !strings.HasPrefix(key, "/") && !strings.HasPrefix(key, "arn:") {
+		key = "/" + key
+	}
+
+	return p.svc.GetParameterValue(ctx, key)
+}
@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrSecretNotFound is returned when the secret doesn't exist.
+var ErrSecretNotFound = errors.New("secrets: secret not found")
+
+// EnvProvider is the secrets provider of the environment variables, the key is the variable name.
+type EnvProvider struct{}
+
+// NewEnvProvider returns a new EnvProvider.
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{}
+}
+
+// GetSecret returns the value of the environment variable.
+func (p *EnvProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s", ErrSecretNotFound, key)
+	}
+
+	return value, nil
+}
+
+// FileProvider is the secrets provider of the local files, the key is the file path,
+// so "secret://file//run/secrets/token" references the file "/run/secrets/token".
+type FileProvider struct{}
+
+// NewFileProvider returns a new FileProvider.
+func NewFileProvider() *FileProvider {
+	return &FileProvider{}
+}
+
+// GetSecret returns the content of the file without the trailing new lines.
+func (p *FileProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	content, err := os.ReadFile(key)
+	if err != nil {
+		return "", fmt.Errorf("secrets: error reading secret file: %w", err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,205 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/golang/mock/gomock"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/aws"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsManagerProvider_GetSecret(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return an error when the service is nil", func(t *testing.T) {
+		p, err := NewSecretsManagerProvider(nil)
+		assert.ErrorIs(t, err, ErrSecretsManagerServiceNil)
+		assert.Nil(t, p)
+	})
+
+	t.Run("Should return the secret value", func(t *testing.T) {
+		mockSMClientAPI := mocks.NewMockSecretsManagerClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		SMIn := &secretsmanager.GetSecretValueInput{
+			SecretId:     awssdk.String("IDPSCIM_SCIMAccessToken"),
+			VersionStage: awssdk.String("AWSCURRENT"),
+		}
+		SMOut := &secretsmanager.GetSecretValueOutput{SecretString: awssdk.String("my-token")}
+
+		mockSMClientAPI.EXPECT().GetSecretValue(ctx, SMIn).Times(1).Return(SMOut, nil)
+
+		svc, err := aws.NewSecretsManagerService(mockSMClientAPI)
+		assert.NoError(t, err)
+
+		p, err := NewSecretsManagerProvider(svc)
+		assert.NoError(t, err)
+
+		value, err := p.GetSecret(ctx, "IDPSCIM_SCIMAccessToken")
+		assert.NoError(t, err)
+		assert.Equal(t, "my-token", value)
+	})
+}
+
+func TestSSMProvider_GetSecret(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return an error when the service is nil", func(t *testing.T) {
+		p, err := NewSSMProvider(nil)
+		assert.ErrorIs(t, err, ErrSSMServiceNil)
+		assert.Nil(t, p)
+	})
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "hierarchical without leading slash", key: "idpscim/token", want: "/idpscim/token"},
+		{name: "hierarchical with leading slash", key: "/idpscim/token", want: "/idpscim/token"},
+		{name: "not hierarchical", key: "idpscim-token", want: "idpscim-token"},
+		{name: "arn", key: "arn:aws:ssm:eu-west-1:123456789012:parameter/idpscim/token", want: "arn:aws:ssm:eu-west-1:123456789012:parameter/idpscim/token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSSMClientAPI := mocks.NewMockSSMClientAPI(mockCtrl)
+			ctx := context.TODO()
+
+			pIn := &ssm.GetParameterInput{
+				Name:           awssdk.String(tt.want),
+				WithDecryption: awssdk.Bool(true),
+			}
+			pOut := &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: awssdk.String("my-token")}}
+
+			mockSSMClientAPI.EXPECT().GetParameter(ctx, pIn).Times(1).Return(pOut, nil)
+
+			svc, err := aws.NewSSMService(mockSSMClientAPI)
+			assert.NoError(t, err)
+
+			p, err := NewSSMProvider(svc)
+			assert.NoError(t, err)
+
+			value, err := p.GetSecret(ctx, tt.key)
+			assert.NoError(t, err)
+			assert.Equal(t, "my-token", value)
+		})
+	}
+}
+
+func TestEnvProvider_GetSecret(t *testing.T) {
+	t.Setenv("IDPSCIM_TEST_SECRET", "my-token")
+	p := NewEnvProvider()
+
+	t.Run("Should return the environment variable value", func(t *testing.T) {
+		value, err := p.GetSecret(context.TODO(), "IDPSCIM_TEST_SECRET")
+		assert.NoError(t, err)
+		assert.Equal(t, "my-token", value)
+	})
+
+	t.Run("Should return an error when the environment variable doesn't exist", func(t *testing.T) {
+		_, err := p.GetSecret(context.TODO(), "IDPSCIM_TEST_SECRET_MISSING")
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+	})
+}
+
+func TestFileProvider_GetSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(file, []byte("my-token\n"), 0o600))
+
+	p := NewFileProvider()
+
+	t.Run("Should return the file content without trailing new lines", func(t *testing.T) {
+		value, err := p.GetSecret(context.TODO(), file)
+		assert.NoError(t, err)
+		assert.Equal(t, "my-token", value)
+	})
+
+	t.Run("Should return an error when the file doesn't exist", func(t *testing.T) {
+		_, err := p.GetSecret(context.TODO(), file+".missing")
+		assert.Error(t, err)
+	})
+}
+
+func TestVaultProvider_GetSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "my-vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/idpscim":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data":{"data":{"token":"my-token"},"metadata":{"version":2}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("Should return an error when address or token are empty", func(t *testing.T) {
+		_, err := NewVaultProvider("", "my-vault-token")
+		assert.ErrorIs(t, err, ErrVaultAddressNil)
+
+		_, err = NewVaultProvider(server.URL, "")
+		assert.ErrorIs(t, err, ErrVaultTokenNil)
+	})
+
+	t.Run("Should return the secret data", func(t *testing.T) {
+		p, err := NewVaultProvider(server.URL+"/", "my-vault-token", WithVaultHTTPClient(server.Client()))
+		assert.NoError(t, err)
+
+		value, err := p.GetSecret(context.TODO(), "secret/idpscim")
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"token":"my-token"}`, value)
+	})
+
+	t.Run("Should resolve the field of the secret data", func(t *testing.T) {
+		p, err := NewVaultProvider(server.URL, "my-vault-token", WithVaultHTTPClient(server.Client()))
+		assert.NoError(t, err)
+
+		r, err := NewResolver(WithProvider("vault", p))
+		assert.NoError(t, err)
+
+		value, err := r.Resolve(context.TODO(), "secret://vault/secret/idpscim#token")
+		assert.NoError(t, err)
+		assert.Equal(t, "my-token", value)
+	})
+
+	t.Run("Should return an error when the secret doesn't exist", func(t *testing.T) {
+		p, err := NewVaultProvider(server.URL, "my-vault-token", WithVaultHTTPClient(server.Client()))
+		assert.NoError(t, err)
+
+		_, err = p.GetSecret(context.TODO(), "secret/missing")
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+	})
+
+	t.Run("Should return an error when the token is not valid", func(t *testing.T) {
+		p, err := NewVaultProvider(server.URL, "other-token", WithVaultHTTPClient(server.Client()))
+		assert.NoError(t, err)
+
+		_, err = p.GetSecret(context.TODO(), "secret/idpscim")
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return an error when the key has no path", func(t *testing.T) {
+		p, err := NewVaultProvider(server.URL, "my-vault-token", WithVaultHTTPClient(server.Client()))
+		assert.NoError(t, err)
+
+		_, err = p.GetSecret(context.TODO(), "secret")
+		assert.ErrorIs(t, err, ErrVaultKeyInvalid)
+	})
+}
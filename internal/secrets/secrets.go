@@ -0,0 +1,238 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// ReferencePrefix is the prefix of the config values that reference a secret,
+	// e.g. "secret://ssm/idpscim/scim-access-token" or "secret://vault/secret/idpscim#token".
+	ReferencePrefix = "secret://"
+
+	// fieldSeparator separates the secret key and the field of a JSON secret
+	fieldSeparator = "#"
+)
+
+var (
+	// ErrReferenceInvalid is returned when a secret reference is not in the form secret://<provider>/<key>[#field].
+	ErrReferenceInvalid = errors.New("secrets: secret reference is not valid, expected secret://<provider>/<key>[#field]")
+
+	// ErrProviderNotFound is returned when the provider of a secret reference is not registered.
+	ErrProviderNotFound = errors.New("secrets: secrets provider not found")
+
+	// ErrProviderNil is returned when a registered secrets provider is nil.
+	ErrProviderNil = errors.New("secrets: secrets provider cannot be nil")
+
+	// ErrSecretFieldNotFound is returned when the field of a JSON secret doesn't exist.
+	ErrSecretFieldNotFound = errors.New("secrets: secret field not found")
+
+	// ErrTagsNotSupported is returned when the secrets provider doesn't support secret tags.
+	ErrTagsNotSupported = errors.New("secrets: secrets provider doesn't support tags")
+)
+
+// SecretsProvider is the interface implemented by the secrets backends.
+type SecretsProvider interface {
+	GetSecret(ctx context.Context, key string) (string, error)
+}
+
+// TagsProvider is the interface implemented by the secrets backends that support secret tags.
+type TagsProvider interface {
+	GetSecretTags(ctx context.Context, key string) (map[string]string, error)
+}
+
+// Resolver resolves the secret references using the registered secrets providers.
+type Resolver struct {
+	providers map[string]SecretsProvider
+}
+
+// ResolverOption is a function that configures the Resolver.
+type ResolverOption func(*Resolver)
+
+// WithProvider registers a secrets provider with the given name, the name used in the secret references.
+func WithProvider(name string, provider SecretsProvider) ResolverOption {
+	return func(r *Resolver) {
+		r.providers[name] = provider
+	}
+}
+
+// NewResolver returns a new Resolver with the given secrets providers.
+func NewResolver(opts ...ResolverOption) (*Resolver, error) {
+	r := &Resolver{
+		providers: make(map[string]SecretsProvider),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for name, provider := range r.providers {
+		if provider == nil {
+			return nil, fmt.Errorf("%w: %s", ErrProviderNil, name)
+		}
+	}
+
+	return r, nil
+}
+
+// IsReference returns true when the value is a secret reference.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, ReferencePrefix)
+}
+
+// ParseReference splits a secret reference in the form secret://<provider>/<key>[#field].
+func ParseReference(ref string) (provider, key, field string, err error) {
+	if !IsReference(ref) {
+		return "", "", "", fmt.Errorf("%w: %s", ErrReferenceInvalid, ref)
+	}
+
+	provider, key, ok := strings.Cut(strings.TrimPrefix(ref, ReferencePrefix), "/")
+	if !ok || provider == "" {
+		return "", "", "", fmt.Errorf("%w: %s", ErrReferenceInvalid, ref)
+	}
+
+	key, field, _ = strings.Cut(key, fieldSeparator)
+	if key == "" {
+		return "", "", "", fmt.Errorf("%w: %s", ErrReferenceInvalid, ref)
+	}
+
+	return provider, key, field, nil
+}
+
+// Resolve returns the secret referenced by the value, values that are not secret references are returned as is.
+// When the reference has a field, the secret must be a JSON object and the value of the field is returned.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+
+	name, key, field, err := ParseReference(value)
+	if err != nil {
+		return "", err
+	}
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrProviderNotFound, name)
+	}
+
+	secret, err := provider.GetSecret(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("secrets: error getting secret %s from %s: %w", key, name, err)
+	}
+
+	if field == "" {
+		return secret, nil
+	}
+
+	return secretField(secret, field)
+}
+
+// Tags returns the tags of the secret referenced by ref, the provider must implement TagsProvider.
+func (r *Resolver) Tags(ctx context.Context, ref string) (map[string]string, error) {
+	name, key, _, err := ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, name)
+	}
+
+	tp, ok := provider.(TagsProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTagsNotSupported, name)
+	}
+
+	tags, err := tp.GetSecretTags(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: error getting secret %s tags from %s: %w", key, name, err)
+	}
+
+	return tags, nil
+}
+
+// ResolveAll resolves the secret references of all the string fields, including the ones in slices and nested structs,
+// of the struct pointed by v. The fields tagged with `secret:"-"` are skipped, e.g. the references resolved by the caller.
+func (r *Resolver) ResolveAll(ctx context.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("secrets: a non nil pointer is required, got %T", v)
+	}
+
+	return r.resolveValue(ctx, rv.Elem(), "")
+}
+
+func (r *Resolver) resolveValue(ctx context.Context, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return r.resolveValue(ctx, v.Elem(), path)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Tag.Get("secret") == "-" {
+				continue
+			}
+			if err := r.resolveValue(ctx, v.Field(i), joinPath(path, field.Name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := r.resolveValue(ctx, v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if !IsReference(v.String()) || !v.CanSet() {
+			return nil
+		}
+
+		secret, err := r.Resolve(ctx, v.String())
+		if err != nil {
+			return fmt.Errorf("secrets: error resolving %s: %w", path, err)
+		}
+		v.SetString(secret)
+	}
+
+	return nil
+}
+
+// secretField returns the value of the field of a JSON object secret.
+func secretField(secret, field string) (string, error) {
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secrets: error decoding secret with field %s, a JSON object is expected: %w", field, err)
+	}
+
+	value, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretFieldNotFound, field)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("secrets: error encoding secret field %s: %w", field, err)
+		}
+		return string(b), nil
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
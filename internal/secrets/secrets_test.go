@@ -0,0 +1,229 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mapProvider is a SecretsProvider backed by a map
+type mapProvider map[string]string
+
+func (p mapProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	value, ok := p[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func TestNewResolver(t *testing.T) {
+	t.Run("Should return a resolver with the providers", func(t *testing.T) {
+		r, err := NewResolver(WithProvider("env", NewEnvProvider()))
+		assert.NoError(t, err)
+		assert.NotNil(t, r)
+		assert.Len(t, r.providers, 1)
+	})
+
+	t.Run("Should return an error when a provider is nil", func(t *testing.T) {
+		r, err := NewResolver(WithProvider("ssm", nil))
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrProviderNil)
+		assert.Nil(t, r)
+	})
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name         string
+		ref          string
+		wantProvider string
+		wantKey      string
+		wantField    string
+		wantErr      bool
+	}{
+		{name: "ssm", ref: "secret://ssm/idpscim/token", wantProvider: "ssm", wantKey: "idpscim/token"},
+		{name: "absolute file", ref: "secret://file//run/secrets/token", wantProvider: "file", wantKey: "/run/secrets/token"},
+		{name: "field", ref: "secret://vault/secret/idpscim#token", wantProvider: "vault", wantKey: "secret/idpscim", wantField: "token"},
+		{name: "not a reference", ref: "ssm/idpscim/token", wantErr: true},
+		{name: "without key", ref: "secret://ssm", wantErr: true},
+		{name: "empty key", ref: "secret://ssm/#token", wantErr: true},
+		{name: "empty provider", ref: "secret:///token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, key, field, err := ParseReference(tt.ref)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrReferenceInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantProvider, provider)
+			assert.Equal(t, tt.wantKey, key)
+			assert.Equal(t, tt.wantField, field)
+		})
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	ctx := context.TODO()
+	r, err := NewResolver(WithProvider("test", mapProvider{
+		"token": "my-token",
+		"json":  `{"token":"json-token","port":8080}`,
+	}))
+	assert.NoError(t, err)
+
+	t.Run("Should return the value when it is not a reference", func(t *testing.T) {
+		value, err := r.Resolve(ctx, "plain-value")
+		assert.NoError(t, err)
+		assert.Equal(t, "plain-value", value)
+	})
+
+	t.Run("Should return the secret", func(t *testing.T) {
+		value, err := r.Resolve(ctx, "secret://test/token")
+		assert.NoError(t, err)
+		assert.Equal(t, "my-token", value)
+	})
+
+	t.Run("Should return the field of a JSON secret", func(t *testing.T) {
+		value, err := r.Resolve(ctx, "secret://test/json#token")
+		assert.NoError(t, err)
+		assert.Equal(t, "json-token", value)
+
+		value, err = r.Resolve(ctx, "secret://test/json#port")
+		assert.NoError(t, err)
+		assert.Equal(t, "8080", value)
+	})
+
+	t.Run("Should return an error when the field doesn't exist", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "secret://test/json#missing")
+		assert.ErrorIs(t, err, ErrSecretFieldNotFound)
+	})
+
+	t.Run("Should return an error when the secret is not a JSON object", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "secret://test/token#token")
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return an error when the provider doesn't exist", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "secret://vault/secret/token")
+		assert.ErrorIs(t, err, ErrProviderNotFound)
+	})
+
+	t.Run("Should return an error when the secret doesn't exist", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "secret://test/missing")
+		assert.True(t, errors.Is(err, ErrSecretNotFound))
+	})
+}
+
+// tagsProvider is a SecretsProvider with tags
+type tagsProvider struct {
+	mapProvider
+	tags map[string]string
+}
+
+func (p tagsProvider) GetSecretTags(ctx context.Context, key string) (map[string]string, error) {
+	if _, ok := p.mapProvider[key]; !ok {
+		return nil, ErrSecretNotFound
+	}
+	return p.tags, nil
+}
+
+func TestResolver_Tags(t *testing.T) {
+	ctx := context.TODO()
+	r, err := NewResolver(
+		WithProvider("test", mapProvider{"token": "my-token"}),
+		WithProvider("tags", tagsProvider{mapProvider{"token": "my-token"}, map[string]string{"expires-at": "2023-01-10"}}),
+	)
+	assert.NoError(t, err)
+
+	t.Run("Should return the tags of the secret", func(t *testing.T) {
+		tags, err := r.Tags(ctx, "secret://tags/token")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"expires-at": "2023-01-10"}, tags)
+	})
+
+	t.Run("Should return an error when the provider doesn't support tags", func(t *testing.T) {
+		_, err := r.Tags(ctx, "secret://test/token")
+		assert.ErrorIs(t, err, ErrTagsNotSupported)
+	})
+
+	t.Run("Should return an error when the secret doesn't exist", func(t *testing.T) {
+		_, err := r.Tags(ctx, "secret://tags/missing")
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+	})
+}
+
+func TestResolver_ResolveAll(t *testing.T) {
+	ctx := context.TODO()
+	r, err := NewResolver(WithProvider("test", mapProvider{
+		"token":  "my-token",
+		"email":  "admin@mydomain.com",
+		"domain": "mydomain.com",
+	}))
+	assert.NoError(t, err)
+
+	type tenant struct {
+		UserEmail string
+		Domains   []string
+	}
+
+	type config struct {
+		Token    string
+		Endpoint string
+		Port     int
+		Filters  []string
+		Tenants  []tenant
+		Tenant   *tenant
+		secret   string
+
+		TokenSecretName string `secret:"-"`
+	}
+
+	t.Run("Should resolve all the references", func(t *testing.T) {
+		cfg := config{
+			Token:    "secret://test/token",
+			Endpoint: "https://scim.example.com",
+			Port:     8080,
+			Filters:  []string{"name:AWS*", "secret://test/email"},
+			Tenants:  []tenant{{UserEmail: "secret://test/email", Domains: []string{"secret://test/domain"}}},
+			Tenant:   &tenant{UserEmail: "secret://test/email"},
+			secret:   "secret://test/token",
+		}
+
+		err := r.ResolveAll(ctx, &cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "my-token", cfg.Token)
+		assert.Equal(t, "https://scim.example.com", cfg.Endpoint)
+		assert.Equal(t, []string{"name:AWS*", "admin@mydomain.com"}, cfg.Filters)
+		assert.Equal(t, "admin@mydomain.com", cfg.Tenants[0].UserEmail)
+		assert.Equal(t, []string{"mydomain.com"}, cfg.Tenants[0].Domains)
+		assert.Equal(t, "admin@mydomain.com", cfg.Tenant.UserEmail)
+		assert.Equal(t, "secret://test/token", cfg.secret)
+	})
+
+	t.Run("Should skip the fields tagged to be skipped", func(t *testing.T) {
+		cfg := config{Token: "secret://test/token", TokenSecretName: "secret://test/token"}
+
+		err := r.ResolveAll(ctx, &cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "my-token", cfg.Token)
+		assert.Equal(t, "secret://test/token", cfg.TokenSecretName)
+	})
+
+	t.Run("Should return an error with the field path", func(t *testing.T) {
+		cfg := config{Tenants: []tenant{{UserEmail: "secret://test/missing"}}}
+
+		err := r.ResolveAll(ctx, &cfg)
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+		assert.Contains(t, err.Error(), "Tenants[0].UserEmail")
+	})
+
+	t.Run("Should return an error when it is not a pointer", func(t *testing.T) {
+		err := r.ResolveAll(ctx, config{})
+		assert.Error(t, err)
+	})
+}
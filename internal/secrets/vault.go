@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrVaultAddressNil is returned when the HashiCorp Vault address is empty.
+	ErrVaultAddressNil = errors.New("secrets: vault address is required")
+
+	// ErrVaultTokenNil is returned when the HashiCorp Vault token is empty.
+	ErrVaultTokenNil = errors.New("secrets: vault token is required")
+
+	// ErrVaultKeyInvalid is returned when the key is not in the form <mount>/<path>.
+	ErrVaultKeyInvalid = errors.New("secrets: vault key is not valid, expected <mount>/<path>")
+)
+
+// VaultProvider is the secrets provider of the HashiCorp Vault KV version 2 secrets engine.
+// the key is in the form <mount>/<path> and the secret is the JSON object of the secret data,
+// so "secret://vault/secret/idpscim#token" references the field "token" of the secret "idpscim" in the mount "secret".
+type VaultProvider struct {
+	address    string
+	token      string
+	httpClient *http.Client
+}
+
+// VaultProviderOption is a function that configures the VaultProvider.
+type VaultProviderOption func(*VaultProvider)
+
+// WithVaultHTTPClient sets the HTTP client used to call the HashiCorp Vault API.
+func WithVaultHTTPClient(client *http.Client) VaultProviderOption {
+	return func(p *VaultProvider) {
+		p.httpClient = client
+	}
+}
+
+// NewVaultProvider returns a new VaultProvider.
+func NewVaultProvider(address, token string, opts ...VaultProviderOption) (*VaultProvider, error) {
+	if address == "" {
+		return nil, ErrVaultAddressNil
+	}
+	if token == "" {
+		return nil, ErrVaultTokenNil
+	}
+
+	p := &VaultProvider{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      token,
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
+}
+
+// vaultKVResponse is the response of the KV version 2 read secret API
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// GetSecret returns the latest version of the secret data as a JSON object.
+// References:
+// - https://developer.hashicorp.com/vault/api-docs/secret/kv/kv-v2#read-secret-version
+func (p *VaultProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	mount, path, ok := strings.Cut(strings.Trim(key, "/"), "/")
+	if !ok || mount == "" || path == "" {
+		return "", fmt.Errorf("%w: %s", ErrVaultKeyInvalid, key)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", p.address, mount, path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("secrets: error creating vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secrets: error reading vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: vault secret %s", ErrSecretNotFound, key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secrets: error reading vault secret %s, status code: %d", key, resp.StatusCode)
+	}
+
+	var kv vaultKVResponse
+	if err := json.NewDecoder(resp.Body).Decode(&kv); err != nil {
+		return "", fmt.Errorf("secrets: error decoding vault response: %w", err)
+	}
+
+	data, err := json.Marshal(kv.Data.Data)
+	if err != nil {
+		return "", fmt.Errorf("secrets: error encoding vault secret data: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+
+type options struct {
+	serviceName    string
+	serviceVersion string
+	sampleRatio    float64
+	exporterOpts   []otlptracehttp.Option
+}
+
+// Option is a function that can be used to configure the tracing.
+type Option func(*options)
+
+// WithServiceName sets the name of the service in the exported spans.
+func WithServiceName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.serviceName = name
+		}
+	}
+}
+
+// WithServiceVersion sets the version of the service in the exported spans.
+func WithServiceVersion(version string) Option {
+	return func(o *options) {
+		o.serviceVersion = version
+	}
+}
+
+// WithSampleRatio sets the ratio, between 0 and 1, of the traces sampled.
+func WithSampleRatio(ratio float64) Option {
+	return func(o *options) {
+		o.sampleRatio = ratio
+	}
+}
+
+// WithExporterOptions sets the options of the OTLP exporter, e.g. the headers of the export requests.
+func WithExporterOptions(opts ...otlptracehttp.Option) Option {
+	return func(o *options) {
+		o.exporterOpts = append(o.exporterOpts, opts...)
+	}
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultServiceName is the default name of the service in the exported spans.
+	DefaultServiceName = "idp-scim-sync"
+
+	// DefaultSampleRatio is the default ratio of the traces sampled, all of them.
+	DefaultSampleRatio = 1.0
+)
+
+var (
+	// ErrSampleRatioInvalid is returned when the sample ratio is not between 0 and 1.
+	ErrSampleRatioInvalid = errors.New("tracing: sample ratio must be between 0 and 1")
+
+	// ErrEndpointInvalid is returned when the OTLP endpoint is not a valid http(s) url.
+	ErrEndpointInvalid = errors.New("tracing: endpoint must be a valid http(s) url")
+)
+
+// ShutdownFunc flushes the pending spans and stops the export.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup sets the global OpenTelemetry tracer provider exporting the spans with OTLP over HTTP to the endpoint,
+// the returned function must be called before exiting to flush the pending spans.
+//
+// When the endpoint is empty the tracing is disabled, the global tracer provider is not changed
+// and the spans are no-op.
+func Setup(endpoint string, opts ...Option) (ShutdownFunc, error) {
+	o := &options{
+		serviceName: DefaultServiceName,
+		sampleRatio: DefaultSampleRatio,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if endpoint == "" {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	if o.sampleRatio < 0 || o.sampleRatio > 1 {
+		return nil, fmt.Errorf("%w: %v", ErrSampleRatioInvalid, o.sampleRatio)
+	}
+
+	endpointOpts, err := endpointOptions(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	exp, err := otlptracehttp.New(context.Background(), append(endpointOpts, o.exporterOpts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: error creating exporter: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceNameKey.String(o.serviceName)}
+	if o.serviceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(o.serviceVersion))
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attrs...)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// endpointOptions returns the options of the OTLP exporter for the endpoint, the base url of the collector,
+// e.g. http://localhost:4318, or the full url of its traces endpoint when it has a path.
+func endpointOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrEndpointInvalid, endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if u.Path != "" && u.Path != "/" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	return opts, nil
+}
+
+// Start starts a span with the tracer, returning the context with the span.
+// It is used by all the packages to start their spans. When the tracing is disabled the span is
+// a no-op and the context is returned untouched, so it is the same one given by the caller.
+func Start(ctx context.Context, tracer trace.Tracer, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	spanCtx, span := tracer.Start(ctx, name, opts...)
+	if !span.SpanContext().IsValid() {
+		return ctx, span
+	}
+
+	return spanCtx, span
+}
+
+// End records the error, if any, in the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("Should be a no-op without endpoint", func(t *testing.T) {
+		before := otel.GetTracerProvider()
+
+		shutdown, err := Setup("", WithSampleRatio(2))
+		assert.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+		assert.Equal(t, before, otel.GetTracerProvider())
+	})
+
+	t.Run("Should return an error with an invalid sample ratio", func(t *testing.T) {
+		shutdown, err := Setup("http://localhost:4318", WithSampleRatio(1.5))
+		assert.ErrorIs(t, err, ErrSampleRatioInvalid)
+		assert.Nil(t, shutdown)
+	})
+
+	t.Run("Should return an error with an invalid endpoint", func(t *testing.T) {
+		for _, endpoint := range []string{"localhost:4318", "grpc://localhost:4317", "http://"} {
+			shutdown, err := Setup(endpoint)
+			assert.ErrorIs(t, err, ErrEndpointInvalid)
+			assert.Nil(t, shutdown)
+		}
+	})
+
+	t.Run("Should export the spans to the endpoint", func(t *testing.T) {
+		before := otel.GetTracerProvider()
+		defer otel.SetTracerProvider(before)
+
+		var gotPath, gotHeader string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotHeader = r.Header.Get("X-Api-Key")
+		}))
+		defer srv.Close()
+
+		shutdown, err := Setup(srv.URL+"/otlp/v1/traces", WithExporterOptions(otlptracehttp.WithHeaders(map[string]string{"X-Api-Key": "secret"})))
+		assert.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "test")
+		span.End()
+
+		assert.NoError(t, shutdown(context.Background()))
+		assert.Equal(t, "/otlp/v1/traces", gotPath)
+		assert.Equal(t, "secret", gotHeader)
+	})
+}
+
+func TestEndpointOptions(t *testing.T) {
+	t.Run("Should use the default traces path with the base url", func(t *testing.T) {
+		opts, err := endpointOptions("https://collector.example.com")
+		assert.NoError(t, err)
+		assert.Len(t, opts, 1)
+	})
+
+	t.Run("Should be insecure and keep the path of the url", func(t *testing.T) {
+		opts, err := endpointOptions("http://localhost:4318/otlp/v1/traces")
+		assert.NoError(t, err)
+		assert.Len(t, opts, 3)
+	})
+}
+
+func TestStart(t *testing.T) {
+	t.Run("Should return the same context when the tracing is disabled", func(t *testing.T) {
+		ctx := context.Background()
+
+		got, span := Start(ctx, trace.NewNoopTracerProvider().Tracer("test"), "test")
+		assert.Equal(t, ctx, got)
+		assert.False(t, span.IsRecording())
+	})
+
+	t.Run("Should return the context with the span", func(t *testing.T) {
+		tp := sdktrace.NewTracerProvider()
+
+		got, span := Start(context.Background(), tp.Tracer("test"), "test")
+		assert.Equal(t, span, trace.SpanFromContext(got))
+		assert.True(t, span.IsRecording())
+	})
+}
+
+func TestEnd(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "ok")
+	End(span, nil)
+
+	_, span = tracer.Start(context.Background(), "error")
+	End(span, errors.New("test error"))
+
+	ended := sr.Ended()
+	assert.Equal(t, 2, len(ended))
+	assert.Equal(t, codes.Unset, ended[0].Status().Code)
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+	assert.Equal(t, "test error", ended[1].Status().Description)
+}
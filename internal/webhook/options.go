@@ -0,0 +1,15 @@
+package webhook
+
+import "time"
+
+// ReceiverOption is a function that can be used to configure the webhook Receiver.
+type ReceiverOption func(*Receiver)
+
+// WithDebounce sets the time the events are collected before triggering the sync of their changes.
+func WithDebounce(debounce time.Duration) ReceiverOption {
+	return func(r *Receiver) {
+		if debounce > 0 {
+			r.debounce = debounce
+		}
+	}
+}
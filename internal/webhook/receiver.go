@@ -0,0 +1,277 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slashdevops/idp-scim-sync/internal/config"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+)
+
+const (
+	// maxBodySize is the maximum size of the body of the notifications
+	maxBodySize = 1 << 20
+
+	// headers of the Google push notifications
+	// https://developers.google.com/admin-sdk/directory/v1/guides/push#understanding-the-notification-message-format
+	channelTokenHeader  = "X-Goog-Channel-Token"
+	resourceStateHeader = "X-Goog-Resource-State"
+
+	// resourceStateSync is the resource state of the first notification of a new channel
+	resourceStateSync = "sync"
+
+	// tokenQueryParameter is the query parameter with the token of the Pub/Sub push subscriptions
+	tokenQueryParameter = "token"
+)
+
+var (
+	// ErrTokenEmpty is returned when the token of the receiver is empty.
+	ErrTokenEmpty = errors.New("webhook: token cannot be empty")
+
+	// ErrTriggerNil is returned when the sync trigger of the receiver is nil.
+	ErrTriggerNil = errors.New("webhook: sync trigger cannot be nil")
+)
+
+// Trigger starts a sync of the groups and users changed, it returns an error when the sync cannot be started,
+// e.g. because another one is in progress, then the changes are triggered again after the debounce.
+type Trigger func(changes *model.IdentityProviderChanges) error
+
+// Receiver is the HTTP handler of the Google Workspace change events, it receives:
+//   - the push notifications of the Google Directory API watch channels, e.g. users.watch,
+//     verified by the channel token (X-Goog-Channel-Token header).
+//   - the Google Workspace Admin audit events relayed by a Pub/Sub push subscription, a Reports API activity
+//     or a Cloud Logging entry as message data, verified by the token query parameter of the push endpoint.
+//
+// The groups and users of the events received during the debounce are synced together.
+type Receiver struct {
+	token    string
+	trigger  Trigger
+	debounce time.Duration
+
+	mu      sync.Mutex
+	changes *model.IdentityProviderChangesBuilderChoice
+	pending bool
+	timer   *time.Timer
+}
+
+// NewReceiver returns a new Receiver of the events verified by the given token, triggering the sync of their changes.
+func NewReceiver(token string, trigger Trigger, opts ...ReceiverOption) (*Receiver, error) {
+	if token == "" {
+		return nil, ErrTokenEmpty
+	}
+	if trigger == nil {
+		return nil, ErrTriggerNil
+	}
+
+	r := &Receiver{
+		token:    token,
+		trigger:  trigger,
+		debounce: config.DefaultWebhookDebounce,
+		changes:  model.IdentityProviderChangesBuilder(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+// ServeHTTP receives an event and schedules the sync of its changes.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("webhook: method %s not allowed", req.Method))
+		return
+	}
+
+	if !r.authorized(req) {
+		writeError(w, http.StatusUnauthorized, errors.New("webhook: unauthorized"))
+		return
+	}
+
+	body := http.MaxBytesReader(w, req.Body, maxBodySize)
+
+	var (
+		changes *model.IdentityProviderChanges
+		err     error
+	)
+
+	if state := req.Header.Get(resourceStateHeader); state != "" {
+		if state == resourceStateSync {
+			// the channel was created, there are no changes yet
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		changes, err = pushNotificationChanges(body)
+	} else {
+		changes, err = pubSubChanges(body)
+	}
+	if err != nil {
+		log.WithError(err).Warn("webhook: invalid event")
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(changes.Groups) == 0 && len(changes.Users) == 0 {
+		log.Debug("webhook: event without groups or users changed")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"groups": changes.Groups,
+		"users":  changes.Users,
+	}).Info("webhook: event received")
+
+	r.add(changes)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Stop stops the pending sync of the changes received, if any.
+func (r *Receiver) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+}
+
+// authorized verifies the channel token of the push notifications or the token of the Pub/Sub push endpoint.
+func (r *Receiver) authorized(req *http.Request) bool {
+	token := req.Header.Get(channelTokenHeader)
+	if token == "" {
+		token = req.URL.Query().Get(tokenQueryParameter)
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(r.token)) == 1
+}
+
+// add adds the changes to the pending ones, the sync is triggered after the debounce of the first pending change.
+func (r *Receiver) add(changes *model.IdentityProviderChanges) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.changes.WithChanges(changes)
+	r.schedule()
+}
+
+// schedule triggers the sync of the pending changes after the debounce, it must be called with the lock held.
+func (r *Receiver) schedule() {
+	if r.pending {
+		return
+	}
+
+	r.pending = true
+	r.timer = time.AfterFunc(r.debounce, r.flush)
+}
+
+// flush triggers the sync of the pending changes, they are kept pending when the sync cannot be started.
+func (r *Receiver) flush() {
+	r.mu.Lock()
+	changes := r.changes.Build()
+	r.changes = model.IdentityProviderChangesBuilder()
+	r.pending = false
+	r.mu.Unlock()
+
+	if err := r.trigger(changes); err != nil {
+		log.WithError(err).WithField("retryIn", r.debounce.String()).Warn("webhook: cannot trigger the sync of the changes")
+		r.add(changes)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"groups": len(changes.Groups),
+		"users":  len(changes.Users),
+	}).Info("webhook: sync of the changes triggered")
+}
+
+// pushNotificationChanges returns the user changed of a push notification of the users watch channels,
+// the body is the user resource.
+// https://developers.google.com/admin-sdk/directory/reference/rest/v1/users/watch
+func pushNotificationChanges(body io.Reader) (*model.IdentityProviderChanges, error) {
+	var user struct {
+		PrimaryEmail string `json:"primaryEmail"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("webhook: error decoding the push notification: %w", err)
+	}
+
+	return model.IdentityProviderChangesBuilder().WithUser(user.PrimaryEmail).Build(), nil
+}
+
+// pubSubMessage is the body of the requests of the Pub/Sub push subscriptions.
+// https://cloud.google.com/pubsub/docs/push#receive_push
+type pubSubMessage struct {
+	Message struct {
+		Data      []byte `json:"data"`
+		MessageID string `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// auditEvent is an Admin audit event, a Reports API activity or a Cloud Logging entry of the Google Workspace audit logs.
+type auditEvent struct {
+	// Events of a Reports API activity
+	Events []auditEventDetail `json:"events"`
+
+	// ProtoPayload of a Cloud Logging entry
+	ProtoPayload struct {
+		Metadata struct {
+			Event []auditEventDetail `json:"event"`
+		} `json:"metadata"`
+	} `json:"protoPayload"`
+}
+
+type auditEventDetail struct {
+	// Parameters of the Reports API activities and Parameter of the Cloud Logging entries
+	Parameters []auditEventParameter `json:"parameters"`
+	Parameter  []auditEventParameter `json:"parameter"`
+}
+
+type auditEventParameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// pubSubChanges returns the groups and users changed of an Admin audit event relayed by a Pub/Sub push subscription.
+func pubSubChanges(body io.Reader) (*model.IdentityProviderChanges, error) {
+	var msg pubSubMessage
+	if err := json.NewDecoder(body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("webhook: error decoding the pub/sub message: %w", err)
+	}
+
+	var event auditEvent
+	if err := json.Unmarshal(msg.Message.Data, &event); err != nil {
+		return nil, fmt.Errorf("webhook: error decoding the audit event of the pub/sub message: %s, %w", msg.Message.MessageID, err)
+	}
+
+	b := model.IdentityProviderChangesBuilder()
+
+	details := append(event.Events, event.ProtoPayload.Metadata.Event...)
+	for _, detail := range details {
+		for _, parameter := range append(detail.Parameters, detail.Parameter...) {
+			b.WithParameter(parameter.Name, parameter.Value)
+		}
+	}
+
+	return b.Build(), nil
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); err != nil {
+		log.WithError(err).Error("webhook: error writing the response")
+	}
+}
@@ -0,0 +1,236 @@
+package webhook
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slashdevops/idp-scim-sync/internal/config"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the changes triggered, failing the first fails triggers.
+type recorder struct {
+	mu      sync.Mutex
+	fails   int
+	changes []*model.IdentityProviderChanges
+}
+
+func (r *recorder) trigger(changes *model.IdentityProviderChanges) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fails > 0 {
+		r.fails--
+		return errors.New("test error")
+	}
+
+	r.changes = append(r.changes, changes)
+	return nil
+}
+
+func (r *recorder) triggered() []*model.IdentityProviderChanges {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.changes
+}
+
+func pubSubBody(event string) string {
+	return `{"message":{"data":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `","messageId":"1"},"subscription":"projects/p/subscriptions/s"}`
+}
+
+func TestNewReceiver(t *testing.T) {
+	trigger := func(changes *model.IdentityProviderChanges) error { return nil }
+
+	t.Run("Should return a receiver", func(t *testing.T) {
+		r, err := NewReceiver("token", trigger, WithDebounce(time.Minute))
+		assert.NoError(t, err)
+		assert.NotNil(t, r)
+		assert.Equal(t, time.Minute, r.debounce)
+	})
+
+	t.Run("Should use the default debounce", func(t *testing.T) {
+		r, err := NewReceiver("token", trigger, WithDebounce(0))
+		assert.NoError(t, err)
+		assert.Equal(t, config.DefaultWebhookDebounce, r.debounce)
+	})
+
+	t.Run("Should return an error without token", func(t *testing.T) {
+		r, err := NewReceiver("", trigger)
+		assert.ErrorIs(t, err, ErrTokenEmpty)
+		assert.Nil(t, r)
+	})
+
+	t.Run("Should return an error without trigger", func(t *testing.T) {
+		r, err := NewReceiver("token", nil)
+		assert.ErrorIs(t, err, ErrTriggerNil)
+		assert.Nil(t, r)
+	})
+}
+
+func TestReceiver_ServeHTTP(t *testing.T) {
+	reportsActivity := `{"events":[{"name":"ADD_GROUP_MEMBER","parameters":[{"name":"USER_EMAIL","value":"User.1@mail.com"},{"name":"GROUP_EMAIL","value":"group.1@mail.com"}]}]}`
+	loggingEntry := `{"protoPayload":{"metadata":{"event":[{"eventName":"CHANGE_GROUP_NAME","parameter":[{"name":"GROUP_EMAIL","value":"Group.2@mail.com"}]}]}}}`
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		headers map[string]string
+		body    string
+		code    int
+		want    *model.IdentityProviderChanges
+	}{
+		{
+			name:   "Should accept the audit event of a reports activity",
+			method: http.MethodPost,
+			target: "/webhook?token=secret",
+			body:   pubSubBody(reportsActivity),
+			code:   http.StatusAccepted,
+			want:   &model.IdentityProviderChanges{Groups: []string{"group.1@mail.com"}, Users: []string{"user.1@mail.com"}},
+		},
+		{
+			name:   "Should accept the audit event of a cloud logging entry",
+			method: http.MethodPost,
+			target: "/webhook?token=secret",
+			body:   pubSubBody(loggingEntry),
+			code:   http.StatusAccepted,
+			want:   &model.IdentityProviderChanges{Groups: []string{"group.2@mail.com"}, Users: []string{}},
+		},
+		{
+			name:    "Should accept the push notification of a user",
+			method:  http.MethodPost,
+			target:  "/webhook",
+			headers: map[string]string{channelTokenHeader: "secret", resourceStateHeader: "update"},
+			body:    `{"kind":"admin#directory#user","primaryEmail":"User.2@mail.com"}`,
+			code:    http.StatusAccepted,
+			want:    &model.IdentityProviderChanges{Users: []string{"user.2@mail.com"}},
+		},
+		{
+			name:    "Should ignore the sync notification of a new channel",
+			method:  http.MethodPost,
+			target:  "/webhook",
+			headers: map[string]string{channelTokenHeader: "secret", resourceStateHeader: "sync"},
+			code:    http.StatusOK,
+		},
+		{
+			name:   "Should ignore the audit events without groups or users",
+			method: http.MethodPost,
+			target: "/webhook?token=secret",
+			body:   pubSubBody(`{"events":[{"name":"CHANGE_APPLICATION_SETTING"}]}`),
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Should reject the requests without token",
+			method: http.MethodPost,
+			target: "/webhook",
+			body:   pubSubBody(reportsActivity),
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:    "Should reject the requests with an invalid token",
+			method:  http.MethodPost,
+			target:  "/webhook",
+			headers: map[string]string{channelTokenHeader: "invalid", resourceStateHeader: "update"},
+			code:    http.StatusUnauthorized,
+		},
+		{
+			name:   "Should reject the methods other than POST",
+			method: http.MethodGet,
+			target: "/webhook?token=secret",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "Should reject the invalid pub/sub messages",
+			method: http.MethodPost,
+			target: "/webhook?token=secret",
+			body:   `{"message":`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "Should reject the invalid audit events",
+			method: http.MethodPost,
+			target: "/webhook?token=secret",
+			body:   pubSubBody(`not json`),
+			code:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			r, err := NewReceiver("secret", rec.trigger, WithDebounce(10*time.Millisecond))
+			assert.NoError(t, err)
+			defer r.Stop()
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+
+			if tt.want == nil {
+				time.Sleep(50 * time.Millisecond)
+				assert.Empty(t, rec.triggered())
+				return
+			}
+
+			assert.Eventually(t, func() bool { return len(rec.triggered()) == 1 }, time.Second, 5*time.Millisecond)
+			got := rec.triggered()[0]
+			assert.ElementsMatch(t, tt.want.Groups, got.Groups)
+			assert.ElementsMatch(t, tt.want.Users, got.Users)
+		})
+	}
+}
+
+func TestReceiver_Debounce(t *testing.T) {
+	send := func(r *Receiver, event string) {
+		req := httptest.NewRequest(http.MethodPost, "/webhook?token=secret", strings.NewReader(pubSubBody(event)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	}
+
+	t.Run("Should trigger the changes of a burst of events together", func(t *testing.T) {
+		rec := &recorder{}
+		r, err := NewReceiver("secret", rec.trigger, WithDebounce(50*time.Millisecond))
+		assert.NoError(t, err)
+		defer r.Stop()
+
+		send(r, `{"events":[{"parameters":[{"name":"USER_EMAIL","value":"user.1@mail.com"}]}]}`)
+		send(r, `{"events":[{"parameters":[{"name":"USER_EMAIL","value":"user.2@mail.com"}]}]}`)
+		send(r, `{"events":[{"parameters":[{"name":"GROUP_EMAIL","value":"group.1@mail.com"},{"name":"USER_EMAIL","value":"user.1@mail.com"}]}]}`)
+
+		assert.Eventually(t, func() bool { return len(rec.triggered()) == 1 }, time.Second, 5*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+
+		got := rec.triggered()
+		assert.Len(t, got, 1)
+		assert.Equal(t, &model.IdentityProviderChanges{
+			Groups: []string{"group.1@mail.com"},
+			Users:  []string{"user.1@mail.com", "user.2@mail.com"},
+		}, got[0])
+	})
+
+	t.Run("Should trigger again the changes when the sync cannot be started", func(t *testing.T) {
+		rec := &recorder{fails: 2}
+		r, err := NewReceiver("secret", rec.trigger, WithDebounce(10*time.Millisecond))
+		assert.NoError(t, err)
+		defer r.Stop()
+
+		send(r, `{"events":[{"parameters":[{"name":"USER_EMAIL","value":"user.1@mail.com"}]}]}`)
+
+		assert.Eventually(t, func() bool { return len(rec.triggered()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []string{"user.1@mail.com"}, rec.triggered()[0].Users)
+	})
+}
@@ -0,0 +1,76 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cloudwatchlogs.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	cloudwatchlogs "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCloudWatchLogsClientAPI is a mock of CloudWatchLogsClientAPI interface.
+type MockCloudWatchLogsClientAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockCloudWatchLogsClientAPIMockRecorder
+}
+
+// MockCloudWatchLogsClientAPIMockRecorder is the mock recorder for MockCloudWatchLogsClientAPI.
+type MockCloudWatchLogsClientAPIMockRecorder struct {
+	mock *MockCloudWatchLogsClientAPI
+}
+
+// NewMockCloudWatchLogsClientAPI creates a new mock instance.
+func NewMockCloudWatchLogsClientAPI(ctrl *gomock.Controller) *MockCloudWatchLogsClientAPI {
+	mock := &MockCloudWatchLogsClientAPI{ctrl: ctrl}
+	mock.recorder = &MockCloudWatchLogsClientAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCloudWatchLogsClientAPI) EXPECT() *MockCloudWatchLogsClientAPIMockRecorder {
+	return m.recorder
+}
+
+// CreateLogStream mocks base method.
+func (m *MockCloudWatchLogsClientAPI) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateLogStream", varargs...)
+	ret0, _ := ret[0].(*cloudwatchlogs.CreateLogStreamOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLogStream indicates an expected call of CreateLogStream.
+func (mr *MockCloudWatchLogsClientAPIMockRecorder) CreateLogStream(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLogStream", reflect.TypeOf((*MockCloudWatchLogsClientAPI)(nil).CreateLogStream), varargs...)
+}
+
+// PutLogEvents mocks base method.
+func (m *MockCloudWatchLogsClientAPI) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutLogEvents", varargs...)
+	ret0, _ := ret[0].(*cloudwatchlogs.PutLogEventsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutLogEvents indicates an expected call of PutLogEvents.
+func (mr *MockCloudWatchLogsClientAPIMockRecorder) PutLogEvents(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutLogEvents", reflect.TypeOf((*MockCloudWatchLogsClientAPI)(nil).PutLogEvents), varargs...)
+}
@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: s3.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	s3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockS3ClientAPI is a mock of S3ClientAPI interface.
+type MockS3ClientAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockS3ClientAPIMockRecorder
+}
+
+// MockS3ClientAPIMockRecorder is the mock recorder for MockS3ClientAPI.
+type MockS3ClientAPIMockRecorder struct {
+	mock *MockS3ClientAPI
+}
+
+// NewMockS3ClientAPI creates a new mock instance.
+func NewMockS3ClientAPI(ctrl *gomock.Controller) *MockS3ClientAPI {
+	mock := &MockS3ClientAPI{ctrl: ctrl}
+	mock.recorder = &MockS3ClientAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockS3ClientAPI) EXPECT() *MockS3ClientAPIMockRecorder {
+	return m.recorder
+}
+
+// PutObject mocks base method.
+func (m *MockS3ClientAPI) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutObject", varargs...)
+	ret0, _ := ret[0].(*s3.PutObjectOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutObject indicates an expected call of PutObject.
+func (mr *MockS3ClientAPIMockRecorder) PutObject(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutObject", reflect.TypeOf((*MockS3ClientAPI)(nil).PutObject), varargs...)
+}
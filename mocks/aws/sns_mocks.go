@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: sns.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	sns "github.com/aws/aws-sdk-go-v2/service/sns"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSNSClientAPI is a mock of SNSClientAPI interface.
+type MockSNSClientAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockSNSClientAPIMockRecorder
+}
+
+// MockSNSClientAPIMockRecorder is the mock recorder for MockSNSClientAPI.
+type MockSNSClientAPIMockRecorder struct {
+	mock *MockSNSClientAPI
+}
+
+// NewMockSNSClientAPI creates a new mock instance.
+func NewMockSNSClientAPI(ctrl *gomock.Controller) *MockSNSClientAPI {
+	mock := &MockSNSClientAPI{ctrl: ctrl}
+	mock.recorder = &MockSNSClientAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSNSClientAPI) EXPECT() *MockSNSClientAPIMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockSNSClientAPI) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Publish", varargs...)
+	ret0, _ := ret[0].(*sns.PublishOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockSNSClientAPIMockRecorder) Publish(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockSNSClientAPI)(nil).Publish), varargs...)
+}
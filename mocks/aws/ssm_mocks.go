@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ssm.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	ssm "github.com/aws/aws-sdk-go-v2/service/ssm"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSSMClientAPI is a mock of SSMClientAPI interface.
+type MockSSMClientAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockSSMClientAPIMockRecorder
+}
+
+// MockSSMClientAPIMockRecorder is the mock recorder for MockSSMClientAPI.
+type MockSSMClientAPIMockRecorder struct {
+	mock *MockSSMClientAPI
+}
+
+// NewMockSSMClientAPI creates a new mock instance.
+func NewMockSSMClientAPI(ctrl *gomock.Controller) *MockSSMClientAPI {
+	mock := &MockSSMClientAPI{ctrl: ctrl}
+	mock.recorder = &MockSSMClientAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSSMClientAPI) EXPECT() *MockSSMClientAPIMockRecorder {
+	return m.recorder
+}
+
+// GetParameter mocks base method.
+func (m *MockSSMClientAPI) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetParameter", varargs...)
+	ret0, _ := ret[0].(*ssm.GetParameterOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetParameter indicates an expected call of GetParameter.
+func (mr *MockSSMClientAPIMockRecorder) GetParameter(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetParameter", reflect.TypeOf((*MockSSMClientAPI)(nil).GetParameter), varargs...)
+}
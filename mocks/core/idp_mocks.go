@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/slashdevops/idp-scim-sync/internal/model"
@@ -35,6 +36,21 @@ func (m *MockIdentityProviderService) EXPECT() *MockIdentityProviderServiceMockR
 	return m.recorder
 }
 
+// GetGroup mocks base method.
+func (m *MockIdentityProviderService) GetGroup(ctx context.Context, group string) (*model.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, group)
+	ret0, _ := ret[0].(*model.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockIdentityProviderServiceMockRecorder) GetGroup(ctx, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockIdentityProviderService)(nil).GetGroup), ctx, group)
+}
+
 // GetGroupMembers mocks base method.
 func (m *MockIdentityProviderService) GetGroupMembers(ctx context.Context, id string) (*model.MembersResult, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +96,21 @@ func (mr *MockIdentityProviderServiceMockRecorder) GetGroupsMembers(ctx, gr inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupsMembers", reflect.TypeOf((*MockIdentityProviderService)(nil).GetGroupsMembers), ctx, gr)
 }
 
+// GetUser mocks base method.
+func (m *MockIdentityProviderService) GetUser(ctx context.Context, email string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, email)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockIdentityProviderServiceMockRecorder) GetUser(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockIdentityProviderService)(nil).GetUser), ctx, email)
+}
+
 // GetUsers mocks base method.
 func (m *MockIdentityProviderService) GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error) {
 	m.ctrl.T.Helper()
@@ -109,3 +140,41 @@ func (mr *MockIdentityProviderServiceMockRecorder) GetUsersByGroupsMembers(ctx,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByGroupsMembers", reflect.TypeOf((*MockIdentityProviderService)(nil).GetUsersByGroupsMembers), ctx, gmr)
 }
+
+// MockIdentityProviderChangesSource is a mock of IdentityProviderChangesSource interface.
+type MockIdentityProviderChangesSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityProviderChangesSourceMockRecorder
+}
+
+// MockIdentityProviderChangesSourceMockRecorder is the mock recorder for MockIdentityProviderChangesSource.
+type MockIdentityProviderChangesSourceMockRecorder struct {
+	mock *MockIdentityProviderChangesSource
+}
+
+// NewMockIdentityProviderChangesSource creates a new mock instance.
+func NewMockIdentityProviderChangesSource(ctrl *gomock.Controller) *MockIdentityProviderChangesSource {
+	mock := &MockIdentityProviderChangesSource{ctrl: ctrl}
+	mock.recorder = &MockIdentityProviderChangesSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityProviderChangesSource) EXPECT() *MockIdentityProviderChangesSourceMockRecorder {
+	return m.recorder
+}
+
+// GetChanges mocks base method.
+func (m *MockIdentityProviderChangesSource) GetChanges(ctx context.Context, since time.Time) (*model.IdentityProviderChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", ctx, since)
+	ret0, _ := ret[0].(*model.IdentityProviderChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockIdentityProviderChangesSourceMockRecorder) GetChanges(ctx, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockIdentityProviderChangesSource)(nil).GetChanges), ctx, since)
+}
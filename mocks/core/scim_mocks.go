@@ -80,6 +80,21 @@ func (mr *MockSCIMServiceMockRecorder) CreateUsers(ctx, ur interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUsers", reflect.TypeOf((*MockSCIMService)(nil).CreateUsers), ctx, ur)
 }
 
+// DeactivateUsers mocks base method.
+func (m *MockSCIMService) DeactivateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateUsers", ctx, ur)
+	ret0, _ := ret[0].(*model.UsersResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeactivateUsers indicates an expected call of DeactivateUsers.
+func (mr *MockSCIMServiceMockRecorder) DeactivateUsers(ctx, ur interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateUsers", reflect.TypeOf((*MockSCIMService)(nil).DeactivateUsers), ctx, ur)
+}
+
 // DeleteGroups mocks base method.
 func (m *MockSCIMService) DeleteGroups(ctx context.Context, gr *model.GroupsResult) error {
 	m.ctrl.T.Helper()
@@ -167,6 +182,21 @@ func (mr *MockSCIMServiceMockRecorder) GetGroupsMembersBruteForce(ctx, gr, ur in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupsMembersBruteForce", reflect.TypeOf((*MockSCIMService)(nil).GetGroupsMembersBruteForce), ctx, gr, ur)
 }
 
+// GetUser mocks base method.
+func (m *MockSCIMService) GetUser(ctx context.Context, scimID string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, scimID)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockSCIMServiceMockRecorder) GetUser(ctx, scimID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockSCIMService)(nil).GetUser), ctx, scimID)
+}
+
 // GetUsers mocks base method.
 func (m *MockSCIMService) GetUsers(ctx context.Context) (*model.UsersResult, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: changes.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	admin "google.golang.org/api/admin/reports/v1"
+)
+
+// MockGoogleReportsService is a mock of GoogleReportsService interface.
+type MockGoogleReportsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGoogleReportsServiceMockRecorder
+}
+
+// MockGoogleReportsServiceMockRecorder is the mock recorder for MockGoogleReportsService.
+type MockGoogleReportsServiceMockRecorder struct {
+	mock *MockGoogleReportsService
+}
+
+// NewMockGoogleReportsService creates a new mock instance.
+func NewMockGoogleReportsService(ctrl *gomock.Controller) *MockGoogleReportsService {
+	mock := &MockGoogleReportsService{ctrl: ctrl}
+	mock.recorder = &MockGoogleReportsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGoogleReportsService) EXPECT() *MockGoogleReportsServiceMockRecorder {
+	return m.recorder
+}
+
+// ListActivities mocks base method.
+func (m *MockGoogleReportsService) ListActivities(ctx context.Context, application string, startTime time.Time) ([]*admin.Activity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActivities", ctx, application, startTime)
+	ret0, _ := ret[0].([]*admin.Activity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActivities indicates an expected call of ListActivities.
+func (mr *MockGoogleReportsServiceMockRecorder) ListActivities(ctx, application, startTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActivities", reflect.TypeOf((*MockGoogleReportsService)(nil).ListActivities), ctx, application, startTime)
+}
@@ -36,6 +36,35 @@ func (m *MockGoogleProviderService) EXPECT() *MockGoogleProviderServiceMockRecor
 	return m.recorder
 }
 
+// CheckAuth mocks base method.
+func (m *MockGoogleProviderService) CheckAuth(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAuth", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckAuth indicates an expected call of CheckAuth.
+func (mr *MockGoogleProviderServiceMockRecorder) CheckAuth(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAuth", reflect.TypeOf((*MockGoogleProviderService)(nil).CheckAuth), ctx)
+}
+
+// GetGroup mocks base method.
+func (m *MockGoogleProviderService) GetGroup(ctx context.Context, groupID string) (*admin.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, groupID)
+	ret0, _ := ret[0].(*admin.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGoogleProviderServiceMockRecorder) GetGroup(ctx, groupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGoogleProviderService)(nil).GetGroup), ctx, groupID)
+}
+
 // GetUser mocks base method.
 func (m *MockGoogleProviderService) GetUser(ctx context.Context, userID string) (*admin.User, error) {
 	m.ctrl.T.Helper()
@@ -87,16 +116,21 @@ func (mr *MockGoogleProviderServiceMockRecorder) ListGroups(ctx, query interface
 }
 
 // ListUsers mocks base method.
-func (m *MockGoogleProviderService) ListUsers(ctx context.Context, query []string) ([]*admin.User, error) {
+func (m *MockGoogleProviderService) ListUsers(ctx context.Context, query []string, opts ...google.ListUsersOption) ([]*admin.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListUsers", ctx, query)
+	varargs := []interface{}{ctx, query}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListUsers", varargs...)
 	ret0, _ := ret[0].([]*admin.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListUsers indicates an expected call of ListUsers.
-func (mr *MockGoogleProviderServiceMockRecorder) ListUsers(ctx, query interface{}) *gomock.Call {
+func (mr *MockGoogleProviderServiceMockRecorder) ListUsers(ctx, query interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockGoogleProviderService)(nil).ListUsers), ctx, query)
+	varargs := append([]interface{}{ctx, query}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockGoogleProviderService)(nil).ListUsers), varargs...)
 }
@@ -197,6 +197,20 @@ func (mr *MockAWSSCIMProviderMockRecorder) PatchGroup(ctx, pgr interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchGroup", reflect.TypeOf((*MockAWSSCIMProvider)(nil).PatchGroup), ctx, pgr)
 }
 
+// PatchUser mocks base method.
+func (m *MockAWSSCIMProvider) PatchUser(ctx context.Context, pur *aws.PatchUserRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUser", ctx, pur)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchUser indicates an expected call of PatchUser.
+func (mr *MockAWSSCIMProviderMockRecorder) PatchUser(ctx, pur interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockAWSSCIMProvider)(nil).PatchUser), ctx, pur)
+}
+
 // PutUser mocks base method.
 func (m *MockAWSSCIMProvider) PutUser(ctx context.Context, usr *aws.PutUserRequest) (*aws.PutUserResponse, error) {
 	m.ctrl.T.Helper()
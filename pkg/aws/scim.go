@@ -10,6 +10,8 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -81,16 +83,45 @@ type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// TokenReloader returns the current AWS SSO SCIM access token, it is used to reload a rotated token.
+type TokenReloader func(ctx context.Context) (string, error)
+
+// RequestObserver is called after each request sent to the AWS SSO SCIM API with its method,
+// the resource (e.g. Users, Groups) and the status code of the response, 0 when the request failed.
+type RequestObserver func(method, resource string, statusCode int, duration time.Duration)
+
 // SCIMService is an AWS SCIM Service.
 type SCIMService struct {
-	httpClient  HTTPClient
-	url         *url.URL
-	UserAgent   string
+	httpClient    HTTPClient
+	url           *url.URL
+	UserAgent     string
+	tokenReloader TokenReloader
+	observer      RequestObserver
+
+	mu          sync.RWMutex
 	bearerToken string
 }
 
+// SCIMServiceOption is a function that configures the SCIMService.
+type SCIMServiceOption func(*SCIMService)
+
+// WithTokenReloader sets the function used to reload the access token when the AWS SSO SCIM API rejects it,
+// the rejected request is sent again when the reloaded token is a new one.
+func WithTokenReloader(reloader TokenReloader) SCIMServiceOption {
+	return func(s *SCIMService) {
+		s.tokenReloader = reloader
+	}
+}
+
+// WithRequestObserver sets the function called after each request sent to the AWS SSO SCIM API, e.g. to record metrics.
+func WithRequestObserver(observer RequestObserver) SCIMServiceOption {
+	return func(s *SCIMService) {
+		s.observer = observer
+	}
+}
+
 // NewSCIMService creates a new AWS SCIM Service.
-func NewSCIMService(httpClient HTTPClient, urlStr, token string) (*SCIMService, error) {
+func NewSCIMService(httpClient HTTPClient, urlStr, token string, opts ...SCIMServiceOption) (*SCIMService, error) {
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
@@ -104,11 +135,17 @@ func NewSCIMService(httpClient HTTPClient, urlStr, token string) (*SCIMService,
 		return nil, fmt.Errorf("aws: error parsing url: %w", err)
 	}
 
-	return &SCIMService{
+	s := &SCIMService{
 		httpClient:  httpClient,
 		url:         u,
 		bearerToken: token,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
 }
 
 // newRequest creates an http.Request with the given method, URL, and (optionally) body.
@@ -152,13 +189,53 @@ func (s *SCIMService) newRequest(ctx context.Context, method string, u *url.URL,
 }
 
 // do sends an HTTP request and returns an HTTP response, following policy (e.g. redirects, cookies, auth) as configured on the client.
-func (s *SCIMService) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+func (s *SCIMService) do(ctx context.Context, req *http.Request) (resp *http.Response, err error) {
+	ctx, span := s.startRequestSpan(ctx, req)
+	defer func() { endRequestSpan(span, resp, err) }()
+
 	req = req.WithContext(ctx)
 
+	token := s.token()
+
 	// Set bearer token
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.bearerToken))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-	resp, err := s.httpClient.Do(req)
+	resp, err = s.send(req)
+	if err != nil {
+		return nil, fmt.Errorf("aws do: error sending request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized || s.tokenReloader == nil {
+		return resp, nil
+	}
+
+	// the token could be rotated since it was read, so reload it and send the request again when it changed
+	reloaded, err := s.reloadToken(ctx, token)
+	if err != nil {
+		log.WithError(err).Warn("aws do: error reloading the access token")
+		return resp, nil
+	}
+	if !reloaded {
+		return resp, nil
+	}
+
+	if req.Body != nil {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("aws do: error getting request body: %w", err)
+		}
+		req.Body = body
+	}
+	resp.Body.Close()
+
+	span.AddEvent("access token reloaded")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.token()))
+
+	resp, err = s.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("aws do: error sending request: %w", err)
 	}
@@ -166,6 +243,64 @@ func (s *SCIMService) do(ctx context.Context, req *http.Request) (*http.Response
 	return resp, nil
 }
 
+// send sends the request and reports it to the observer, if any.
+func (s *SCIMService) send(req *http.Request) (*http.Response, error) {
+	if s.observer == nil {
+		return s.httpClient.Do(req)
+	}
+
+	start := time.Now()
+	resp, err := s.httpClient.Do(req)
+
+	statusCode := 0
+	if err == nil {
+		statusCode = resp.StatusCode
+	}
+	s.observer(req.Method, s.resource(req.URL), statusCode, time.Since(start))
+
+	return resp, err
+}
+
+// resource returns the SCIM resource of the request url, the first element of its path after the endpoint path.
+func (s *SCIMService) resource(u *url.URL) string {
+	p := strings.TrimPrefix(u.Path, s.url.Path)
+	p = strings.TrimPrefix(p, "/")
+
+	if i := strings.Index(p, "/"); i >= 0 {
+		p = p[:i]
+	}
+
+	return p
+}
+
+// token returns the current access token.
+func (s *SCIMService) token() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bearerToken
+}
+
+// reloadToken reloads the access token, it returns true when the reloaded token is different of the rejected one.
+func (s *SCIMService) reloadToken(ctx context.Context, rejected string) (bool, error) {
+	token, err := s.tokenReloader(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if token == "" || token == rejected {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	s.bearerToken = token
+	s.mu.Unlock()
+
+	log.Info("aws: scim access token reloaded, it was rotated")
+
+	return true, nil
+}
+
 // checkHTTPResponse checks the status code of the HTTP response.
 func (s *SCIMService) checkHTTPResponse(resp *http.Response) error {
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
@@ -179,6 +314,10 @@ func (s *SCIMService) checkHTTPResponse(resp *http.Response) error {
 			"status":     resp.Status,
 		}).Tracef("aws checkHTTPResponse: body: %s\n", string(body))
 
+		if resp.StatusCode == http.StatusUnauthorized {
+			return &AuthError{&HTTPResponseError{resp.StatusCode, resp.Status, string(body)}}
+		}
+
 		return &HTTPResponseError{resp.StatusCode, resp.Status, string(body)}
 	}
 
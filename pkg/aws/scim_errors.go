@@ -1,6 +1,9 @@
 package aws
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type HTTPResponseError struct {
 	StatusCode int    `json:"StatusCode"`   // Http status code
@@ -11,3 +14,24 @@ type HTTPResponseError struct {
 func (e *HTTPResponseError) Error() string {
 	return fmt.Sprintf("statusCode: %d,  errCode: %s, errMsg: %s", e.StatusCode, e.Code, e.Message)
 }
+
+// AuthError is returned when the AWS SSO SCIM API rejects the access token (http 401 Unauthorized),
+// usually because the token is expired or it was revoked.
+type AuthError struct {
+	*HTTPResponseError
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("aws: scim access token rejected, it could be expired or revoked, %s", e.HTTPResponseError.Error())
+}
+
+// Unwrap returns the HTTPResponseError of the rejected request.
+func (e *AuthError) Unwrap() error {
+	return e.HTTPResponseError
+}
+
+// IsAuthError returns true when the error is or wraps an AuthError.
+func IsAuthError(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr)
+}
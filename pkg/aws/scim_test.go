@@ -12,10 +12,16 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	mocks "github.com/slashdevops/idp-scim-sync/mocks/aws"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type mockErrReader int
@@ -237,6 +243,212 @@ func TestDo(t *testing.T) {
 	})
 }
 
+func TestDoRequestObserver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	endpoint := "https://testing.com/tenant/scim/v2/"
+
+	t.Run("should observe the request with its resource and status code", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		mockResp := &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+		mockHTTPClient.EXPECT().Do(gomock.Any()).Return(mockResp, nil).Times(1)
+
+		var gotMethod, gotResource string
+		var gotStatusCode int
+		observer := func(method, resource string, statusCode int, duration time.Duration) {
+			gotMethod, gotResource, gotStatusCode = method, resource, statusCode
+		}
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "MyToken", WithRequestObserver(observer))
+		assert.NoError(t, err)
+
+		reqURL, err := url.Parse(endpoint + "Users/123456789")
+		assert.NoError(t, err)
+
+		req, err := service.newRequest(context.Background(), http.MethodGet, reqURL, nil)
+		assert.NoError(t, err)
+
+		got, err := service.do(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, mockResp, got)
+
+		assert.Equal(t, http.MethodGet, gotMethod)
+		assert.Equal(t, "Users", gotResource)
+		assert.Equal(t, http.StatusOK, gotStatusCode)
+	})
+
+	t.Run("should observe the failed request without status code", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+		mockHTTPClient.EXPECT().Do(gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		gotStatusCode := -1
+		observer := func(method, resource string, statusCode int, duration time.Duration) {
+			gotStatusCode = statusCode
+		}
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "MyToken", WithRequestObserver(observer))
+		assert.NoError(t, err)
+
+		reqURL, err := url.Parse(endpoint + "Groups")
+		assert.NoError(t, err)
+
+		req, err := service.newRequest(context.Background(), http.MethodGet, reqURL, nil)
+		assert.NoError(t, err)
+
+		_, err = service.do(context.Background(), req)
+		assert.Error(t, err)
+		assert.Equal(t, 0, gotStatusCode)
+	})
+}
+
+func TestDoTracing(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	endpoint := "https://testing.com/tenant/scim/v2/"
+
+	sr := tracetest.NewSpanRecorder()
+	defaultTracer := tracer
+	tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	defer func() { tracer = defaultTracer }()
+
+	t.Run("should trace the request with its status code and retries", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		mockResp := &http.Response{
+			Status:     "429 Too Many Requests",
+			StatusCode: http.StatusTooManyRequests,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+		mockHTTPClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+			// the retrying client records the retries with the context of the request
+			RecordRetry(req.Context(), 1)
+			RecordRetry(req.Context(), 2)
+			return mockResp, nil
+		}).Times(1)
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "MyToken")
+		assert.NoError(t, err)
+
+		reqURL, err := url.Parse(endpoint + "Groups/123456789")
+		assert.NoError(t, err)
+
+		req, err := service.newRequest(context.Background(), http.MethodPatch, reqURL, nil)
+		assert.NoError(t, err)
+
+		_, err = service.do(context.Background(), req)
+		assert.NoError(t, err)
+
+		spans := sr.Ended()
+		assert.Equal(t, 1, len(spans))
+
+		span := spans[0]
+		assert.Equal(t, "SCIM PATCH Groups", span.Name())
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Contains(t, span.Attributes(), attribute.String("scim.resource", "Groups"))
+		assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", http.StatusTooManyRequests))
+		assert.Contains(t, span.Attributes(), attribute.Int("http.retry_count", 2))
+		assert.Equal(t, 2, len(span.Events()))
+	})
+}
+
+func TestDoTokenReloader(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	endpoint := "https://testing.com"
+
+	unauthorized := func() *http.Response {
+		return &http.Response{
+			Status:     "401 Unauthorized",
+			StatusCode: http.StatusUnauthorized,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+	}
+
+	t.Run("should send the request again with the reloaded token", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		mockResp := &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		gomock.InOrder(
+			mockHTTPClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "Bearer OldToken", req.Header.Get("Authorization"))
+				return unauthorized(), nil
+			}),
+			mockHTTPClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "Bearer NewToken", req.Header.Get("Authorization"))
+				body, err := io.ReadAll(req.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "{\"userName\":\"user.1@mydomain.com\"}\n", string(body))
+				return mockResp, nil
+			}),
+		)
+
+		reloader := func(ctx context.Context) (string, error) { return "NewToken", nil }
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "OldToken", WithTokenReloader(reloader))
+		assert.NoError(t, err)
+
+		reqURL, err := url.Parse(endpoint)
+		assert.NoError(t, err)
+
+		req, err := service.newRequest(context.Background(), http.MethodPost, reqURL, map[string]string{"userName": "user.1@mydomain.com"})
+		assert.NoError(t, err)
+
+		got, err := service.do(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, mockResp, got)
+		assert.Equal(t, "NewToken", service.token())
+	})
+
+	t.Run("should return the unauthorized response when the token didn't change", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		mockHTTPClient.EXPECT().Do(gomock.Any()).Times(1).Return(unauthorized(), nil)
+
+		reloader := func(ctx context.Context) (string, error) { return "OldToken", nil }
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "OldToken", WithTokenReloader(reloader))
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, endpoint, nil)
+
+		got, err := service.do(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, got.StatusCode)
+
+		err = service.checkHTTPResponse(got)
+		assert.True(t, IsAuthError(err))
+	})
+
+	t.Run("should return the unauthorized response when the reload fails", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		mockHTTPClient.EXPECT().Do(gomock.Any()).Times(1).Return(unauthorized(), nil)
+
+		reloader := func(ctx context.Context) (string, error) { return "", errors.New("test error") }
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "OldToken", WithTokenReloader(reloader))
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, endpoint, nil)
+
+		got, err := service.do(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, got.StatusCode)
+		assert.Equal(t, "OldToken", service.token())
+	})
+}
+
 func TestCheckHTTPResponse(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -372,6 +584,28 @@ func TestCheckHTTPResponse(t *testing.T) {
 		}
 	})
 
+	t.Run("should return AuthError when respond code is 401", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		got, err := NewSCIMService(mockHTTPClient, endpoint, "MyToken")
+		assert.NoError(t, err)
+
+		mockResp := &http.Response{
+			Status:     "401 Unauthorized",
+			StatusCode: http.StatusUnauthorized,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+
+		gotErr := got.checkHTTPResponse(mockResp)
+		assert.True(t, IsAuthError(gotErr))
+
+		httpErr := new(HTTPResponseError)
+		assert.True(t, errors.As(gotErr, &httpErr))
+		assert.Equal(t, http.StatusUnauthorized, httpErr.StatusCode)
+
+		assert.False(t, IsAuthError(&HTTPResponseError{StatusCode: http.StatusForbidden}))
+	})
+
 	t.Run("should return error when response and body has error", func(t *testing.T) {
 		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
 
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SCIMAccessTokenLifetime is the lifetime of the AWS SSO SCIM access tokens.
+// reference: https://docs.aws.amazon.com/singlesignon/latest/userguide/provision-automatically.html
+const SCIMAccessTokenLifetime = 365 * 24 * time.Hour
+
+// ErrSCIMAccessTokenDateInvalid is returned when a date of the access token is not in the RFC3339 or YYYY-MM-DD format.
+var ErrSCIMAccessTokenDateInvalid = errors.New("aws: scim access token date is not valid, expected RFC3339 or YYYY-MM-DD")
+
+// SCIMAccessTokenExpiry represents the creation and expiry dates of an AWS SSO SCIM access token.
+type SCIMAccessTokenExpiry struct {
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// NewSCIMAccessTokenExpiry returns the SCIMAccessTokenExpiry of the given dates, both are optional,
+// when the expiry date is empty it is computed from the creation date.
+func NewSCIMAccessTokenExpiry(createdAt, expiresAt string) (SCIMAccessTokenExpiry, error) {
+	var e SCIMAccessTokenExpiry
+	var err error
+
+	if createdAt != "" {
+		if e.CreatedAt, err = parseTokenDate(createdAt); err != nil {
+			return SCIMAccessTokenExpiry{}, err
+		}
+	}
+
+	if expiresAt != "" {
+		if e.ExpiresAt, err = parseTokenDate(expiresAt); err != nil {
+			return SCIMAccessTokenExpiry{}, err
+		}
+	}
+
+	return e, nil
+}
+
+// Expiry returns the expiry date of the token, false when it is unknown.
+func (e SCIMAccessTokenExpiry) Expiry() (time.Time, bool) {
+	if !e.ExpiresAt.IsZero() {
+		return e.ExpiresAt, true
+	}
+
+	if !e.CreatedAt.IsZero() {
+		return e.CreatedAt.Add(SCIMAccessTokenLifetime), true
+	}
+
+	return time.Time{}, false
+}
+
+// Remaining returns the time until the token expires, negative when it is already expired.
+func (e SCIMAccessTokenExpiry) Remaining(now time.Time) (time.Duration, bool) {
+	expiry, ok := e.Expiry()
+	if !ok {
+		return 0, false
+	}
+
+	return expiry.Sub(now), true
+}
+
+func parseTokenDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrSCIMAccessTokenDateInvalid, value)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSCIMAccessTokenExpiry(t *testing.T) {
+	t.Run("Should return the expiry date when it is given", func(t *testing.T) {
+		e, err := NewSCIMAccessTokenExpiry("2022-01-10", "2022-06-01T10:00:00Z")
+		assert.NoError(t, err)
+
+		expiry, ok := e.Expiry()
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC), expiry)
+	})
+
+	t.Run("Should compute the expiry date from the creation date", func(t *testing.T) {
+		e, err := NewSCIMAccessTokenExpiry("2022-01-10", "")
+		assert.NoError(t, err)
+
+		expiry, ok := e.Expiry()
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), expiry)
+
+		remaining, ok := e.Remaining(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.True(t, ok)
+		assert.Equal(t, 9*24*time.Hour, remaining)
+	})
+
+	t.Run("Should return unknown expiry without dates", func(t *testing.T) {
+		e, err := NewSCIMAccessTokenExpiry("", "")
+		assert.NoError(t, err)
+
+		_, ok := e.Expiry()
+		assert.False(t, ok)
+
+		_, ok = e.Remaining(time.Now())
+		assert.False(t, ok)
+	})
+
+	t.Run("Should return an error when a date is not valid", func(t *testing.T) {
+		_, err := NewSCIMAccessTokenExpiry("10/01/2022", "")
+		assert.ErrorIs(t, err, ErrSCIMAccessTokenDateInvalid)
+
+		_, err = NewSCIMAccessTokenExpiry("", "tomorrow")
+		assert.ErrorIs(t, err, ErrSCIMAccessTokenDateInvalid)
+	})
+}
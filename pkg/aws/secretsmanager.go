@@ -22,6 +22,7 @@ var ErrSecretManagerClientNil = errors.New("aws: AWS SecretsManager Client canno
 // SecretsManagerClientAPI is the interface to consume the secretsmanager client methods.
 type SecretsManagerClientAPI interface {
 	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error)
 }
 
 // SecretsManagerService is the wrapper for the AWS SecretsManager client.
@@ -67,3 +68,20 @@ func (s *SecretsManagerService) GetSecretValue(ctx context.Context, secretKey st
 
 	return secretString, nil
 }
+
+// GetSecretTags returns the tags of the given secret name or arn.
+func (s *SecretsManagerService) GetSecretTags(ctx context.Context, secretKey string) (map[string]string, error) {
+	r, err := s.svc.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(secretKey),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aws: error describing secret: %v", err)
+	}
+
+	tags := make(map[string]string, len(r.Tags))
+	for _, tag := range r.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return tags, nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/golang/mock/gomock"
 	mocks "github.com/slashdevops/idp-scim-sync/mocks/aws"
 	"github.com/stretchr/testify/assert"
@@ -124,3 +125,46 @@ func TestSecretsManager_GetSecretValue(t *testing.T) {
 		assert.Equal(t, key, value)
 	})
 }
+
+func TestSecretsManager_GetSecretTags(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return the tags of the secret", func(t *testing.T) {
+		mockSMClientAPI := mocks.NewMockSecretsManagerClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		SMIn := &secretsmanager.DescribeSecretInput{
+			SecretId: aws.String("testKey"),
+		}
+
+		SMOut := &secretsmanager.DescribeSecretOutput{
+			Tags: []types.Tag{
+				{Key: aws.String("idpscim:token-expires-at"), Value: aws.String("2023-01-10")},
+			},
+		}
+
+		mockSMClientAPI.EXPECT().DescribeSecret(ctx, SMIn).Times(1).Return(SMOut, nil)
+
+		svc, err := NewSecretsManagerService(mockSMClientAPI)
+		assert.NoError(t, err)
+
+		tags, err := svc.GetSecretTags(ctx, "testKey")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"idpscim:token-expires-at": "2023-01-10"}, tags)
+	})
+
+	t.Run("Should return an error when the secret doesn't exist", func(t *testing.T) {
+		mockSMClientAPI := mocks.NewMockSecretsManagerClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		mockSMClientAPI.EXPECT().DescribeSecret(ctx, gomock.Any()).Times(1).Return(nil, errors.New("test error"))
+
+		svc, err := NewSecretsManagerService(mockSMClientAPI)
+		assert.NoError(t, err)
+
+		tags, err := svc.GetSecretTags(ctx, "testKey")
+		assert.Error(t, err)
+		assert.Nil(t, tags)
+	})
+}
@@ -0,0 +1,71 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/pkg/errors"
+)
+
+// consume sns.Client
+
+var (
+	// ErrSNSClientNil is returned when the SNSClientAPI is nil.
+	ErrSNSClientNil = errors.New("aws: AWS SNS Client cannot be nil")
+
+	// ErrTopicARNEmpty is returned when the SNS topic ARN is empty.
+	ErrTopicARNEmpty = errors.New("aws: SNS topic ARN cannot be empty")
+)
+
+// snsSubjectMaxLength is the maximum length of the subject of the SNS messages
+const snsSubjectMaxLength = 100
+
+//go:generate go run github.com/golang/mock/mockgen@v1.6.0 -package=mocks -destination=../../mocks/aws/sns_mocks.go -source=sns.go SNSClientAPI
+
+// SNSClientAPI is the interface to consume the sns client methods.
+type SNSClientAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSService is the wrapper for the AWS SNS client.
+type SNSService struct {
+	svc SNSClientAPI
+}
+
+// NewSNSService returns a new SNSService.
+func NewSNSService(svc SNSClientAPI) (*SNSService, error) {
+	if svc == nil {
+		return nil, ErrSNSClientNil
+	}
+
+	return &SNSService{
+		svc: svc,
+	}, nil
+}
+
+// Publish publishes the message to the given topic arn, the subject is truncated to the SNS maximum length.
+func (s *SNSService) Publish(ctx context.Context, topicARN, subject, message string) error {
+	if topicARN == "" {
+		return ErrTopicARNEmpty
+	}
+
+	in := &sns.PublishInput{
+		TopicArn: aws.String(topicARN),
+		Message:  aws.String(message),
+	}
+
+	if subject != "" {
+		if len(subject) > snsSubjectMaxLength {
+			subject = subject[:snsSubjectMaxLength]
+		}
+		in.Subject = aws.String(subject)
+	}
+
+	if _, err := s.svc.Publish(ctx, in); err != nil {
+		return fmt.Errorf("aws: error publishing message: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/golang/mock/gomock"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSNSService(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return SNSService", func(t *testing.T) {
+		svc, err := NewSNSService(mocks.NewMockSNSClientAPI(mockCtrl))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("Should return an error if no client is provided", func(t *testing.T) {
+		svc, err := NewSNSService(nil)
+		assert.ErrorIs(t, err, ErrSNSClientNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestSNSService_Publish(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	topicARN := "arn:aws:sns:us-east-1:123456789012:idpscim"
+
+	t.Run("Should publish the message", func(t *testing.T) {
+		mockSNSClientAPI := mocks.NewMockSNSClientAPI(mockCtrl)
+		mockSNSClientAPI.EXPECT().Publish(context.Background(), &sns.PublishInput{
+			TopicArn: aws.String(topicARN),
+			Subject:  aws.String("sync finished"),
+			Message:  aws.String("1 group created"),
+		}).Return(&sns.PublishOutput{MessageId: aws.String("1")}, nil).Times(1)
+
+		svc, err := NewSNSService(mockSNSClientAPI)
+		assert.NoError(t, err)
+
+		assert.NoError(t, svc.Publish(context.Background(), topicARN, "sync finished", "1 group created"))
+	})
+
+	t.Run("Should truncate the subject", func(t *testing.T) {
+		mockSNSClientAPI := mocks.NewMockSNSClientAPI(mockCtrl)
+		mockSNSClientAPI.EXPECT().Publish(context.Background(), &sns.PublishInput{
+			TopicArn: aws.String(topicARN),
+			Subject:  aws.String(strings.Repeat("a", 100)),
+			Message:  aws.String("message"),
+		}).Return(&sns.PublishOutput{}, nil).Times(1)
+
+		svc, err := NewSNSService(mockSNSClientAPI)
+		assert.NoError(t, err)
+
+		assert.NoError(t, svc.Publish(context.Background(), topicARN, strings.Repeat("a", 150), "message"))
+	})
+
+	t.Run("Should return an error when the topic is empty", func(t *testing.T) {
+		svc, err := NewSNSService(mocks.NewMockSNSClientAPI(mockCtrl))
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, svc.Publish(context.Background(), "", "subject", "message"), ErrTopicARNEmpty)
+	})
+
+	t.Run("Should return an error when the publish fails", func(t *testing.T) {
+		mockSNSClientAPI := mocks.NewMockSNSClientAPI(mockCtrl)
+		mockSNSClientAPI.EXPECT().Publish(context.Background(), gomock.Any()).Return(nil, errors.New("test error")).Times(1)
+
+		svc, err := NewSNSService(mockSNSClientAPI)
+		assert.NoError(t, err)
+
+		err = svc.Publish(context.Background(), topicARN, "", "message")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "test error")
+	})
+}
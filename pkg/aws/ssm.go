@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// consume ssm.Client
+
+// ErrSSMClientNil is returned when the SSMClientAPI is nil.
+var ErrSSMClientNil = errors.New("aws: AWS SSM Client cannot be nil")
+
+//go:generate go run github.com/golang/mock/mockgen@v1.6.0 -package=mocks -destination=../../mocks/aws/ssm_mocks.go -source=ssm.go SSMClientAPI
+
+// SSMClientAPI is the interface to consume the ssm client methods.
+type SSMClientAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// SSMService is the wrapper for the AWS Systems Manager Parameter Store client.
+type SSMService struct {
+	svc SSMClientAPI
+}
+
+// NewSSMService returns a new SSMService.
+func NewSSMService(svc SSMClientAPI) (*SSMService, error) {
+	if svc == nil {
+		return nil, ErrSSMClientNil
+	}
+
+	return &SSMService{
+		svc: svc,
+	}, nil
+}
+
+// GetParameterValue returns the value for the given parameter name or arn.
+// SecureString parameters are returned decrypted.
+func (s *SSMService) GetParameterValue(ctx context.Context, name string) (string, error) {
+	pIn := &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	}
+
+	r, err := s.svc.GetParameter(ctx, pIn)
+	if err != nil {
+		return "", fmt.Errorf("aws: error getting parameter value: %v", err)
+	}
+
+	if r.Parameter == nil || r.Parameter.Value == nil {
+		return "", fmt.Errorf("aws: parameter %s has no value", name)
+	}
+
+	return *r.Parameter.Value, nil
+}
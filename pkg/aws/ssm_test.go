@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/golang/mock/gomock"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSSMService(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return SSMService", func(t *testing.T) {
+		mockSSMClientAPI := mocks.NewMockSSMClientAPI(mockCtrl)
+
+		svc, err := NewSSMService(mockSSMClientAPI)
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("Should return an error if no client is provided", func(t *testing.T) {
+		svc, err := NewSSMService(nil)
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrSSMClientNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestSSMService_GetParameterValue(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should return the decrypted value when parameter exist", func(t *testing.T) {
+		mockSSMClientAPI := mocks.NewMockSSMClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		pIn := &ssm.GetParameterInput{
+			Name:           aws.String("/idpscim/token"),
+			WithDecryption: aws.Bool(true),
+		}
+
+		pOut := &ssm.GetParameterOutput{
+			Parameter: &types.Parameter{Value: aws.String("testValue")},
+		}
+
+		mockSSMClientAPI.EXPECT().GetParameter(ctx, pIn).Times(1).Return(pOut, nil)
+
+		svc, err := NewSSMService(mockSSMClientAPI)
+		assert.NoError(t, err)
+
+		value, err := svc.GetParameterValue(ctx, "/idpscim/token")
+		assert.NoError(t, err)
+		assert.Equal(t, "testValue", value)
+	})
+
+	t.Run("Should return an error when parameter doesn't exist", func(t *testing.T) {
+		mockSSMClientAPI := mocks.NewMockSSMClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		mockSSMClientAPI.EXPECT().GetParameter(ctx, gomock.Any()).Times(1).Return(nil, errors.New("test error"))
+
+		svc, err := NewSSMService(mockSSMClientAPI)
+		assert.NoError(t, err)
+
+		value, err := svc.GetParameterValue(ctx, "/idpscim/missing")
+		assert.Error(t, err)
+		assert.Empty(t, value)
+	})
+
+	t.Run("Should return an error when parameter has no value", func(t *testing.T) {
+		mockSSMClientAPI := mocks.NewMockSSMClientAPI(mockCtrl)
+		ctx := context.TODO()
+
+		mockSSMClientAPI.EXPECT().GetParameter(ctx, gomock.Any()).Times(1).Return(&ssm.GetParameterOutput{}, nil)
+
+		svc, err := NewSSMService(mockSSMClientAPI)
+		assert.NoError(t, err)
+
+		value, err := svc.GetParameterValue(ctx, "/idpscim/empty")
+		assert.Error(t, err)
+		assert.Empty(t, value)
+	})
+}
@@ -0,0 +1,55 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// retryCountKey is the span attribute with the number of retries of a SCIM request
+const retryCountKey = attribute.Key("http.retry_count")
+
+// tracer creates the spans of the SCIM requests, they are no-op until a global tracer provider is set
+var tracer = otel.Tracer("github.com/slashdevops/idp-scim-sync/pkg/aws")
+
+// RecordRetry records the retry attempt in the span of the SCIM request of the context.
+// It is meant to be called by the retrying HTTP clients, e.g. from the retryablehttp.Client RequestLogHook
+// with the context of the retried request.
+func RecordRetry(ctx context.Context, attempt int) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(retryCountKey.Int(attempt))
+	span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt)))
+}
+
+// startRequestSpan starts the client span of a SCIM request, e.g. "SCIM PATCH Groups".
+func (s *SCIMService) startRequestSpan(ctx context.Context, req *http.Request) (context.Context, trace.Span) {
+	resource := s.resource(req.URL)
+
+	return tracing.Start(ctx, tracer, "SCIM "+req.Method+" "+resource,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPTargetKey.String(req.URL.Path),
+			attribute.String("scim.resource", resource),
+			retryCountKey.Int(0),
+		),
+	)
+}
+
+// endRequestSpan records the status code of the response or the error in the span and ends it.
+func endRequestSpan(span trace.Span, resp *http.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if resp != nil {
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(resp.StatusCode, trace.SpanKindClient))
+	}
+	span.End()
+}
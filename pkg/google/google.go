@@ -3,21 +3,31 @@ package google
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	admin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/googleapi"
-	"google.golang.org/api/option"
-
-	log "github.com/sirupsen/logrus"
 )
 
 const (
 	// https://cloud.google.com/storage/docs/json_api
 	groupsRequiredFields    googleapi.Field = "nextPageToken, groups(id,name,email,etag)"
+	getGroupRequiredFields  googleapi.Field = "id,name,email,etag"
 	membersRequiredFields   googleapi.Field = "nextPageToken, members(id,email,status,type,etag)"
-	listUsersRequiredFields googleapi.Field = "nextPageToken, users(id,name,primaryEmail,suspended,etag,emails)"
-	getUsersRequiredFields  googleapi.Field = "id,name,primaryEmail,suspended,etag"
+	listUsersRequiredFields googleapi.Field = "nextPageToken, users(id,name,primaryEmail,suspended,archived,orgUnitPath,customSchemas,etag,emails)"
+	getUsersRequiredFields  googleapi.Field = "id,name,primaryEmail,suspended,archived,orgUnitPath,customSchemas,etag"
+	checkAuthRequiredFields googleapi.Field = "users(id)"
+
+	// defaultCustomer is the alias of the customer of the impersonated user
+	// https://developers.google.com/admin-sdk/directory/reference/rest/v1/users/list#query-parameters
+	defaultCustomer = "my_customer"
+
+	// usersProjection is needed to get the users custom schemas
+	// https://developers.google.com/admin-sdk/directory/reference/rest/v1/users/get#projection
+	usersProjection = "full"
 )
 
 var (
@@ -33,10 +43,14 @@ var (
 
 // DirectoryService represent the  Google Directory API client.
 type DirectoryService struct {
-	svc *admin.Service
+	svc      *admin.Service
+	customer string
+	domains  []string
 }
 
 // NewService create a Google Directory Service.
+// The serviceAccount could be a service account key or an external account (workload identity federation) credential config,
+// the domain-wide delegation (userEmail) is only possible with a service account key, see NewServiceWithSignJWT for keyless delegation.
 // References:
 // - https://pkg.go.dev/google.golang.org/api/admin/directory/v1
 // Examples of scope:
@@ -44,6 +58,22 @@ type DirectoryService struct {
 // - "https://www.googleapis.com/auth/admin.directory.group.member.readonly"
 // - "https://www.googleapis.com/auth/admin.directory.user.readonly"
 func NewService(ctx context.Context, userEmail string, serviceAccount []byte, scope ...string) (*admin.Service, error) {
+	ts, err := credentialsTokenSource(ctx, userEmail, serviceAccount, scope...)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := newAdminService(ctx, ts)
+	if err != nil {
+		return nil, fmt.Errorf("google: error creating service: %v", err)
+	}
+
+	return svc, nil
+}
+
+// credentialsTokenSource returns the token source of the service account or external account credentials,
+// impersonating the userEmail when it is set.
+func credentialsTokenSource(ctx context.Context, userEmail string, serviceAccount []byte, scope ...string) (oauth2.TokenSource, error) {
 	if len(scope) == 0 {
 		return nil, ErrGoogleClientScopeNil
 	}
@@ -56,79 +86,128 @@ func NewService(ctx context.Context, userEmail string, serviceAccount []byte, sc
 		return nil, fmt.Errorf("google: error getting config for Service Account: %v", err)
 	}
 
-	svc, err := admin.NewService(ctx, option.WithTokenSource(creds.TokenSource))
-	if err != nil {
-		return nil, fmt.Errorf("google: error creating service: %v", err)
-	}
-
-	return svc, nil
+	return creds.TokenSource, nil
 }
 
 // NewDirectoryService create a Google Directory API client.
 // References:
 // - https://developers.google.com/admin-sdk/directory/v1/guides/delegation?utm_source=pocket_mylist#go
-func NewDirectoryService(svc *admin.Service) (*DirectoryService, error) {
-	return &DirectoryService{
-		svc: svc,
-	}, nil
+func NewDirectoryService(svc *admin.Service, opts ...DirectoryServiceOption) (*DirectoryService, error) {
+	ds := &DirectoryService{
+		svc:      svc,
+		customer: defaultCustomer,
+	}
+
+	for _, opt := range opts {
+		opt(ds)
+	}
+
+	return ds, nil
+}
+
+// usersListCalls returns a users list call per domain when domains are configured,
+// otherwise a single users list call for the customer.
+func (ds *DirectoryService) usersListCalls() []*admin.UsersListCall {
+	if len(ds.domains) == 0 {
+		return []*admin.UsersListCall{ds.svc.Users.List().Customer(ds.customer)}
+	}
+
+	calls := make([]*admin.UsersListCall, 0, len(ds.domains))
+	for _, d := range ds.domains {
+		calls = append(calls, ds.svc.Users.List().Domain(d))
+	}
+
+	return calls
+}
+
+// groupsListCalls returns a groups list call per domain when domains are configured,
+// otherwise a single groups list call for the customer.
+func (ds *DirectoryService) groupsListCalls() []*admin.GroupsListCall {
+	if len(ds.domains) == 0 {
+		return []*admin.GroupsListCall{ds.svc.Groups.List().Customer(ds.customer)}
+	}
+
+	calls := make([]*admin.GroupsListCall, 0, len(ds.domains))
+	for _, d := range ds.domains {
+		calls = append(calls, ds.svc.Groups.List().Domain(d))
+	}
+
+	return calls
 }
 
 // ListUsers list all users in a Google Directory filtered by query.
-func (ds *DirectoryService) ListUsers(ctx context.Context, query []string) ([]*admin.User, error) {
-	u := make([]*admin.User, 0)
-	var err error
+// references:
+// - https://developers.google.com/admin-sdk/directory/v1/guides/search-users
+func (ds *DirectoryService) ListUsers(ctx context.Context, query []string, opts ...ListUsersOption) (u []*admin.User, err error) {
+	ctx, span := startSpan(ctx, "ListUsers", attribute.StringSlice("google.query", query))
+	defer func() { endSpan(span, len(u), err) }()
+
+	u = make([]*admin.User, 0)
+
+	lo := listUsersOptions{}
+	for _, opt := range opts {
+		opt(&lo)
+	}
+
+	if len(query) == 0 {
+		query = []string{""}
+	}
+
+	for _, q := range query {
+		if lo.orgUnitPath != "" {
+			// this matches the organizational unit and all its sub organizational units
+			q = strings.TrimSpace(fmt.Sprintf("orgUnitPath='%s' %s", strings.ReplaceAll(lo.orgUnitPath, "'", "\\'"), q))
+		}
 
-	if len(query) > 0 {
-		for _, q := range query {
+		for _, ulc := range ds.usersListCalls() {
+			ulc = ulc.Projection(usersProjection)
 			if q != "" {
-				err = ds.svc.Users.List().Query(q).Customer("my_customer").Fields(listUsersRequiredFields).Pages(ctx, func(users *admin.Users) error {
-					u = append(u, users.Users...)
-					return nil
-				})
-			} else {
-				err = ds.svc.Users.List().Customer("my_customer").Fields(listUsersRequiredFields).Pages(ctx, func(users *admin.Users) error {
-					u = append(u, users.Users...)
-					return nil
-				})
+				ulc = ulc.Query(q)
+			}
+
+			err := ulc.Fields(listUsersRequiredFields).Pages(ctx, func(users *admin.Users) error {
+				u = append(u, users.Users...)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("google: error listing users: %w", err)
 			}
 		}
-	} else {
-		err = ds.svc.Users.List().Customer("my_customer").Fields(listUsersRequiredFields).Pages(ctx, func(users *admin.Users) error {
-			u = append(u, users.Users...)
-			return nil
-		})
 	}
-	return u, err
+
+	return u, nil
 }
 
 // ListGroups list all groups in a Google Directory filtered by query.
 // References:
 // - https://developers.google.com/admin-sdk/directory/reference/rest/v1/groups
-func (ds *DirectoryService) ListGroups(ctx context.Context, query []string) ([]*admin.Group, error) {
-	g := make([]*admin.Group, 0)
-	var err error
+func (ds *DirectoryService) ListGroups(ctx context.Context, query []string) (g []*admin.Group, err error) {
+	ctx, span := startSpan(ctx, "ListGroups", attribute.StringSlice("google.query", query))
+	defer func() { endSpan(span, len(g), err) }()
+
+	g = make([]*admin.Group, 0)
 
-	if len(query) > 0 {
-		for _, q := range query {
+	if len(query) == 0 {
+		query = []string{""}
+	}
+
+	for _, q := range query {
+		for _, glc := range ds.groupsListCalls() {
 			if q != "" {
-				err = ds.svc.Groups.List().Customer("my_customer").Query(q).Fields(groupsRequiredFields).Pages(ctx, func(groups *admin.Groups) error {
-					g = append(g, groups.Groups...)
-					return nil
-				})
-			} else {
-				err = ds.svc.Groups.List().Customer("my_customer").Fields(groupsRequiredFields).Pages(ctx, func(groups *admin.Groups) error {
-					g = append(g, groups.Groups...)
-					return nil
-				})
+				glc = glc.Query(q)
+			}
+
+			err := glc.Fields(groupsRequiredFields).Pages(ctx, func(groups *admin.Groups) error {
+				g = append(g, groups.Groups...)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("google: error listing groups: %w", err)
 			}
 		}
-	} else {
-		err = ds.svc.Groups.List().Customer("my_customer").Fields(groupsRequiredFields).Pages(ctx, func(groups *admin.Groups) error {
-			g = append(g, groups.Groups...)
-			return nil
-		})
 	}
-	return g, err
+
+	return g, nil
 }
 
 // ListGroupMembers return a list of all members given a group ID.
@@ -136,17 +215,20 @@ func (ds *DirectoryService) ListGroups(ctx context.Context, query []string) ([]*
 // - https://developers.google.com/admin-sdk/directory/reference/rest/v1/members/list
 // - https://developers.google.com/admin-sdk/directory/v1/guides/manage-group-members
 // - https://cloud.google.com/identity/docs/how-to/query-memberships
-func (ds *DirectoryService) ListGroupMembers(ctx context.Context, groupID string, queries ...GetGroupMembersOption) ([]*admin.Member, error) {
+func (ds *DirectoryService) ListGroupMembers(ctx context.Context, groupID string, queries ...GetGroupMembersOption) (m []*admin.Member, err error) {
 	if groupID == "" {
 		return nil, ErrGroupIDNil
 	}
 
+	ctx, span := startSpan(ctx, "ListGroupMembers", attribute.String("group.id", groupID))
+	defer func() { endSpan(span, len(m), err) }()
+
 	qs := getGroupMembersOptions{}
 	for _, q := range queries {
 		q(&qs)
 	}
 
-	m := make([]*admin.Member, 0)
+	m = make([]*admin.Member, 0)
 	mlc := ds.svc.Members.List(groupID)
 
 	if qs.includeDerivedMembership {
@@ -162,15 +244,9 @@ func (ds *DirectoryService) ListGroupMembers(ctx context.Context, groupID string
 		mlc = mlc.Roles(qs.roles)
 	}
 
-	err := mlc.Fields(membersRequiredFields).Pages(ctx, func(members *admin.Members) error {
-		for _, member := range members.Members {
-			// Add only active members to list
-			if member.Status == "ACTIVE" {
-				m = append(m, member)
-			} else {
-				log.Warnf("google: not including %s to group %s members due to incorrect status %s (not ACTIVE)", member.Email, groupID, member.Status)
-			}
-		}
+	// all the members are returned, whatever their status is, the caller decides what to do with the non ACTIVE ones
+	err = mlc.Fields(membersRequiredFields).Pages(ctx, func(members *admin.Members) error {
+		m = append(m, members.Members...)
 		return nil
 	})
 
@@ -184,7 +260,9 @@ func (ds *DirectoryService) GetUser(ctx context.Context, userID string) (*admin.
 		return nil, ErrUserIDNil
 	}
 
-	u, err := ds.svc.Users.Get(userID).Fields(getUsersRequiredFields).Context(ctx).Do()
+	ctx, span := startSpan(ctx, "GetUser")
+	u, err := ds.svc.Users.Get(userID).Projection(usersProjection).Fields(getUsersRequiredFields).Context(ctx).Do()
+	endSpan(span, 1, err)
 	if err != nil {
 		return nil, fmt.Errorf("google: error getting user %s: %v", userID, err)
 	}
@@ -193,15 +271,32 @@ func (ds *DirectoryService) GetUser(ctx context.Context, userID string) (*admin.
 }
 
 // GetGroup return a group given a group ID.
+// groupID: the group's email address, group alias, or unique group ID.
 func (ds *DirectoryService) GetGroup(ctx context.Context, groupID string) (*admin.Group, error) {
 	if groupID == "" {
 		return nil, ErrGroupIDNil
 	}
 
-	g, err := ds.svc.Groups.Get(groupID).Fields(groupsRequiredFields).Context(ctx).Do()
+	ctx, span := startSpan(ctx, "GetGroup", attribute.String("group.id", groupID))
+	g, err := ds.svc.Groups.Get(groupID).Fields(getGroupRequiredFields).Context(ctx).Do()
+	endSpan(span, 1, err)
 	if err != nil {
 		return nil, fmt.Errorf("google: error getting group %s: %v", groupID, err)
 	}
 
 	return g, nil
 }
+
+// CheckAuth checks the credentials and the delegation are valid, listing a single user of the customer or of each domain.
+func (ds *DirectoryService) CheckAuth(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "CheckAuth")
+	defer func() { endSpan(span, 0, err) }()
+
+	for _, call := range ds.usersListCalls() {
+		if _, err := call.MaxResults(1).Fields(checkAuthRequiredFields).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("google: error checking authentication: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -30,6 +30,21 @@ func TestNewService(t *testing.T) {
 		assert.NotNil(t, svc)
 	})
 
+	t.Run("Should return a new Service with an external account credential config", func(t *testing.T) {
+		ctx := context.TODO()
+		credentialsFile := "testdata/external_account.json"
+		scope := "admin.AdminDirectoryGroupReadonlyScope, admin.AdminDirectoryGroupMemberReadonlyScope, admin.AdminDirectoryUserReadonlyScope"
+
+		credentials, err := os.ReadFile(credentialsFile)
+		if err != nil {
+			t.Fatalf("Error loading golden file: %s", err)
+		}
+
+		svc, err := NewService(ctx, "", credentials, scope)
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
 	t.Run("Should return a new Service with empty service account parameter", func(t *testing.T) {
 		ctx := context.TODO()
 		userEmail := ""
@@ -301,6 +316,117 @@ func TestNewDirectoryService_ListUsers(t *testing.T) {
 		assert.Equal(t, "user", got[0].Name.GivenName)
 		assert.False(t, got[0].Suspended)
 	})
+
+	t.Run("should return the users of the organizational unit", func(t *testing.T) {
+		ctx := context.TODO()
+
+		filter := []string{"isSuspended=false"}
+		urlPath := "/admin/directory/v1/users"
+
+		userList := &admin.Users{
+			Etag: "etag-users",
+			Kind: "directory#users",
+			Users: []*admin.User{
+				{
+					Id:           "123456789",
+					Etag:         "etag-user-123456789",
+					PrimaryEmail: "user.1@mail.com",
+					OrgUnitPath:  "/Engineering/Backend",
+					Name: &admin.UserName{
+						FamilyName: "1",
+						GivenName:  "user",
+					},
+				},
+			},
+		}
+		jsonBytes, err := userList.MarshalJSON()
+		assert.NoError(t, err)
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, urlPath, r.URL.Path)
+			assert.Equal(t, "orgUnitPath='/Engineering' isSuspended=false", r.URL.Query().Get("query"))
+			assert.Equal(t, "full", r.URL.Query().Get("projection"))
+			w.Write(jsonBytes)
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc)
+		assert.NoError(t, err)
+		assert.NotNil(t, client)
+
+		got, err := client.ListUsers(ctx, filter, WithOrgUnitPath("/Engineering"))
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, len(got))
+		assert.Equal(t, "/Engineering/Backend", got[0].OrgUnitPath)
+	})
+
+	t.Run("should return the users of all the domains", func(t *testing.T) {
+		ctx := context.TODO()
+
+		urlPath := "/admin/directory/v1/users"
+		domains := make([]string, 0)
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, urlPath, r.URL.Path)
+			assert.Equal(t, "", r.URL.Query().Get("customer"))
+
+			domain := r.URL.Query().Get("domain")
+			domains = append(domains, domain)
+
+			userList := &admin.Users{
+				Users: []*admin.User{
+					{Id: domain, PrimaryEmail: "user.1@" + domain},
+				},
+			}
+			jsonBytes, err := userList.MarshalJSON()
+			assert.NoError(t, err)
+			w.Write(jsonBytes)
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc, WithDomains([]string{"mail.com", "other.com"}))
+		assert.NoError(t, err)
+
+		got, err := client.ListUsers(ctx, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"mail.com", "other.com"}, domains)
+		assert.Equal(t, 2, len(got))
+		assert.Equal(t, "user.1@mail.com", got[0].PrimaryEmail)
+		assert.Equal(t, "user.1@other.com", got[1].PrimaryEmail)
+	})
+
+	t.Run("should use the given customer", func(t *testing.T) {
+		ctx := context.TODO()
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "C01234567", r.URL.Query().Get("customer"))
+
+			jsonBytes, err := (&admin.Users{}).MarshalJSON()
+			assert.NoError(t, err)
+			w.Write(jsonBytes)
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc, WithCustomer("C01234567"))
+		assert.NoError(t, err)
+
+		got, err := client.ListUsers(ctx, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(got))
+	})
 }
 
 func TestNewDirectoryService_ListGroups(t *testing.T) {
@@ -565,7 +691,7 @@ func TestNewDirectoryService_ListGroupMembers(t *testing.T) {
 		assert.Equal(t, "member.2@mail.com", got[1].Email)
 	})
 
-	t.Run("should return a valid list of one members when a member has Status DEACTIVATE", func(t *testing.T) {
+	t.Run("should return all the members when a member has Status SUSPENDED", func(t *testing.T) {
 		ctx := context.TODO()
 
 		groupID := "123456789"
@@ -579,7 +705,7 @@ func TestNewDirectoryService_ListGroupMembers(t *testing.T) {
 					Id:     "123456789",
 					Etag:   "etag-member-123456789",
 					Email:  "member.1@mail.com",
-					Status: "DEACTIVATE",
+					Status: "SUSPENDED",
 					Type:   "USER",
 				},
 				{
@@ -611,10 +737,11 @@ func TestNewDirectoryService_ListGroupMembers(t *testing.T) {
 		got, err := client.ListGroupMembers(ctx, groupID)
 		assert.NoError(t, err)
 
-		assert.Equal(t, 1, len(got))
-		assert.Equal(t, "987654321", got[0].Id)
-		assert.Equal(t, "etag-member-987654321", got[0].Etag)
-		assert.Equal(t, "member.2@mail.com", got[0].Email)
+		assert.Equal(t, 2, len(got))
+		assert.Equal(t, "SUSPENDED", got[0].Status)
+		assert.Equal(t, "ACTIVE", got[1].Status)
+		assert.Equal(t, "member.1@mail.com", got[0].Email)
+		assert.Equal(t, "member.2@mail.com", got[1].Email)
 	})
 }
 
@@ -770,6 +897,7 @@ func TestNewDirectoryService_GetGroup(t *testing.T) {
 		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			assert.Equal(t, "GET", r.Method)
 			assert.Equal(t, urlPath, r.URL.Path)
+			assert.Equal(t, string(getGroupRequiredFields), r.URL.Query().Get("fields"))
 			w.Write(jsonBytes)
 		}))
 		defer svr.Close()
@@ -791,3 +919,51 @@ func TestNewDirectoryService_GetGroup(t *testing.T) {
 		assert.Equal(t, "group 1", got.Name)
 	})
 }
+
+func TestNewDirectoryService_CheckAuth(t *testing.T) {
+	t.Run("should list a single user of the customer", func(t *testing.T) {
+		ctx := context.TODO()
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/admin/directory/v1/users", r.URL.Path)
+			assert.Equal(t, "my_customer", r.URL.Query().Get("customer"))
+			assert.Equal(t, "1", r.URL.Query().Get("maxResults"))
+			assert.Equal(t, "users(id)", r.URL.Query().Get("fields"))
+
+			jsonBytes, err := (&admin.Users{}).MarshalJSON()
+			assert.NoError(t, err)
+			w.Write(jsonBytes)
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc)
+		assert.NoError(t, err)
+
+		err = client.CheckAuth(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return an error when the request is not authorized", func(t *testing.T) {
+		ctx := context.TODO()
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"message":"Not Authorized to access this resource/api"}}`))
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc, WithDomains([]string{"mail.com"}))
+		assert.NoError(t, err)
+
+		err = client.CheckAuth(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Not Authorized")
+	})
+}
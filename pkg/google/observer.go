@@ -0,0 +1,77 @@
+package google
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// directoryBasePath is the path of the Google Directory API resources
+const directoryBasePath = "/admin/directory/v1/"
+
+// RequestObserver is called after each request sent to the Google Directory API with its method,
+// the resource (e.g. users, groups, members) and the status code of the response, 0 when the request failed.
+type RequestObserver func(method, resource string, statusCode int, duration time.Duration)
+
+type requestObserverKey struct{}
+
+// ContextWithRequestObserver returns a copy of the context with the observer of the requests
+// sent by the services created with NewService and NewServiceWithSignJWT, e.g. to record metrics.
+func ContextWithRequestObserver(ctx context.Context, observer RequestObserver) context.Context {
+	return context.WithValue(ctx, requestObserverKey{}, observer)
+}
+
+// newAdminService creates the Google Directory Service authenticated with the token source,
+// its requests are observed when the context has a request observer.
+func newAdminService(ctx context.Context, ts oauth2.TokenSource) (*admin.Service, error) {
+	observer, _ := ctx.Value(requestObserverKey{}).(RequestObserver)
+	if observer == nil {
+		return admin.NewService(ctx, option.WithTokenSource(ts))
+	}
+
+	trans, err := htransport.NewTransport(ctx, &observedTransport{base: http.DefaultTransport, observer: observer}, option.WithTokenSource(ts))
+	if err != nil {
+		return nil, err
+	}
+
+	return admin.NewService(ctx, option.WithHTTPClient(&http.Client{Transport: trans}))
+}
+
+// observedTransport is an http.RoundTripper that reports the requests to the observer.
+type observedTransport struct {
+	base     http.RoundTripper
+	observer RequestObserver
+}
+
+// RoundTrip sends the request and reports it to the observer.
+func (t *observedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	statusCode := 0
+	if err == nil {
+		statusCode = resp.StatusCode
+	}
+	t.observer(req.Method, directoryResource(req.URL.Path), statusCode, time.Since(start))
+
+	return resp, err
+}
+
+// directoryResource returns the resource of a Google Directory API path,
+// e.g. users for /admin/directory/v1/users/{userKey} and members for /admin/directory/v1/groups/{groupKey}/members.
+func directoryResource(path string) string {
+	p := strings.TrimPrefix(path, directoryBasePath)
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+
+	if len(parts) >= 3 {
+		return parts[2]
+	}
+
+	return parts[0]
+}
@@ -0,0 +1,70 @@
+package google
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectoryResource(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "users list", path: "/admin/directory/v1/users", want: "users"},
+		{name: "user get", path: "/admin/directory/v1/users/user.1@mail.com", want: "users"},
+		{name: "groups list", path: "/admin/directory/v1/groups", want: "groups"},
+		{name: "group members", path: "/admin/directory/v1/groups/group-1/members", want: "members"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, directoryResource(tt.path))
+		})
+	}
+}
+
+func TestObservedTransport(t *testing.T) {
+	t.Run("Should observe the request with its resource and status code", func(t *testing.T) {
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer svr.Close()
+
+		var gotMethod, gotResource string
+		var gotStatusCode int
+		client := &http.Client{Transport: &observedTransport{
+			base: http.DefaultTransport,
+			observer: func(method, resource string, statusCode int, duration time.Duration) {
+				gotMethod, gotResource, gotStatusCode = method, resource, statusCode
+			},
+		}}
+
+		resp, err := client.Get(svr.URL + "/admin/directory/v1/groups/group-1/members")
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.MethodGet, gotMethod)
+		assert.Equal(t, "members", gotResource)
+		assert.Equal(t, http.StatusForbidden, gotStatusCode)
+	})
+}
+
+func TestNewService_RequestObserver(t *testing.T) {
+	t.Run("Should return a new Service observing its requests", func(t *testing.T) {
+		ctx := ContextWithRequestObserver(context.TODO(), func(method, resource string, statusCode int, duration time.Duration) {})
+
+		serviceAccount, err := os.ReadFile("testdata/service_account.json")
+		assert.NoError(t, err)
+
+		svc, err := NewService(ctx, "mock-email@mock-project.iam.gserviceaccount.com", serviceAccount, "admin.AdminDirectoryUserReadonlyScope")
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+}
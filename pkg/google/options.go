@@ -42,3 +42,46 @@ func WithRoles(role string) GetGroupMembersOption {
 		ggmo.roles = role
 	}
 }
+
+type listUsersOptions struct {
+	orgUnitPath string
+}
+
+// ListUsersOption is a function that can be used to configure the Google users list
+// following the Option pattern.
+type ListUsersOption func(*listUsersOptions)
+
+// WithOrgUnitPath is a ListUsersOption that can be used to select the users by organizational unit.
+// orgUnitPath the full path of the organizational unit, the users of its sub organizational units are included too.
+func WithOrgUnitPath(orgUnitPath string) ListUsersOption {
+	return func(luo *listUsersOptions) {
+		luo.orgUnitPath = orgUnitPath
+	}
+}
+
+// DirectoryServiceOption is a function that can be used to configure the DirectoryService
+// following the Option pattern.
+type DirectoryServiceOption func(*DirectoryService)
+
+// WithCustomer is a DirectoryServiceOption that can be used to read the directory of the given customer ID
+// instead of the customer of the impersonated user ("my_customer").
+func WithCustomer(customer string) DirectoryServiceOption {
+	return func(ds *DirectoryService) {
+		if customer != "" {
+			ds.customer = customer
+		}
+	}
+}
+
+// WithDomains is a DirectoryServiceOption that can be used to read the users and groups of the given domains
+// instead of the whole customer directory.
+func WithDomains(domains []string) DirectoryServiceOption {
+	return func(ds *DirectoryService) {
+		ds.domains = make([]string, 0, len(domains))
+		for _, d := range domains {
+			if d != "" {
+				ds.domains = append(ds.domains, d)
+			}
+		}
+	}
+}
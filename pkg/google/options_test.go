@@ -105,3 +105,82 @@ func TestWithRoles(t *testing.T) {
 		}
 	})
 }
+
+func TestWithOrgUnitPath(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var luo ListUsersOption
+
+		got := WithOrgUnitPath("/Engineering")
+
+		if reflect.TypeOf(got) != reflect.TypeOf(luo) {
+			t.Errorf("WithOrgUnitPath() return %T, different type than %T", got, luo)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		opt := WithOrgUnitPath("/Engineering")
+		got := listUsersOptions{}
+		opt(&got)
+
+		want := listUsersOptions{
+			orgUnitPath: "/Engineering",
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestWithCustomer(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var dso DirectoryServiceOption
+
+		got := WithCustomer("C01234567")
+
+		if reflect.TypeOf(got) != reflect.TypeOf(dso) {
+			t.Errorf("WithCustomer() return %T, different type than %T", got, dso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		got := &DirectoryService{customer: defaultCustomer}
+		WithCustomer("C01234567")(got)
+
+		if got.customer != "C01234567" {
+			t.Errorf("got = %v, want %v", got.customer, "C01234567")
+		}
+	})
+
+	t.Run("keep the default customer when empty", func(t *testing.T) {
+		got := &DirectoryService{customer: defaultCustomer}
+		WithCustomer("")(got)
+
+		if got.customer != defaultCustomer {
+			t.Errorf("got = %v, want %v", got.customer, defaultCustomer)
+		}
+	})
+}
+
+func TestWithDomains(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var dso DirectoryServiceOption
+
+		got := WithDomains([]string{"mail.com"})
+
+		if reflect.TypeOf(got) != reflect.TypeOf(dso) {
+			t.Errorf("WithDomains() return %T, different type than %T", got, dso)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		got := &DirectoryService{}
+		WithDomains([]string{"mail.com", "", "other.com"})(got)
+
+		want := []string{"mail.com", "other.com"}
+
+		if !reflect.DeepEqual(got.domains, want) {
+			t.Errorf("got = %v, want %v", got.domains, want)
+		}
+	})
+}
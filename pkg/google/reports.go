@@ -0,0 +1,93 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	reports "google.golang.org/api/admin/reports/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const (
+	// https://developers.google.com/admin-sdk/reports/reference/rest/v1/activities/list
+	activitiesRequiredFields googleapi.Field = "nextPageToken, items(id,events(type,name,parameters(name,value)))"
+
+	// allUsers is the userKey to get the activities of all the users
+	allUsers = "all"
+)
+
+// ErrApplicationNameNil is returned when the application name is empty.
+var ErrApplicationNameNil = fmt.Errorf("google: application name is required")
+
+// ReportsService represent the Google Reports API client.
+type ReportsService struct {
+	svc *reports.Service
+}
+
+// NewReportsAPIService create a Google Reports API Service, see NewService for the parameters.
+// Example of scope:
+// - "https://www.googleapis.com/auth/admin.reports.audit.readonly"
+func NewReportsAPIService(ctx context.Context, userEmail string, serviceAccount []byte, scope ...string) (*reports.Service, error) {
+	ts, err := credentialsTokenSource(ctx, userEmail, serviceAccount, scope...)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := reports.NewService(ctx, option.WithTokenSource(ts))
+	if err != nil {
+		return nil, fmt.Errorf("google: error creating reports service: %v", err)
+	}
+
+	return svc, nil
+}
+
+// NewReportsAPIServiceWithSignJWT create a Google Reports API Service using domain-wide delegation
+// without a service account private key, see NewServiceWithSignJWT for the parameters.
+func NewReportsAPIServiceWithSignJWT(ctx context.Context, userEmail, signerServiceAccount string, credentials []byte, scope ...string) (*reports.Service, error) {
+	ts, err := signJWTTokenSourceFor(ctx, userEmail, signerServiceAccount, credentials, scope...)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := reports.NewService(ctx, option.WithTokenSource(ts))
+	if err != nil {
+		return nil, fmt.Errorf("google: error creating reports service: %v", err)
+	}
+
+	return svc, nil
+}
+
+// NewReportsService create a Google Reports API client.
+func NewReportsService(svc *reports.Service) (*ReportsService, error) {
+	return &ReportsService{svc: svc}, nil
+}
+
+// ListActivities list all the activities of all the users in an application since the start time.
+// application: the application of the activities, e.g. admin or groups.
+// references:
+// - https://developers.google.com/admin-sdk/reports/reference/rest/v1/activities/list
+// - https://developers.google.com/admin-sdk/reports/v1/appendix/activity/admin-group-settings
+// - https://developers.google.com/admin-sdk/reports/v1/appendix/activity/admin-user-settings
+func (rs *ReportsService) ListActivities(ctx context.Context, application string, startTime time.Time) (a []*reports.Activity, err error) {
+	if application == "" {
+		return nil, ErrApplicationNameNil
+	}
+
+	ctx, span := startSpan(ctx, "ListActivities", attribute.String("google.application", application))
+	defer func() { endSpan(span, len(a), err) }()
+
+	a = make([]*reports.Activity, 0)
+
+	err = rs.svc.Activities.List(allUsers, application).
+		StartTime(startTime.UTC().Format(time.RFC3339)).
+		Fields(activitiesRequiredFields).
+		Pages(ctx, func(activities *reports.Activities) error {
+			a = append(a, activities.Items...)
+			return nil
+		})
+
+	return a, err
+}
@@ -0,0 +1,128 @@
+package google
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	reports "google.golang.org/api/admin/reports/v1"
+	"google.golang.org/api/option"
+)
+
+func TestNewReportsAPIService(t *testing.T) {
+	t.Run("Should return a new Reports Service with mocked parameters", func(t *testing.T) {
+		ctx := context.TODO()
+		userEmail := "mock-email@mock-project.iam.gserviceaccount.com"
+		serviceAccountFile := "testdata/service_account.json"
+		scope := "https://www.googleapis.com/auth/admin.reports.audit.readonly"
+
+		serviceAccount, err := os.ReadFile(serviceAccountFile)
+		if err != nil {
+			t.Fatalf("Error loading golden file: %s", err)
+		}
+
+		svc, err := NewReportsAPIService(ctx, userEmail, serviceAccount, scope)
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+
+		client, err := NewReportsService(svc)
+		assert.NoError(t, err)
+		assert.NotNil(t, client)
+	})
+
+	t.Run("Should return an error when scope is nil", func(t *testing.T) {
+		svc, err := NewReportsAPIService(context.TODO(), "", nil)
+		assert.ErrorIs(t, err, ErrGoogleClientScopeNil)
+		assert.Nil(t, svc)
+	})
+
+	t.Run("Should return an error with sign jwt without signer service account", func(t *testing.T) {
+		svc, err := NewReportsAPIServiceWithSignJWT(context.TODO(), "user@mail.com", "", nil, "scope")
+		assert.ErrorIs(t, err, ErrSignerServiceAccountNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestReportsService_ListActivities(t *testing.T) {
+	startTime := time.Date(2022, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should return the activities of all the pages", func(t *testing.T) {
+		ctx := context.TODO()
+
+		pages := []*reports.Activities{
+			{
+				NextPageToken: "page-2",
+				Items: []*reports.Activity{
+					{Events: []*reports.ActivityEvents{{Name: "ADD_GROUP_MEMBER", Type: "GROUP_SETTINGS"}}},
+				},
+			},
+			{
+				Items: []*reports.Activity{
+					{Events: []*reports.ActivityEvents{{Name: "CHANGE_LAST_NAME", Type: "USER_SETTINGS"}}},
+				},
+			},
+		}
+
+		requests := 0
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/admin/reports/v1/activity/users/all/applications/admin", r.URL.Path)
+			assert.Equal(t, "2022-01-01T10:00:00Z", r.URL.Query().Get("startTime"))
+
+			page := pages[0]
+			if r.URL.Query().Get("pageToken") == "page-2" {
+				page = pages[1]
+			}
+			requests++
+
+			jsonBytes, err := page.MarshalJSON()
+			assert.NoError(t, err)
+			w.Write(jsonBytes)
+		}))
+		defer svr.Close()
+
+		svc, err := reports.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewReportsService(svc)
+		assert.NoError(t, err)
+
+		got, err := client.ListActivities(ctx, "admin", startTime)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, requests)
+		assert.Len(t, got, 2)
+		assert.Equal(t, "ADD_GROUP_MEMBER", got[0].Events[0].Name)
+		assert.Equal(t, "CHANGE_LAST_NAME", got[1].Events[0].Name)
+	})
+
+	t.Run("Should return an error without application", func(t *testing.T) {
+		client, err := NewReportsService(&reports.Service{})
+		assert.NoError(t, err)
+
+		got, err := client.ListActivities(context.TODO(), "", startTime)
+		assert.ErrorIs(t, err, ErrApplicationNameNil)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should return the error of the API", func(t *testing.T) {
+		ctx := context.TODO()
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer svr.Close()
+
+		svc, err := reports.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewReportsService(svc)
+		assert.NoError(t, err)
+
+		_, err = client.ListActivities(ctx, "admin", startTime)
+		assert.Error(t, err)
+	})
+}
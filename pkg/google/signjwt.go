@@ -0,0 +1,186 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	// cloudPlatformScope is the scope needed to call the IAM Credentials API
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// jwtBearerGrantType is the grant type used to exchange a signed JWT by an access token
+	// https://developers.google.com/identity/protocols/oauth2/service-account#httprest
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	// signJWTLifetime is the lifetime of the signed JWT, the maximum allowed by Google is 1 hour
+	signJWTLifetime = time.Hour
+)
+
+var (
+	// ErrSignerServiceAccountNil is returned when the signer service account email is empty.
+	ErrSignerServiceAccountNil = fmt.Errorf("google: signer service account is required")
+
+	// ErrUserEmailNil is returned when the user email to impersonate is empty.
+	ErrUserEmailNil = fmt.Errorf("google: user email is required")
+)
+
+// NewServiceWithSignJWT create a Google Directory Service using domain-wide delegation without a service account private key.
+//
+// The domain-wide delegation JWT of the signerServiceAccount is signed through the IAM Credentials signJwt API,
+// authenticated with the given credentials, which could be an external account (workload identity federation) configuration.
+// When the credentials are empty, the Application Default Credentials are used.
+//
+// References:
+// - https://cloud.google.com/iam/docs/reference/credentials/rest/v1/projects.serviceAccounts/signJwt
+// - https://cloud.google.com/iam/docs/workload-identity-federation-with-other-clouds
+func NewServiceWithSignJWT(ctx context.Context, userEmail, signerServiceAccount string, credentials []byte, scope ...string) (*admin.Service, error) {
+	ts, err := signJWTTokenSourceFor(ctx, userEmail, signerServiceAccount, credentials, scope...)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := newAdminService(ctx, ts)
+	if err != nil {
+		return nil, fmt.Errorf("google: error creating service: %v", err)
+	}
+
+	return svc, nil
+}
+
+// signJWTTokenSourceFor returns the reusable token source of the domain-wide delegation access tokens
+// of the signerServiceAccount impersonating the userEmail, see NewServiceWithSignJWT.
+func signJWTTokenSourceFor(ctx context.Context, userEmail, signerServiceAccount string, credentials []byte, scope ...string) (oauth2.TokenSource, error) {
+	if len(scope) == 0 {
+		return nil, ErrGoogleClientScopeNil
+	}
+	if userEmail == "" {
+		return nil, ErrUserEmailNil
+	}
+	if signerServiceAccount == "" {
+		return nil, ErrSignerServiceAccountNil
+	}
+
+	var creds *google.Credentials
+	var err error
+
+	if len(credentials) > 0 {
+		creds, err = google.CredentialsFromJSON(ctx, credentials, cloudPlatformScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, cloudPlatformScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("google: error getting credentials to sign jwt: %v", err)
+	}
+
+	iamSvc, err := iamcredentials.NewService(ctx, option.WithTokenSource(creds.TokenSource))
+	if err != nil {
+		return nil, fmt.Errorf("google: error creating iam credentials service: %v", err)
+	}
+
+	ts := &signJWTTokenSource{
+		ctx:                  ctx,
+		iam:                  iamSvc,
+		httpClient:           http.DefaultClient,
+		tokenURL:             google.JWTTokenURL,
+		signerServiceAccount: signerServiceAccount,
+		subject:              userEmail,
+		scopes:               scope,
+	}
+
+	return oauth2.ReuseTokenSource(nil, ts), nil
+}
+
+// signJWTTokenSource is an oauth2.TokenSource that returns domain-wide delegation access tokens
+// of a service account whose JWT assertions are signed by the IAM Credentials API.
+type signJWTTokenSource struct {
+	ctx                  context.Context
+	iam                  *iamcredentials.Service
+	httpClient           *http.Client
+	tokenURL             string
+	signerServiceAccount string
+	subject              string
+	scopes               []string
+}
+
+// jwtClaims are the claims of the domain-wide delegation JWT
+type jwtClaims struct {
+	Iss   string `json:"iss"`
+	Sub   string `json:"sub"`
+	Scope string `json:"scope"`
+	Aud   string `json:"aud"`
+	Iat   int64  `json:"iat"`
+	Exp   int64  `json:"exp"`
+}
+
+// tokenResponse is the response of the token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token signs a new domain-wide delegation JWT and exchanges it by an access token.
+func (ts *signJWTTokenSource) Token() (*oauth2.Token, error) {
+	now := time.Now()
+
+	claims, err := json.Marshal(jwtClaims{
+		Iss:   ts.signerServiceAccount,
+		Sub:   ts.subject,
+		Scope: strings.Join(ts.scopes, " "),
+		Aud:   ts.tokenURL,
+		Iat:   now.Unix(),
+		Exp:   now.Add(signJWTLifetime).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("google: error marshalling jwt claims: %w", err)
+	}
+
+	name := fmt.Sprintf("projects/-/serviceAccounts/%s", ts.signerServiceAccount)
+	signed, err := ts.iam.Projects.ServiceAccounts.SignJwt(name, &iamcredentials.SignJwtRequest{Payload: string(claims)}).Context(ts.ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("google: error signing jwt: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", jwtBearerGrantType)
+	form.Set("assertion", signed.SignedJwt)
+
+	req, err := http.NewRequestWithContext(ts.ctx, http.MethodPost, ts.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("google: error creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ts.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("google: error exchanging jwt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google: error exchanging jwt, status code: %d", resp.StatusCode)
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("google: error decoding token response: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: tr.AccessToken,
+		TokenType:   tr.TokenType,
+		Expiry:      now.Add(time.Duration(tr.ExpiresIn) * time.Second),
+	}, nil
+}
@@ -0,0 +1,118 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+func TestNewServiceWithSignJWT(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Should return an error when scope is nil", func(t *testing.T) {
+		svc, err := NewServiceWithSignJWT(ctx, "admin@mail.com", "signer@project.iam.gserviceaccount.com", nil)
+		assert.ErrorIs(t, err, ErrGoogleClientScopeNil)
+		assert.Nil(t, svc)
+	})
+
+	t.Run("Should return an error when user email is empty", func(t *testing.T) {
+		svc, err := NewServiceWithSignJWT(ctx, "", "signer@project.iam.gserviceaccount.com", nil, "scope")
+		assert.ErrorIs(t, err, ErrUserEmailNil)
+		assert.Nil(t, svc)
+	})
+
+	t.Run("Should return an error when signer service account is empty", func(t *testing.T) {
+		svc, err := NewServiceWithSignJWT(ctx, "admin@mail.com", "", nil, "scope")
+		assert.ErrorIs(t, err, ErrSignerServiceAccountNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestSignJWTTokenSource_Token(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Should sign the jwt and exchange it by an access token", func(t *testing.T) {
+		var claims jwtClaims
+
+		iamSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/v1/projects/-/serviceAccounts/signer@project.iam.gserviceaccount.com:signJwt", r.URL.Path)
+
+			var req iamcredentials.SignJwtRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.NoError(t, json.Unmarshal([]byte(req.Payload), &claims))
+
+			w.Write([]byte(`{"keyId":"1","signedJwt":"signed.jwt.value"}`))
+		}))
+		defer iamSvr.Close()
+
+		tokenSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, jwtBearerGrantType, r.Form.Get("grant_type"))
+			assert.Equal(t, "signed.jwt.value", r.Form.Get("assertion"))
+
+			w.Write([]byte(`{"access_token":"access-token","token_type":"Bearer","expires_in":3600}`))
+		}))
+		defer tokenSvr.Close()
+
+		iamSvc, err := iamcredentials.NewService(ctx, option.WithHTTPClient(iamSvr.Client()), option.WithEndpoint(iamSvr.URL))
+		assert.NoError(t, err)
+
+		ts := &signJWTTokenSource{
+			ctx:                  ctx,
+			iam:                  iamSvc,
+			httpClient:           tokenSvr.Client(),
+			tokenURL:             tokenSvr.URL,
+			signerServiceAccount: "signer@project.iam.gserviceaccount.com",
+			subject:              "admin@mail.com",
+			scopes:               []string{"scope1", "scope2"},
+		}
+
+		got, err := ts.Token()
+		assert.NoError(t, err)
+		assert.Equal(t, "access-token", got.AccessToken)
+		assert.Equal(t, "Bearer", got.TokenType)
+		assert.True(t, got.Valid())
+
+		assert.Equal(t, "signer@project.iam.gserviceaccount.com", claims.Iss)
+		assert.Equal(t, "admin@mail.com", claims.Sub)
+		assert.Equal(t, "scope1 scope2", claims.Scope)
+		assert.Equal(t, tokenSvr.URL, claims.Aud)
+	})
+
+	t.Run("Should return an error when the token exchange fails", func(t *testing.T) {
+		iamSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"keyId":"1","signedJwt":"signed.jwt.value"}`))
+		}))
+		defer iamSvr.Close()
+
+		tokenSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer tokenSvr.Close()
+
+		iamSvc, err := iamcredentials.NewService(ctx, option.WithHTTPClient(iamSvr.Client()), option.WithEndpoint(iamSvr.URL))
+		assert.NoError(t, err)
+
+		ts := &signJWTTokenSource{
+			ctx:                  ctx,
+			iam:                  iamSvc,
+			httpClient:           tokenSvr.Client(),
+			tokenURL:             tokenSvr.URL,
+			signerServiceAccount: "signer@project.iam.gserviceaccount.com",
+			subject:              "admin@mail.com",
+			scopes:               []string{"scope1"},
+		}
+
+		got, err := ts.Token()
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
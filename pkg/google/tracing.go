@@ -0,0 +1,41 @@
+package google
+
+import (
+	"context"
+	"errors"
+
+	"github.com/slashdevops/idp-scim-sync/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/googleapi"
+)
+
+// tracer creates the spans of the Google Directory API calls, they are no-op until a global tracer provider is set
+var tracer = otel.Tracer("github.com/slashdevops/idp-scim-sync/pkg/google")
+
+// startSpan starts the client span of a Google Directory API call, e.g. "google.ListUsers".
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, tracer, "google."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan records the number of items returned or the error, with its status code when it is a Google API error, and ends the span.
+func endSpan(span trace.Span, items int, err error) {
+	if err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) {
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(gerr.Code))
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.Int("items", items))
+	}
+	span.End()
+}